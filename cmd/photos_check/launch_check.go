@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"os/signal"
+	"photos/pkg/config"
+	"photos/pkg/consistency"
+	"photos/pkg/utils"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func main() {
+	var repair, dryRun, asJSON bool
+	var minAge time.Duration
+	flag.BoolVar(&repair, "repair", false, "Mark photos whose file is missing and move orphan files to the quarantine")
+	flag.BoolVar(&dryRun, "dry-run", false, "Only log the repairs, nothing is changed")
+	flag.BoolVar(&asJSON, "json", false, "Print the report as JSON on the standard output")
+	flag.DurationVar(&minAge, "min-age", time.Hour, "Files written more recently are not reported, they may belong to an upload in progress")
+
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+	zerolog.DurationFieldUnit = time.Millisecond
+	cfg := config.Load()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	opts := consistency.Options{
+		Storage:   cfg.Storage.Storage,
+		CacheRoot: utils.MediaCachePath(),
+		MinAge:    minAge,
+		DryRun:    dryRun,
+		Logger:    cfg.Logger,
+	}
+	currentTime := time.Now()
+	report, err := consistency.Check(ctx, cfg.DB.DB, opts)
+	if err != nil {
+		cfg.Logger.Fatal().Err(err).Msg("consistency check failed")
+	}
+	consistency.Log(cfg.Logger, report)
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			cfg.Logger.Fatal().Err(err).Msg("failed to print report")
+		}
+	}
+	if repair && !report.Clean() {
+		if err := consistency.Repair(ctx, cfg.DB.DB, report, opts); err != nil {
+			cfg.Logger.Fatal().Err(err).Msg("consistency repair failed")
+		}
+		cfg.Logger.Info().Bool("dry_run", dryRun).Dur("duration", time.Since(currentTime)).Msg("consistency repair done")
+	}
+}
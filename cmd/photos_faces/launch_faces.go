@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"photos/pkg/config"
+	"photos/pkg/face_detection"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func main() {
+	var photoID uint
+	var recognize bool
+	flag.UintVar(&photoID, "photo", 0, "Only scan this photo, even if it was already scanned")
+	flag.BoolVar(&recognize, "recognize", false, "Classify again the faces of the unlabeled face groups after the scan")
+
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+	zerolog.DurationFieldUnit = time.Millisecond
+	cfg := config.Load()
+
+	if !cfg.Faces.Enabled {
+		cfg.Logger.Fatal().Msg("face detection is disabled in the config file")
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	err := face_detection.Initialize(ctx, cfg.DB.DB, face_detection.Options{
+		Backend:   cfg.Faces.Backend,
+		Models:    cfg.Faces.Models,
+		Threshold: cfg.Faces.Threshold,
+		Logger:    cfg.Logger,
+	})
+	if err != nil {
+		cfg.Logger.Fatal().Err(err).Msg("failed to initialize face detector")
+	}
+	detector := face_detection.GlobalFaceDetector
+	if detector == nil {
+		cfg.Logger.Fatal().Msg("face detection is not built in, rebuild the command without the no_face_detection tag")
+	}
+	defer detector.Close()
+
+	photoIDs := []uint32{uint32(photoID)}
+	if photoID == 0 {
+		photoIDs, err = cfg.DB.GetPhotosWithoutFaceScan(ctx, detector.Model())
+		if err != nil {
+			cfg.Logger.Fatal().Err(err).Msg("failed to list photos to scan")
+		}
+	}
+	currentTime := time.Now()
+	var faces, failed int
+	for i, id := range photoIDs {
+		if ctx.Err() != nil {
+			break
+		}
+		found, err := detector.DetectFaces(ctx, cfg.DB.DB, cfg.Storage.Storage, id)
+		if err != nil {
+			cfg.Logger.Error().Err(err).Uint32("photo_id", id).Msg("face detection failed")
+			failed++
+			continue
+		}
+		faces += len(found)
+		cfg.Logger.Info().Uint32("photo_id", id).Int("faces", len(found)).Int("done", i+1).Int("total", len(photoIDs)).Msg("photo scanned")
+	}
+	if recognize && ctx.Err() == nil {
+		updated, err := detector.RecognizeUnlabeledFaces(ctx, cfg.DB.DB)
+		if err != nil {
+			cfg.Logger.Error().Err(err).Msg("face recognition failed")
+			failed++
+		}
+		cfg.Logger.Info().Int("faces", len(updated)).Msg("unlabeled faces recognized")
+	}
+	cfg.Logger.Info().
+		Int("photos", len(photoIDs)).
+		Int("faces", faces).
+		Int("failed", failed).
+		Dur("duration", time.Since(currentTime)).
+		Msg("face detection done")
+	if failed > 0 || ctx.Err() != nil {
+		os.Exit(1)
+	}
+}
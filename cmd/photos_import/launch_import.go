@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"photos/pkg/config"
+	"photos/pkg/importer"
+	"photos/pkg/jobs"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func main() {
+	var source, mode, duplicates string
+	flag.StringVar(&source, "source", "", "Directory tree to import, every folder becomes an event")
+	flag.StringVar(&mode, "mode", "copy", "Whether photos are copied or moved into the storage (copy or move)")
+	flag.StringVar(&duplicates, "duplicates", "", "What to do with photos already stored in another event (warn, link or reject, default from the config file)")
+
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+	zerolog.DurationFieldUnit = time.Millisecond
+	cfg := config.Load()
+
+	if source == "" {
+		flag.Usage()
+		cfg.Logger.Fatal().Msg("the source directory must be specified")
+	}
+	importMode, err := importer.ParseMode(mode)
+	if err != nil {
+		cfg.Logger.Fatal().Err(err).Msg("invalid import mode")
+	}
+	if duplicates == "" {
+		duplicates = cfg.Storage.Duplicates
+	}
+	duplicatePolicy, err := importer.ParseDuplicatePolicy(duplicates)
+	if err != nil {
+		cfg.Logger.Fatal().Err(err).Msg("invalid duplicate policy")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	keys, err := importer.IndexPerceptualHashes(ctx, cfg.DB.DB)
+	if err != nil {
+		cfg.Logger.Fatal().Err(err).Msg("failed to index perceptual hashes")
+	}
+	if keys > 0 {
+		cfg.Logger.Info().Int64("keys", keys).Msg("indexed perceptual hashes")
+	}
+
+	lastReport := time.Now()
+	currentTime := time.Now()
+	progress, err := importer.Run(ctx, cfg.DB.DB, importer.Options{
+		Source:      source,
+		Storage:     cfg.Storage.Storage,
+		Mode:        importMode,
+		Duplicates:  duplicatePolicy,
+		MaxDistance: cfg.Storage.MaxDistance,
+		Enqueue:     jobs.Enqueuer(cfg.Faces.Enabled),
+		Logger:      cfg.Logger,
+		OnProgress: func(p importer.Progress) {
+			if time.Since(lastReport) < time.Second {
+				return
+			}
+			lastReport = time.Now()
+			cfg.Logger.Info().Int("files", p.Files).Int("imported", p.Imported).Int("skipped", p.Skipped).Int("failed", p.Failed).Str("current", p.Current).Msg("importing")
+		},
+	})
+	cfg.Logger.Info().
+		Int("directories", progress.Directories).
+		Int("files", progress.Files).
+		Int("imported", progress.Imported).
+		Int("skipped", progress.Skipped).
+		Int("duplicates", progress.Duplicates).
+		Int("similar", progress.Similar).
+		Int("failed", progress.Failed).
+		Dur("duration", time.Since(currentTime)).
+		Msg("import done")
+	if err != nil {
+		cfg.Logger.Error().Err(err).Msg("import aborted")
+		os.Exit(1)
+	}
+}
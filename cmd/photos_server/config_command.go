@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"photos/pkg/config"
+	"time"
+)
+
+const configUsage = `usage: photos_server config <command> [flags]
+
+commands:
+  init      write a default config file with fresh secrets
+  validate  check the config file and the environment variables
+  show      print the config in use, the file overridden by the environment variables
+  migrate   add the fields missing from the config file with their default value
+  keys      generate a key, or rotate the keys of the session and CSRF cookies with -rotate
+`
+
+// runConfigCommand runs a config subcommand and returns the exit code of the program.
+func runConfigCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, configUsage)
+		return 2
+	}
+	flags := flag.NewFlagSet("config "+args[0], flag.ExitOnError)
+	cfgPath := flags.String("config", "config.yml", "Path to the configuration file")
+	force := flags.Bool("force", false, "Overwrite an existing config file (init)")
+	redact := flags.Bool("redact", false, "Replace the secrets and passwords (show)")
+	rotate := flags.Bool("rotate", false, "Add a new key in front of the keys of the config file instead of printing one (keys)")
+	token := flags.String("token", "", "Token type whose keys are rotated, csrf or session, both if empty (keys)")
+	overlap := flags.Duration("overlap", 0, "Duration the former keys still verify cookies, the cookie max age if 0 (keys)")
+	encrypt := flags.Bool("encrypt", false, "Encrypt the session cookies with the new key (keys)")
+	_ = flags.Parse(args[1:])
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+
+	switch args[0] {
+	case "init":
+		if err := config.Init(*cfgPath, *force); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to create config file: %v\n", err)
+			return 1
+		}
+		fmt.Printf("config file created at %s, fill the database DSN before starting the server\n", *cfgPath)
+	case "validate":
+		if err := config.ValidateFile(*cfgPath); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+			return 1
+		}
+		fmt.Printf("%s is valid\n", *cfgPath)
+	case "show":
+		if err := config.Show(os.Stdout, *cfgPath, *redact); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to show config: %v\n", err)
+			return 1
+		}
+	case "migrate":
+		added, err := config.Migrate(*cfgPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to migrate config file: %v\n", err)
+			return 1
+		}
+		for _, field := range added {
+			fmt.Printf("added %s\n", field)
+		}
+		fmt.Printf("%d fields added to %s\n", len(added), *cfgPath)
+	case "keys":
+		if !*rotate {
+			key, err := config.GenerateKey()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "failed to generate key: %v\n", err)
+				return 1
+			}
+			fmt.Println(key)
+			return 0
+		}
+		var tokens []string
+		if *token != "" {
+			tokens = []string{*token}
+		}
+		changes, err := config.RotateKeys(*cfgPath, tokens, *overlap, *encrypt, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to rotate keys: %v\n", err)
+			return 1
+		}
+		for _, change := range changes {
+			fmt.Println(change)
+		}
+		fmt.Println("restart the server to sign the cookies with the new keys")
+	default:
+		fmt.Fprint(os.Stderr, configUsage)
+		return 2
+	}
+	return 0
+}
@@ -8,8 +8,15 @@ import (
 	"os"
 	"os/signal"
 	"photos/pkg/config"
+	"photos/pkg/consistency"
+	"photos/pkg/face_detection"
 	"photos/pkg/handlers"
+	"photos/pkg/importer"
+	"photos/pkg/jobs"
+	"photos/pkg/metrics"
 	"photos/pkg/routes"
+	"photos/pkg/tracing"
+	"photos/pkg/utils"
 	"syscall"
 	"time"
 
@@ -17,6 +24,9 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfigCommand(os.Args[2:]))
+	}
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	zerolog.DurationFieldUnit = time.Millisecond
 	cfg := config.Load()
@@ -34,6 +44,37 @@ func main() {
 	}
 	dbCtxCancel()
 	cfg.Logger.Info().Dur("latency", time.Since(currentTime)).Msg("pinged database")
+	// Photos stored before their perceptual hashes were indexed are found as near duplicates once indexed.
+	keys, err := importer.IndexPerceptualHashes(context.Background(), cfg.DB.DB)
+	if err != nil {
+		cfg.Logger.Fatal().Err(err).Msg("failed to index perceptual hashes")
+	}
+	if keys > 0 {
+		cfg.Logger.Info().Int64("keys", keys).Msg("indexed perceptual hashes")
+	}
+	if cfg.Metrics.Enabled {
+		err = metrics.RegisterDB(cfg.DB.DB, func() time.Duration { return cfg.Live.Load().SessionMaxAge })
+		if err != nil {
+			cfg.Logger.Fatal().Err(err).Msg("failed to register database metrics")
+		}
+	}
+	shutdownTracing := func(context.Context) error { return nil }
+	if cfg.Tracing.Enabled {
+		shutdownTracing, err = tracing.Setup(context.Background(), tracing.Options{
+			Endpoint:    cfg.Tracing.Endpoint,
+			ServiceName: cfg.Tracing.ServiceName,
+			SampleRatio: cfg.Tracing.SampleRatio,
+			Logger:      cfg.Logger,
+		})
+		if err != nil {
+			cfg.Logger.Fatal().Err(err).Msg("failed to set up tracing")
+		}
+		cfg.Logger.Info().Str("endpoint", cfg.Tracing.Endpoint).Msg("exporting traces")
+	}
+
+	serverCtx, serverCtxCancel := context.WithCancel(context.Background())
+	importCtx, importCtxCancel := context.WithCancel(serverCtx)
+	cfg.Importer = importer.NewTracker(importCtx)
 
 	server := &http.Server{
 		Addr:           fmt.Sprintf("127.0.0.1:%d", cfg.Server.Port),
@@ -44,10 +85,64 @@ func main() {
 		MaxHeaderBytes: cfg.Server.MaxHeaderBytes,
 	}
 
-	serverCtx, serverCtxCancel := context.WithCancel(context.Background())
+	if cfg.Consistency.Interval > 0 {
+		go consistency.Schedule(serverCtx, cfg.DB.DB, cfg.Consistency.Interval, cfg.Consistency.Repair, consistency.Options{
+			Storage:   cfg.Storage.Storage,
+			CacheRoot: utils.MediaCachePath(),
+			MinAge:    cfg.Consistency.MinAge,
+			DryRun:    cfg.Consistency.DryRun,
+			Logger:    cfg.Logger,
+		})
+	}
+	if cfg.Faces.Enabled {
+		err := face_detection.Initialize(serverCtx, cfg.DB.DB, face_detection.Options{
+			Backend:   cfg.Faces.Backend,
+			Models:    cfg.Faces.Models,
+			Threshold: cfg.Faces.Threshold,
+			Logger:    cfg.Logger,
+		})
+		if err != nil {
+			cfg.Logger.Error().Err(err).Msg("failed to initialize face detector, faces will not be detected")
+		}
+	}
+	jobsCtx, jobsCtxCancel := context.WithCancel(serverCtx)
+	jobsDone := make(chan struct{})
+	go func() {
+		defer close(jobsDone)
+		if cfg.Jobs.Workers <= 0 {
+			return
+		}
+		jobHandlers := jobs.Handlers(cfg.DB.DB, cfg.Storage.Storage, face_detection.GlobalFaceDetector)
+		jobs.NewPool(cfg.DB.DB, jobHandlers, jobs.Options{
+			Workers:      cfg.Jobs.Workers,
+			PollInterval: cfg.Jobs.PollInterval,
+			Lease:        cfg.Jobs.Lease,
+			MaxAttempts:  cfg.Jobs.MaxAttempts,
+			Backoff:      cfg.Jobs.Backoff,
+			MaxBackoff:   cfg.Jobs.MaxBackoff,
+			Retention:    cfg.Jobs.Retention,
+			Logger:       cfg.Logger,
+		}).Run(jobsCtx)
+	}()
+	// Reload the configuration on SIGHUP, a rejected file keeps the running configuration
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			changes, err := cfg.Reload()
+			if err != nil {
+				cfg.Logger.Error().Err(err).Msg("configuration reload rejected")
+				continue
+			}
+			for _, change := range changes {
+				cfg.Logger.Info().Str("change", change).Msg("configuration changed")
+			}
+			cfg.Logger.Info().Int("changes", len(changes)).Msg("configuration reloaded")
+		}
+	}()
 	// Listen for syscall signals for process to interrupt/quit
 	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	go func() {
 		<-sig
 
@@ -66,11 +161,25 @@ func main() {
 		if err != nil {
 			cfg.Logger.Fatal().Err(err).Msg("failed to shut down server")
 		}
+		// Running jobs are given up, their lease expires and they run again after the restart.
+		jobsCtxCancel()
+		<-jobsDone
+		// A running import stops between two files, and reports the cancellation as its error.
+		importCtxCancel()
+		cfg.Importer.Wait()
+		if face_detection.GlobalFaceDetector != nil {
+			_ = face_detection.GlobalFaceDetector.Close()
+		}
 		err = cfg.DB.Close()
 		if err != nil {
 			cfg.Logger.Fatal().Err(err).Msg("failed to close database connection")
 
 		}
+		// The spans of the last requests are exported before the process exits.
+		err = shutdownTracing(shutdownCtx)
+		if err != nil {
+			cfg.Logger.Error().Err(err).Msg("failed to export remaining spans")
+		}
 		shutdownCtxCancel()
 		serverCtxCancel()
 	}()
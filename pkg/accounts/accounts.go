@@ -0,0 +1,257 @@
+package accounts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"photos/pkg/face_detection"
+	"time"
+)
+
+// Export holds the personal data kept about a user, as written in the data.json file of a data export.
+type Export struct {
+	User           User            `json:"user"`
+	Sessions       []Session       `json:"sessions"`
+	Folders        []Folder        `json:"folders"`
+	FaceLabels     []FaceLabel     `json:"face_labels"`
+	FaceClaims     []FaceClaim     `json:"face_claims"`
+	FaceConsent    string          `json:"face_consent"`
+	PublicName     bool            `json:"public_name"` // Whether the name of the user is shown on their faces to everyone.
+	ConsentChanges []ConsentChange `json:"consent_changes"`
+	Photos         []Photo         `json:"photos"`
+}
+
+// User is the account of the user.
+type User struct {
+	UserID           uint32     `json:"user_id"`
+	Email            string     `json:"email"`
+	FullName         string     `json:"full_name"`
+	BusinessCategory string     `json:"business_category"`
+	DepartmentNumber string     `json:"department_number"`
+	IsAdmin          bool       `json:"is_admin"`
+	SignupDate       time.Time  `json:"signup_date"`
+	LastSigninDate   time.Time  `json:"last_signin_date"`
+	SigninLocked     bool       `json:"signin_locked"`
+	SigninLockedDate *time.Time `json:"signin_locked_date,omitempty"`
+}
+
+// Session is a login of the user. Its token is left out, since it still grants access to the account.
+type Session struct {
+	SessionID    uint32    `json:"session_id"`
+	CreationDate time.Time `json:"creation_date"`
+}
+
+// Folder is a folder created by the user.
+type Folder struct {
+	FolderID       uint32     `json:"folder_id"`
+	Name           string     `json:"name"`
+	Description    string     `json:"description"`
+	ParentFolderID *uint32    `json:"parent_folder_id,omitempty"`
+	CreationDate   *time.Time `json:"creation_date,omitempty"`
+}
+
+// FaceLabel is a face group linked to the user.
+type FaceLabel struct {
+	FaceGroupID  uint32    `json:"face_group_id"`
+	Label        string    `json:"label"`
+	CreationDate time.Time `json:"creation_date"`
+}
+
+// FaceClaim is a face group the user recognized themselves in, waiting for an admin.
+type FaceClaim struct {
+	FaceGroupID uint32    `json:"face_group_id"`
+	ClaimDate   time.Time `json:"claim_date"`
+}
+
+// ConsentChange is a change of the consent of the user to face recognition.
+type ConsentChange struct {
+	Consent         string    `json:"consent"`
+	PreviousConsent string    `json:"previous_consent"`
+	ChangedByUser   bool      `json:"changed_by_user"`
+	IP              string    `json:"ip"`
+	ChangeDate      time.Time `json:"change_date"`
+}
+
+// Photo is a photo the user was recognized on. Its file is stored next to data.json in the export.
+type Photo struct {
+	PhotoID      uint32     `json:"photo_id"`
+	EventID      uint32     `json:"event_id"`
+	CreationDate *time.Time `json:"creation_date,omitempty"`
+}
+
+// Collect gathers the personal data kept about a user.
+//
+// Parameters:
+//   - ctx: Context of the request.
+//   - q: Queries reading the data.
+//   - userID: ID of the user.
+//
+// Returns:
+//   - Export: The data of the user.
+//   - []query.Photo: The photos the user was recognized on, whose files belong to the export, in the order of its photos.
+//   - error: sql.ErrNoRows if the user does not exist, or a database error.
+func Collect(ctx context.Context, q query.Querier, userID uint32) (Export, []query.Photo, error) {
+	user, err := q.GetUser(ctx, userID)
+	if err != nil {
+		return Export{}, nil, err
+	}
+	export := Export{
+		User: User{
+			UserID:           user.UserID,
+			Email:            user.Email,
+			FullName:         user.FullName,
+			BusinessCategory: string(user.BusinessCategory),
+			DepartmentNumber: user.DepartmentNumber,
+			IsAdmin:          user.IsAdmin,
+			SignupDate:       user.SignupDate,
+			LastSigninDate:   user.LastSigninDate,
+			SigninLocked:     user.SigninLocked,
+			SigninLockedDate: timePtr(user.SigninLockedDate),
+		},
+		Sessions:       []Session{},
+		Folders:        []Folder{},
+		FaceLabels:     []FaceLabel{},
+		FaceClaims:     []FaceClaim{},
+		ConsentChanges: []ConsentChange{},
+		Photos:         []Photo{},
+	}
+
+	sessions, err := q.GetSessionsOfUser(ctx, userID)
+	if err != nil {
+		return Export{}, nil, fmt.Errorf("failed to read sessions: %w", err)
+	}
+	for _, session := range sessions {
+		export.Sessions = append(export.Sessions, Session{SessionID: session.SessionID, CreationDate: session.CreationDate})
+	}
+
+	folders, err := q.GetUserFoldersOfUser(ctx, userID)
+	if err != nil {
+		return Export{}, nil, fmt.Errorf("failed to read folders: %w", err)
+	}
+	for _, folder := range folders {
+		f := Folder{
+			FolderID:     folder.UserFolderID,
+			Name:         folder.Name,
+			Description:  folder.Description,
+			CreationDate: timePtr(folder.CreationDate),
+		}
+		if folder.ParentFolderID.Valid {
+			parentID := uint32(folder.ParentFolderID.Int32)
+			f.ParentFolderID = &parentID
+		}
+		export.Folders = append(export.Folders, f)
+	}
+
+	groups, err := q.GetFaceGroupsOfUser(ctx, sql.NullInt32{Int32: int32(userID), Valid: true})
+	if err != nil {
+		return Export{}, nil, fmt.Errorf("failed to read face groups: %w", err)
+	}
+	for _, group := range groups {
+		export.FaceLabels = append(export.FaceLabels, FaceLabel{
+			FaceGroupID:  group.FaceGroupID,
+			Label:        group.Label.String,
+			CreationDate: group.CreationDate,
+		})
+	}
+
+	claims, err := q.GetFaceGroupClaimsOfUser(ctx, userID)
+	if err != nil {
+		return Export{}, nil, fmt.Errorf("failed to read face group claims: %w", err)
+	}
+	for _, claim := range claims {
+		export.FaceClaims = append(export.FaceClaims, FaceClaim{FaceGroupID: claim.FaceGroupID, ClaimDate: claim.ClaimDate})
+	}
+
+	consent, err := face_detection.GetConsent(ctx, q, userID)
+	if err != nil {
+		return Export{}, nil, fmt.Errorf("failed to read face consent: %w", err)
+	}
+	export.FaceConsent = string(consent)
+	_, err = q.GetPublicFaceName(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Export{}, nil, fmt.Errorf("failed to read name visibility: %w", err)
+	}
+	export.PublicName = err == nil
+	changes, err := q.GetFaceConsentChanges(ctx, sql.NullInt32{Int32: int32(userID), Valid: true})
+	if err != nil {
+		return Export{}, nil, fmt.Errorf("failed to read consent changes: %w", err)
+	}
+	for _, change := range changes {
+		export.ConsentChanges = append(export.ConsentChanges, ConsentChange{
+			Consent:         string(change.Consent),
+			PreviousConsent: string(change.PreviousConsent),
+			ChangedByUser:   change.ChangedBy.Valid && uint32(change.ChangedBy.Int32) == userID,
+			IP:              change.Ip,
+			ChangeDate:      change.ChangeDate,
+		})
+	}
+
+	photos, err := q.GetRecognizedPhotos(ctx, userID)
+	if err != nil {
+		return Export{}, nil, fmt.Errorf("failed to read photos: %w", err)
+	}
+	for _, photo := range photos {
+		export.Photos = append(export.Photos, Photo{
+			PhotoID:      photo.PhotoID,
+			EventID:      photo.EventID,
+			CreationDate: timePtr(photo.CreationDate),
+		})
+	}
+	return export, photos, nil
+}
+
+// Erase deletes a user along with the sessions, folders and face data of the user, in a single transaction.
+// The photos uploaded by the user are kept without uploader, the consent changes without user nor address. The face detectors must reload their faces afterwards.
+//
+// Parameters:
+//   - ctx: Context of the request.
+//   - database: Database holding the user.
+//   - userID: ID of the user.
+//
+// Returns:
+//   - error: sql.ErrNoRows if the user does not exist, or a database error.
+func Erase(ctx context.Context, database *db.DB, userID uint32) error {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := database.WithTx(tx)
+
+	if _, err := qtx.GetUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := qtx.DeleteSessionsOfUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete sessions: %w", err)
+	}
+	// Sub-folders reference their parent, they are detached so that the folders can be deleted in any order.
+	if err := qtx.DetachUserFoldersOfUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to detach folders: %w", err)
+	}
+	if err := qtx.DeleteUserFoldersOfUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete folders: %w", err)
+	}
+	if err := face_detection.DeleteUserFaces(ctx, qtx, userID); err != nil {
+		return fmt.Errorf("failed to delete faces: %w", err)
+	}
+	// The consent changes are an audit record, they outlive the user without a link to the user nor an address.
+	err = qtx.AnonymizeFaceConsentChangesOfUser(ctx, sql.NullInt32{Int32: int32(userID), Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to anonymize consent changes: %w", err)
+	}
+	if err := qtx.DeleteUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return tx.Commit()
+}
+
+// timePtr returns the time of a nullable date, nil if it is not set.
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,125 @@
+package accounts
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"photos/pkg/face_detection"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var userColumns = []string{"user_id", "signup_date", "last_signin_date", "signin_locked", "signin_locked_date",
+	"is_admin", "email", "full_name", "business_category", "department_number"}
+
+func TestCollect(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	date := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM users").WithArgs(3).WillReturnRows(sqlmock.NewRows(userColumns).
+		AddRow(3, date, date, false, nil, false, "jeanne.martin@etu.emse.fr", "Jeanne Martin", "STUDENT", "ICM"))
+	mock.ExpectQuery("FROM sessions").WithArgs(3).WillReturnRows(sqlmock.NewRows(
+		[]string{"session_id", "user_id", "creation_date", "session_token"}).AddRow(5, 3, date, "secret"))
+	mock.ExpectQuery("FROM user_folders").WithArgs(3).WillReturnRows(sqlmock.NewRows(
+		[]string{"user_folder_id", "is_sub_folder", "name", "description", "creation_date", "user_id", "parent_folder_id"}).
+		AddRow(1, false, "Gala", "", date, 3, nil).
+		AddRow(2, true, "Soirée", "", date, 3, 1))
+	mock.ExpectQuery("FROM face_groups").WithArgs(3).WillReturnRows(sqlmock.NewRows(
+		[]string{"face_group_id", "label", "user_id", "creation_date"}).AddRow(7, "Jeanne Martin", 3, date))
+	mock.ExpectQuery("FROM face_group_claims").WithArgs(3).WillReturnRows(sqlmock.NewRows(
+		[]string{"face_group_id", "user_id", "claim_date"}).AddRow(9, 3, date))
+	mock.ExpectQuery("FROM face_consents").WithArgs(3).WillReturnRows(sqlmock.NewRows(
+		[]string{"user_id", "consent", "update_date"}).AddRow(3, "GRANTED", date))
+	mock.ExpectQuery("FROM public_face_names").WithArgs(3).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("FROM face_consent_changes").WithArgs(3).WillReturnRows(sqlmock.NewRows(
+		[]string{"face_consent_change_id", "user_id", "consent", "previous_consent", "changed_by", "ip", "change_date"}).
+		AddRow(1, 3, "GRANTED", "UNKNOWN", 3, "192.0.2.1", date))
+	mock.ExpectQuery("FROM photos p").WithArgs(3).WillReturnRows(sqlmock.NewRows(
+		[]string{"photo_id", "path_to_photo", "file_hash", "perceptual_hash", "file_size", "creation_date", "event_id", "uploader_id", "is_hidden"}).
+		AddRow(11, "gala/1.jpg", "hash", nil, 100, date, 2, nil, false))
+
+	export, photos, err := Collect(context.Background(), query.New(mockDB), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "jeanne.martin@etu.emse.fr", export.User.Email)
+	assert.Equal(t, []Session{{SessionID: 5, CreationDate: date}}, export.Sessions, "session tokens must be left out")
+	assert.Len(t, export.Folders, 2)
+	assert.Equal(t, uint32(1), *export.Folders[1].ParentFolderID)
+	assert.Equal(t, []FaceLabel{{FaceGroupID: 7, Label: "Jeanne Martin", CreationDate: date}}, export.FaceLabels)
+	assert.Equal(t, []FaceClaim{{FaceGroupID: 9, ClaimDate: date}}, export.FaceClaims)
+	assert.Equal(t, "GRANTED", export.FaceConsent)
+	assert.False(t, export.PublicName)
+	assert.True(t, export.ConsentChanges[0].ChangedByUser)
+	assert.Equal(t, uint32(11), export.Photos[0].PhotoID)
+	assert.Len(t, photos, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestErase(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	database := &db.DB{DB: mockDB, Querier: query.New(mockDB)}
+	date := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM users").WithArgs(3).WillReturnRows(sqlmock.NewRows(userColumns).
+		AddRow(3, date, date, false, nil, false, "jeanne.martin@etu.emse.fr", "Jeanne Martin", "STUDENT", "ICM"))
+	mock.ExpectExec("DELETE FROM sessions").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE user_folders").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM user_folders").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM image_faces").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec("DELETE FROM face_groups").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM face_group_claims").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM public_face_names").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM recognized_users").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec("UPDATE face_consent_changes SET ip = ''").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM users").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, Erase(context.Background(), database, 3))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM users").WithArgs(4).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	assert.ErrorIs(t, Erase(context.Background(), database, 4), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEraseKeepsConsentChanges(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.New(db.Options{Driver: db.DriverSQLite, Path: filepath.Join(t.TempDir(), "photos.db"), MaxOpenConns: 1})
+	assert.NoError(t, err)
+	defer database.Close()
+	userID, err := database.AttemptCreatingUser(ctx, query.AttemptCreatingUserParams{
+		Email:            "jeanne.martin@etu.emse.fr",
+		BusinessCategory: query.UsersBusinessCategorySTUDENT,
+	})
+	assert.NoError(t, err)
+	for _, consent := range []query.FaceConsentsConsent{query.FaceConsentsConsentGRANTED, query.FaceConsentsConsentREFUSED} {
+		_, err := face_detection.SetConsent(ctx, database, uint32(userID), consent, uint32(userID), "192.0.2.1")
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, Erase(ctx, database, uint32(userID)))
+	rows, err := database.QueryContext(ctx, "SELECT user_id, changed_by, ip FROM face_consent_changes")
+	assert.NoError(t, err)
+	defer rows.Close()
+	changes := 0
+	for rows.Next() {
+		var user, changedBy sql.NullInt32
+		var ip string
+		assert.NoError(t, rows.Scan(&user, &changedBy, &ip))
+		assert.False(t, user.Valid, "the change must not be linked to the erased user")
+		assert.False(t, changedBy.Valid)
+		assert.Empty(t, ip, "the address of the erased user must not be kept")
+		changes++
+	}
+	assert.NoError(t, rows.Err())
+	assert.Equal(t, 2, changes, "the consent changes of an erased user must be kept")
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"photos/pkg/tracing"
 	"time"
 )
 
@@ -21,7 +22,7 @@ func newHTTPClient(requestTimeout time.Duration, useCookie, disableKeepAlive, di
 	}
 	client := http.Client{
 		Timeout:   requestTimeout,
-		Transport: transport,
+		Transport: &tracing.Transport{Base: transport},
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
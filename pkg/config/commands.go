@@ -0,0 +1,234 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces the secrets shown by Show.
+const redacted = "REDACTED"
+
+// secretKeys are the keys of the configuration file holding secrets.
+var secretKeys = map[string]bool{
+	"secret":            true,
+	"encryption_secret": true,
+	"password":          true,
+	"dsn":               true,
+	"secret_access_key": true,
+	"bearer_token":      true,
+}
+
+// Init writes a default configuration file with fresh secrets.
+//
+// Parameters:
+//   - path: Path of the configuration file.
+//   - force: Whether an existing file is overwritten.
+//
+// Returns:
+//   - error: An error if the file exists and force is false, or if it could not be written.
+func Init(path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists", path)
+	}
+	return createDefaultConfig(path)
+}
+
+// ValidateFile reads a configuration file, overrides it with the environment variables and validates it.
+//
+// Parameters:
+//   - path: Path of the configuration file.
+//
+// Returns:
+//   - error: Every invalid value, joined, or an error if the file could not be read.
+func ValidateFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	_, err = parse(data, os.LookupEnv)
+	return err
+}
+
+// Show writes the configuration in use, that is the file overridden by the environment variables, as YAML.
+// Invalid values are shown as well, since the output helps finding them.
+//
+// Parameters:
+//   - w: Writer receiving the configuration.
+//   - path: Path of the configuration file.
+//   - redact: Whether the secrets and passwords are replaced.
+//
+// Returns:
+//   - error: An error if the file could not be read or decoded.
+func Show(w io.Writer, path string, redact bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	cfg := baseConfig()
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return fmt.Errorf("failed to unmarshal config file: %w", err)
+		}
+	}
+	// Invalid variables are left out, the configuration is shown with the values they could not override.
+	_ = applyEnv(&cfg, os.LookupEnv)
+
+	var node yaml.Node
+	if err := node.Encode(&cfg); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if redact {
+		redactSecrets(&node)
+	}
+	encoder := yaml.NewEncoder(w)
+	defer encoder.Close()
+	return encoder.Encode(&node)
+}
+
+// Migrate adds the fields missing from a configuration file with their default value, keeping the values, the order
+// and the comments of the file. Missing secrets are generated, and the single secret of the tokens of older files is
+// moved into their keys.
+//
+// Parameters:
+//   - path: Path of the configuration file.
+//
+// Returns:
+//   - []string: Paths of the added fields, such as jobs.workers.
+//   - error: An error if the file could not be read, decoded or written.
+func Migrate(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var file yaml.Node
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config file: %w", err)
+	}
+	if len(file.Content) == 0 {
+		file = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if file.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("config file is not a mapping")
+	}
+
+	defaults, err := defaultConfig()
+	if err != nil {
+		return nil, err
+	}
+	var defaultNode yaml.Node
+	if err := defaultNode.Encode(&defaults); err != nil {
+		return nil, fmt.Errorf("failed to encode default config: %w", err)
+	}
+	added := upgradeSecrets(file.Content[0])
+	added = append(added, mergeMissing(file.Content[0], &defaultNode, "")...)
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	out, err := yaml.Marshal(&file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := replaceFile(path, out, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write config file: %w", err)
+	}
+	return added, nil
+}
+
+// replaceFile writes a file to a temporary file of its directory which is then renamed over it, so that a failed write
+// leaves the former file and a reload never reads a partial one. A symbolic link is kept, and its target replaced.
+//
+// Parameters:
+//   - path: Path of the file to replace.
+//   - data: New content of the file.
+//   - perm: Permissions of the new file.
+//
+// Returns:
+//   - error: An error if the file could not be written.
+func replaceFile(path string, data []byte, perm os.FileMode) error {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(perm)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), target)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// mergeMissing appends the keys of the defaults missing from a mapping, and merges the nested mappings.
+func mergeMissing(dst, defaults *yaml.Node, prefix string) []string {
+	var added []string
+	for i := 0; i+1 < len(defaults.Content); i += 2 {
+		key, value := defaults.Content[i], defaults.Content[i+1]
+		name := key.Value
+		if prefix != "" {
+			name = prefix + "." + key.Value
+		}
+		existing := lookupKey(dst, key.Value)
+		if existing == nil {
+			dst.Content = append(dst.Content, key, value)
+			added = append(added, name)
+			continue
+		}
+		if existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			added = append(added, mergeMissing(existing, value, name)...)
+		}
+	}
+	return added
+}
+
+// redactSecrets replaces the values of the secret keys of a node and its children.
+func redactSecrets(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value := node.Content[i+1]
+			if secretKeys[node.Content[i].Value] && value.Kind == yaml.ScalarNode && value.Value != "" {
+				value.Value = redacted
+				value.Tag = "!!str"
+				value.Style = 0
+			}
+		}
+	}
+	for _, child := range node.Content {
+		redactSecrets(child)
+	}
+}
+
+// lookupKey returns the value of a key of a mapping, nil if it is missing.
+func lookupKey(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestInit ensures that Init does not overwrite an existing file unless forced.
+func TestInit(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yml")
+
+	assert.NoError(t, Init(cfgPath, false))
+	assert.FileExists(t, cfgPath)
+	assert.Error(t, Init(cfgPath, false), "Init should refuse to overwrite a config file")
+	assert.NoError(t, Init(cfgPath, true))
+}
+
+// TestShow ensures that Show redacts the secrets and passwords.
+func TestShow(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yml")
+	assert.NoError(t, Init(cfgPath, false))
+	t.Setenv("PHOTOS_DB_PROD_PASSWORD", "hunter2")
+	t.Setenv("PHOTOS_SERVER_PORT", "9090")
+	t.Setenv("PHOTOS_METRICS_BEARER_TOKEN", "scraper-token")
+
+	var out bytes.Buffer
+	assert.NoError(t, Show(&out, cfgPath, true))
+	assert.Contains(t, out.String(), "port: 9090", "environment variables should be applied")
+	assert.Contains(t, out.String(), "secret: REDACTED")
+	assert.NotContains(t, out.String(), "hunter2")
+	assert.NotContains(t, out.String(), "scraper-token")
+
+	out.Reset()
+	assert.NoError(t, Show(&out, cfgPath, false))
+	assert.Contains(t, out.String(), "hunter2")
+}
+
+// TestMigrate ensures that Migrate adds the missing fields and keeps the values and comments of the file.
+func TestMigrate(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yml")
+	content := "# Photos\nserver:\n    port: 9000 # behind the proxy\n"
+	assert.NoError(t, os.WriteFile(cfgPath, []byte(content), 0600))
+
+	added, err := Migrate(cfgPath)
+	assert.NoError(t, err)
+	assert.Contains(t, added, "server.read_timeout")
+	assert.Contains(t, added, "jobs")
+	assert.NotContains(t, added, "server.port")
+
+	data, err := os.ReadFile(cfgPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "# Photos")
+	assert.Contains(t, string(data), "port: 9000 # behind the proxy")
+	assert.Contains(t, string(data), "read_timeout: 6s")
+
+	added, err = Migrate(cfgPath)
+	assert.NoError(t, err)
+	assert.Empty(t, added, "a migrated file should have no missing field")
+}
+
+// TestReplaceFile ensures that a replaced file keeps its permissions and links, and that no temporary file is left.
+func TestReplaceFile(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "photos.yml")
+	assert.NoError(t, os.WriteFile(target, []byte("old"), 0600))
+	link := filepath.Join(dir, "config.yml")
+	assert.NoError(t, os.Symlink(target, link))
+
+	assert.NoError(t, replaceFile(link, []byte("new"), 0640))
+	data, err := os.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	info, err := os.Lstat(link)
+	assert.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSymlink, "the link must be kept")
+	info, err = os.Stat(target)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2, "no temporary file must be left")
+
+	assert.Error(t, replaceFile(filepath.Join(dir, "missing", "config.yml"), []byte("new"), 0600))
+}
@@ -1,20 +1,48 @@
 package config
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	"html/template"
+	stdlog "log"
 	"net/http"
 	"os"
 	"photos/pkg/db"
-	"strings"
+	"photos/pkg/logging"
+	"photos/pkg/phash"
+	"photos/pkg/storage"
+	"photos/pkg/utils"
+	"slices"
 	"time"
 
 	"github.com/gorilla/securecookie"
 	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v3"
 )
 
+// Defaults of the settings which were hard-coded before they were configurable. Files written before keep the
+// former behavior.
+var (
+	defaultCORSOrigins = []string{"https://*", "http://*"}
+	defaultLogLevel    = "info"
+	defaultRateLimits  = RateLimits{Global: 60, Login: 10, Unlock: 10}
+	defaultMetrics     = Metrics{Path: "/metrics", AllowedIPs: []string{"127.0.0.1", "::1"}}
+	defaultTracing     = Tracing{Endpoint: "http://localhost:4318", ServiceName: "photos", SampleRatio: 1}
+	defaultLog         = Log{
+		Level:   defaultLogLevel,
+		Format:  logging.FormatConsole,
+		Outputs: []string{logging.OutputStdout, logging.OutputFile},
+		File: LogFile{
+			Path:       "photos.log",
+			MaxSize:    100 << 20,
+			MaxAge:     24 * time.Hour,
+			MaxBackups: 14,
+			Retention:  30 * 24 * time.Hour,
+		},
+	}
+)
+
 // defaultConfig generates and returns a default configuration object.
 //
 // The function creates default values for various fields in the Config struct,
@@ -32,6 +60,10 @@ func defaultConfig() (Config, error) {
 	if err != nil {
 		return Config{}, err
 	}
+	s3, err := generateSecureHex(16)
+	if err != nil {
+		return Config{}, err
+	}
 
 	defaultCfg := Config{
 		DevMode: DevMode{
@@ -45,11 +77,14 @@ func defaultConfig() (Config, error) {
 			IdleTimeout:           30 * time.Second,
 			MaxHeaderBytes:        1024 * 4,
 			MaxBodySize:           1024,
+			MaxUploadSize:         200 << 20,
+			UploadTimeout:         5 * time.Minute,
+			CORSOrigins:           slices.Clone(defaultCORSOrigins),
 		},
 		Security: Security{
 			Csrf: CsrfToken{
 				Token: Token{
-					Secret:         s1,
+					Keys:           []Key{{Secret: s1}},
 					CookieName:     "csrf_token",
 					CookieMaxAge:   10 * time.Minute,
 					CookieSecure:   true,
@@ -61,14 +96,13 @@ func defaultConfig() (Config, error) {
 			},
 			Session: SessionToken{
 				Token: Token{
-					Secret:         s2,
+					Keys:           []Key{{Secret: s2, EncryptionSecret: s3}},
 					CookieName:     "session_token",
 					CookieMaxAge:   time.Hour,
 					CookieSecure:   true,
 					CookieHTTPOnly: true,
 					CookieSameSite: http.SameSiteStrictMode,
 				},
-				SecureCookie: securecookie.New(s2, nil),
 			},
 		},
 		BaseURLs: BaseURLs{
@@ -82,34 +116,102 @@ func defaultConfig() (Config, error) {
 			},
 		},
 		Routes: Routes{
-			Favicon:     "/favicon.ico",
-			Landing:     "/",
-			Login:       "/login",
-			CasCallback: "/cas",
-			Dashboard:   "/dashboard",
-			Logout:      "/logout",
+			Favicon:              "/favicon.ico",
+			Landing:              "/",
+			Login:                "/login",
+			CasCallback:          "/cas",
+			Dashboard:            "/dashboard",
+			Logout:               "/logout",
+			Photos:               "/photos",
+			PhotoDetails:         "/photos/{photo_id}",
+			PhotoThumbnail:       "/photos/{photo_id}/thumbnail",
+			PhotoPreview:         "/photos/{photo_id}/preview",
+			PhotoDownload:        "/photos/{photo_id}/download",
+			PhotosArchive:        "/photos/archive",
+			EventArchive:         "/events/{event_id}/archive",
+			EventUnlock:          "/events/{event_id}/unlock",
+			MyPhotos:             "/me/photos",
+			FaceConsent:          "/me/consent",
+			FaceName:             "/me/consent/name",
+			FaceGroupClaim:       "/faces/{face_group_id}/claim",
+			MyData:               "/me/data",
+			AdminImport:          "/admin/import",
+			AdminMetadataPolicy:  "/admin/events/{event_id}/metadata_policy",
+			AdminEventPassword:   "/admin/events/{event_id}/password",
+			AdminEventVisibility: "/admin/events/{event_id}/visibility",
+			AdminPhotoVisibility: "/admin/photos/{photo_id}/visibility",
+			AdminUpload:          "/admin/upload",
+			AdminDuplicates:      "/admin/duplicates",
+			AdminDuplicate:       "/admin/duplicates/{photo_id}/{duplicate_of_photo_id}",
+			AdminPhotoDelete:     "/admin/photos/{photo_id}/delete",
+			AdminUsage:           "/admin/usage",
+			AdminFaceGroupUser:   "/admin/faces/{face_group_id}/user",
+			AdminFaceGroupClaim:  "/admin/faces/{face_group_id}/claims/{user_id}",
+			AdminFaceGroups:      "/admin/faces",
+			AdminFaceGroup:       "/admin/faces/{face_group_id}",
+			AdminFaceGroupMerge:  "/admin/faces/{face_group_id}/merge",
+			AdminImageFacesMove:  "/admin/faces/{face_group_id}/move",
+			AdminFaceGroupSplit:  "/admin/faces/split",
+			AdminFacesRecognize:  "/admin/faces/recognize",
+			AdminFaceThumbnail:   "/admin/image_faces/{image_face_id}/thumbnail",
+			AdminJobs:            "/admin/jobs",
+			AdminJobRetry:        "/admin/jobs/{job_id}/retry",
+			AdminUserErase:       "/admin/users/{user_id}/erase",
 		},
+		Storage: Storage{
+			Driver: "local",
+			Root:   "photos",
+			S3: S3{
+				Region:    "us-east-1",
+				PathStyle: true,
+			},
+			SignedURLExpiry: 15 * time.Minute,
+			ImportDir:       "imports",
+			Duplicates:      "warn",
+			MaxDistance:     phash.DefaultMaxDistance,
+		},
+		Downloads: Downloads{
+			MaxConcurrentArchives: 2,
+			MaxArchivePhotos:      100,
+		},
+		Consistency: Consistency{
+			Interval: 24 * time.Hour,
+			MinAge:   time.Hour,
+		},
+		Faces: Faces{
+			Backend: "pico",
+			Models:  "pkg/face_detection/models",
+		},
+		Jobs: Jobs{
+			Workers:      2,
+			PollInterval: 5 * time.Second,
+			Lease:        10 * time.Minute,
+			MaxAttempts:  5,
+			Backoff:      time.Minute,
+			MaxBackoff:   6 * time.Hour,
+			Retention:    7 * 24 * time.Hour,
+		},
+		Log:        defaultLog.clone(),
+		Metrics:    defaultMetrics.clone(),
+		Tracing:    defaultTracing,
+		RateLimits: defaultRateLimits,
 	}
 	return defaultCfg, nil
 }
 
 // Load loads the application configuration from a YAML file.
 //
-// If the configuration file does not exist, it prompts the user to create a default one.
+// If the configuration file does not exist, it exits at once, the file being created by the config init command.
 // It also sets up logging, parses HTML templates, and initializes the database connection
-// and HTTP client based on the configuration.
+// and HTTP client based on the configuration. Until the configuration is read, messages go to the console. The
+// logger of the configuration then also receives the messages of the global zerolog logger and of the standard log
+// package.
 //
 // Returns:
 //   - Config: The application configuration object populated with values from the YAML file
 //     or generated defaults.
 func Load() Config {
-	consoleWriter := zerolog.NewConsoleWriter()
-	logFile, err := os.Create("logs")
-	if err != nil {
-		consoleLogger := zerolog.New(consoleWriter).With().Timestamp().Logger()
-		consoleLogger.Fatal().Err(err).Msg("could not create log file")
-	}
-	logger := zerolog.New(zerolog.MultiLevelWriter(consoleWriter, logFile)).With().Timestamp().Logger()
+	logger := zerolog.New(zerolog.NewConsoleWriter()).With().Timestamp().Logger()
 
 	var cfgPath string
 	flag.StringVar(&cfgPath, "config", "config.yml", "Path to the configuration file (default: config.yml)")
@@ -120,24 +222,9 @@ func Load() Config {
 		logger.Fatal().Msg("unexpected arguments were given")
 	}
 
-	// Check if the config file exists
-	if _, err = os.Stat(cfgPath); os.IsNotExist(err) {
-		logger.Info().Str("path", cfgPath).Msg("config file not found")
-		logger.Info().Msg("would you like to create a default config file? (yes/no): ")
-
-		var response string
-		_, _ = fmt.Scanln(&response)
-		response = strings.ToLower(response)
-
-		if strings.HasPrefix(response, "y") {
-			if err = createDefaultConfig(cfgPath); err != nil {
-				logger.Fatal().Err(err).Msg("failed to create default config file")
-			}
-			logger.Info().Str("path", cfgPath).Msg("default config file created")
-			logger.Info().Str("path", cfgPath).Msg("you now have to specify the database DSN in the created config file")
-		} else {
-			logger.Fatal().Msg("exiting program, no config file created")
-		}
+	// Prompting would hang under a service manager, the file is created by the config init command instead.
+	if _, err := os.Stat(cfgPath); os.IsNotExist(err) {
+		logger.Fatal().Str("path", cfgPath).Msg("config file not found, create it with photos_server config init")
 	}
 
 	logger.Info().Str("path", cfgPath).Msg("using config file")
@@ -146,34 +233,166 @@ func Load() Config {
 		logger.Fatal().Err(err).Msg("failed to read config file")
 	}
 
-	cfg := Config{}
-	err = yaml.Unmarshal(data, &cfg)
+	cfg, err := parse(data, os.LookupEnv)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("failed to unmarshal config file")
+		logger.Fatal().Err(err).Msg("invalid configuration")
 	}
-	cfg.Templates, err = template.ParseGlob("assets/templates/*.html")
+	cfg.Path = cfgPath
+	configured, err := logging.New(cfg.Log.options())
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to set up logging")
+	}
+	logger = configured
+	log.Logger = logger
+	stdlog.SetFlags(0)
+	stdlog.SetOutput(logger)
+
+	reloadable, err := newReloadable(cfg)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("failed to parse html templates")
+		logger.Fatal().Err(err).Msg("failed to load reloadable settings")
 	}
+	cfg.Live = NewLive(reloadable)
 
 	if cfg.DevMode.Enabled {
-		cfg.DB.DB, err = db.New(cfg.DB.Dev.Username, cfg.DB.Dev.Password, cfg.DB.Dev.Host, cfg.DB.Dev.Port, cfg.DB.Dev.Name, cfg.DB.Dev.Cert, cfg.DB.Dev.MaxOpenConns, cfg.DB.Dev.MaxIdleConns, cfg.DB.Dev.ConnMaxLifetime, false)
+		cfg.DB.DB, err = db.New(cfg.DB.Dev.options())
 		if err != nil {
 			logger.Fatal().Err(err).Msg("failed to create database connection")
 		}
 	} else {
-		cfg.DB.DB, err = db.New(cfg.DB.Prod.Username, cfg.DB.Prod.Password, cfg.DB.Prod.Host, cfg.DB.Prod.Port, cfg.DB.Prod.Name, cfg.DB.Prod.Cert, cfg.DB.Prod.MaxOpenConns, cfg.DB.Prod.MaxIdleConns, cfg.DB.Prod.ConnMaxLifetime, false)
+		cfg.DB.DB, err = db.New(cfg.DB.Prod.options())
 		if err != nil {
 			logger.Fatal().Err(err).Msg("failed to create database connection")
 		}
 	}
+	cfg.Storage.Storage, err = newStorage(cfg.Storage)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create storage driver")
+	}
 	cfg.HttpClient = newHTTPClient(6*time.Second, false, false, false, nil)
-	cfg.Security.Session.SecureCookie = securecookie.New(cfg.Security.Session.Secret, nil)
+	cfg.Security.Csrf.Codecs = cfg.Security.Csrf.codecs(time.Now, securecookie.JSONEncoder{})
+	cfg.Security.Session.Codecs = cfg.Security.Session.codecs(time.Now, nil)
 	cfg.Logger = logger
+	cfg.Archives = utils.NewKeyedLimiter(cfg.Downloads.MaxConcurrentArchives)
 
 	return cfg
 }
 
+// parse decodes a configuration file, overrides it with the environment variables and validates it.
+//
+// Parameters:
+//   - data: Content of the YAML configuration file.
+//   - lookup: Function reading an environment variable, os.LookupEnv outside of tests.
+//
+// Returns:
+//   - Config: The configuration.
+//   - error: Every invalid value of the file, the environment and the resulting configuration, joined.
+func parse(data []byte, lookup func(string) (string, bool)) (Config, error) {
+	cfg := baseConfig()
+	var errs []error
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		// Type errors leave the other fields decoded, they are reported with the other invalid values.
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return Config{}, fmt.Errorf("failed to unmarshal config file: %w", err)
+		}
+		errs = append(errs, err)
+	}
+	errs = append(errs, applyEnv(&cfg, lookup), cfg.Validate())
+	return cfg, errors.Join(errs...)
+}
+
+// baseConfig returns the configuration a file is decoded into, holding the defaults of the settings which were
+// hard-coded before they were configurable.
+func baseConfig() Config {
+	return Config{
+		Server:     Server{CORSOrigins: slices.Clone(defaultCORSOrigins)},
+		Log:        defaultLog.clone(),
+		Metrics:    defaultMetrics.clone(),
+		Tracing:    defaultTracing,
+		RateLimits: defaultRateLimits,
+	}
+}
+
+// clone returns a copy of the configuration of the logs which does not share its outputs.
+func (l Log) clone() Log {
+	l.Outputs = slices.Clone(l.Outputs)
+	return l
+}
+
+// clone returns a copy of the configuration of the metrics which does not share its allowed addresses.
+func (m Metrics) clone() Metrics {
+	m.AllowedIPs = slices.Clone(m.AllowedIPs)
+	return m
+}
+
+// options converts the configuration of the logs into the options of the logging package.
+func (l Log) options() logging.Options {
+	return logging.Options{
+		Format:  l.Format,
+		Outputs: l.Outputs,
+		File: logging.FileOptions{
+			Path:       l.File.Path,
+			MaxSize:    l.File.MaxSize,
+			MaxAge:     l.File.MaxAge,
+			MaxBackups: l.File.MaxBackups,
+			Retention:  l.File.Retention,
+		},
+	}
+}
+
+// options converts the connection details of a database into the options of the db package.
+func (dsn DSN) options() db.Options {
+	return db.Options{
+		Driver:   dsn.Driver,
+		Path:     dsn.Path,
+		DSN:      dsn.DSN,
+		Username: dsn.Username,
+		Password: dsn.Password,
+		Host:     dsn.Host,
+		Port:     dsn.Port,
+		Socket:   dsn.Socket,
+		Name:     dsn.Name,
+		Params:   dsn.Params,
+		TLS: db.TLSOptions{
+			Enabled:    dsn.TLS.Enabled,
+			CA:         dsn.TLS.CA,
+			Cert:       dsn.TLS.Cert,
+			Key:        dsn.TLS.Key,
+			ServerName: dsn.TLS.ServerName,
+			SkipVerify: dsn.TLS.SkipVerify,
+		},
+		MaxOpenConns:    dsn.MaxOpenConns,
+		MaxIdleConns:    dsn.MaxIdleConns,
+		ConnMaxLifetime: dsn.ConnMaxLifetime,
+	}
+}
+
+// newStorage creates the storage driver selected in the configuration.
+//
+// Parameters:
+//   - cfg: The storage configuration.
+//
+// Returns:
+//   - storage.Storage: The storage driver.
+//   - error: An error if the driver is unknown or misconfigured.
+func newStorage(cfg Storage) (storage.Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return storage.NewLocal(cfg.Root), nil
+	case "s3":
+		return storage.NewS3(storage.S3Options{
+			Endpoint:        cfg.S3.Endpoint,
+			Region:          cfg.S3.Region,
+			Bucket:          cfg.S3.Bucket,
+			AccessKeyID:     cfg.S3.AccessKeyID,
+			SecretAccessKey: cfg.S3.SecretAccessKey,
+			PathStyle:       cfg.S3.PathStyle,
+			HTTPClient:      newHTTPClient(0, false, false, false, nil),
+		})
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+}
+
 // createDefaultConfig generates a default configuration file at the specified path.
 //
 // Parameters:
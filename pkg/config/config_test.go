@@ -18,8 +18,9 @@ func TestDefaultConfig(t *testing.T) {
 	// Verify some default values
 	assert.Equal(t, 8080, cfg.Server.Port, "Default server port should be 8080")
 	assert.True(t, cfg.DevMode.Enabled, "DevMode should be enabled by default")
-	assert.NotEmpty(t, cfg.Security.Csrf.Token.Secret, "CSRF Token Secret should be generated")
-	assert.NotEmpty(t, cfg.Security.Session.Token.Secret, "Session Token Secret should be generated")
+	assert.Len(t, cfg.Security.Csrf.Token.Keys, 1, "CSRF Token Key should be generated")
+	assert.NotEmpty(t, cfg.Security.Csrf.Token.Keys[0].Secret, "CSRF Token Secret should be generated")
+	assert.NotEmpty(t, cfg.Security.Session.Token.Keys[0].EncryptionSecret, "Session Token Encryption Secret should be generated")
 	assert.Equal(t, "/favicon.ico", cfg.Routes.Favicon, "Default favicon route should be set")
 }
 
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix namespaces the environment variables overriding the configuration.
+const envPrefix = "PHOTOS"
+
+// fileSuffix marks the environment variables holding the path of a file with the value, as systemd credentials and
+// Docker secrets provide them.
+const fileSuffix = "_FILE"
+
+// applyEnv overrides the fields of the configuration with the environment variables named after their YAML path,
+// such as PHOTOS_DB_PROD_PASSWORD for db.prod.password. A variable suffixed with _FILE names a file holding the value,
+// its trailing newlines removed.
+//
+// Parameters:
+//   - cfg: The configuration to override.
+//   - lookup: Function reading an environment variable, os.LookupEnv outside of tests.
+//
+// Returns:
+//   - error: The invalid values of every variable, joined, or nil.
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	return errors.Join(applyEnvToStruct(reflect.ValueOf(cfg).Elem(), envPrefix, lookup)...)
+}
+
+// applyEnvToStruct overrides the fields of a struct whose variables start with the prefix.
+func applyEnvToStruct(v reflect.Value, prefix string, lookup func(string) (string, bool)) []error {
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		key := prefix + "_" + strings.ToUpper(name)
+		fv := v.Field(i)
+		if _, ok := fv.Addr().Interface().(yaml.Unmarshaler); !ok && fv.Kind() == reflect.Struct {
+			errs = append(errs, applyEnvToStruct(fv, key, lookup)...)
+			continue
+		}
+		value, ok, err := lookupValue(key, lookup)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := setField(fv, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+	return errs
+}
+
+// lookupValue reads a variable, or the file named by the variable with the _FILE suffix.
+func lookupValue(key string, lookup func(string) (string, bool)) (string, bool, error) {
+	if value, ok := lookup(key); ok {
+		return value, true, nil
+	}
+	path, ok := lookup(key + fileSuffix)
+	if !ok {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s%s: %w", key, fileSuffix, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// setField sets a field from the value of a variable. Strings are taken as is, other values are decoded as YAML
+// so that they are written as in the configuration file.
+func setField(fv reflect.Value, value string) error {
+	if fv.Kind() == reflect.String {
+		fv.SetString(value)
+		return nil
+	}
+	return yaml.Unmarshal([]byte(value), fv.Addr().Interface())
+}
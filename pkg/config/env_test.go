@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lookupMap returns a lookup function reading the variables of a map.
+func lookupMap(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+// TestApplyEnv ensures that environment variables override the fields named after their YAML path.
+func TestApplyEnv(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "password")
+	assert.NoError(t, os.WriteFile(secretFile, []byte("s3cret\n"), 0600))
+
+	cfg := Config{}
+	err := applyEnv(&cfg, lookupMap(map[string]string{
+		"PHOTOS_SERVER_PORT":                     "9090",
+		"PHOTOS_SERVER_READ_TIMEOUT":             "3s",
+		"PHOTOS_DEV_MODE_ENABLED":                "true",
+		"PHOTOS_DB_PROD_HOST":                    "db.internal",
+		"PHOTOS_DB_PROD_PASSWORD_FILE":           secretFile,
+		"PHOTOS_SECURITY_SESSION_TOKEN_KEYS":     "[{secret: '12345678'}]",
+		"PHOTOS_SECURITY_CSRF_TOKEN_COOKIE_NAME": "csrf: token",
+	}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, cfg.Server.Port)
+	assert.Equal(t, 3*time.Second, cfg.Server.ReadTimeout)
+	assert.True(t, cfg.DevMode.Enabled)
+	assert.Equal(t, "db.internal", cfg.DB.Prod.Host)
+	assert.Equal(t, "s3cret", cfg.DB.Prod.Password, "the trailing newline of secret files should be removed")
+	assert.Equal(t, []Key{{Secret: secretKey([]byte{0x12, 0x34, 0x56, 0x78})}}, cfg.Security.Session.Keys)
+	assert.Equal(t, "csrf: token", cfg.Security.Csrf.CookieName, "strings should be taken as is")
+}
+
+// TestApplyEnvErrors ensures that every invalid variable is reported.
+func TestApplyEnvErrors(t *testing.T) {
+	cfg := Config{}
+	err := applyEnv(&cfg, lookupMap(map[string]string{
+		"PHOTOS_SERVER_PORT":                 "eighty",
+		"PHOTOS_SECURITY_SESSION_TOKEN_KEYS": "[{secret: nothex}]",
+		"PHOTOS_DB_DEV_PASSWORD_FILE":        filepath.Join(t.TempDir(), "missing"),
+	}))
+
+	assert.ErrorContains(t, err, "PHOTOS_SERVER_PORT")
+	assert.ErrorContains(t, err, "PHOTOS_SECURITY_SESSION_TOKEN_KEYS")
+	assert.ErrorContains(t, err, "PHOTOS_DB_DEV_PASSWORD_FILE")
+}
@@ -0,0 +1,268 @@
+package config
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"gopkg.in/yaml.v3"
+)
+
+// tokenTypes are the token types whose keys are rotated, named after their section of security.
+var tokenTypes = []string{"csrf", "session"}
+
+// errKeyRetired is returned by the codec of a key used after its retirement.
+var errKeyRetired = errors.New("securecookie: the key is retired")
+
+// codecs builds the codecs of the keys which are not retired, the first one encoding the cookies. The codecs of the
+// keys retiring later refuse the cookies from their retirement on, so that a running server drops the keys without
+// being restarted.
+//
+// Parameters:
+//   - now: Clock the retirement of the keys is compared to, time.Now outside of tests.
+//   - serializer: Serializer of the cookie values, nil for the securecookie default.
+//
+// Returns:
+//   - []securecookie.Codec: One codec per key in use.
+func (t Token) codecs(now func() time.Time, serializer securecookie.Serializer) []securecookie.Codec {
+	var codecs []securecookie.Codec
+	for _, key := range t.Keys {
+		if !key.RetireAt.IsZero() && !now().Before(key.RetireAt) {
+			continue
+		}
+		var block []byte
+		if len(key.EncryptionSecret) > 0 {
+			block = key.EncryptionSecret
+		}
+		codec := securecookie.New(key.Secret, block)
+		if serializer != nil {
+			codec.SetSerializer(serializer)
+		}
+		if key.RetireAt.IsZero() {
+			codecs = append(codecs, codec)
+			continue
+		}
+		codecs = append(codecs, retiringCodec{Codec: codec, retireAt: key.RetireAt, now: now})
+	}
+	return codecs
+}
+
+// retiringCodec is the codec of a key which stops encoding and decoding cookies at its retirement.
+type retiringCodec struct {
+	securecookie.Codec
+	retireAt time.Time
+	now      func() time.Time
+}
+
+// Encode encodes a cookie value, unless the key is retired.
+func (c retiringCodec) Encode(name string, value interface{}) (string, error) {
+	if !c.now().Before(c.retireAt) {
+		return "", errKeyRetired
+	}
+	return c.Codec.Encode(name, value)
+}
+
+// Decode decodes a cookie value, unless the key is retired.
+func (c retiringCodec) Decode(name, value string, dst interface{}) error {
+	if !c.now().Before(c.retireAt) {
+		return errKeyRetired
+	}
+	return c.Codec.Decode(name, value, dst)
+}
+
+// GenerateKey generates a secret in the format of the configuration file, for a key added by hand or given through an
+// environment variable.
+//
+// Returns:
+//   - string: A hexadecimal secret.
+//   - error: An error if random byte generation fails.
+func GenerateKey() (string, error) {
+	key, err := generateSecureHex(16)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// RotateKeys adds a new key in front of the keys of the token types, so that it signs the new cookies, while the former
+// keys verify the cookies they signed until they retire after the overlap period. Keys which retired are removed. The
+// values, the order and the comments of the file are kept.
+//
+// Parameters:
+//   - path: Path of the configuration file.
+//   - tokens: Token types whose keys are rotated, csrf or session, none for both.
+//   - overlap: Duration the former keys still verify cookies, 0 for the cookie max age of each token type.
+//   - encrypt: Whether the new session key encrypts the cookies. A session key is also encrypting if the key it
+//     replaces was.
+//   - now: Time of the rotation.
+//
+// Returns:
+//   - []string: The changes, one per token type and removed key.
+//   - error: An error if the file could not be read, decoded or written, or if a token type has no keys.
+func RotateKeys(path string, tokens []string, overlap time.Duration, encrypt bool, now time.Time) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var file yaml.Node
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config file: %w", err)
+	}
+	cfg := baseConfig()
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("failed to unmarshal config file: %w", err)
+		}
+	}
+	if len(file.Content) == 0 {
+		return nil, errors.New("config file is empty")
+	}
+
+	if len(tokens) == 0 {
+		tokens = tokenTypes
+	}
+	var changes []string
+	for _, name := range tokens {
+		var maxAge time.Duration
+		switch name {
+		case "csrf":
+			maxAge = cfg.Security.Csrf.CookieMaxAge
+		case "session":
+			maxAge = cfg.Security.Session.CookieMaxAge
+		default:
+			return nil, fmt.Errorf("unknown token type %q, expected csrf or session", name)
+		}
+		section := "security." + name + ".token"
+		var keys *yaml.Node
+		if security := lookupKey(file.Content[0], "security"); security != nil {
+			if tokenType := lookupKey(security, name); tokenType != nil {
+				if token := lookupKey(tokenType, "token"); token != nil {
+					keys = lookupKey(token, "keys")
+				}
+			}
+		}
+		if keys == nil || keys.Kind != yaml.SequenceNode || len(keys.Content) == 0 {
+			return nil, fmt.Errorf("%s.keys is missing, run photos_server config migrate first", section)
+		}
+
+		retireAt := now.Add(overlap)
+		if overlap <= 0 {
+			retireAt = now.Add(maxAge)
+		}
+		retireAt = retireAt.UTC().Truncate(time.Second)
+		var current Key
+		if err := keys.Content[0].Decode(&current); err != nil {
+			return nil, fmt.Errorf("failed to decode %s.keys: %w", section, err)
+		}
+		next, err := newKey(name == "session" && (encrypt || len(current.EncryptionSecret) > 0))
+		if err != nil {
+			return nil, err
+		}
+		content := []*yaml.Node{next}
+		for _, item := range keys.Content {
+			var key Key
+			if err := item.Decode(&key); err != nil {
+				return nil, fmt.Errorf("failed to decode %s.keys: %w", section, err)
+			}
+			if !key.RetireAt.IsZero() && !now.Before(key.RetireAt) {
+				changes = append(changes, fmt.Sprintf("%s: removed a key retired at %s", section, key.RetireAt.Format(time.RFC3339)))
+				continue
+			}
+			if key.RetireAt.IsZero() {
+				var value yaml.Node
+				if err := value.Encode(retireAt); err != nil {
+					return nil, fmt.Errorf("failed to encode retirement time: %w", err)
+				}
+				setKey(item, "retire_at", &value)
+			}
+			content = append(content, item)
+		}
+		keys.Content = content
+		changes = append(changes, fmt.Sprintf("%s: added a key, the former keys retire at %s", section, retireAt.Format(time.RFC3339)))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	out, err := yaml.Marshal(&file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := replaceFile(path, out, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write config file: %w", err)
+	}
+	return changes, nil
+}
+
+// upgradeSecrets moves the single secret of the token types of files written before key rotation into their keys, so
+// that the cookies they signed stay valid.
+//
+// Parameters:
+//   - root: Mapping of the configuration file.
+//
+// Returns:
+//   - []string: Paths of the added keys fields.
+func upgradeSecrets(root *yaml.Node) []string {
+	security := lookupKey(root, "security")
+	if security == nil || security.Kind != yaml.MappingNode {
+		return nil
+	}
+	var added []string
+	for _, name := range tokenTypes {
+		tokenType := lookupKey(security, name)
+		if tokenType == nil || tokenType.Kind != yaml.MappingNode {
+			continue
+		}
+		token := lookupKey(tokenType, "token")
+		if token == nil || token.Kind != yaml.MappingNode || lookupKey(token, "keys") != nil {
+			continue
+		}
+		for i := 0; i+1 < len(token.Content); i += 2 {
+			if token.Content[i].Value != "secret" {
+				continue
+			}
+			key := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{token.Content[i], token.Content[i+1]}}
+			token.Content[i] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "keys"}
+			token.Content[i+1] = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{key}}
+			added = append(added, "security."+name+".token.keys")
+			break
+		}
+	}
+	return added
+}
+
+// newKey generates a key and encodes it as a YAML node.
+func newKey(encrypt bool) (*yaml.Node, error) {
+	secret, err := generateSecureHex(16)
+	if err != nil {
+		return nil, err
+	}
+	key := Key{Secret: secret}
+	if encrypt {
+		key.EncryptionSecret, err = generateSecureHex(16)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var node yaml.Node
+	if err := node.Encode(key); err != nil {
+		return nil, fmt.Errorf("failed to encode key: %w", err)
+	}
+	return &node, nil
+}
+
+// setKey sets the value of a key of a mapping, appending the key if it is missing.
+func setKey(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// TestCodecs ensures that the first key encodes the cookies, the former keys still decode them until they retire, and
+// retired keys are left out.
+func TestCodecs(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	former := Key{Secret: secretKey("former-signing-key"), EncryptionSecret: secretKey("0123456789abcdef")}
+	previous := Token{Keys: []Key{former}}
+	encoded, err := securecookie.EncodeMulti("session", "value", previous.codecs(clock, nil)...)
+	assert.NoError(t, err)
+
+	former.RetireAt = now.Add(time.Hour)
+	rotated := Token{Keys: []Key{{Secret: secretKey("new-signing-key")}, former}}
+	codecs := rotated.codecs(clock, nil)
+	assert.Len(t, codecs, 2)
+	var value string
+	assert.NoError(t, securecookie.DecodeMulti("session", encoded, &value, codecs...))
+	assert.Equal(t, "value", value)
+	assert.Error(t, codecs[0].Decode("session", encoded, &value), "the new key should not decode the former cookies")
+
+	now = now.Add(2 * time.Hour)
+	assert.ErrorIs(t, codecs[1].Decode("session", encoded, &value), errKeyRetired, "keys should retire while running")
+	_, err = codecs[1].Encode("session", "value")
+	assert.ErrorIs(t, err, errKeyRetired)
+	assert.Len(t, rotated.codecs(clock, nil), 1, "retired keys should be left out")
+}
+
+// TestRotateKeys ensures that a new key is added in front, the former ones retire after the overlap and retired keys
+// are removed.
+func TestRotateKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	assert.NoError(t, createDefaultConfig(path))
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	changes, err := RotateKeys(path, nil, 0, false, now)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+
+	cfg := readConfig(t, path)
+	assert.Len(t, cfg.Security.Csrf.Keys, 2)
+	assert.True(t, cfg.Security.Csrf.Keys[0].RetireAt.IsZero())
+	assert.Empty(t, cfg.Security.Csrf.Keys[0].EncryptionSecret)
+	assert.Equal(t, now.Add(cfg.Security.Csrf.CookieMaxAge), cfg.Security.Csrf.Keys[1].RetireAt.UTC())
+	assert.NotEmpty(t, cfg.Security.Session.Keys[0].EncryptionSecret, "an encrypting session key should be replaced by one")
+	assert.Equal(t, now.Add(cfg.Security.Session.CookieMaxAge), cfg.Security.Session.Keys[1].RetireAt.UTC())
+	assert.Empty(t, validateKeys("security.csrf.token.keys", cfg.Security.Csrf.Keys, false))
+	assert.Empty(t, validateKeys("security.session.token.keys", cfg.Security.Session.Keys, true))
+
+	changes, err = RotateKeys(path, []string{"csrf"}, time.Minute, false, now.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+	assert.Contains(t, changes[0], "removed a key")
+	cfg = readConfig(t, path)
+	assert.Len(t, cfg.Security.Csrf.Keys, 2)
+	assert.Len(t, cfg.Security.Session.Keys, 2)
+
+	_, err = RotateKeys(path, []string{"api"}, 0, false, now)
+	assert.ErrorContains(t, err, "unknown token type")
+}
+
+// TestMigrateSecrets ensures that the single secret of older files is moved into their keys.
+func TestMigrateSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	data := "security:\n  session:\n    token:\n      secret: 12345678\n      cookie_name: session_token\n"
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0600))
+
+	added, err := Migrate(path)
+	assert.NoError(t, err)
+	assert.Contains(t, added, "security.session.token.keys")
+	cfg := readConfig(t, path)
+	assert.Equal(t, []Key{{Secret: secretKey([]byte{0x12, 0x34, 0x56, 0x78})}}, cfg.Security.Session.Keys)
+	assert.Len(t, cfg.Security.Csrf.Keys, 1, "the missing csrf keys should be generated")
+}
+
+// TestValidateKeys ensures that the keys of the token types are checked.
+func TestValidateKeys(t *testing.T) {
+	assert.ErrorContains(t, errors.Join(validateKeys("keys", nil, true)...), "config migrate")
+	errs := validateKeys("keys", []Key{
+		{Secret: secretKey("key"), RetireAt: time.Now()},
+		{Secret: secretKey("key"), EncryptionSecret: secretKey("short")},
+	}, true)
+	assert.ErrorContains(t, errors.Join(errs...), "keys[0] signs the cookies")
+	assert.ErrorContains(t, errors.Join(errs...), "keys[1].encryption_secret must hold 16, 24 or 32 bytes")
+	errs = validateKeys("keys", []Key{{Secret: make(secretKey, csrfKeyLength), EncryptionSecret: make(secretKey, 16)}}, false)
+	assert.ErrorContains(t, errors.Join(errs...), "not supported")
+}
+
+// readConfig decodes a configuration file.
+func readConfig(t *testing.T, path string) Config {
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	var cfg Config
+	assert.NoError(t, yaml.Unmarshal(data, &cfg))
+	return cfg
+}
@@ -1,9 +1,11 @@
 package config
 
 import (
-	"html/template"
 	"net/http"
 	"photos/pkg/db"
+	"photos/pkg/importer"
+	"photos/pkg/storage"
+	"photos/pkg/utils"
 	"time"
 
 	"github.com/gorilla/securecookie"
@@ -13,16 +15,28 @@ import (
 // Config represents the main configuration structure for the application.
 // It includes settings for development mode, server, security, database, base URLs, and routes.
 type Config struct {
-	DevMode  DevMode  `yaml:"dev_mode"`  // Development mode settings.
-	Server   Server   `yaml:"server"`    // Server-related configuration.
-	Security Security `yaml:"security"`  // Security settings such as CSRF and session tokens.
-	DB       DB       `yaml:"db"`        // Database connection details for development and production.
-	BaseURLs BaseURLs `yaml:"base_urls"` // URLs for different environments (Dev and Prod).
-	Routes   Routes   `yaml:"routes"`    // Application route paths.
+	DevMode     DevMode     `yaml:"dev_mode"`    // Development mode settings.
+	Server      Server      `yaml:"server"`      // Server-related configuration.
+	Security    Security    `yaml:"security"`    // Security settings such as CSRF and session tokens.
+	DB          DB          `yaml:"db"`          // Database connection details for development and production.
+	BaseURLs    BaseURLs    `yaml:"base_urls"`   // URLs for different environments (Dev and Prod).
+	Routes      Routes      `yaml:"routes"`      // Application route paths.
+	Storage     Storage     `yaml:"storage"`     // Photo storage settings.
+	Downloads   Downloads   `yaml:"downloads"`   // Archive download settings.
+	Consistency Consistency `yaml:"consistency"` // Database and storage consistency check settings.
+	Faces       Faces       `yaml:"faces"`       // Face detection settings.
+	Jobs        Jobs        `yaml:"jobs"`        // Background job queue settings.
+	Log         Log         `yaml:"log"`         // Logging settings.
+	Metrics     Metrics     `yaml:"metrics"`     // Prometheus metrics endpoint settings.
+	Tracing     Tracing     `yaml:"tracing"`     // OpenTelemetry tracing settings.
+	RateLimits  RateLimits  `yaml:"rate_limits"` // Request rate limits.
 
-	HttpClient *http.Client       `yaml:"-"` // HTTP client instance (excluded from YAML).
-	Templates  *template.Template `yaml:"-"` // Parsed HTML templates (excluded from YAML).
-	Logger     zerolog.Logger     `yaml:"-"` // Logger instance (excluded from YAML).
+	Path       string              `yaml:"-"` // Path of the configuration file, read again on reload (excluded from YAML).
+	Live       *Live               `yaml:"-"` // Settings swapped on reload, templates included (excluded from YAML).
+	HttpClient *http.Client        `yaml:"-"` // HTTP client instance (excluded from YAML).
+	Logger     zerolog.Logger      `yaml:"-"` // Logger instance (excluded from YAML).
+	Importer   *importer.Tracker   `yaml:"-"` // Background bulk import tracker (excluded from YAML).
+	Archives   *utils.KeyedLimiter `yaml:"-"` // Concurrent archive downloads per user (excluded from YAML).
 }
 
 // DevMode contains the configuration for development mode.
@@ -39,16 +53,29 @@ type Server struct {
 	RequestContextTimeout time.Duration `yaml:"request_context_timeout"` // Context timeout for requests.
 	MaxHeaderBytes        int           `yaml:"max_header_bytes"`        // Maximum size of request headers.
 	MaxBodySize           int64         `yaml:"max_body_size"`           // Maximum size of request bodies.
+	MaxUploadSize         int64         `yaml:"max_upload_size"`         // Maximum size of photo upload bodies.
+	UploadTimeout         time.Duration `yaml:"upload_timeout"`          // Maximum duration for reading photo upload bodies.
+	CORSOrigins           []string      `yaml:"cors_origins"`            // Origins allowed by CORS, * matching any part of an origin.
+}
+
+// Key is a key of the cookies of a token type. Keys are rotated: a new key signs the cookies while the former ones
+// still verify them until they retire.
+type Key struct {
+	Secret           secretKey `yaml:"secret"`                      // Key signing the cookies.
+	EncryptionSecret secretKey `yaml:"encryption_secret,omitempty"` // Key encrypting the cookies, of 16, 24 or 32 bytes, none to only sign them.
+	RetireAt         time.Time `yaml:"retire_at,omitempty"`         // Time after which the key no longer verifies cookies, zero for never.
 }
 
 // Token represents a base token configuration for CSRF and session tokens.
 type Token struct {
-	Secret         secretKey     `yaml:"secret"`           // The secret key used for token generation.
+	Keys           []Key         `yaml:"keys"`             // Keys of the cookies, the first signs them and all verify them.
 	CookieName     string        `yaml:"cookie_name"`      // Name of the token's cookie.
 	CookieMaxAge   time.Duration `yaml:"cookie_max_age"`   // Maximum age of the cookie.
 	CookieSecure   bool          `yaml:"cookie_secure"`    // Whether the cookie requires a secure connection.
 	CookieHTTPOnly bool          `yaml:"cookie_http_only"` // Whether the cookie is HTTP-only.
 	CookieSameSite http.SameSite `yaml:"cookie_same_site"` // SameSite policy for the cookie.
+
+	Codecs []securecookie.Codec `yaml:"-"` // Codecs of the keys in use, the first encodes the cookies (excluded from YAML).
 }
 
 // CsrfToken represents the configuration for CSRF tokens.
@@ -61,26 +88,41 @@ type CsrfToken struct {
 // SessionToken represents the configuration for session tokens.
 type SessionToken struct {
 	Token
-	SecureCookie *securecookie.SecureCookie `yaml:"-"` // SecureCookie instance for session handling (excluded from YAML).
 }
 
 // Security holds the security-related configurations such as CSRF and session tokens.
 type Security struct {
 	Csrf    CsrfToken    `yaml:"csrf"`    // CSRF token configuration.
 	Session SessionToken `yaml:"session"` // Session token configuration.
+	Admins  []string     `yaml:"admins"`  // Emails of the users who are admins, in addition to the admins of the database.
+}
+
+// DBTLS holds the TLS configuration of a database connection.
+type DBTLS struct {
+	Enabled    bool   `yaml:"enabled"`     // Whether the connection uses TLS.
+	CA         string `yaml:"ca"`          // Path of the PEM CA certificates, the system ones if empty.
+	Cert       string `yaml:"cert"`        // Path of the PEM client certificate, for servers requiring one.
+	Key        string `yaml:"key"`         // Path of the PEM private key of the client certificate.
+	ServerName string `yaml:"server_name"` // Name the server certificate is verified against, the host if empty.
+	SkipVerify bool   `yaml:"skip_verify"` // Whether the server certificate is not verified, for tests only.
 }
 
 // DSN represents the Data Source Name (DSN) configuration for database connections.
 type DSN struct {
-	Name            string        `yaml:"name"`              // Database name.
-	Username        string        `yaml:"username"`          // Database username.
-	Password        string        `yaml:"password"`          // Database password.
-	Port            string        `yaml:"port"`              // Port of the database server.
-	Host            string        `yaml:"host"`              // Hostname or IP of the database server.
-	Cert            string        `yaml:"cert"`              // TLS certificate path.
-	MaxIdleConns    int           `yaml:"max_idle_conns"`    // Maximum number of idle connections.
-	MaxOpenConns    int           `yaml:"max_open_conns"`    // Maximum number of open connections.
-	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"` // Maximum lifetime of a single connection.
+	Driver          string            `yaml:"driver"`            // Driver of the database, mysql, sqlite or postgres.
+	Path            string            `yaml:"path"`              // Path of the SQLite database file.
+	DSN             string            `yaml:"dsn"`               // Full DSN of the driver, overriding the connection fields when set.
+	Name            string            `yaml:"name"`              // Database name.
+	Username        string            `yaml:"username"`          // Database username.
+	Password        string            `yaml:"password"`          // Database password.
+	Port            string            `yaml:"port"`              // Port of the database server.
+	Host            string            `yaml:"host"`              // Hostname or IP of the database server.
+	Socket          string            `yaml:"socket"`            // Path of the unix socket of the database server, its directory with postgres, instead of host and port.
+	Params          map[string]string `yaml:"params"`            // Extra DSN parameters, such as charset or timeout.
+	TLS             DBTLS             `yaml:"tls"`               // TLS of the connection.
+	MaxIdleConns    int               `yaml:"max_idle_conns"`    // Maximum number of idle connections.
+	MaxOpenConns    int               `yaml:"max_open_conns"`    // Maximum number of open connections.
+	ConnMaxLifetime time.Duration     `yaml:"conn_max_lifetime"` // Maximum lifetime of a single connection.
 }
 
 // DB represents the database configuration for development and production environments.
@@ -92,12 +134,104 @@ type DB struct {
 
 // Routes contains the paths for various application routes.
 type Routes struct {
-	Favicon     string `yaml:"favicon"`      // Path to the favicon.
-	Landing     string `yaml:"landing"`      // Path to the landing page.
-	Login       string `yaml:"login"`        // Path to the login page.
-	CasCallback string `yaml:"cas_callback"` // Path to the CAS callback.
-	Dashboard   string `yaml:"dashboard"`    // Path to the user dashboard.
-	Logout      string `yaml:"logout"`       // Path to the logout page.
+	Favicon              string `yaml:"favicon"`                // Path to the favicon.
+	Landing              string `yaml:"landing"`                // Path to the landing page.
+	Login                string `yaml:"login"`                  // Path to the login page.
+	CasCallback          string `yaml:"cas_callback"`           // Path to the CAS callback.
+	Dashboard            string `yaml:"dashboard"`              // Path to the user dashboard.
+	Logout               string `yaml:"logout"`                 // Path to the logout page.
+	Photos               string `yaml:"photos"`                 // Path to the photos of an event, sorted by capture date.
+	PhotoDetails         string `yaml:"photo_details"`          // Path to the details and metadata of a photo.
+	PhotoThumbnail       string `yaml:"photo_thumbnail"`        // Path to the thumbnail of a photo.
+	PhotoPreview         string `yaml:"photo_preview"`          // Path to the large preview of a photo.
+	PhotoDownload        string `yaml:"photo_download"`         // Path to the full-size download of a photo.
+	PhotosArchive        string `yaml:"photos_archive"`         // Path to the ZIP download of a selection of photos.
+	EventArchive         string `yaml:"event_archive"`          // Path to the ZIP download of an event.
+	EventUnlock          string `yaml:"event_unlock"`           // Path to the endpoint unlocking a password protected event.
+	MyPhotos             string `yaml:"my_photos"`              // Path to the photos the user was recognized on.
+	FaceConsent          string `yaml:"face_consent"`           // Path to the consent of the user to face recognition.
+	FaceName             string `yaml:"face_name"`              // Path to the endpoint choosing whether the name of the user is shown on their faces.
+	FaceGroupClaim       string `yaml:"face_group_claim"`       // Path to the endpoint asking an admin to link a face group to the user.
+	MyData               string `yaml:"my_data"`                // Path to the ZIP export of the personal data of the user.
+	AdminImport          string `yaml:"admin_import"`           // Path to the admin bulk import endpoint.
+	AdminMetadataPolicy  string `yaml:"admin_metadata_policy"`  // Path to the admin endpoint setting the metadata policy of an event.
+	AdminEventPassword   string `yaml:"admin_event_password"`   // Path to the admin endpoint setting the password of an event.
+	AdminEventVisibility string `yaml:"admin_event_visibility"` // Path to the admin endpoint hiding or showing an event.
+	AdminPhotoVisibility string `yaml:"admin_photo_visibility"` // Path to the admin endpoint hiding or showing a photo.
+	AdminUpload          string `yaml:"admin_upload"`           // Path to the admin photo upload endpoint.
+	AdminDuplicates      string `yaml:"admin_duplicates"`       // Path to the admin review screen of near duplicates.
+	AdminDuplicate       string `yaml:"admin_duplicate"`        // Path to the admin endpoint resolving a near duplicate.
+	AdminPhotoDelete     string `yaml:"admin_photo_delete"`     // Path to the admin endpoint deleting a photo.
+	AdminUsage           string `yaml:"admin_usage"`            // Path to the admin page showing the storage usage.
+	AdminFaceGroupUser   string `yaml:"admin_face_group_user"`  // Path to the admin endpoint linking a face group to a user.
+	AdminFaceGroupClaim  string `yaml:"admin_face_group_claim"` // Path to the admin endpoint accepting or rejecting the claim of a face group.
+	AdminFaceGroups      string `yaml:"admin_face_groups"`      // Path to the admin page listing the face groups.
+	AdminFaceGroup       string `yaml:"admin_face_group"`       // Path to the admin page showing the faces of a face group.
+	AdminFaceGroupMerge  string `yaml:"admin_face_group_merge"` // Path to the admin endpoint merging a face group into another one.
+	AdminImageFacesMove  string `yaml:"admin_image_faces_move"` // Path to the admin endpoint moving faces of a face group to another one.
+	AdminFaceGroupSplit  string `yaml:"admin_face_group_split"` // Path to the admin endpoint moving faces to a new face group.
+	AdminFacesRecognize  string `yaml:"admin_faces_recognize"`  // Path to the admin endpoint classifying the unlabeled faces again.
+	AdminFaceThumbnail   string `yaml:"admin_face_thumbnail"`   // Path to the admin thumbnail of a face.
+	AdminJobs            string `yaml:"admin_jobs"`             // Path to the admin page showing the background jobs.
+	AdminJobRetry        string `yaml:"admin_job_retry"`        // Path to the admin endpoint running a finished or dead job again.
+	AdminUserErase       string `yaml:"admin_user_erase"`       // Path to the admin endpoint erasing a user and their personal data.
+}
+
+// Storage holds the configuration for the photo storage.
+type Storage struct {
+	storage.Storage `yaml:"-"`    // Embedded storage driver for photo and derivative files (excluded from YAML).
+	Driver          string        `yaml:"driver"`            // Storage driver: local or s3.
+	Root            string        `yaml:"root"`              // Directory where the photos are stored by the local driver.
+	S3              S3            `yaml:"s3"`                // Bucket where the photos are stored by the s3 driver.
+	SignedURLExpiry time.Duration `yaml:"signed_url_expiry"` // Lifetime of the signed URLs thumbnails and previews are redirected to, if the driver supports them.
+	ImportDir       string        `yaml:"import_dir"`        // Directory admins can import photo trees from.
+	Duplicates      string        `yaml:"duplicates"`        // Default policy for photos already stored in another event: warn, link or reject.
+	MaxDistance     int           `yaml:"max_distance"`      // Perceptual hash distance under which photos are near duplicates, negative to disable.
+	Quota           int64         `yaml:"quota"`             // Bytes the storage can hold, originals and derivatives, 0 for no limit.
+	UploaderQuota   int64         `yaml:"uploader_quota"`    // Bytes of photos a single admin can upload, 0 for no limit.
+}
+
+// S3 holds the location and credentials of an S3 compatible bucket.
+type S3 struct {
+	Endpoint        string `yaml:"endpoint"`          // URL of the service.
+	Region          string `yaml:"region"`            // Region of the bucket.
+	Bucket          string `yaml:"bucket"`            // Name of the bucket.
+	AccessKeyID     string `yaml:"access_key_id"`     // Access key of the credentials.
+	SecretAccessKey string `yaml:"secret_access_key"` // Secret key of the credentials.
+	PathStyle       bool   `yaml:"path_style"`        // Whether the bucket is addressed in the path, as MinIO expects.
+}
+
+// Downloads holds the configuration for the ZIP downloads of events and photo selections.
+type Downloads struct {
+	MaxConcurrentArchives int `yaml:"max_concurrent_archives"` // Archives a user can download at the same time, 0 for no limit.
+	MaxArchivePhotos      int `yaml:"max_archive_photos"`      // Maximum number of photos in an event or selection archive.
+}
+
+// Consistency holds the configuration of the scheduled consistency check of the database and the storage.
+type Consistency struct {
+	Interval time.Duration `yaml:"interval"` // Time between two checks, 0 to disable them.
+	Repair   bool          `yaml:"repair"`   // Whether differences are repaired: missing photos marked and orphan files quarantined.
+	DryRun   bool          `yaml:"dry_run"`  // Whether repairs are only logged.
+	MinAge   time.Duration `yaml:"min_age"`  // Files written more recently are not reported, they may belong to an upload in progress.
+}
+
+// Faces holds the configuration of the face detection.
+type Faces struct {
+	Enabled   bool    `yaml:"enabled"`   // Whether the faces of the photos are detected.
+	Backend   string  `yaml:"backend"`   // Backend detecting the faces: pico, or dlib when built with the dlib tag.
+	Models    string  `yaml:"models"`    // Directory holding the model files of the backend.
+	Threshold float32 `yaml:"threshold"` // Maximum distance between two faces of the same person, 0 for the backend default.
+}
+
+// Jobs holds the configuration of the background jobs processing the uploaded photos.
+type Jobs struct {
+	Workers      int           `yaml:"workers"`       // Number of jobs run concurrently by the server, 0 to leave them queued.
+	PollInterval time.Duration `yaml:"poll_interval"` // Time between two looks for new jobs when the queue is empty.
+	Lease        time.Duration `yaml:"lease"`         // Time a job can run before it is run again by another worker.
+	MaxAttempts  int           `yaml:"max_attempts"`  // Number of attempts before a job is dead.
+	Backoff      time.Duration `yaml:"backoff"`       // Delay before the second attempt, doubled after every failure.
+	MaxBackoff   time.Duration `yaml:"max_backoff"`   // Longest delay between two attempts.
+	Retention    time.Duration `yaml:"retention"`     // Time finished jobs are kept, 0 to keep them forever.
 }
 
 // BaseURL represents the configuration for a set of URLs.
@@ -111,3 +245,43 @@ type BaseURLs struct {
 	Dev  BaseURL `yaml:"dev"`  // Development environment URLs.
 	Prod BaseURL `yaml:"prod"` // Production environment URLs.
 }
+
+// Log holds the configuration of the logs.
+type Log struct {
+	Level   string   `yaml:"level"`   // Minimum level of the logged messages: trace, debug, info, warn or error.
+	Format  string   `yaml:"format"`  // Format of the messages: console or json.
+	Outputs []string `yaml:"outputs"` // Outputs the messages are written to: stdout, stderr or file.
+	File    LogFile  `yaml:"file"`    // Log file of the file output.
+}
+
+// LogFile holds the path and the rotation of the log file.
+type LogFile struct {
+	Path       string        `yaml:"path"`        // Path of the log file, appended to across restarts.
+	MaxSize    int64         `yaml:"max_size"`    // Size in bytes the file is rotated at, 0 for no limit.
+	MaxAge     time.Duration `yaml:"max_age"`     // Time after which the file is rotated, 0 for no limit.
+	MaxBackups int           `yaml:"max_backups"` // Number of rotated files kept, 0 for all of them.
+	Retention  time.Duration `yaml:"retention"`   // Time the rotated files are kept, 0 to keep them forever.
+}
+
+// Metrics holds the configuration of the endpoint serving the Prometheus metrics.
+type Metrics struct {
+	Enabled     bool     `yaml:"enabled"`      // Whether the metrics are served.
+	Path        string   `yaml:"path"`         // Path of the metrics endpoint.
+	AllowedIPs  []string `yaml:"allowed_ips"`  // IP addresses or CIDR ranges allowed to scrape the metrics when connecting directly.
+	BearerToken string   `yaml:"bearer_token"` // Token allowing the scrapers sending it in the Authorization header, none if empty.
+}
+
+// Tracing holds the configuration of the OpenTelemetry tracing of the requests.
+type Tracing struct {
+	Enabled     bool    `yaml:"enabled"`      // Whether the spans are exported.
+	Endpoint    string  `yaml:"endpoint"`     // URL of the OTLP HTTP receiver of the collector, its path defaulting to /v1/traces.
+	ServiceName string  `yaml:"service_name"` // Name of the service the spans are attributed to.
+	SampleRatio float64 `yaml:"sample_ratio"` // Ratio of the traces started by the server which are recorded, from 0 to 1.
+}
+
+// RateLimits holds the number of requests a client can make to an endpoint every minute, 0 for no limit.
+type RateLimits struct {
+	Global int `yaml:"global"` // Requests to any endpoint.
+	Login  int `yaml:"login"`  // Requests to the login page and the CAS callback.
+	Unlock int `yaml:"unlock"` // Attempts to unlock a password protected event.
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+)
+
+// templatesGlob matches the HTML templates, parsed again on reload.
+const templatesGlob = "assets/templates/*.html"
+
+// reloadablePaths are the fields of the configuration file a reload applies, or the prefixes of their paths.
+// Other fields need a restart.
+var reloadablePaths = []string{
+	"log.level",
+	"rate_limits.",
+	"server.cors_origins",
+	"security.admins",
+	"security.session.token.cookie_max_age",
+}
+
+// Reloadable holds the settings a running server applies again on reload.
+type Reloadable struct {
+	Templates     *template.Template // Parsed HTML templates.
+	SessionMaxAge time.Duration      // Lifetime of the sessions.
+	Admins        map[string]bool    // Emails of the users who are admins, in addition to the admins of the database.
+	CORSOrigins   []string           // Origins allowed by CORS.
+	RateLimits    RateLimits         // Request rate limits.
+	LogLevel      zerolog.Level      // Minimum level of the logged messages.
+
+	running map[string]string // Values the server runs with by path, compared with the next configuration on reload.
+}
+
+// IsAdmin tells whether an email is in the admin list of the configuration.
+func (r *Reloadable) IsAdmin(email string) bool {
+	return r.Admins[strings.ToLower(email)]
+}
+
+// AllowOrigin tells whether CORS requests are allowed from an origin.
+func (r *Reloadable) AllowOrigin(origin string) bool {
+	for _, pattern := range r.CORSOrigins {
+		if matchOrigin(pattern, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Live holds the current reloadable settings. It is shared by the copies of the configuration, and swapped
+// atomically so that requests in progress keep the settings they started with.
+type Live struct {
+	current atomic.Pointer[Reloadable]
+}
+
+// NewLive creates the live settings of a configuration, starting with the given settings.
+//
+// Parameters:
+//   - r: The settings, whose log level is applied.
+//
+// Returns:
+//   - *Live: The live settings, shared by the copies of the configuration.
+func NewLive(r *Reloadable) *Live {
+	l := &Live{}
+	l.store(r)
+	return l
+}
+
+// Load returns the current settings.
+func (l *Live) Load() *Reloadable {
+	return l.current.Load()
+}
+
+// store swaps the current settings and applies the log level.
+func (l *Live) store(r *Reloadable) {
+	zerolog.SetGlobalLevel(r.LogLevel)
+	l.current.Store(r)
+}
+
+// Reload reads the configuration file again, with the environment variables, and swaps the reloadable settings.
+// An invalid configuration is rejected and the current settings are kept.
+//
+// Returns:
+//   - []string: The changes of the configuration, one per field, those needing a restart marked so.
+//   - error: Every invalid value of the new configuration, or an error if the file or the templates could not be read.
+func (cfg Config) Reload() ([]string, error) {
+	data, err := os.ReadFile(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	next, err := parse(data, os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	reloadable, err := newReloadable(next)
+	if err != nil {
+		return nil, err
+	}
+	// The fields needing a restart keep their running value, so that they are reported until the restart.
+	running := cfg.Live.Load().running
+	changes := diffValues(running, reloadable.running)
+	reloadable.running = applied(running, reloadable.running)
+	cfg.Live.store(reloadable)
+	return changes, nil
+}
+
+// applied returns the values a server runs with after a reload: the reloadable values of the new configuration, and
+// the running values of the fields needing a restart.
+func applied(running, next map[string]string) map[string]string {
+	values := make(map[string]string, len(next))
+	for path, value := range running {
+		if !isReloadable(path) {
+			values[path] = value
+		}
+	}
+	for path, value := range next {
+		if isReloadable(path) {
+			values[path] = value
+		}
+	}
+	return values
+}
+
+// newReloadable parses the templates and prepares the reloadable settings of a configuration.
+func newReloadable(cfg Config) (*Reloadable, error) {
+	templates, err := template.New("").Funcs(templateFuncs).ParseGlob(templatesGlob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html templates: %w", err)
+	}
+	level, err := zerolog.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level: %w", err)
+	}
+	running, err := flatten(cfg)
+	if err != nil {
+		return nil, err
+	}
+	admins := make(map[string]bool, len(cfg.Security.Admins))
+	for _, email := range cfg.Security.Admins {
+		admins[strings.ToLower(email)] = true
+	}
+	return &Reloadable{
+		Templates:     templates,
+		SessionMaxAge: cfg.Security.Session.CookieMaxAge,
+		Admins:        admins,
+		CORSOrigins:   slices.Clone(cfg.Server.CORSOrigins),
+		RateLimits:    cfg.RateLimits,
+		LogLevel:      level,
+		running:       running,
+	}, nil
+}
+
+// diff lists the fields which differ between two configurations, with their old and new values. Secrets are
+// redacted.
+func diff(previous, next Config) ([]string, error) {
+	before, err := flatten(previous)
+	if err != nil {
+		return nil, err
+	}
+	after, err := flatten(next)
+	if err != nil {
+		return nil, err
+	}
+	return diffValues(before, after), nil
+}
+
+// diffValues lists the paths whose value differs between two flattened configurations, with their old and new values.
+func diffValues(before, after map[string]string) []string {
+	paths := make([]string, 0, len(after))
+	for path := range after {
+		paths = append(paths, path)
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var changes []string
+	for _, path := range paths {
+		if before[path] == after[path] {
+			continue
+		}
+		change := fmt.Sprintf("%s: %s -> %s", path, before[path], after[path])
+		if !isReloadable(path) {
+			change += " (needs a restart)"
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// flatten encodes a configuration and returns its scalar values by path, secrets redacted.
+func flatten(cfg Config) (map[string]string, error) {
+	var node yaml.Node
+	if err := node.Encode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	redactSecrets(&node)
+	values := map[string]string{}
+	flattenNode(&node, "", values)
+	return values, nil
+}
+
+// flattenNode adds the values of a node and its children to the map. Sequences of scalars are kept as one flow value,
+// the items of the other sequences are indexed.
+func flattenNode(node *yaml.Node, path string, values map[string]string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if path != "" {
+				key = path + "." + key
+			}
+			flattenNode(node.Content[i+1], key, values)
+		}
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				for i, item := range node.Content {
+					flattenNode(item, fmt.Sprintf("%s[%d]", path, i), values)
+				}
+				return
+			}
+			items = append(items, item.Value)
+		}
+		values[path] = "[" + strings.Join(items, ", ") + "]"
+	default:
+		values[path] = node.Value
+	}
+}
+
+// isReloadable tells whether a reload applies the change of a field.
+func isReloadable(path string) bool {
+	for _, reloadable := range reloadablePaths {
+		if path == reloadable || (strings.HasSuffix(reloadable, ".") && strings.HasPrefix(path, reloadable)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOrigin tells whether an origin matches a pattern, whose * matches any part of the origin.
+func matchOrigin(pattern, origin string) bool {
+	prefix, suffix, wildcard := strings.Cut(pattern, "*")
+	if !wildcard {
+		return strings.EqualFold(pattern, origin)
+	}
+	origin = strings.ToLower(origin)
+	prefix, suffix = strings.ToLower(prefix), strings.ToLower(suffix)
+	return len(origin) >= len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix)
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// TestDiff ensures that the changes are listed by path, secrets redacted and fields needing a restart marked.
+func TestDiff(t *testing.T) {
+	previous := validConfig(t)
+	next := validConfig(t)
+	next.Log.Level = "debug"
+	next.Server.Port = 9000
+	next.DB.Dev.Password = "hunter2"
+	next.Server.CORSOrigins = []string{"https://photos.example.com"}
+
+	changes, err := diff(previous, next)
+	assert.NoError(t, err)
+	assert.Contains(t, changes, "log.level: info -> debug")
+	assert.Contains(t, changes, "server.cors_origins: [https://*, http://*] -> [https://photos.example.com]")
+	assert.Contains(t, changes, "server.port: 8080 -> 9000 (needs a restart)")
+	for _, change := range changes {
+		assert.NotContains(t, change, "hunter2", "secrets should be redacted")
+	}
+
+	changes, err = diff(previous, previous)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+// TestIsReloadable ensures that only the reloadable fields are applied by a reload.
+func TestIsReloadable(t *testing.T) {
+	assert.True(t, isReloadable("log.level"))
+	assert.True(t, isReloadable("rate_limits.login"))
+	assert.True(t, isReloadable("security.admins"))
+	assert.False(t, isReloadable("rate_limits"))
+	assert.False(t, isReloadable("server.port"))
+	assert.False(t, isReloadable("security.admins_extra"))
+}
+
+// TestAllowOrigin ensures that the CORS origins are matched with their wildcards.
+func TestAllowOrigin(t *testing.T) {
+	live := &Reloadable{CORSOrigins: []string{"https://*.example.com", "http://localhost:8000"}}
+	assert.True(t, live.AllowOrigin("https://photos.example.com"))
+	assert.True(t, live.AllowOrigin("HTTP://localhost:8000"))
+	assert.False(t, live.AllowOrigin("http://photos.example.com"))
+	assert.False(t, live.AllowOrigin("https://example.org"))
+}
+
+// TestIsAdmin ensures that the admin list ignores the case of the emails.
+func TestIsAdmin(t *testing.T) {
+	live := &Reloadable{Admins: map[string]bool{"admin@example.com": true}}
+	assert.True(t, live.IsAdmin("Admin@Example.com"))
+	assert.False(t, live.IsAdmin("user@example.com"))
+}
+
+// TestReloadRejected ensures that an invalid configuration file keeps the current settings.
+func TestReloadRejected(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	assert.NoError(t, os.WriteFile(path, []byte("log:\n  level: loud\n"), 0600))
+	current := &Reloadable{LogLevel: 1}
+	cfg := validConfig(t)
+	cfg.Path = path
+	cfg.Live = &Live{}
+	cfg.Live.current.Store(current)
+
+	changes, err := cfg.Reload()
+	assert.ErrorContains(t, err, "log.level")
+	assert.Nil(t, changes)
+	assert.Same(t, current, cfg.Live.Load())
+}
+
+// TestReloadPending ensures that a change needing a restart is reported by every reload until the restart, and that a
+// reloaded change is reported once.
+func TestReloadPending(t *testing.T) {
+	path, err := filepath.Abs(filepath.Join(t.TempDir(), "config.yml"))
+	assert.NoError(t, err)
+	// The templates are read from the root of the repository.
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir("../.."))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	cfg := validConfig(t)
+	current, err := newReloadable(cfg)
+	assert.NoError(t, err)
+	cfg.Path = path
+	cfg.Live = NewLive(current)
+
+	next := validConfig(t)
+	next.Log.Level = "debug"
+	next.Server.Port = 9000
+	data, err := yaml.Marshal(&next)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path, data, 0600))
+	changes, err := cfg.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"log.level: info -> debug", "server.port: 8080 -> 9000 (needs a restart)"}, changes)
+
+	changes, err = cfg.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"server.port: 8080 -> 9000 (needs a restart)"}, changes, "the restart must stay pending")
+	assert.Equal(t, zerolog.DebugLevel, cfg.Live.Load().LogLevel)
+}
@@ -27,12 +27,13 @@ func (k secretKey) MarshalYAML() (interface{}, error) {
 //   - node: A YAML node containing the hexadecimal string.
 //
 // Returns:
-//   - error: An error if the hexadecimal string is invalid or decoding fails.
+//   - error: A *yaml.TypeError if the hexadecimal string is invalid, so that the decoding of the other fields goes on
+//     and every invalid value is reported at once.
 func (k *secretKey) UnmarshalYAML(node *yaml.Node) error {
 	value := node.Value
 	ba, err := hex.DecodeString(value)
 	if err != nil {
-		return err
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: malformed hex secret: %v", node.Line, err)}}
 	}
 	*k = ba
 	return nil
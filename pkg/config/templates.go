@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+)
+
+// templateFuncs are the functions the HTML templates can call.
+var templateFuncs = template.FuncMap{
+	"route": route,
+}
+
+// route fills the parameters of a route pattern of the configuration, so the templates link to the configured
+// routes, such as {{route $.Routes.PhotoPreview "photo_id" .PhotoID}}.
+//
+// Parameters:
+//   - pattern: The chi pattern of the route, such as /photos/{photo_id}/preview.
+//   - params: The names of the parameters of the pattern, each followed by its value.
+//
+// Returns:
+//   - string: The path of the route, with the escaped values in place of the parameters.
+//   - error: An error if a name has no value, or if a parameter of the pattern is left unfilled.
+func route(pattern string, params ...interface{}) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("route %s: parameter %v has no value", pattern, params[len(params)-1])
+	}
+	path := pattern
+	for i := 0; i < len(params); i += 2 {
+		placeholder := fmt.Sprintf("{%v}", params[i])
+		if !strings.Contains(path, placeholder) {
+			return "", fmt.Errorf("route %s: no parameter %v", pattern, params[i])
+		}
+		path = strings.ReplaceAll(path, placeholder, url.PathEscape(fmt.Sprint(params[i+1])))
+	}
+	if strings.Contains(path, "{") {
+		return "", fmt.Errorf("route %s: missing parameters", pattern)
+	}
+	return path, nil
+}
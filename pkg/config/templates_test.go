@@ -0,0 +1,32 @@
+package config
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRoute ensures that the parameters of a route are filled with their escaped values.
+func TestRoute(t *testing.T) {
+	path, err := route("/admin/duplicates/{photo_id}/{duplicate_of_photo_id}", "photo_id", 12, "duplicate_of_photo_id", uint32(7))
+	assert.NoError(t, err)
+	assert.Equal(t, "/admin/duplicates/12/7", path)
+
+	path, err = route("/photos/{photo_id}/preview", "photo_id", "a/b")
+	assert.NoError(t, err)
+	assert.Equal(t, "/photos/a%2Fb/preview", path)
+
+	_, err = route("/photos/{photo_id}/preview")
+	assert.Error(t, err, "unfilled parameter")
+	_, err = route("/photos/{photo_id}/preview", "photo_id")
+	assert.Error(t, err, "parameter without value")
+	_, err = route("/photos/{photo_id}/preview", "event_id", 1)
+	assert.Error(t, err, "unknown parameter")
+}
+
+// TestTemplatesParse ensures that the templates parse with the functions they are given.
+func TestTemplatesParse(t *testing.T) {
+	_, err := template.New("").Funcs(templateFuncs).ParseGlob("../../" + templatesGlob)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"photos/pkg/db"
+	"photos/pkg/logging"
+	"photos/pkg/metrics"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// csrfKeyLength is the length of the key gorilla/csrf requires.
+const csrfKeyLength = 32
+
+// Validate checks the values of the configuration before the server starts.
+//
+// Returns:
+//   - error: Every invalid value, joined, or nil if the configuration is valid.
+func (cfg Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	positive := func(name string, d time.Duration) {
+		check(d > 0, "%s must be positive, got %s", name, d)
+	}
+	nonNegative := func(name string, d time.Duration) {
+		check(d >= 0, "%s must not be negative, got %s", name, d)
+	}
+
+	check(cfg.Server.Port > 0 && cfg.Server.Port <= 65535, "server.port must be between 1 and 65535, got %d", cfg.Server.Port)
+	positive("server.read_timeout", cfg.Server.ReadTimeout)
+	positive("server.write_timeout", cfg.Server.WriteTimeout)
+	positive("server.idle_timeout", cfg.Server.IdleTimeout)
+	positive("server.request_context_timeout", cfg.Server.RequestContextTimeout)
+	positive("server.upload_timeout", cfg.Server.UploadTimeout)
+	check(cfg.Server.MaxHeaderBytes > 0, "server.max_header_bytes must be positive, got %d", cfg.Server.MaxHeaderBytes)
+	check(cfg.Server.MaxBodySize > 0, "server.max_body_size must be positive, got %d", cfg.Server.MaxBodySize)
+	check(cfg.Server.MaxUploadSize > 0, "server.max_upload_size must be positive, got %d", cfg.Server.MaxUploadSize)
+	for _, origin := range cfg.Server.CORSOrigins {
+		check(origin != "" && strings.Count(origin, "*") <= 1, "server.cors_origins must hold origins with at most one *, got %q", origin)
+	}
+
+	errs = append(errs, validateKeys("security.csrf.token.keys", cfg.Security.Csrf.Keys, false)...)
+	errs = append(errs, validateKeys("security.session.token.keys", cfg.Security.Session.Keys, true)...)
+	positive("security.csrf.token.cookie_max_age", cfg.Security.Csrf.CookieMaxAge)
+	positive("security.session.token.cookie_max_age", cfg.Security.Session.CookieMaxAge)
+	for _, email := range cfg.Security.Admins {
+		check(strings.Contains(email, "@"), "security.admins must hold emails, got %q", email)
+	}
+
+	// Only the database of the current mode is used, the other one may be left empty.
+	if cfg.DevMode.Enabled {
+		errs = append(errs, validateDSN("db.dev", cfg.DB.Dev)...)
+		errs = append(errs, validateBaseURL("base_urls.dev", cfg.BaseURLs.Dev)...)
+	} else {
+		errs = append(errs, validateDSN("db.prod", cfg.DB.Prod)...)
+		errs = append(errs, validateBaseURL("base_urls.prod", cfg.BaseURLs.Prod)...)
+	}
+
+	switch cfg.Storage.Driver {
+	case "", "local":
+		check(cfg.Storage.Root != "", "storage.root must not be empty with the local driver")
+	case "s3":
+		check(cfg.Storage.S3.Bucket != "", "storage.s3.bucket must not be empty with the s3 driver")
+	default:
+		errs = append(errs, fmt.Errorf("storage.driver must be local or s3, got %q", cfg.Storage.Driver))
+	}
+	switch cfg.Storage.Duplicates {
+	case "", "warn", "link", "reject":
+	default:
+		errs = append(errs, fmt.Errorf("storage.duplicates must be warn, link or reject, got %q", cfg.Storage.Duplicates))
+	}
+	nonNegative("storage.signed_url_expiry", cfg.Storage.SignedURLExpiry)
+	check(cfg.Storage.Quota >= 0, "storage.quota must not be negative, got %d", cfg.Storage.Quota)
+	check(cfg.Storage.UploaderQuota >= 0, "storage.uploader_quota must not be negative, got %d", cfg.Storage.UploaderQuota)
+
+	check(cfg.Downloads.MaxConcurrentArchives >= 0, "downloads.max_concurrent_archives must not be negative, got %d", cfg.Downloads.MaxConcurrentArchives)
+	check(cfg.Downloads.MaxArchivePhotos >= 0, "downloads.max_archive_photos must not be negative, got %d", cfg.Downloads.MaxArchivePhotos)
+
+	nonNegative("consistency.interval", cfg.Consistency.Interval)
+	nonNegative("consistency.min_age", cfg.Consistency.MinAge)
+
+	if cfg.Faces.Enabled {
+		check(cfg.Faces.Backend != "", "faces.backend must not be empty when faces are enabled")
+		check(cfg.Faces.Threshold >= 0, "faces.threshold must not be negative, got %v", cfg.Faces.Threshold)
+	}
+
+	check(cfg.Jobs.Workers >= 0, "jobs.workers must not be negative, got %d", cfg.Jobs.Workers)
+	if cfg.Jobs.Workers > 0 {
+		positive("jobs.poll_interval", cfg.Jobs.PollInterval)
+		positive("jobs.lease", cfg.Jobs.Lease)
+		check(cfg.Jobs.MaxAttempts > 0, "jobs.max_attempts must be positive, got %d", cfg.Jobs.MaxAttempts)
+		nonNegative("jobs.backoff", cfg.Jobs.Backoff)
+		nonNegative("jobs.max_backoff", cfg.Jobs.MaxBackoff)
+	}
+	nonNegative("jobs.retention", cfg.Jobs.Retention)
+
+	_, err := zerolog.ParseLevel(cfg.Log.Level)
+	check(err == nil, "log.level must be trace, debug, info, warn or error, got %q", cfg.Log.Level)
+	check(cfg.Log.Format == logging.FormatConsole || cfg.Log.Format == logging.FormatJSON, "log.format must be console or json, got %q", cfg.Log.Format)
+	check(len(cfg.Log.Outputs) > 0, "log.outputs must not be empty")
+	for _, output := range cfg.Log.Outputs {
+		switch output {
+		case logging.OutputStdout, logging.OutputStderr:
+		case logging.OutputFile:
+			check(cfg.Log.File.Path != "", "log.file.path must not be empty with the file output")
+		default:
+			check(false, "log.outputs must hold stdout, stderr or file, got %q", output)
+		}
+	}
+	check(cfg.Log.File.MaxSize >= 0, "log.file.max_size must not be negative, got %d", cfg.Log.File.MaxSize)
+	nonNegative("log.file.max_age", cfg.Log.File.MaxAge)
+	check(cfg.Log.File.MaxBackups >= 0, "log.file.max_backups must not be negative, got %d", cfg.Log.File.MaxBackups)
+	nonNegative("log.file.retention", cfg.Log.File.Retention)
+	if cfg.Metrics.Enabled {
+		check(strings.HasPrefix(cfg.Metrics.Path, "/"), "metrics.path must start with /, got %q", cfg.Metrics.Path)
+		check(len(cfg.Metrics.AllowedIPs) > 0 || cfg.Metrics.BearerToken != "", "metrics.allowed_ips or metrics.bearer_token must be set when the metrics are enabled")
+	}
+	if _, err := metrics.ParseAllowList(cfg.Metrics.AllowedIPs); err != nil {
+		errs = append(errs, fmt.Errorf("metrics.allowed_ips: %w", err))
+	}
+	if cfg.Tracing.Enabled {
+		endpoint, err := url.Parse(cfg.Tracing.Endpoint)
+		check(err == nil && (endpoint.Scheme == "http" || endpoint.Scheme == "https") && endpoint.Host != "", "tracing.endpoint must be an http or https URL, got %q", cfg.Tracing.Endpoint)
+		check(cfg.Tracing.ServiceName != "", "tracing.service_name must not be empty")
+	}
+	check(cfg.Tracing.SampleRatio >= 0 && cfg.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1, got %g", cfg.Tracing.SampleRatio)
+	check(cfg.RateLimits.Global >= 0, "rate_limits.global must not be negative, got %d", cfg.RateLimits.Global)
+	check(cfg.RateLimits.Login >= 0, "rate_limits.login must not be negative, got %d", cfg.RateLimits.Login)
+	check(cfg.RateLimits.Unlock >= 0, "rate_limits.unlock must not be negative, got %d", cfg.RateLimits.Unlock)
+	return errors.Join(errs...)
+}
+
+// validateDSN checks the connection details of a database.
+func validateDSN(name string, dsn DSN) []error {
+	var errs []error
+	switch dsn.Driver {
+	case "", db.DriverMySQL, db.DriverPostgres:
+	case db.DriverSQLite:
+		if dsn.DSN == "" && dsn.Path == "" {
+			errs = append(errs, fmt.Errorf("%s.path must not be empty with the sqlite driver", name))
+		}
+		return errs
+	default:
+		return append(errs, fmt.Errorf("%s.driver must be mysql, sqlite or postgres, got %q", name, dsn.Driver))
+	}
+	// A full DSN replaces the connection fields, the driver checks it.
+	if dsn.DSN == "" {
+		if dsn.Name == "" {
+			errs = append(errs, fmt.Errorf("%s.name must not be empty", name))
+		}
+		if dsn.Username == "" {
+			errs = append(errs, fmt.Errorf("%s.username must not be empty", name))
+		}
+		if dsn.Socket == "" {
+			if dsn.Host == "" {
+				errs = append(errs, fmt.Errorf("%s.host must not be empty without a socket", name))
+			}
+			if port, err := strconv.Atoi(dsn.Port); err != nil || port <= 0 || port > 65535 {
+				errs = append(errs, fmt.Errorf("%s.port must be between 1 and 65535, got %q", name, dsn.Port))
+			}
+		}
+	}
+	if _, err := dsn.options().FormatDSN(); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+	if dsn.TLS.Enabled && (dsn.TLS.Cert == "") != (dsn.TLS.Key == "") {
+		errs = append(errs, fmt.Errorf("%s.tls.cert and %s.tls.key must be set together", name, name))
+	}
+	if dsn.MaxIdleConns < 0 || dsn.MaxOpenConns < 0 {
+		errs = append(errs, fmt.Errorf("%s.max_idle_conns and %s.max_open_conns must not be negative", name, name))
+	}
+	if dsn.ConnMaxLifetime < 0 {
+		errs = append(errs, fmt.Errorf("%s.conn_max_lifetime must not be negative, got %s", name, dsn.ConnMaxLifetime))
+	}
+	return errs
+}
+
+// validateKeys checks the keys of a token type. The keys of CSRF tokens must have the length gorilla/csrf requires and
+// cannot encrypt, since gorilla/csrf only signs its cookies.
+func validateKeys(name string, keys []Key, encryption bool) []error {
+	if len(keys) == 0 {
+		return []error{fmt.Errorf("%s must not be empty, run photos_server config migrate to move the secret of older config files", name)}
+	}
+	var errs []error
+	if !keys[0].RetireAt.IsZero() {
+		errs = append(errs, fmt.Errorf("%s[0] signs the cookies and must not retire", name))
+	}
+	for i, key := range keys {
+		switch {
+		case len(key.Secret) == 0:
+			errs = append(errs, fmt.Errorf("%s[%d].secret must not be empty", name, i))
+		case !encryption && len(key.Secret) != csrfKeyLength:
+			errs = append(errs, fmt.Errorf("%s[%d].secret must hold %d bytes, got %d", name, i, csrfKeyLength, len(key.Secret)))
+		}
+		switch len(key.EncryptionSecret) {
+		case 0:
+		case 16, 24, 32:
+			if !encryption {
+				errs = append(errs, fmt.Errorf("%s[%d].encryption_secret is not supported, CSRF cookies are only signed", name, i))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("%s[%d].encryption_secret must hold 16, 24 or 32 bytes, got %d", name, i, len(key.EncryptionSecret)))
+		}
+	}
+	return errs
+}
+
+// validateBaseURL checks the service and CAS URLs.
+func validateBaseURL(name string, baseURL BaseURL) []error {
+	var errs []error
+	urls := []struct{ key, value string }{{"service", baseURL.Service}, {"cas", baseURL.Cas}}
+	for _, u := range urls {
+		if parsed, err := url.Parse(u.value); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			errs = append(errs, fmt.Errorf("%s.%s must be an absolute URL, got %q", name, u.key, u.value))
+		}
+	}
+	return errs
+}
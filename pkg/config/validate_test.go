@@ -0,0 +1,137 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// validConfig returns the default configuration with a database, so that it passes the validation.
+func validConfig(t *testing.T) Config {
+	cfg, err := defaultConfig()
+	assert.NoError(t, err)
+	cfg.DB.Dev = DSN{Name: "photos", Username: "photos", Host: "127.0.0.1", Port: "3306"}
+	return cfg
+}
+
+// TestValidate ensures that a complete configuration is valid.
+func TestValidate(t *testing.T) {
+	assert.NoError(t, validConfig(t).Validate())
+}
+
+// TestValidateErrors ensures that every invalid value is reported at once.
+func TestValidateErrors(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Server.Port = 70000
+	cfg.Server.ReadTimeout = 0
+	cfg.DB.Dev = DSN{}
+	cfg.Security.Csrf.Keys = []Key{{Secret: secretKey("short")}}
+	cfg.Storage.Driver = "ftp"
+
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "server.port")
+	assert.ErrorContains(t, err, "server.read_timeout")
+	assert.ErrorContains(t, err, "db.dev.host")
+	assert.ErrorContains(t, err, "db.dev.port")
+	assert.ErrorContains(t, err, "security.csrf.token.keys[0].secret")
+	assert.ErrorContains(t, err, "storage.driver")
+	assert.NotContains(t, err.Error(), "db.prod", "the database of the other mode should not be checked")
+}
+
+// TestParse ensures that malformed secrets of the file are reported along with the other invalid values.
+func TestParse(t *testing.T) {
+	cfg := validConfig(t)
+	data, err := yaml.Marshal(&cfg)
+	assert.NoError(t, err)
+
+	parsed, err := parse(data, lookupMap(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Server.Port, parsed.Server.Port)
+
+	var node yaml.Node
+	assert.NoError(t, yaml.Unmarshal(data, &node))
+	security := lookupKey(node.Content[0], "security")
+	keys := lookupKey(lookupKey(lookupKey(security, "session"), "token"), "keys")
+	lookupKey(keys.Content[0], "secret").Value = "nothex"
+
+	data, err = yaml.Marshal(&node)
+	assert.NoError(t, err)
+	_, err = parse(data, lookupMap(map[string]string{"PHOTOS_SERVER_PORT": "0"}))
+	assert.ErrorContains(t, err, "malformed hex secret")
+	assert.ErrorContains(t, err, "security.session.token.keys[0].secret")
+	assert.ErrorContains(t, err, "server.port")
+}
+
+// TestValidateDSN ensures that a socket replaces the host and port, and a full DSN the connection fields.
+func TestValidateDSN(t *testing.T) {
+	assert.Empty(t, validateDSN("db.dev", DSN{Name: "photos", Username: "photos", Socket: "/run/mysqld/mysqld.sock"}))
+	assert.Empty(t, validateDSN("db.dev", DSN{DSN: "photos@tcp(db:3306)/photos"}))
+	assert.Empty(t, validateDSN("db.dev", DSN{Driver: "postgres", Name: "photos", Username: "photos", Host: "db", Port: "5432"}))
+	assert.Empty(t, validateDSN("db.dev", DSN{Driver: "postgres", DSN: "postgres://photos@db/photos"}))
+	assert.ErrorContains(t, errors.Join(validateDSN("db.dev", DSN{Driver: "postgres", Name: "photos", Username: "photos"})...), "db.dev.host")
+	assert.ErrorContains(t, errors.Join(validateDSN("db.dev", DSN{Driver: "oracle"})...), "mysql, sqlite or postgres")
+
+	err := errors.Join(validateDSN("db.dev", DSN{DSN: "not a dsn", TLS: DBTLS{Enabled: true, Cert: "client.pem"}})...)
+	assert.ErrorContains(t, err, "db.dev: invalid dsn")
+	assert.ErrorContains(t, err, "db.dev.tls.cert and db.dev.tls.key")
+}
+
+// TestValidateLog ensures that the format, the outputs and the rotation of the logs are checked, and that files
+// without the new fields keep a console and a file output.
+func TestValidateLog(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Log.Format = "xml"
+	cfg.Log.Outputs = []string{"stdout", "syslog", "file"}
+	cfg.Log.File.Path = ""
+	cfg.Log.File.MaxBackups = -1
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "log.format")
+	assert.ErrorContains(t, err, `log.outputs must hold stdout, stderr or file, got "syslog"`)
+	assert.ErrorContains(t, err, "log.file.path")
+	assert.ErrorContains(t, err, "log.file.max_backups")
+
+	cfg.Log.Outputs = nil
+	assert.ErrorContains(t, cfg.Validate(), "log.outputs must not be empty")
+
+	parsed, _ := parse([]byte("log:\n  level: debug\n"), lookupMap(nil))
+	assert.Equal(t, "debug", parsed.Log.Level)
+	assert.Equal(t, []string{"stdout", "file"}, parsed.Log.Outputs)
+	assert.Equal(t, "photos.log", parsed.Log.File.Path)
+}
+
+// TestValidateMetrics ensures that the metrics endpoint is restricted and its allowed addresses are checked.
+func TestValidateMetrics(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Metrics = Metrics{Enabled: true, Path: "metrics", AllowedIPs: []string{"10.0.0.0/8", "localhost"}}
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "metrics.path must start with /")
+	assert.ErrorContains(t, err, `metrics.allowed_ips: invalid IP address "localhost"`)
+
+	cfg.Metrics = Metrics{Enabled: true, Path: "/metrics"}
+	assert.ErrorContains(t, cfg.Validate(), "metrics.allowed_ips or metrics.bearer_token must be set")
+	cfg.Metrics.BearerToken = "token"
+	assert.NoError(t, cfg.Validate())
+
+	parsed, _ := parse([]byte("metrics:\n  enabled: true\n"), lookupMap(nil))
+	assert.Equal(t, "/metrics", parsed.Metrics.Path)
+	assert.Equal(t, []string{"127.0.0.1", "::1"}, parsed.Metrics.AllowedIPs)
+}
+
+// TestValidateTracing ensures that the endpoint of the collector and the sampling of the traces are checked.
+func TestValidateTracing(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Tracing = Tracing{Enabled: true, Endpoint: "localhost:4318", SampleRatio: 2}
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, `tracing.endpoint must be an http or https URL, got "localhost:4318"`)
+	assert.ErrorContains(t, err, "tracing.service_name must not be empty")
+	assert.ErrorContains(t, err, "tracing.sample_ratio must be between 0 and 1, got 2")
+
+	cfg.Tracing = Tracing{Enabled: true, Endpoint: "https://otel.example.com/v1/traces", ServiceName: "photos", SampleRatio: 0.1}
+	assert.NoError(t, cfg.Validate())
+
+	parsed, _ := parse([]byte("tracing:\n  enabled: true\n"), lookupMap(nil))
+	assert.Equal(t, "http://localhost:4318", parsed.Tracing.Endpoint)
+	assert.Equal(t, 1.0, parsed.Tracing.SampleRatio)
+}
@@ -0,0 +1,468 @@
+package consistency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"photos/pkg/derivative"
+	"photos/pkg/storage"
+	"photos/pkg/usage"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// QuarantinePrefix starts the keys of the objects moved aside by a repair. Quarantined objects are never checked.
+const QuarantinePrefix = "quarantine/"
+
+// derivativesPrefix starts the keys of the thumbnails and previews, stored under the ID of their photo.
+const derivativesPrefix = "derivatives/"
+
+// Options configures a consistency check.
+type Options struct {
+	Storage   storage.Storage // Storage holding the photos and their derivatives.
+	CacheRoot string          // Root of the media cache directories, empty to skip them.
+	MinAge    time.Duration   // Objects written more recently may belong to an upload in progress and are not reported.
+	DryRun    bool            // Only log what a repair would do.
+	Logger    zerolog.Logger  // Logger receiving every finding and repair.
+}
+
+// Missing is a photo whose file is not in the storage.
+type Missing struct {
+	PhotoID uint32 `json:"photo_id"`
+	Path    string `json:"path"`
+	Marked  bool   `json:"marked"` // Whether a previous repair already marked the photo.
+}
+
+// Unsized is a photo stored before the sizes of the photos were recorded, which is not accounted for in the storage usage.
+type Unsized struct {
+	PhotoID uint32 `json:"photo_id"`
+	Size    int64  `json:"size"` // Size of its file.
+}
+
+// Report lists the differences between the database and the storage.
+type Report struct {
+	Photos       int            `json:"photos"`        // Number of photos checked.
+	Objects      int            `json:"objects"`       // Number of objects listed.
+	MissingFiles []Missing      `json:"missing_files"` // Photos whose file is missing.
+	Restored     []uint32       `json:"restored"`      // Photos marked as missing whose file is back.
+	Unsized      []Unsized      `json:"unsized"`       // Photos whose size is not recorded.
+	OrphanFiles  []storage.Info `json:"orphan_files"`  // Objects referenced by no photo, including the derivatives of deleted photos.
+	OrphanCaches []string       `json:"orphan_caches"` // Media cache directories of photos which do not exist.
+	LegacyCaches []string       `json:"legacy_caches"` // Media cache directories of existing photos, left from before derivatives were stored.
+}
+
+// Clean tells whether the database and the storage agree.
+func (r Report) Clean() bool {
+	for _, missing := range r.MissingFiles {
+		if !missing.Marked {
+			return false
+		}
+	}
+	return len(r.Restored) == 0 && len(r.Unsized) == 0 && len(r.OrphanFiles) == 0 && len(r.OrphanCaches) == 0 && len(r.LegacyCaches) == 0
+}
+
+// Check compares the photos of the database with the objects of the storage and the media cache directories.
+//
+// Parameters:
+//   - ctx: Context used to cancel the check.
+//   - database: Database holding the photos.
+//   - opts: Check options.
+//
+// Returns:
+//   - Report: The differences found.
+//   - error: An error if the photos or the objects could not be listed.
+func Check(ctx context.Context, database *db.DB, opts Options) (Report, error) {
+	photos, err := database.GetPhotoPaths(ctx)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to list photos: %w", err)
+	}
+	marked, err := database.GetMissingPhotoIDs(ctx)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to list missing photos: %w", err)
+	}
+	var objects []storage.Info
+	err = opts.Storage.List(ctx, "", func(info storage.Info) error {
+		if !strings.HasPrefix(info.Key, QuarantinePrefix) {
+			objects = append(objects, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	report := compare(photos, marked, objects, time.Now().Add(-opts.MinAge))
+	if opts.CacheRoot != "" {
+		report.OrphanCaches, report.LegacyCaches, err = orphanCaches(opts.CacheRoot, photos)
+		if err != nil {
+			return Report{}, fmt.Errorf("failed to check media cache: %w", err)
+		}
+	}
+	return report, nil
+}
+
+// compare matches the photos with the objects. Objects modified after notAfter are not reported as orphans.
+func compare(photos []query.GetPhotoPathsRow, marked []uint32, objects []storage.Info, notAfter time.Time) Report {
+	report := Report{Photos: len(photos), Objects: len(objects)}
+	stored := make(map[string]bool, len(objects))
+	sizes := make(map[string]int64, len(objects))
+	for _, object := range objects {
+		stored[object.Key] = true
+		sizes[object.Key] = object.Size
+	}
+	isMarked := make(map[uint32]bool, len(marked))
+	for _, photoID := range marked {
+		isMarked[photoID] = true
+	}
+
+	referenced := make(map[string]bool, len(photos))
+	photoIDs := make(map[uint32]bool, len(photos))
+	for _, photo := range photos {
+		referenced[photo.PathToPhoto] = true
+		photoIDs[photo.PhotoID] = true
+		switch {
+		case !stored[photo.PathToPhoto]:
+			report.MissingFiles = append(report.MissingFiles, Missing{PhotoID: photo.PhotoID, Path: photo.PathToPhoto, Marked: isMarked[photo.PhotoID]})
+		case isMarked[photo.PhotoID]:
+			report.Restored = append(report.Restored, photo.PhotoID)
+		}
+		if stored[photo.PathToPhoto] && photo.FileSize == 0 && sizes[photo.PathToPhoto] > 0 {
+			report.Unsized = append(report.Unsized, Unsized{PhotoID: photo.PhotoID, Size: sizes[photo.PathToPhoto]})
+		}
+	}
+
+	for _, object := range objects {
+		if object.ModTime.After(notAfter) {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(object.Key, derivativesPrefix); ok {
+			photoID, err := strconv.ParseUint(strings.SplitN(rest, "/", 2)[0], 10, 32)
+			if err != nil || !photoIDs[uint32(photoID)] {
+				report.OrphanFiles = append(report.OrphanFiles, object)
+			}
+			continue
+		}
+		if !referenced[object.Key] {
+			report.OrphanFiles = append(report.OrphanFiles, object)
+		}
+	}
+	return report
+}
+
+// orphanCaches lists the <event ID>/<photo ID> cache directories of photos which do not exist or moved to another event,
+// then the ones of existing photos. Derivatives are kept in the storage, so the directories of existing photos only hold
+// derivatives generated before, which the repair moves to the storage.
+func orphanCaches(root string, photos []query.GetPhotoPathsRow) ([]string, []string, error) {
+	eventOf := make(map[uint32]uint32, len(photos))
+	for _, photo := range photos {
+		eventOf[photo.PhotoID] = photo.EventID
+	}
+	events, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var orphans, legacy []string
+	for _, event := range events {
+		eventID, err := strconv.ParseUint(event.Name(), 10, 32)
+		if err != nil || !event.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(root, event.Name()))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, entry := range entries {
+			photoID, err := strconv.ParseUint(entry.Name(), 10, 32)
+			if err != nil || !entry.IsDir() {
+				continue
+			}
+			if actual, ok := eventOf[uint32(photoID)]; !ok || actual != uint32(eventID) {
+				orphans = append(orphans, path.Join(event.Name(), entry.Name()))
+			} else {
+				legacy = append(legacy, path.Join(event.Name(), entry.Name()))
+			}
+		}
+	}
+	return orphans, legacy, nil
+}
+
+// Repair fixes the differences of a report: missing photos are marked, photos whose file is back are unmarked, the
+// sizes of the photos are recorded and accounted for, orphan objects and cache directories are moved to a quarantine where admins can review them, and the derivatives of
+// the legacy cache directories are moved to the storage.
+//
+// Parameters:
+//   - ctx: Context used to cancel the repair.
+//   - database: Database holding the photos.
+//   - report: The report of a previous check.
+//   - opts: Repair options, nothing is changed in dry run mode.
+//
+// Returns:
+//   - error: An error if a repair failed, the repairs done before are kept.
+func Repair(ctx context.Context, database *db.DB, report Report, opts Options) error {
+	stamp := time.Now().UTC().Format("20060102-150405")
+	for _, missing := range report.MissingFiles {
+		if missing.Marked {
+			continue
+		}
+		opts.Logger.Info().Bool("dry_run", opts.DryRun).Uint32("photo_id", missing.PhotoID).Str("path", missing.Path).Msg("marking photo as missing")
+		if !opts.DryRun {
+			if err := database.CreateMissingPhoto(ctx, missing.PhotoID); err != nil {
+				return fmt.Errorf("failed to mark photo %d as missing: %w", missing.PhotoID, err)
+			}
+		}
+	}
+	for _, photoID := range report.Restored {
+		opts.Logger.Info().Bool("dry_run", opts.DryRun).Uint32("photo_id", photoID).Msg("unmarking restored photo")
+		if !opts.DryRun {
+			if err := database.DeleteMissingPhoto(ctx, photoID); err != nil {
+				return fmt.Errorf("failed to unmark photo %d: %w", photoID, err)
+			}
+		}
+	}
+	for _, unsized := range report.Unsized {
+		opts.Logger.Info().Bool("dry_run", opts.DryRun).Uint32("photo_id", unsized.PhotoID).Int64("size", unsized.Size).Msg("recording photo size")
+		if !opts.DryRun {
+			if err := recordSize(ctx, database, unsized); err != nil {
+				return err
+			}
+		}
+	}
+	for _, object := range report.OrphanFiles {
+		dest := QuarantinePrefix + stamp + "/" + object.Key
+		opts.Logger.Info().Bool("dry_run", opts.DryRun).Str("key", object.Key).Str("quarantine", dest).Msg("quarantining orphan file")
+		if !opts.DryRun {
+			if err := quarantineObject(ctx, database, opts.Storage, object.Key, dest); err != nil {
+				return err
+			}
+		}
+	}
+	for _, dir := range report.OrphanCaches {
+		src := filepath.Join(opts.CacheRoot, filepath.FromSlash(dir))
+		dest := filepath.Join(opts.CacheRoot, ".quarantine", stamp, filepath.FromSlash(dir))
+		opts.Logger.Info().Bool("dry_run", opts.DryRun).Str("path", src).Str("quarantine", dest).Msg("quarantining orphan cache directory")
+		if !opts.DryRun {
+			if err := os.MkdirAll(filepath.Dir(dest), 0750); err != nil {
+				return err
+			}
+			if err := os.Rename(src, dest); err != nil {
+				return fmt.Errorf("failed to quarantine %s: %w", src, err)
+			}
+		}
+	}
+	for _, dir := range report.LegacyCaches {
+		src := filepath.Join(opts.CacheRoot, filepath.FromSlash(dir))
+		opts.Logger.Info().Bool("dry_run", opts.DryRun).Str("path", src).Msg("moving cached derivatives to the storage")
+		if !opts.DryRun {
+			if err := migrateCache(ctx, database, opts.Storage, src); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordSize records the size of a photo and accounts for it in the storage usage. A file referenced by a photo without
+// size was stored before the sizes were recorded, so it counts in the usage of the whole storage once the last of its
+// photos without size is accounted for.
+func recordSize(ctx context.Context, database *db.DB, unsized Unsized) error {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := database.WithTx(tx)
+
+	updated, err := qtx.UpdatePhotoFileSize(ctx, query.UpdatePhotoFileSizeParams{FileSize: unsized.Size, PhotoID: unsized.PhotoID})
+	if err != nil {
+		return fmt.Errorf("failed to record size of photo %d: %w", unsized.PhotoID, err)
+	}
+	// The photo was deleted, or its size recorded, since the check.
+	if updated == 0 {
+		return nil
+	}
+	photo, err := qtx.GetPhoto(ctx, unsized.PhotoID)
+	if err != nil {
+		return fmt.Errorf("failed to get photo %d: %w", unsized.PhotoID, err)
+	}
+	unsizedLeft, err := qtx.CountUnsizedPhotosWithPath(ctx, photo.PathToPhoto)
+	if err != nil {
+		return fmt.Errorf("failed to count photo references: %w", err)
+	}
+	if err := usage.AddPhoto(ctx, qtx, usage.Quotas{}, photo.EventID, photo.UploaderID, unsized.Size); err != nil {
+		return err
+	}
+	if unsizedLeft == 0 {
+		if err := usage.ReserveFile(ctx, qtx, 0, unsized.Size); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// migrateCache stores the derivatives of a legacy cache directory which are not stored yet, accounting for their size,
+// then removes the directory.
+func migrateCache(ctx context.Context, database *db.DB, store storage.Storage, dir string) error {
+	photoID, err := strconv.ParseUint(filepath.Base(dir), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid cache directory %s: %w", dir, err)
+	}
+	photo, err := database.GetPhoto(ctx, uint32(photoID))
+	if err != nil {
+		return fmt.Errorf("failed to get photo %d: %w", photoID, err)
+	}
+	for _, kind := range []derivative.Kind{derivative.Thumbnail, derivative.Preview} {
+		key := derivative.Key(photo.PhotoID, kind)
+		if _, err := store.Stat(ctx, key); !errors.Is(err, storage.ErrNotExist) {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		file, err := os.Open(filepath.Join(dir, string(kind)+".jpg"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		info, err := file.Stat()
+		if err == nil {
+			err = store.Put(ctx, key, file, info.Size())
+		}
+		_ = file.Close()
+		if err != nil {
+			return fmt.Errorf("failed to store %s of photo %d: %w", kind, photoID, err)
+		}
+		if err := usage.AddDerivative(ctx, database.Querier, photo.EventID, kind, info.Size()); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(dir)
+}
+
+// quarantineObject copies an object under the quarantine prefix, then deletes it and removes it from the usage of the
+// whole storage, which does not count the quarantine. Objects referenced again since the check, by an upload of the
+// same content, are left in place.
+func quarantineObject(ctx context.Context, database *db.DB, store storage.Storage, key, dest string) error {
+	if !strings.HasPrefix(key, derivativesPrefix) {
+		references, err := database.CountPhotosWithPath(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to count references of %s: %w", key, err)
+		}
+		if references > 0 {
+			return nil
+		}
+	}
+	file, info, err := store.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := store.Put(ctx, dest, file, info.Size); err != nil {
+		return fmt.Errorf("failed to quarantine %s: %w", key, err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		return err
+	}
+	kind, ok := usageKind(key)
+	if !ok {
+		return nil
+	}
+	if err := usage.RemoveFile(ctx, database.Querier, kind, info.Size); err != nil {
+		return fmt.Errorf("quarantined %s, but failed to update the storage usage: %w", key, err)
+	}
+	return nil
+}
+
+// usageKind returns the usage kind of a stored file, false for the files which are not accounted for, neither
+// originals nor known derivatives.
+func usageKind(key string) (query.StorageUsageKind, bool) {
+	rest, ok := strings.CutPrefix(key, derivativesPrefix)
+	if !ok {
+		return query.StorageUsageKindORIGINAL, true
+	}
+	for _, kind := range []derivative.Kind{derivative.Thumbnail, derivative.Preview} {
+		if path.Base(rest) == path.Base(derivative.Key(0, kind)) {
+			return usage.Kind(kind), true
+		}
+	}
+	return "", false
+}
+
+// Schedule checks the consistency every interval until the context is canceled, repairing the differences if repair is set.
+//
+// Parameters:
+//   - ctx: Context stopping the schedule.
+//   - database: Database holding the photos.
+//   - interval: Time between two checks.
+//   - repair: Whether the differences are repaired.
+//   - opts: Check and repair options.
+func Schedule(ctx context.Context, database *db.DB, interval time.Duration, repair bool, opts Options) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		report, err := Check(ctx, database, opts)
+		if err != nil {
+			opts.Logger.Error().Err(err).Msg("consistency check failed")
+			continue
+		}
+		Log(opts.Logger, report)
+		if repair && !report.Clean() {
+			if err := Repair(ctx, database, report, opts); err != nil {
+				opts.Logger.Error().Err(err).Msg("consistency repair failed")
+			}
+		}
+	}
+}
+
+// Log reports every difference of a report, then a summary.
+func Log(logger zerolog.Logger, report Report) {
+	for _, missing := range report.MissingFiles {
+		logger.Warn().Uint32("photo_id", missing.PhotoID).Str("path", missing.Path).Bool("marked", missing.Marked).Msg("photo file missing")
+	}
+	for _, photoID := range report.Restored {
+		logger.Info().Uint32("photo_id", photoID).Msg("missing photo file restored")
+	}
+	for _, unsized := range report.Unsized {
+		logger.Info().Uint32("photo_id", unsized.PhotoID).Int64("size", unsized.Size).Msg("photo size not recorded")
+	}
+	for _, object := range report.OrphanFiles {
+		logger.Warn().Str("key", object.Key).Int64("size", object.Size).Msg("file referenced by no photo")
+	}
+	for _, dir := range report.OrphanCaches {
+		logger.Warn().Str("path", dir).Msg("cache directory of a deleted photo")
+	}
+	for _, dir := range report.LegacyCaches {
+		logger.Warn().Str("path", dir).Msg("cache directory of derivatives not moved to the storage")
+	}
+	logger.Info().
+		Int("photos", report.Photos).
+		Int("objects", report.Objects).
+		Int("missing_files", len(report.MissingFiles)).
+		Int("restored", len(report.Restored)).
+		Int("unsized", len(report.Unsized)).
+		Int("orphan_files", len(report.OrphanFiles)).
+		Int("orphan_caches", len(report.OrphanCaches)).
+		Int("legacy_caches", len(report.LegacyCaches)).
+		Msg("consistency check done")
+}
@@ -0,0 +1,179 @@
+package consistency
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"photos/pkg/derivative"
+	"photos/pkg/storage"
+	"photos/pkg/usage"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-2 * time.Hour)
+	photos := []query.GetPhotoPathsRow{
+		{PhotoID: 1, PathToPhoto: "ab/cd/one.jpg", EventID: 1},
+		{PhotoID: 2, PathToPhoto: "ab/cd/two.jpg", EventID: 1},
+		{PhotoID: 3, PathToPhoto: "ab/cd/one.jpg", EventID: 2},
+		{PhotoID: 4, PathToPhoto: "1/legacy.jpg", EventID: 1},
+	}
+	objects := []storage.Info{
+		{Key: "ab/cd/one.jpg", ModTime: old},
+		{Key: "1/legacy.jpg", ModTime: old},
+		{Key: "ef/gh/orphan.jpg", ModTime: old},
+		{Key: "ef/gh/uploading.jpg", ModTime: now},
+		{Key: "derivatives/1/thumbnail.jpg", ModTime: old},
+		{Key: "derivatives/9/thumbnail.jpg", ModTime: old},
+	}
+
+	report := compare(photos, []uint32{2, 4}, objects, now.Add(-time.Hour))
+	assert.Equal(t, 4, report.Photos)
+	assert.Equal(t, 6, report.Objects)
+	assert.Equal(t, []Missing{{PhotoID: 2, Path: "ab/cd/two.jpg", Marked: true}}, report.MissingFiles)
+	assert.Equal(t, []uint32{4}, report.Restored, "a marked photo whose file is back must be unmarked")
+	assert.Equal(t, []storage.Info{
+		{Key: "ef/gh/orphan.jpg", ModTime: old},
+		{Key: "derivatives/9/thumbnail.jpg", ModTime: old},
+	}, report.OrphanFiles, "recent objects and files shared by several photos are not orphans")
+	assert.False(t, report.Clean())
+
+	report = compare(photos[:1], []uint32{}, objects[:1], now)
+	assert.True(t, report.Clean())
+	report = compare(photos[1:2], []uint32{2}, nil, now)
+	assert.True(t, report.Clean(), "photos already marked as missing need no repair")
+}
+
+// TestRepairUnsized ensures that the sizes of the photos stored before they were recorded are accounted for, their
+// shared file counting once in the usage of the whole storage.
+func TestRepairUnsized(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.New(db.Options{Driver: db.DriverSQLite, Path: filepath.Join(t.TempDir(), "photos.db"), MaxOpenConns: 1})
+	assert.NoError(t, err)
+	defer database.Close()
+	store := storage.NewLocal(t.TempDir())
+	assert.NoError(t, store.Put(ctx, "ab/cd/photo.jpg", strings.NewReader("photo"), 5))
+	var events []uint32
+	for range 2 {
+		eventID, err := database.CreateEvent(ctx, query.CreateEventParams{Name: "Gala", EventDate: time.Now()})
+		assert.NoError(t, err)
+		events = append(events, uint32(eventID))
+		_, err = database.CreatePhoto(ctx, query.CreatePhotoParams{PathToPhoto: "ab/cd/photo.jpg", FileHash: "hash", EventID: uint32(eventID)})
+		assert.NoError(t, err)
+	}
+
+	opts := Options{Storage: store}
+	report, err := Check(ctx, database, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []Unsized{{PhotoID: 1, Size: 5}, {PhotoID: 2, Size: 5}}, report.Unsized)
+	assert.False(t, report.Clean())
+	assert.NoError(t, Repair(ctx, database, report, opts))
+	assert.NoError(t, Repair(ctx, database, report, opts), "sizes already recorded are not accounted for again")
+
+	report, err = Check(ctx, database, opts)
+	assert.NoError(t, err)
+	assert.True(t, report.Clean())
+	for scope, ids := range map[query.StorageUsageScope][]uint32{query.StorageUsageScopeEVENT: events, query.StorageUsageScopeGLOBAL: {0}} {
+		for _, id := range ids {
+			used, err := database.GetStorageUsage(ctx, query.GetStorageUsageParams{Scope: scope, ScopeID: id})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(5), used, "%s %d", scope, id)
+		}
+	}
+}
+
+// TestRepairOrphanFiles ensures that quarantined files are removed from the usage of the whole storage.
+func TestRepairOrphanFiles(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.New(db.Options{Driver: db.DriverSQLite, Path: filepath.Join(t.TempDir(), "photos.db"), MaxOpenConns: 1})
+	assert.NoError(t, err)
+	defer database.Close()
+	store := storage.NewLocal(t.TempDir())
+	assert.NoError(t, store.Put(ctx, "ab/cd/photo.jpg", strings.NewReader("photo"), 5))
+	assert.NoError(t, store.Put(ctx, derivative.Key(9, derivative.Thumbnail), strings.NewReader("thumb"), 5))
+	assert.NoError(t, store.Put(ctx, "derivatives/9/unknown.jpg", strings.NewReader("unknown"), 7))
+	assert.NoError(t, usage.ReserveFile(ctx, database.Querier, 0, 5))
+	assert.NoError(t, usage.AddDerivative(ctx, database.Querier, 1, derivative.Thumbnail, 5))
+
+	opts := Options{Storage: store}
+	report, err := Check(ctx, database, opts)
+	assert.NoError(t, err)
+	assert.Len(t, report.OrphanFiles, 3)
+	assert.NoError(t, Repair(ctx, database, report, opts))
+
+	report, err = Check(ctx, database, opts)
+	assert.NoError(t, err)
+	assert.True(t, report.Clean())
+	used, err := database.GetStorageUsage(ctx, query.GetStorageUsageParams{Scope: query.StorageUsageScopeGLOBAL, ScopeID: 0})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), used)
+}
+
+func TestOrphanCaches(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"1/1", "1/2", "2/1", "3/7", "not-an-event/1"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0750))
+	}
+	photos := []query.GetPhotoPathsRow{{PhotoID: 1, EventID: 1}, {PhotoID: 2, EventID: 1}}
+
+	orphans, legacy, err := orphanCaches(root, photos)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2/1", "3/7"}, orphans)
+	assert.Equal(t, []string{"1/1", "1/2"}, legacy)
+
+	orphans, legacy, err = orphanCaches(filepath.Join(root, "missing"), photos)
+	assert.NoError(t, err)
+	assert.Empty(t, orphans, "a missing cache root has no orphans")
+	assert.Empty(t, legacy)
+}
+
+// TestRepairLegacyCaches ensures that the derivatives cached before they were stored are moved to the storage, without
+// replacing the derivatives stored since, and are accounted for.
+func TestRepairLegacyCaches(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.New(db.Options{Driver: db.DriverSQLite, Path: filepath.Join(t.TempDir(), "photos.db"), MaxOpenConns: 1})
+	assert.NoError(t, err)
+	defer database.Close()
+	eventID, err := database.CreateEvent(ctx, query.CreateEventParams{Name: "Gala", EventDate: time.Now()})
+	assert.NoError(t, err)
+	photoID, err := database.CreatePhoto(ctx, query.CreatePhotoParams{PathToPhoto: "ab/cd/photo.jpg", FileHash: "hash", EventID: uint32(eventID)})
+	assert.NoError(t, err)
+	store := storage.NewLocal(t.TempDir())
+	assert.NoError(t, store.Put(ctx, derivative.Key(uint32(photoID), derivative.Preview), strings.NewReader("stored"), 6))
+
+	cacheRoot := t.TempDir()
+	dir := filepath.Join(cacheRoot, strconv.FormatInt(eventID, 10), strconv.FormatInt(photoID, 10))
+	assert.NoError(t, os.MkdirAll(dir, 0750))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "thumbnail.jpg"), []byte("thumb"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "preview.jpg"), []byte("cached"), 0600))
+
+	opts := Options{Storage: store, CacheRoot: cacheRoot}
+	report, err := Check(ctx, database, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{path.Join(strconv.FormatInt(eventID, 10), strconv.FormatInt(photoID, 10))}, report.LegacyCaches)
+	assert.False(t, report.Clean())
+	assert.NoError(t, Repair(ctx, database, report, opts))
+
+	assert.NoDirExists(t, dir)
+	for kind, content := range map[derivative.Kind]string{derivative.Thumbnail: "thumb", derivative.Preview: "stored"} {
+		file, _, err := store.Get(ctx, derivative.Key(uint32(photoID), kind))
+		assert.NoError(t, err)
+		data, err := io.ReadAll(file)
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+		assert.Equal(t, content, string(data))
+	}
+	used, err := database.GetStorageUsage(ctx, query.GetStorageUsageParams{Scope: query.StorageUsageScopeEVENT, ScopeID: uint32(eventID)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), used, "only the moved thumbnail is accounted for")
+}
@@ -3,49 +3,130 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"photos/pkg/db/postgres"
 	"photos/pkg/db/query"
+	"photos/pkg/db/sqlite"
+	"photos/pkg/tracing"
 	"sync"
-	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
 // DB is a wrapper around the standard sql.DB struct, adding a mutex for thread-safe
-// operations and an embedded Queries struct for interacting with the database.
+// operations and the queries of its engine for interacting with the database. The queries are traced.
 type DB struct {
-	*sql.DB                   // The underlying SQL database connection.
-	mux            sync.Mutex // Mutex to provide thread-safe access.
-	*query.Queries            // Query methods for interacting with the database.
+	*sql.DB                                      // The underlying SQL database connection.
+	mux           sync.Mutex                     // Mutex to provide thread-safe access.
+	query.Querier                                // Query methods for interacting with the database.
+	system        string                         // Database system of the spans of the queries: mysql, sqlite or postgresql.
+	queries       func(query.DBTX) query.Querier // Queries of the engine, run on the database or a transaction.
 }
 
-// New creates and configures a new MySQL database connection.
+// New creates and configures a new MySQL, SQLite or PostgreSQL database connection. The TLS configuration of MySQL is
+// registered in the driver before the connection is opened, the tables of SQLite and PostgreSQL are created if they do
+// not exist.
 //
 // Parameters:
-//   - username: The username for connecting to the database.
-//   - password: The password for the database user.
-//   - host: The hostname or IP address of the database server.
-//   - port: The port on which the database server is listening.
-//   - dbName: The name of the database to connect to.
-//   - cert: Path to the TLS certificate file (unused in this function but can be extended for secure connections).
-//   - maxOpenConns: Maximum number of open connections to the database.
-//   - maxIdleConns: Maximum number of idle connections in the pool.
-//   - connMaxLifetime: Maximum lifetime of a connection.
-//   - useTLS: Boolean indicating whether to use TLS for the connection.
+//   - opts: The connection details and the pool settings.
 //
 // Returns:
 //   - *DB: A pointer to the initialized DB struct.
-//   - error: An error if the connection could not be established.
-func New(username, password, host, port, dbName, cert string, maxOpenConns, maxIdleConns int, connMaxLifetime time.Duration, useTLS bool) (*DB, error) {
-	mysqlDB, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?tls=%t&loc=UTC&parseTime=true", username, password, host, port, dbName, useTLS))
+//   - error: An error if the DSN is invalid, the TLS certificates could not be loaded or the connection could not be
+//     established.
+func New(opts Options) (*DB, error) {
+	if opts.Driver == DriverSQLite {
+		return newSQLite(opts)
+	}
+	if opts.Driver == DriverPostgres {
+		return newPostgres(opts)
+	}
+	cfg, err := opts.driverConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.registerTLS(cfg); err != nil {
+		return nil, err
+	}
+	mysqlDB, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return nil, err
+	}
+	mysqlDB.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	mysqlDB.SetMaxOpenConns(opts.MaxOpenConns)
+	mysqlDB.SetMaxIdleConns(opts.MaxIdleConns)
+
+	return newDB(mysqlDB, "mysql", mysqlQueries), nil
+}
+
+// newSQLite opens a SQLite database and creates its tables if they do not exist.
+func newSQLite(opts Options) (*DB, error) {
+	sqliteDB, err := sql.Open(sqlite.DriverName, opts.sqliteDSN())
+	if err != nil {
+		return nil, err
+	}
+	sqliteDB.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	sqliteDB.SetMaxOpenConns(opts.MaxOpenConns)
+	sqliteDB.SetMaxIdleConns(opts.MaxIdleConns)
+	if _, err := sqliteDB.Exec(sqlite.Schema); err != nil {
+		sqliteDB.Close()
+		return nil, fmt.Errorf("failed to create the sqlite schema: %w", err)
+	}
+	return newDB(sqliteDB, "sqlite", sqlite.New), nil
+}
+
+// newPostgres connects to a PostgreSQL database and creates its tables if they do not exist.
+func newPostgres(opts Options) (*DB, error) {
+	cfg, err := opts.postgresConfig()
 	if err != nil {
 		return nil, err
 	}
-	mysqlDB.SetConnMaxLifetime(connMaxLifetime)
-	mysqlDB.SetMaxOpenConns(maxOpenConns)
-	mysqlDB.SetMaxIdleConns(maxIdleConns)
+	if err := opts.setPostgresTLS(cfg); err != nil {
+		return nil, err
+	}
+	postgresDB, err := sql.Open(postgres.DriverName, stdlib.RegisterConnConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	postgresDB.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	postgresDB.SetMaxOpenConns(opts.MaxOpenConns)
+	postgresDB.SetMaxIdleConns(opts.MaxIdleConns)
+	if _, err := postgresDB.Exec(postgres.Schema); err != nil {
+		postgresDB.Close()
+		return nil, fmt.Errorf("failed to create the postgres schema: %w", err)
+	}
+	return newDB(postgresDB, "postgresql", postgres.New), nil
+}
+
+// mysqlQueries returns the MySQL queries of the query package.
+func mysqlQueries(db query.DBTX) query.Querier {
+	return query.New(db)
+}
+
+// newDB wraps a database connection, tracing the queries of its engine.
+func newDB(sqlDB *sql.DB, system string, queries func(query.DBTX) query.Querier) *DB {
+	return &DB{
+		DB:      sqlDB,
+		mux:     sync.Mutex{},
+		Querier: queries(tracing.DBTX(sqlDB, system)),
+		system:  system,
+		queries: queries,
+	}
+}
 
-	db := &DB{DB: mysqlDB, mux: sync.Mutex{}, Queries: query.New(mysqlDB)}
-	return db, nil
+// WithTx returns the queries of a transaction, traced like the queries of the database.
+//
+// Parameters:
+//   - tx: The transaction.
+//
+// Returns:
+//   - query.Querier: The queries running in the transaction.
+func (db *DB) WithTx(tx *sql.Tx) query.Querier {
+	// A DB built without New, such as over a mock in the tests, runs the MySQL queries.
+	if db.queries == nil {
+		return mysqlQueries(tracing.DBTX(tx, db.system))
+	}
+	return db.queries(tracing.DBTX(tx, db.system))
 }
 
 // Lock acquires the mutex lock for thread-safe operations on the DB object.
@@ -1,6 +1,11 @@
 package db
 
 import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"photos/pkg/db/query"
 	"testing"
 	"time"
 
@@ -17,7 +22,16 @@ func TestNew(t *testing.T) {
 	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
 
 	// Replace sql.Open with a mock database connection
-	db, err := New("user", "password", "localhost", "3306", "testdb", "", 10, 5, 30*time.Minute, false)
+	db, err := New(Options{
+		Username:        "user",
+		Password:        "password",
+		Host:            "localhost",
+		Port:            "3306",
+		Name:            "testdb",
+		MaxOpenConns:    10,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: 30 * time.Minute,
+	})
 
 	assert.NoError(t, err, "New should not return an error with valid parameters")
 	assert.NotNil(t, db, "New should return a valid DB object")
@@ -55,3 +69,113 @@ func TestLockAndUnlock(t *testing.T) {
 	time.Sleep(100 * time.Millisecond) // Allow goroutine to run
 	assert.True(t, unlocked, "Unlock should release the lock")
 }
+
+// TestNewSQLite ensures that the queries of the query package run on SQLite, transactions included.
+func TestNewSQLite(t *testing.T) {
+	db, err := New(Options{Driver: DriverSQLite, Path: filepath.Join(t.TempDir(), "photos.db"), MaxOpenConns: 4})
+	assert.NoError(t, err)
+	defer db.Close()
+	testQueries(t, db)
+}
+
+// TestNewPostgres ensures that the queries of the query package run on PostgreSQL, transactions included. It needs
+// the DSN of an empty database in PHOTOS_TEST_POSTGRES_DSN, such as postgres://photos@localhost/photos_test.
+func TestNewPostgres(t *testing.T) {
+	dsn := os.Getenv("PHOTOS_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("PHOTOS_TEST_POSTGRES_DSN is not set")
+	}
+	db, err := New(Options{Driver: DriverPostgres, DSN: dsn, MaxOpenConns: 4})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+	testQueries(t, db)
+}
+
+// testQueries runs the queries of the query package on an empty database.
+func testQueries(t *testing.T, db *DB) {
+	ctx := context.Background()
+
+	// The id of an existing user is returned like a new one, outside of a transaction.
+	var ids []uint32
+	for _, email := range []string{"jane@example.com", "john@example.com", "jane@example.com"} {
+		id, err := db.AttemptCreatingUser(ctx, query.AttemptCreatingUserParams{
+			Email:            email,
+			FullName:         "Jane",
+			BusinessCategory: query.UsersBusinessCategorySTUDENT,
+		})
+		assert.NoError(t, err)
+		user, err := db.GetUser(ctx, uint32(id))
+		assert.NoError(t, err)
+		assert.Equal(t, email, user.Email)
+		ids = append(ids, user.UserID)
+	}
+	assert.Equal(t, ids[0], ids[2])
+	assert.NotEqual(t, ids[0], ids[1])
+
+	// The creation date of the sessions is compared with the times of the arguments.
+	assert.NoError(t, db.CreateSession(ctx, query.CreateSessionParams{UserID: ids[0], SessionToken: "token"}))
+	sessions, err := db.CountSessionsSince(ctx, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), sessions)
+	sessions, err = db.CountSessionsSince(ctx, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), sessions)
+
+	eventID, err := db.CreateEvent(ctx, query.CreateEventParams{Name: "Gala", EventDate: time.Now()})
+	assert.NoError(t, err)
+	event, err := db.GetEventWithNameAndParent(ctx, query.GetEventWithNameAndParentParams{Name: "Gala"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(eventID), event.EventID)
+	assert.Equal(t, query.EventsMetadataPolicySTRIPGPS, event.MetadataPolicy)
+
+	// Hidden events are not listed.
+	events, err := db.GetEvents(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.NoError(t, db.UpdateEventHidden(ctx, query.UpdateEventHiddenParams{IsHidden: true, EventID: event.EventID}))
+	events, err = db.GetEvents(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	photoID, err := db.CreatePhoto(ctx, query.CreatePhotoParams{PathToPhoto: "a.jpg", FileHash: "hash", EventID: event.EventID})
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, db.AddStorageUsage(ctx, query.AddStorageUsageParams{
+			Scope: query.StorageUsageScopeEVENT, ScopeID: event.EventID, Kind: query.StorageUsageKindORIGINAL, Bytes: 100, Files: 1,
+		}))
+	}
+	bytes, err := db.GetStorageUsage(ctx, query.GetStorageUsageParams{Scope: query.StorageUsageScopeEVENT, ScopeID: event.EventID})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(200), bytes)
+
+	assert.NoError(t, db.SaveFaceConsent(ctx, query.SaveFaceConsentParams{UserID: ids[0], Consent: query.FaceConsentsConsentGRANTED}))
+	assert.NoError(t, db.SaveFaceConsent(ctx, query.SaveFaceConsentParams{UserID: ids[0], Consent: query.FaceConsentsConsentREFUSED}))
+	consent, err := db.GetFaceConsent(ctx, ids[0])
+	assert.NoError(t, err)
+	assert.Equal(t, query.FaceConsentsConsentREFUSED, consent.Consent)
+
+	assert.NoError(t, db.CreateJob(ctx, query.CreateJobParams{Kind: query.JobsKindDERIVATIVES, PhotoID: sql.NullInt32{Int32: int32(photoID), Valid: true}}))
+	job, err := db.GetNextJob(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, db.LeaseJob(ctx, query.LeaseJobParams{LeaseSeconds: 60, JobID: job.JobID}))
+	jobs, err := db.GetJobs(ctx, query.GetJobsParams{Status: query.JobsStatusRUNNING, Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, jobs, 1) {
+		assert.Equal(t, uint32(1), jobs[0].Attempts)
+		assert.WithinDuration(t, jobs[0].RunAfter.Add(time.Minute), jobs[0].LeasedUntil.Time, 2*time.Second)
+	}
+	_, err = db.GetNextJob(ctx)
+	assert.ErrorIs(t, err, sql.ErrNoRows, "a leased job must not be given to another worker")
+	completed, err := db.CompleteJob(ctx, query.CompleteJobParams{JobID: job.JobID, Attempts: 2})
+	assert.NoError(t, err)
+	assert.Zero(t, completed, "a job leased again must not be completed by its previous worker")
+
+	// Foreign keys are enforced and cascade.
+	assert.NoError(t, db.DeletePhoto(ctx, uint32(photoID)))
+	jobs, err = db.GetJobs(ctx, query.GetJobsParams{Status: query.JobsStatusRUNNING, Limit: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, jobs)
+}
@@ -0,0 +1,279 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+)
+
+// Drivers of the database.
+const (
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// sqliteParams are the DSN parameters of SQLite databases: foreign keys are enforced like with MySQL, times are written
+// in the format of SQLite, and write transactions take the lock at once and wait for each other instead of failing.
+var sqliteParams = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite&_txlock=immediate"
+
+// tlsConfigName is the name the TLS configuration of the database is registered under in the driver.
+const tlsConfigName = "photos"
+
+// Options holds the connection details of the database.
+type Options struct {
+	Driver          string            // Driver of the database, mysql by default, sqlite or postgres.
+	Path            string            // Path of the SQLite database file.
+	DSN             string            // Full DSN of the driver, overriding the connection fields below when set.
+	Username        string            // The username for connecting to the database.
+	Password        string            // The password for the database user.
+	Host            string            // The hostname or IP address of the database server.
+	Port            string            // The port on which the database server is listening.
+	Socket          string            // Path of the unix socket of the database server, its directory with PostgreSQL, used instead of host and port.
+	Name            string            // The name of the database to connect to.
+	Params          map[string]string // Extra DSN parameters, such as charset or timeout.
+	TLS             TLSOptions        // TLS of the connection.
+	MaxOpenConns    int               // Maximum number of open connections to the database.
+	MaxIdleConns    int               // Maximum number of idle connections in the pool.
+	ConnMaxLifetime time.Duration     // Maximum lifetime of a connection.
+}
+
+// TLSOptions holds the TLS configuration of the connection to the database.
+type TLSOptions struct {
+	Enabled    bool   // Whether the connection uses TLS.
+	CA         string // Path of the PEM certificates of the authorities the server certificate is verified with, the system ones if empty.
+	Cert       string // Path of the PEM client certificate, for servers requiring one.
+	Key        string // Path of the PEM private key of the client certificate.
+	ServerName string // Name the server certificate is verified against, the host by default.
+	SkipVerify bool   // Whether the server certificate is not verified, for tests only.
+}
+
+// FormatDSN builds the DSN of the driver. The time zone is always UTC and times are always parsed, since the queries
+// rely on it. TLS refers to the configuration New registers with MySQL, and is set by New with PostgreSQL.
+//
+// Returns:
+//   - string: The DSN of the driver.
+//   - error: An error if the DSN override or the extra parameters are invalid.
+func (o Options) FormatDSN() (string, error) {
+	if o.Driver == DriverSQLite {
+		return o.sqliteDSN(), nil
+	}
+	if o.Driver == DriverPostgres {
+		if _, err := o.postgresConfig(); err != nil {
+			return "", err
+		}
+		return o.postgresDSN(), nil
+	}
+	cfg, err := o.driverConfig()
+	if err != nil {
+		return "", err
+	}
+	return cfg.FormatDSN(), nil
+}
+
+// sqliteDSN builds the DSN of modernc.org/sqlite, the full DSN override or the path with the parameters.
+func (o Options) sqliteDSN() string {
+	dsn := o.DSN
+	if dsn == "" {
+		dsn = "file:" + o.Path + "?" + sqliteParams
+	}
+	if len(o.Params) > 0 {
+		params := url.Values{}
+		for key, value := range o.Params {
+			params.Set(key, value)
+		}
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		dsn += separator + params.Encode()
+	}
+	return dsn
+}
+
+// postgresDSN builds the DSN of github.com/jackc/pgx, the full DSN override or a URL of the connection fields, with the
+// extra parameters.
+func (o Options) postgresDSN() string {
+	dsn := o.DSN
+	params := url.Values{}
+	if dsn == "" {
+		u := url.URL{Scheme: "postgres", User: url.User(o.Username), Path: "/" + o.Name}
+		if o.Password != "" {
+			u.User = url.UserPassword(o.Username, o.Password)
+		}
+		if o.Socket != "" {
+			params.Set("host", o.Socket)
+		} else {
+			u.Host = net.JoinHostPort(o.Host, o.Port)
+		}
+		dsn = u.String()
+	}
+	for key, value := range o.Params {
+		params.Set(key, value)
+	}
+	if len(params) == 0 {
+		return dsn
+	}
+	// The DSN override may also be a list of keyword=value settings.
+	if !strings.Contains(dsn, "://") {
+		var settings []string
+		for key := range params {
+			value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(params.Get(key))
+			settings = append(settings, key+"='"+value+"'")
+		}
+		slices.Sort(settings)
+		return strings.TrimSpace(dsn + " " + strings.Join(settings, " "))
+	}
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + params.Encode()
+}
+
+// postgresConfig parses the DSN into the configuration of the driver, with the time zone of the session set to UTC.
+func (o Options) postgresConfig() (*pgx.ConnConfig, error) {
+	cfg, err := pgx.ParseConfig(o.postgresDSN())
+	if err != nil {
+		return nil, fmt.Errorf("invalid dsn: %w", err)
+	}
+	cfg.RuntimeParams["timezone"] = "UTC"
+	return cfg, nil
+}
+
+// driverConfig builds the configuration of the driver the DSN is formatted from.
+func (o Options) driverConfig() (*mysql.Config, error) {
+	dsn := o.DSN
+	if dsn == "" {
+		base := mysql.NewConfig()
+		base.User = o.Username
+		base.Passwd = o.Password
+		base.DBName = o.Name
+		if o.Socket != "" {
+			base.Net = "unix"
+			base.Addr = o.Socket
+		} else {
+			base.Net = "tcp"
+			base.Addr = net.JoinHostPort(o.Host, o.Port)
+		}
+		dsn = base.FormatDSN()
+	}
+	if len(o.Params) > 0 {
+		params := url.Values{}
+		for key, value := range o.Params {
+			params.Set(key, value)
+		}
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		dsn += separator + params.Encode()
+	}
+
+	// The driver resolves named TLS configurations while parsing, TLS is set once the DSN is parsed.
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dsn: %w", err)
+	}
+	cfg.Loc = time.UTC
+	cfg.ParseTime = true
+	if o.TLS.Enabled {
+		cfg.TLS = nil
+		cfg.TLSConfig = tlsConfigName
+	}
+	return cfg, nil
+}
+
+// config loads the certificates of the TLS configuration.
+//
+// Parameters:
+//   - host: Host the server certificate is verified against when no server name is set.
+//
+// Returns:
+//   - *tls.Config: The TLS configuration of the driver.
+//   - error: An error if a certificate could not be read or parsed.
+func (o TLSOptions) config(host string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.SkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = host
+	}
+	if o.CA != "" {
+		pem, err := os.ReadFile(o.CA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("failed to parse CA certificates: no PEM certificate found")
+		}
+	}
+	if o.Cert != "" || o.Key != "" {
+		cert, err := tls.LoadX509KeyPair(o.Cert, o.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// registerTLS registers the TLS configuration of the options in the driver, so that the DSN can refer to it.
+//
+// Parameters:
+//   - cfg: The configuration of the driver, whose host the server certificate is verified against by default.
+//
+// Returns:
+//   - error: An error if a certificate could not be loaded.
+func (o Options) registerTLS(cfg *mysql.Config) error {
+	if !o.TLS.Enabled {
+		return nil
+	}
+	var host string
+	if cfg.Net == "tcp" {
+		host, _, _ = net.SplitHostPort(cfg.Addr)
+	}
+	tlsConfig, err := o.TLS.config(host)
+	if err != nil {
+		return err
+	}
+	return mysql.RegisterTLSConfig(tlsConfigName, tlsConfig)
+}
+
+// setPostgresTLS sets the TLS configuration of the options in the configuration of the driver, without the fallback
+// to a plain connection the sslmode of the DSN may allow.
+//
+// Parameters:
+//   - cfg: The configuration of the driver, whose host the server certificate is verified against by default.
+//
+// Returns:
+//   - error: An error if a certificate could not be loaded.
+func (o Options) setPostgresTLS(cfg *pgx.ConnConfig) error {
+	if !o.TLS.Enabled {
+		return nil
+	}
+	var host string
+	if !strings.HasPrefix(cfg.Host, "/") {
+		host = cfg.Host
+	}
+	tlsConfig, err := o.TLS.config(host)
+	if err != nil {
+		return err
+	}
+	cfg.TLSConfig = tlsConfig
+	cfg.Fallbacks = nil
+	return nil
+}
@@ -0,0 +1,448 @@
+// Command querygen generates the adapter of a query package generated by sqlc for SQLite or PostgreSQL to the Querier
+// interface of the MySQL query package, which the rest of the code uses whatever the database.
+//
+// Both packages have the same queries under the same names, but sqlc gives the columns of each engine its own Go types,
+// such as int64 for the INTEGER ids of SQLite or sql.NullString for the enums of PostgreSQL. The adapter converts the
+// parameters and the results of each query field by field. The adapter is the queries type, which the New function of
+// the engine package returns. Run from the directory of the engine package:
+//
+//	go run ../internal/querygen -local internal/query -engine SQLite -out queries.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	local := flag.String("local", "internal/query", "directory of the query package generated for the engine")
+	shared := flag.String("shared", "../query", "directory of the MySQL query package")
+	engine := flag.String("engine", "", "name of the engine in the comments of the adapter")
+	out := flag.String("out", "queries.go", "file the adapter is written to")
+	flag.Parse()
+
+	code, err := generate(*shared, *local, *engine)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, code, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// pkg is a query package generated by sqlc.
+type pkg struct {
+	name    string              // Name of the package in the generated code.
+	path    string              // Import path of the package.
+	types   map[string]ast.Expr // Types declared by the package, by name.
+	imports map[string]string   // Import paths of the packages its files use, by name.
+}
+
+// load parses the files of a query package.
+//
+// Parameters:
+//   - dir: The directory of the package.
+//   - name: The name the package is imported under.
+//
+// Returns:
+//   - *pkg: The package.
+//   - error: An error if its files could not be parsed or its import path not found.
+func load(dir, name string) (*pkg, error) {
+	path, err := importPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	p := &pkg{name: name, path: path, types: map[string]ast.Expr{}, imports: map[string]string{}}
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, spec := range f.Imports {
+			importPath, _ := strconv.Unquote(spec.Path.Value)
+			p.imports[filepath.Base(importPath)] = importPath
+		}
+		for _, decl := range f.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.TYPE {
+				for _, spec := range gen.Specs {
+					spec := spec.(*ast.TypeSpec)
+					p.types[spec.Name.Name] = spec.Type
+				}
+			}
+		}
+	}
+	if _, ok := p.types["Querier"].(*ast.InterfaceType); !ok {
+		return nil, fmt.Errorf("%s has no Querier interface, is emit_interface set in sqlc.yml?", dir)
+	}
+	return p, nil
+}
+
+// importPath returns the import path of a directory from the module of its go.mod.
+func importPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for root := abs; ; root = filepath.Dir(root) {
+		data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			module := regexp.MustCompile(`(?m)^module\s+(\S+)`).FindSubmatch(data)
+			if module == nil {
+				return "", fmt.Errorf("no module in %s", filepath.Join(root, "go.mod"))
+			}
+			rel, err := filepath.Rel(root, abs)
+			if err != nil {
+				return "", err
+			}
+			return filepath.ToSlash(filepath.Join(string(module[1]), rel)), nil
+		}
+		if filepath.Dir(root) == root {
+			return "", fmt.Errorf("no go.mod above %s", dir)
+		}
+	}
+}
+
+// methods returns the methods of the Querier interface of a package, by name.
+func (p *pkg) methods() map[string]*ast.FuncType {
+	methods := map[string]*ast.FuncType{}
+	for _, field := range p.types["Querier"].(*ast.InterfaceType).Methods.List {
+		methods[field.Names[0].Name] = field.Type.(*ast.FuncType)
+	}
+	return methods
+}
+
+// declared returns the declaration of a type of the package, or nil for the other types.
+func (p *pkg) declared(t ast.Expr) ast.Expr {
+	if ident, ok := t.(*ast.Ident); ok {
+		return p.types[ident.Name]
+	}
+	return nil
+}
+
+// qualify returns a type as written outside of the package.
+func (p *pkg) qualify(t ast.Expr) string {
+	switch t := t.(type) {
+	case *ast.Ident:
+		if _, ok := p.types[t.Name]; ok {
+			return p.name + "." + t.Name
+		}
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "[]" + p.qualify(t.Elt)
+		}
+	case *ast.StarExpr:
+		return "*" + p.qualify(t.X)
+	}
+	return types.ExprString(t)
+}
+
+// fields returns the fields of a struct, by name, in the order of their declaration.
+func fields(s *ast.StructType) ([]string, map[string]ast.Expr) {
+	var names []string
+	byName := map[string]ast.Expr{}
+	for _, field := range s.Fields.List {
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+			byName[name.Name] = field.Type
+		}
+	}
+	return names, byName
+}
+
+// nullTypes are the fields holding the value of the nullable types of database/sql, by type.
+var nullTypes = map[string]string{
+	"sql.NullBool":    "Bool",
+	"sql.NullByte":    "Byte",
+	"sql.NullFloat64": "Float64",
+	"sql.NullInt16":   "Int16",
+	"sql.NullInt32":   "Int32",
+	"sql.NullInt64":   "Int64",
+	"sql.NullString":  "String",
+	"sql.NullTime":    "Time",
+}
+
+// isNumber returns whether a type is a basic integer or floating point type.
+func isNumber(t string) bool {
+	switch t {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+		return true
+	}
+	return false
+}
+
+// isString returns whether a type is declared by its package as a string, as sqlc declares enums.
+func (p *pkg) isString(t ast.Expr) bool {
+	ident, ok := p.declared(t).(*ast.Ident)
+	return ok && ident.Name == "string"
+}
+
+// nullString returns the name of the enum of a nullable enum declared by sqlc, such as JobsKind for NullJobsKind.
+func (p *pkg) nullString(t ast.Expr) (string, bool) {
+	s, ok := p.declared(t).(*ast.StructType)
+	if !ok {
+		return "", false
+	}
+	names, byName := fields(s)
+	if len(names) != 2 || names[1] != "Valid" || !p.isString(byName[names[0]]) {
+		return "", false
+	}
+	return names[0], true
+}
+
+// generator writes the adapter and the conversion functions it uses.
+type generator struct {
+	shared, local *pkg
+	funcs         bytes.Buffer    // Conversion functions of structs.
+	generated     map[string]bool // Names of the conversion functions already written.
+}
+
+// convert returns the expression converting a value from a type of a package to a type of the other.
+//
+// Parameters:
+//   - value: The expression of the value to convert.
+//   - from, fromType: The package and the type of the value.
+//   - to, toType: The package and the type to convert it to.
+//
+// Returns:
+//   - string: The expression of the converted value.
+//   - error: An error if the types cannot be converted.
+func (g *generator) convert(value string, from *pkg, fromType ast.Expr, to *pkg, toType ast.Expr) (string, error) {
+	fromName, toName := types.ExprString(fromType), types.ExprString(toType)
+	fromDecl, toDecl := from.declared(fromType), to.declared(toType)
+	target := to.qualify(toType)
+	switch {
+	case fromName == toName && fromDecl == nil && toDecl == nil:
+		return value, nil
+	case isNumber(fromName) && isNumber(toName),
+		from.isString(fromType) && (toName == "string" || to.isString(toType)),
+		fromName == "string" && to.isString(toType):
+		return target + "(" + value + ")", nil
+	}
+	if fromStruct, ok := fromDecl.(*ast.StructType); ok && fromName == toName {
+		if toStruct, ok := toDecl.(*ast.StructType); ok {
+			name := "fromQuery" + toName
+			if to == g.shared {
+				name = "toQuery" + toName
+			}
+			if err := g.convertStruct(name, from, fromName, fromStruct, to, toStruct); err != nil {
+				return "", err
+			}
+			return name + "(" + value + ")", nil
+		}
+	}
+	if enum, ok := from.nullString(fromType); ok && toName == "sql.NullString" {
+		return fmt.Sprintf("sql.NullString{String: string(%s.%s), Valid: %s.Valid}", value, enum, value), nil
+	}
+	if enum, ok := to.nullString(toType); ok && fromName == "sql.NullString" {
+		return fmt.Sprintf("%s{%s: %s.%s(%s.String), Valid: %s.Valid}", target, enum, to.name, enum, value, value), nil
+	}
+	fromField, fromNull := nullTypes[fromName]
+	toField, toNull := nullTypes[toName]
+	if fromNull && toNull && isNumber(strings.ToLower(fromField)) && isNumber(strings.ToLower(toField)) {
+		return fmt.Sprintf("%s{%s: %s(%s.%s), Valid: %s.Valid}", toName, toField, strings.ToLower(toField), value,
+			fromField, value), nil
+	}
+	return "", fmt.Errorf("cannot convert %s to %s", from.qualify(fromType), target)
+}
+
+// convertStruct writes the function converting a struct of a package to the struct of the same name of the other,
+// unless it is already written.
+func (g *generator) convertStruct(name string, from *pkg, typeName string, fromStruct *ast.StructType, to *pkg,
+	toStruct *ast.StructType) error {
+	if g.generated[name] {
+		return nil
+	}
+	g.generated[name] = true
+	fromNames, fromFields := fields(fromStruct)
+	toNames, toFields := fields(toStruct)
+	if len(fromNames) != len(toNames) {
+		return fmt.Errorf("%s has the fields %v in %s but %v in %s", typeName, fromNames, from.path, toNames,
+			to.path)
+	}
+	var body bytes.Buffer
+	for _, field := range toNames {
+		fromField, ok := fromFields[field]
+		if !ok {
+			return fmt.Errorf("%s has no field %s in %s", typeName, field, from.path)
+		}
+		value, err := g.convert("v."+field, from, fromField, to, toFields[field])
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", typeName, field, err)
+		}
+		fmt.Fprintf(&body, "%s: %s,\n", field, value)
+	}
+	fmt.Fprintf(&g.funcs, "\nfunc %s(v %s.%s) %s.%s {\nreturn %s.%s{\n%s}\n}\n", name, from.name, typeName, to.name,
+		typeName, to.name, typeName, body.String())
+	return nil
+}
+
+// method writes the method of the adapter running a query of the engine.
+func (g *generator) method(w *bytes.Buffer, name string, shared, local *ast.FuncType) error {
+	var params, args []string
+	var localParams []*ast.Field
+	for _, field := range local.Params.List {
+		for range field.Names {
+			localParams = append(localParams, field)
+		}
+	}
+	i := 0
+	for _, field := range shared.Params.List {
+		for _, ident := range field.Names {
+			if i >= len(localParams) {
+				return fmt.Errorf("%s has more parameters in %s", name, g.shared.path)
+			}
+			params = append(params, ident.Name+" "+g.shared.qualify(field.Type))
+			arg, err := g.convert(ident.Name, g.shared, field.Type, g.local, localParams[i].Type)
+			if err != nil {
+				return fmt.Errorf("%s: parameter %s: %w", name, ident.Name, err)
+			}
+			args = append(args, arg)
+			i++
+		}
+	}
+	if i != len(localParams) {
+		return fmt.Errorf("%s has more parameters in %s", name, g.local.path)
+	}
+	if len(shared.Results.List) != len(local.Results.List) {
+		return fmt.Errorf("%s has other results in %s", name, g.local.path)
+	}
+	call := fmt.Sprintf("q.q.%s(%s)", name, strings.Join(args, ", "))
+	var results []string
+	for _, field := range shared.Results.List {
+		results = append(results, g.shared.qualify(field.Type))
+	}
+	fmt.Fprintf(w, "\nfunc (q queries) %s(%s) (%s) {\n", name, strings.Join(params, ", "), strings.Join(results, ", "))
+	if len(results) == 1 {
+		fmt.Fprintf(w, "return %s\n}\n", call)
+		return nil
+	}
+	sharedResult, localResult := shared.Results.List[0].Type, local.Results.List[0].Type
+	sharedSlice, sharedIsSlice := sharedResult.(*ast.ArrayType)
+	localSlice, localIsSlice := localResult.(*ast.ArrayType)
+	if sharedIsSlice && localIsSlice {
+		item, err := g.convert("r", g.local, localSlice.Elt, g.shared, sharedSlice.Elt)
+		if err != nil {
+			return fmt.Errorf("%s: result: %w", name, err)
+		}
+		if item == "r" {
+			fmt.Fprintf(w, "return %s\n}\n", call)
+			return nil
+		}
+		fmt.Fprintf(w, "rows, err := %s\nvar items %s\nfor _, r := range rows {\nitems = append(items, %s)\n}\n"+
+			"return items, err\n}\n", call, results[0], item)
+		return nil
+	}
+	result, err := g.convert("r", g.local, localResult, g.shared, sharedResult)
+	if err != nil {
+		return fmt.Errorf("%s: result: %w", name, err)
+	}
+	if result == "r" {
+		fmt.Fprintf(w, "return %s\n}\n", call)
+		return nil
+	}
+	fmt.Fprintf(w, "r, err := %s\nreturn %s, err\n}\n", call, result)
+	return nil
+}
+
+// generate returns the source of the adapter of a query package.
+//
+// Parameters:
+//   - sharedDir: The directory of the MySQL query package.
+//   - localDir: The directory of the query package of the engine.
+//   - engine: The name of the engine.
+//
+// Returns:
+//   - []byte: The formatted source of the adapter.
+//   - error: An error if a query is missing or its parameters and results cannot be converted.
+func generate(sharedDir, localDir, engine string) ([]byte, error) {
+	shared, err := load(sharedDir, "query")
+	if err != nil {
+		return nil, err
+	}
+	adapter := filepath.Base(mustAbs("."))
+	local, err := load(localDir, adapter+"query")
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{shared: shared, local: local, generated: map[string]bool{}}
+
+	sharedMethods, localMethods := shared.methods(), local.methods()
+	var names []string
+	for name := range sharedMethods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var methods bytes.Buffer
+	for _, name := range names {
+		localMethod, ok := localMethods[name]
+		if !ok {
+			return nil, fmt.Errorf("%s has no query %s", local.path, name)
+		}
+		if err := g.method(&methods, name, sharedMethods[name], localMethod); err != nil {
+			return nil, err
+		}
+	}
+	for name := range localMethods {
+		if _, ok := sharedMethods[name]; !ok {
+			return nil, fmt.Errorf("%s has no query %s", shared.path, name)
+		}
+	}
+
+	var src bytes.Buffer
+	body := methods.String() + g.funcs.String()
+	fmt.Fprintf(&src, "// Code generated by querygen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", adapter)
+	imports := map[string]string{}
+	for name, path := range shared.imports {
+		imports[name] = path
+	}
+	for name, path := range local.imports {
+		imports[name] = path
+	}
+	var used []string
+	for name, path := range imports {
+		if path == shared.path || path == local.path {
+			continue
+		}
+		if regexp.MustCompile(`\b` + name + `\.`).MatchString(body) {
+			used = append(used, strconv.Quote(path))
+		}
+	}
+	sort.Strings(used)
+	fmt.Fprintf(&src, "%s\n\n%q\n%s %q\n)\n", strings.Join(used, "\n"), shared.path, local.name, local.path)
+	fmt.Fprintf(&src, `
+// queries runs the %[1]s queries, converting their parameters and results from and to the types of the query
+// package.
+type queries struct {
+	q *%[2]s.Queries
+}
+`, engine, local.name)
+	src.WriteString(body)
+	return format.Source(src.Bytes())
+}
+
+// mustAbs returns the absolute path of a directory.
+func mustAbs(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return abs
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGenerate ensures that the adapters of the SQLite and PostgreSQL queries are up to date with the query packages
+// generated by sqlc, that is every query of the MySQL query package has a counterpart with convertible parameters and
+// results.
+func TestGenerate(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	for dir, engine := range map[string]string{"../../sqlite": "SQLite", "../../postgres": "PostgreSQL"} {
+		assert.NoError(t, os.Chdir(dir))
+		code, err := generate("../query", "internal/query", engine)
+		if assert.NoError(t, err, engine) {
+			generated, err := os.ReadFile("queries.go")
+			assert.NoError(t, err)
+			assert.Equal(t, string(generated), string(code), "%s/queries.go is out of date, run go generate ./pkg/db/...", dir)
+		}
+		assert.NoError(t, os.Chdir(wd))
+	}
+}
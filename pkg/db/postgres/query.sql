@@ -0,0 +1,613 @@
+-- name: AttemptCreatingUser :one
+INSERT INTO users (email, full_name, business_category, department_number)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (email) DO UPDATE SET email = EXCLUDED.email
+RETURNING user_id;
+
+-- name: GetUser :one
+SELECT *
+FROM users
+WHERE user_id = $1;
+
+-- name: GetUserWithEmail :one
+SELECT *
+FROM users
+WHERE email = $1;
+
+-- name: GetUserWithSession :one
+SELECT u.*
+FROM users u
+JOIN sessions s
+ON s.user_id = u.user_id
+WHERE s.session_token = $1;
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE user_id = $1;
+
+
+
+
+-- name: CreateSession :exec
+INSERT INTO sessions (user_id, session_token)
+VALUES ($1, $2);
+
+-- name: GetSessionWithToken :one
+SELECT *
+FROM sessions
+WHERE session_token = $1;
+
+-- name: DeleteSessionWithToken :exec
+DELETE FROM sessions WHERE session_token = $1;
+
+-- name: GetSessionsOfUser :many
+SELECT *
+FROM sessions
+WHERE user_id = $1
+ORDER BY creation_date, session_id;
+
+-- name: DeleteSessionsOfUser :exec
+DELETE FROM sessions WHERE user_id = $1;
+
+-- name: CountSessionsSince :one
+SELECT COUNT(*)
+FROM sessions
+WHERE creation_date >= $1;
+
+
+
+
+-- name: CreateEvent :one
+INSERT INTO events (name, description, event_date, parent_event_id)
+VALUES ($1, $2, $3, $4)
+RETURNING event_id;
+
+-- name: GetEvent :one
+SELECT *
+FROM events
+WHERE event_id = $1;
+
+-- name: GetEventWithNameAndParent :one
+SELECT *
+FROM events
+WHERE name = $1 AND parent_event_id IS NOT DISTINCT FROM $2
+LIMIT 1;
+
+-- name: GetEvents :many
+SELECT name, description, event_date, creation_date, parent_event_id
+FROM events
+WHERE is_hidden = false;
+
+-- name: UpdateEvent :exec
+UPDATE events
+SET name = $1, description = $2, event_date = $3, parent_event_id = $4
+WHERE event_id = $5;
+
+-- name: GetSubEvents :many
+SELECT *
+FROM events
+WHERE parent_event_id = $1
+ORDER BY event_date, event_id;
+
+-- name: UpdateEventMetadataPolicy :exec
+UPDATE events
+SET metadata_policy = $1
+WHERE event_id = $2;
+
+-- name: UpdateEventHidden :exec
+UPDATE events
+SET is_hidden = $1
+WHERE event_id = $2;
+
+-- name: UpdateEventPasswordHash :exec
+UPDATE events
+SET password_hash = $1
+WHERE event_id = $2;
+
+-- name: DeleteEvent :exec
+DELETE FROM events WHERE event_id = $1;
+
+
+
+
+-- name: CreateUnlockedEvent :exec
+INSERT INTO unlocked_events (session_id, event_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: IsEventUnlocked :one
+SELECT EXISTS(
+    SELECT 1
+    FROM unlocked_events
+    WHERE session_id = $1 AND event_id = $2
+) AS unlocked;
+
+
+
+
+-- name: CreatePhoto :one
+INSERT INTO photos (path_to_photo, file_hash, perceptual_hash, file_size, event_id, uploader_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING photo_id;
+
+-- name: GetPhoto :one
+SELECT * FROM photos WHERE photo_id = $1;
+
+-- name: GetPhotoWithMetadataPolicy :one
+SELECT p.*, e.metadata_policy
+FROM photos p
+JOIN events e
+ON e.event_id = p.event_id
+WHERE p.photo_id = $1;
+
+-- name: GetPhotoWithEventAndHash :one
+SELECT *
+FROM photos
+WHERE event_id = $1 AND file_hash = $2
+LIMIT 1;
+
+-- name: GetPhotosWithHash :many
+SELECT *
+FROM photos
+WHERE file_hash = $1
+ORDER BY photo_id;
+
+-- name: CountPhotosWithPath :one
+SELECT COUNT(*)
+FROM photos
+WHERE path_to_photo = $1;
+
+-- name: CountUnsizedPhotosWithPath :one
+SELECT COUNT(*)
+FROM photos
+WHERE path_to_photo = $1 AND file_size = 0;
+
+-- name: UpdatePhotoFileSize :execrows
+UPDATE photos
+SET file_size = $1
+WHERE photo_id = $2 AND file_size = 0;
+
+-- name: GetPerceptualHashes :many
+SELECT photo_id, perceptual_hash
+FROM photos
+WHERE perceptual_hash IS NOT NULL AND file_hash <> $1;
+
+-- name: GetPerceptualHashesInBands :many
+SELECT DISTINCT p.photo_id, p.perceptual_hash
+FROM perceptual_hash_bands b
+JOIN photos p
+ON p.photo_id = b.photo_id
+WHERE b.band_key IN ($1, $2, $3, $4, $5, $6, $7, $8) AND p.file_hash <> $9;
+
+-- name: CreatePerceptualHashBands :exec
+INSERT INTO perceptual_hash_bands (band_key, photo_id)
+SELECT bands.band * 256 + ((p.perceptual_hash >> (8 * bands.band)) & 255), p.photo_id
+FROM photos p
+CROSS JOIN (
+    SELECT 0 AS band UNION ALL SELECT 1 UNION ALL SELECT 2 UNION ALL SELECT 3
+    UNION ALL SELECT 4 UNION ALL SELECT 5 UNION ALL SELECT 6 UNION ALL SELECT 7
+) bands
+WHERE p.photo_id = $1 AND p.perceptual_hash IS NOT NULL;
+
+-- name: BackfillPerceptualHashBands :execrows
+INSERT INTO perceptual_hash_bands (band_key, photo_id)
+SELECT bands.band * 256 + ((p.perceptual_hash >> (8 * bands.band)) & 255), p.photo_id
+FROM photos p
+CROSS JOIN (
+    SELECT 0 AS band UNION ALL SELECT 1 UNION ALL SELECT 2 UNION ALL SELECT 3
+    UNION ALL SELECT 4 UNION ALL SELECT 5 UNION ALL SELECT 6 UNION ALL SELECT 7
+) bands
+WHERE p.perceptual_hash IS NOT NULL
+AND NOT EXISTS (SELECT 1 FROM perceptual_hash_bands i WHERE i.photo_id = p.photo_id);
+
+-- name: GetPhotosByEventID :many
+SELECT p.*
+FROM photos p
+LEFT JOIN photo_metadata pm
+ON pm.photo_id = p.photo_id
+WHERE p.event_id = $1
+ORDER BY COALESCE(pm.capture_date, p.creation_date), p.photo_id;
+
+-- name: GetPhotosSortedByDate :many
+SELECT p.*
+FROM photos p
+LEFT JOIN photo_metadata pm
+ON pm.photo_id = p.photo_id
+ORDER BY COALESCE(pm.capture_date, p.creation_date) DESC, p.photo_id DESC;
+
+-- name: UpdatePhotoPath :exec
+UPDATE photos
+SET path_to_photo = $1
+WHERE photo_id = $2;
+
+-- name: UpdatePhotoHidden :exec
+UPDATE photos
+SET is_hidden = $1
+WHERE photo_id = $2;
+
+-- name: DeletePhoto :exec
+DELETE FROM photos WHERE photo_id = $1;
+
+-- name: GetPhotoPaths :many
+SELECT photo_id, path_to_photo, file_size, event_id
+FROM photos
+ORDER BY photo_id;
+
+
+
+
+-- name: CreateMissingPhoto :exec
+INSERT INTO missing_photos (photo_id)
+VALUES ($1)
+ON CONFLICT DO NOTHING;
+
+-- name: GetMissingPhotoIDs :many
+SELECT photo_id
+FROM missing_photos
+ORDER BY photo_id;
+
+-- name: DeleteMissingPhoto :exec
+DELETE FROM missing_photos WHERE photo_id = $1;
+
+
+
+
+-- name: CreatePhotoMetadata :exec
+INSERT INTO photo_metadata (
+    photo_id, capture_date, camera_make, camera_model, lens_model, exposure_time, f_number,
+    iso, focal_length, orientation, width, height, latitude, longitude, altitude
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15);
+
+-- name: GetPhotoMetadata :one
+SELECT *
+FROM photo_metadata
+WHERE photo_id = $1;
+
+-- name: DeletePhotoMetadata :exec
+DELETE FROM photo_metadata WHERE photo_id = $1;
+
+
+
+
+-- name: CreateDuplicateCandidate :exec
+INSERT INTO duplicate_candidates (photo_id, duplicate_of_photo_id, distance)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: GetPendingDuplicateCandidates :many
+SELECT *
+FROM duplicate_candidates
+WHERE status = 'PENDING'
+ORDER BY creation_date, photo_id;
+
+-- name: UpdateDuplicateCandidateStatus :exec
+UPDATE duplicate_candidates
+SET status = $1
+WHERE photo_id = $2 AND duplicate_of_photo_id = $3;
+
+
+
+
+-- name: AddStorageUsage :exec
+INSERT INTO storage_usage (scope, scope_id, kind, bytes, files)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (scope, scope_id, kind) DO UPDATE SET bytes = storage_usage.bytes + EXCLUDED.bytes, files = storage_usage.files + EXCLUDED.files;
+
+-- name: ReserveStorageUsage :execrows
+UPDATE storage_usage
+SET bytes = bytes + $1, files = files + 1
+WHERE scope = $2 AND scope_id = $3 AND kind = 'ORIGINAL' AND bytes + $4 <= $5;
+
+-- name: GetStorageUsage :one
+SELECT CAST(COALESCE(SUM(bytes), 0) AS BIGINT) AS bytes
+FROM storage_usage
+WHERE scope = $1 AND scope_id = $2;
+
+-- name: GetGlobalStorageUsage :many
+SELECT kind, bytes, files
+FROM storage_usage
+WHERE scope = 'GLOBAL'
+ORDER BY kind;
+
+-- name: GetEventStorageUsage :many
+SELECT u.scope_id AS event_id, e.name, u.kind, u.bytes, u.files
+FROM storage_usage u
+JOIN events e
+ON e.event_id = u.scope_id
+WHERE u.scope = 'EVENT'
+ORDER BY u.scope_id, u.kind;
+
+-- name: GetUploaderStorageUsage :many
+SELECT u.scope_id AS user_id, us.full_name, us.email, u.bytes, u.files
+FROM storage_usage u
+JOIN users us
+ON us.user_id = u.scope_id
+WHERE u.scope = 'UPLOADER' AND u.kind = 'ORIGINAL'
+ORDER BY u.bytes DESC;
+
+
+
+
+-- name: CreateFaceGroup :one
+INSERT INTO face_groups (label)
+VALUES (NULL)
+RETURNING face_group_id;
+
+-- name: GetUnlabeledFaceGroupIDs :many
+SELECT face_group_id
+FROM face_groups
+WHERE label IS NULL
+ORDER BY face_group_id;
+
+-- name: DeleteFaceGroupIfEmpty :execrows
+DELETE FROM face_groups
+WHERE face_groups.face_group_id = $1
+AND NOT EXISTS (SELECT 1 FROM image_faces WHERE image_faces.face_group_id = face_groups.face_group_id);
+
+-- name: GetFaceGroup :one
+SELECT * FROM face_groups WHERE face_group_id = $1;
+
+-- name: UpdateFaceGroupUser :exec
+UPDATE face_groups
+SET label = $1, user_id = $2
+WHERE face_group_id = $3;
+
+-- name: DeleteFaceGroupsOfUser :exec
+DELETE FROM face_groups WHERE user_id = $1;
+
+-- name: GetFaceGroupsOfUser :many
+SELECT * FROM face_groups WHERE user_id = $1 ORDER BY face_group_id;
+
+-- name: CreateFaceGroupClaim :exec
+INSERT INTO face_group_claims (face_group_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: GetFaceGroupClaims :many
+SELECT c.user_id, u.email, u.full_name, c.claim_date
+FROM face_group_claims c
+JOIN users u
+ON u.user_id = c.user_id
+WHERE c.face_group_id = $1
+ORDER BY c.claim_date, c.user_id;
+
+-- name: GetFaceGroupClaimsOfUser :many
+SELECT * FROM face_group_claims WHERE user_id = $1 ORDER BY face_group_id;
+
+-- name: DeleteFaceGroupClaim :execrows
+DELETE FROM face_group_claims WHERE face_group_id = $1 AND user_id = $2;
+
+-- name: DeleteFaceGroupClaims :exec
+DELETE FROM face_group_claims WHERE face_group_id = $1;
+
+-- name: DeleteFaceGroupClaimsOfUser :exec
+DELETE FROM face_group_claims WHERE user_id = $1;
+
+-- name: GetFaceGroups :many
+SELECT g.face_group_id, g.label, g.user_id, COUNT(*) AS faces, CAST(MIN(f.image_face_id) AS BIGINT) AS sample_face_id,
+    (SELECT COUNT(*) FROM face_group_claims c WHERE c.face_group_id = g.face_group_id) AS claims
+FROM face_groups g
+JOIN image_faces f
+ON f.face_group_id = g.face_group_id
+GROUP BY g.face_group_id
+ORDER BY faces DESC, g.face_group_id
+LIMIT $1 OFFSET $2;
+
+
+
+
+-- name: CreateImageFace :one
+INSERT INTO image_faces (photo_id, face_group_id, model, descriptor, min_x, min_y, max_x, max_y)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING image_face_id;
+
+-- name: GetImageFace :one
+SELECT * FROM image_faces WHERE image_face_id = $1;
+
+-- name: GetImageFacesOfPhoto :many
+SELECT *
+FROM image_faces
+WHERE photo_id = $1
+ORDER BY image_face_id;
+
+-- name: GetImageFaceSamples :many
+SELECT image_face_id, face_group_id, descriptor
+FROM image_faces
+WHERE model = $1
+ORDER BY image_face_id;
+
+-- name: UpdateImageFaceGroup :exec
+UPDATE image_faces
+SET face_group_id = $1
+WHERE image_face_id = $2;
+
+-- name: DeleteImageFacesOfPhoto :exec
+DELETE FROM image_faces WHERE photo_id = $1;
+
+-- name: DeleteImageFacesOfUser :exec
+DELETE FROM image_faces
+WHERE face_group_id IN (SELECT face_group_id FROM face_groups WHERE user_id = $1);
+
+-- name: GetImageFacesOfFaceGroup :many
+SELECT *
+FROM image_faces
+WHERE face_group_id = $1
+ORDER BY image_face_id;
+
+-- name: MergeFaceGroup :exec
+UPDATE image_faces
+SET face_group_id = $1
+WHERE face_group_id = $2;
+
+-- name: GetPhotoIDsOfFaceGroup :many
+SELECT DISTINCT photo_id
+FROM image_faces
+WHERE face_group_id = $1
+ORDER BY photo_id;
+
+-- name: GetFacesOfPhoto :many
+SELECT f.image_face_id, f.face_group_id, f.min_x, f.min_y, f.max_x, f.max_y, g.label, g.user_id, c.consent, n.opt_in_date AS name_opt_in_date
+FROM image_faces f
+JOIN face_groups g
+ON g.face_group_id = f.face_group_id
+LEFT JOIN face_consents c
+ON c.user_id = g.user_id
+LEFT JOIN public_face_names n
+ON n.user_id = g.user_id
+WHERE f.photo_id = $1
+ORDER BY f.image_face_id;
+
+
+
+
+-- name: SaveFaceScan :exec
+INSERT INTO face_scans (photo_id, model, faces)
+VALUES ($1, $2, $3)
+ON CONFLICT (photo_id) DO UPDATE SET model = EXCLUDED.model, faces = EXCLUDED.faces, scan_date = CURRENT_TIMESTAMP;
+
+-- name: GetPhotosWithoutFaceScan :many
+SELECT p.photo_id
+FROM photos p
+LEFT JOIN face_scans f
+ON f.photo_id = p.photo_id
+WHERE f.photo_id IS NULL OR f.model <> $1
+ORDER BY p.photo_id;
+
+
+
+
+-- name: GetUserFoldersOfUser :many
+SELECT *
+FROM user_folders
+WHERE user_id = $1
+ORDER BY user_folder_id;
+
+-- name: DetachUserFoldersOfUser :exec
+UPDATE user_folders SET parent_folder_id = NULL WHERE user_id = $1;
+
+-- name: DeleteUserFoldersOfUser :exec
+DELETE FROM user_folders WHERE user_id = $1;
+
+
+
+
+-- name: CreateRecognizedUsersOfPhoto :exec
+INSERT INTO recognized_users (user_id, photo_id)
+SELECT DISTINCT g.user_id, f.photo_id
+FROM image_faces f
+JOIN face_groups g
+ON g.face_group_id = f.face_group_id
+JOIN face_consents c
+ON c.user_id = g.user_id
+WHERE f.photo_id = $1 AND c.consent = 'GRANTED';
+
+-- name: DeleteRecognizedUsersOfPhoto :exec
+DELETE FROM recognized_users WHERE photo_id = $1;
+
+-- name: DeleteRecognizedUsersOfUser :exec
+DELETE FROM recognized_users WHERE user_id = $1;
+
+-- name: GetRecognizedPhotos :many
+SELECT p.*
+FROM photos p
+JOIN recognized_users r
+ON r.photo_id = p.photo_id
+LEFT JOIN photo_metadata pm
+ON pm.photo_id = p.photo_id
+WHERE r.user_id = $1
+ORDER BY COALESCE(pm.capture_date, p.creation_date), p.photo_id;
+
+
+
+
+-- name: GetFaceConsent :one
+SELECT * FROM face_consents WHERE user_id = $1;
+
+-- name: SaveFaceConsent :exec
+INSERT INTO face_consents (user_id, consent)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET consent = EXCLUDED.consent;
+
+-- name: CreateFaceConsentChange :exec
+INSERT INTO face_consent_changes (user_id, consent, previous_consent, changed_by, ip)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetFaceConsentChanges :many
+SELECT *
+FROM face_consent_changes
+WHERE user_id = $1
+ORDER BY change_date, face_consent_change_id;
+
+-- name: AnonymizeFaceConsentChangesOfUser :exec
+UPDATE face_consent_changes SET ip = '' WHERE user_id = $1;
+
+-- name: CreatePublicFaceName :exec
+INSERT INTO public_face_names (user_id)
+VALUES ($1)
+ON CONFLICT DO NOTHING;
+
+-- name: GetPublicFaceName :one
+SELECT opt_in_date FROM public_face_names WHERE user_id = $1;
+
+-- name: DeletePublicFaceName :exec
+DELETE FROM public_face_names WHERE user_id = $1;
+
+
+
+
+-- name: CreateJob :exec
+INSERT INTO jobs (kind, photo_id)
+VALUES ($1, $2);
+
+-- name: CreateRecognizeJob :exec
+INSERT INTO jobs (kind)
+SELECT 'RECOGNIZE'
+WHERE NOT EXISTS (SELECT 1 FROM jobs WHERE kind = 'RECOGNIZE' AND status = 'PENDING');
+
+-- name: GetNextJob :one
+SELECT *
+FROM jobs
+WHERE (status = 'PENDING' AND run_after <= CURRENT_TIMESTAMP)
+   OR (status = 'RUNNING' AND leased_until < CURRENT_TIMESTAMP)
+ORDER BY run_after, job_id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: LeaseJob :exec
+UPDATE jobs
+SET status = 'RUNNING', attempts = attempts + 1,
+    leased_until = CURRENT_TIMESTAMP + make_interval(secs => CAST(sqlc.arg(lease_seconds) AS BIGINT))
+WHERE job_id = sqlc.arg(job_id);
+
+-- name: CompleteJob :execrows
+UPDATE jobs
+SET status = 'DONE', leased_until = NULL
+WHERE job_id = $1 AND status = 'RUNNING' AND attempts = $2;
+
+-- name: FailJob :execrows
+UPDATE jobs
+SET status = sqlc.arg(status), last_error = sqlc.arg(last_error),
+    run_after = CURRENT_TIMESTAMP + make_interval(secs => CAST(sqlc.arg(backoff_seconds) AS BIGINT)), leased_until = NULL
+WHERE job_id = sqlc.arg(job_id) AND status = 'RUNNING' AND attempts = sqlc.arg(attempts);
+
+-- name: RetryJob :execrows
+UPDATE jobs
+SET status = 'PENDING', attempts = 0, run_after = CURRENT_TIMESTAMP, leased_until = NULL
+WHERE job_id = $1 AND status IN ('DONE', 'DEAD');
+
+-- name: GetJobs :many
+SELECT *
+FROM jobs
+WHERE status = $1
+ORDER BY update_date DESC, job_id DESC
+LIMIT $2;
+
+-- name: CountJobs :many
+SELECT status, COUNT(*) AS jobs
+FROM jobs
+GROUP BY status
+ORDER BY status;
+
+-- name: DeleteDoneJobs :execrows
+DELETE FROM jobs
+WHERE status = 'DONE' AND update_date < CURRENT_TIMESTAMP - make_interval(secs => CAST(sqlc.arg(retention_seconds) AS BIGINT));
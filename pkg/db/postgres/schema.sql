@@ -0,0 +1,235 @@
+-- PostgreSQL counterpart of the schema.sql of the repository root, applied when the database is opened. Tables keep
+-- the column order of MySQL since the queries select their columns with *. Enums are checked text, ON UPDATE
+-- CURRENT_TIMESTAMP is emulated with triggers, and times are stored without time zone, in UTC like with MySQL.
+
+CREATE OR REPLACE FUNCTION set_update_date() RETURNS trigger AS $$
+BEGIN
+    IF NEW.update_date = OLD.update_date THEN
+        NEW.update_date = CURRENT_TIMESTAMP;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TABLE IF NOT EXISTS users (
+    user_id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+
+    signup_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_signin_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    signin_locked BOOLEAN NOT NULL DEFAULT false,
+    signin_locked_date TIMESTAMP,
+
+    is_admin BOOLEAN NOT NULL DEFAULT false,
+
+    email VARCHAR(255) NOT NULL UNIQUE,
+    full_name VARCHAR(255) NOT NULL,
+    business_category TEXT NOT NULL CHECK (business_category IN ('STUDENT', 'TEACHER')),
+    department_number VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    session_id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+
+    user_id INTEGER NOT NULL REFERENCES users(user_id),
+    creation_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    session_token VARCHAR(255) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS events (
+    event_id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    event_date TIMESTAMP NOT NULL,
+    creation_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    parent_event_id INTEGER REFERENCES events(event_id),
+    metadata_policy TEXT NOT NULL DEFAULT 'STRIP_GPS' CHECK (metadata_policy IN ('KEEP_ALL', 'STRIP_GPS', 'STRIP_ALL')),
+    is_hidden BOOLEAN NOT NULL DEFAULT false,
+    password_hash VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS photos (
+    photo_id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+
+    path_to_photo VARCHAR(255) NOT NULL,
+    file_hash CHAR(64) NOT NULL,
+    perceptual_hash BIGINT,
+    file_size BIGINT NOT NULL DEFAULT 0,
+    creation_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    event_id INTEGER NOT NULL REFERENCES events(event_id),
+    uploader_id INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    is_hidden BOOLEAN NOT NULL DEFAULT false
+);
+CREATE INDEX IF NOT EXISTS photos_file_hash ON photos (file_hash);
+CREATE INDEX IF NOT EXISTS photos_path_to_photo ON photos (path_to_photo);
+
+CREATE TABLE IF NOT EXISTS perceptual_hash_bands (
+    band_key INTEGER NOT NULL,
+    photo_id INTEGER NOT NULL REFERENCES photos(photo_id) ON DELETE CASCADE,
+
+    PRIMARY KEY (band_key, photo_id)
+);
+CREATE INDEX IF NOT EXISTS perceptual_hash_bands_photo_id ON perceptual_hash_bands (photo_id);
+
+CREATE TABLE IF NOT EXISTS duplicate_candidates (
+    photo_id INTEGER NOT NULL REFERENCES photos(photo_id) ON DELETE CASCADE,
+    duplicate_of_photo_id INTEGER NOT NULL REFERENCES photos(photo_id) ON DELETE CASCADE,
+
+    distance SMALLINT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'KEPT', 'HIDDEN')),
+    creation_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (photo_id, duplicate_of_photo_id)
+);
+CREATE INDEX IF NOT EXISTS duplicate_candidates_status ON duplicate_candidates (status);
+
+CREATE TABLE IF NOT EXISTS missing_photos (
+    photo_id INTEGER PRIMARY KEY REFERENCES photos(photo_id) ON DELETE CASCADE,
+
+    detection_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS storage_usage (
+    scope TEXT NOT NULL CHECK (scope IN ('GLOBAL', 'EVENT', 'UPLOADER')),
+    scope_id INTEGER NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('ORIGINAL', 'THUMBNAIL', 'PREVIEW')),
+
+    bytes BIGINT NOT NULL DEFAULT 0,
+    files INTEGER NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (scope, scope_id, kind)
+);
+
+CREATE TABLE IF NOT EXISTS photo_metadata (
+    photo_id INTEGER PRIMARY KEY REFERENCES photos(photo_id) ON DELETE CASCADE,
+
+    capture_date TIMESTAMP,
+    camera_make VARCHAR(255) NOT NULL DEFAULT '',
+    camera_model VARCHAR(255) NOT NULL DEFAULT '',
+    lens_model VARCHAR(255) NOT NULL DEFAULT '',
+    exposure_time VARCHAR(32) NOT NULL DEFAULT '',
+    f_number DOUBLE PRECISION NOT NULL DEFAULT 0,
+    iso INTEGER NOT NULL DEFAULT 0,
+    focal_length DOUBLE PRECISION NOT NULL DEFAULT 0,
+    orientation INTEGER NOT NULL DEFAULT 0,
+    width INTEGER NOT NULL DEFAULT 0,
+    height INTEGER NOT NULL DEFAULT 0,
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    altitude DOUBLE PRECISION
+);
+
+CREATE TABLE IF NOT EXISTS face_groups (
+    face_group_id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+
+    label VARCHAR(255),
+    user_id INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    creation_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS image_faces (
+    image_face_id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+
+    photo_id INTEGER NOT NULL REFERENCES photos(photo_id) ON DELETE CASCADE,
+    face_group_id INTEGER NOT NULL REFERENCES face_groups(face_group_id) ON DELETE CASCADE,
+    model VARCHAR(64) NOT NULL,
+    descriptor BYTEA NOT NULL,
+    min_x DOUBLE PRECISION NOT NULL,
+    min_y DOUBLE PRECISION NOT NULL,
+    max_x DOUBLE PRECISION NOT NULL,
+    max_y DOUBLE PRECISION NOT NULL,
+    detection_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS image_faces_model ON image_faces (model);
+
+CREATE TABLE IF NOT EXISTS face_scans (
+    photo_id INTEGER PRIMARY KEY REFERENCES photos(photo_id) ON DELETE CASCADE,
+
+    model VARCHAR(64) NOT NULL,
+    faces INTEGER NOT NULL DEFAULT 0,
+    scan_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS unlocked_events (
+    session_id INTEGER NOT NULL REFERENCES sessions(session_id) ON DELETE CASCADE,
+    event_id INTEGER NOT NULL REFERENCES events(event_id) ON DELETE CASCADE,
+
+    PRIMARY KEY (session_id, event_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_folders (
+    user_folder_id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+
+    is_sub_folder BOOLEAN DEFAULT false,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    creation_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    user_id INTEGER NOT NULL REFERENCES users(user_id),
+    parent_folder_id INTEGER REFERENCES user_folders(user_folder_id)
+);
+
+CREATE TABLE IF NOT EXISTS recognized_users (
+    recognized_user_id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    photo_id INTEGER NOT NULL REFERENCES photos(photo_id) ON DELETE CASCADE,
+
+    UNIQUE (user_id, photo_id)
+);
+
+CREATE TABLE IF NOT EXISTS face_consents (
+    user_id INTEGER PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+
+    consent TEXT NOT NULL DEFAULT 'UNKNOWN' CHECK (consent IN ('UNKNOWN', 'GRANTED', 'REFUSED')),
+    update_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE OR REPLACE TRIGGER face_consents_update_date BEFORE UPDATE ON face_consents
+FOR EACH ROW EXECUTE FUNCTION set_update_date();
+
+CREATE TABLE IF NOT EXISTS face_consent_changes (
+    face_consent_change_id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+
+    user_id INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    consent TEXT NOT NULL CHECK (consent IN ('UNKNOWN', 'GRANTED', 'REFUSED')),
+    previous_consent TEXT NOT NULL CHECK (previous_consent IN ('UNKNOWN', 'GRANTED', 'REFUSED')),
+    changed_by INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    change_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS face_consent_changes_user_id ON face_consent_changes (user_id);
+
+CREATE TABLE IF NOT EXISTS face_group_claims (
+    face_group_id INTEGER NOT NULL REFERENCES face_groups(face_group_id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+
+    claim_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (face_group_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS face_group_claims_user_id ON face_group_claims (user_id);
+
+CREATE TABLE IF NOT EXISTS public_face_names (
+    user_id INTEGER PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+
+    opt_in_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS jobs (
+    job_id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+
+    kind TEXT NOT NULL CHECK (kind IN ('DERIVATIVES', 'METADATA', 'FACES', 'RECOGNIZE')),
+    photo_id INTEGER REFERENCES photos(photo_id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'RUNNING', 'DONE', 'DEAD')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    run_after TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    leased_until TIMESTAMP,
+    creation_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS jobs_status_run_after ON jobs (status, run_after);
+CREATE OR REPLACE TRIGGER jobs_update_date BEFORE UPDATE ON jobs
+FOR EACH ROW EXECUTE FUNCTION set_update_date();
@@ -11,6 +11,395 @@ import (
 	"time"
 )
 
+type DuplicateCandidatesStatus string
+
+const (
+	DuplicateCandidatesStatusPENDING DuplicateCandidatesStatus = "PENDING"
+	DuplicateCandidatesStatusKEPT    DuplicateCandidatesStatus = "KEPT"
+	DuplicateCandidatesStatusHIDDEN  DuplicateCandidatesStatus = "HIDDEN"
+)
+
+func (e *DuplicateCandidatesStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = DuplicateCandidatesStatus(s)
+	case string:
+		*e = DuplicateCandidatesStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for DuplicateCandidatesStatus: %T", src)
+	}
+	return nil
+}
+
+type NullDuplicateCandidatesStatus struct {
+	DuplicateCandidatesStatus DuplicateCandidatesStatus
+	Valid                     bool // Valid is true if DuplicateCandidatesStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullDuplicateCandidatesStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.DuplicateCandidatesStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.DuplicateCandidatesStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullDuplicateCandidatesStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.DuplicateCandidatesStatus), nil
+}
+
+type EventsMetadataPolicy string
+
+const (
+	EventsMetadataPolicyKEEPALL  EventsMetadataPolicy = "KEEP_ALL"
+	EventsMetadataPolicySTRIPGPS EventsMetadataPolicy = "STRIP_GPS"
+	EventsMetadataPolicySTRIPALL EventsMetadataPolicy = "STRIP_ALL"
+)
+
+func (e *EventsMetadataPolicy) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = EventsMetadataPolicy(s)
+	case string:
+		*e = EventsMetadataPolicy(s)
+	default:
+		return fmt.Errorf("unsupported scan type for EventsMetadataPolicy: %T", src)
+	}
+	return nil
+}
+
+type NullEventsMetadataPolicy struct {
+	EventsMetadataPolicy EventsMetadataPolicy
+	Valid                bool // Valid is true if EventsMetadataPolicy is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullEventsMetadataPolicy) Scan(value interface{}) error {
+	if value == nil {
+		ns.EventsMetadataPolicy, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.EventsMetadataPolicy.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullEventsMetadataPolicy) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.EventsMetadataPolicy), nil
+}
+
+type FaceConsentChangesConsent string
+
+const (
+	FaceConsentChangesConsentUNKNOWN FaceConsentChangesConsent = "UNKNOWN"
+	FaceConsentChangesConsentGRANTED FaceConsentChangesConsent = "GRANTED"
+	FaceConsentChangesConsentREFUSED FaceConsentChangesConsent = "REFUSED"
+)
+
+func (e *FaceConsentChangesConsent) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = FaceConsentChangesConsent(s)
+	case string:
+		*e = FaceConsentChangesConsent(s)
+	default:
+		return fmt.Errorf("unsupported scan type for FaceConsentChangesConsent: %T", src)
+	}
+	return nil
+}
+
+type NullFaceConsentChangesConsent struct {
+	FaceConsentChangesConsent FaceConsentChangesConsent
+	Valid                     bool // Valid is true if FaceConsentChangesConsent is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullFaceConsentChangesConsent) Scan(value interface{}) error {
+	if value == nil {
+		ns.FaceConsentChangesConsent, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.FaceConsentChangesConsent.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullFaceConsentChangesConsent) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.FaceConsentChangesConsent), nil
+}
+
+type FaceConsentChangesPreviousConsent string
+
+const (
+	FaceConsentChangesPreviousConsentUNKNOWN FaceConsentChangesPreviousConsent = "UNKNOWN"
+	FaceConsentChangesPreviousConsentGRANTED FaceConsentChangesPreviousConsent = "GRANTED"
+	FaceConsentChangesPreviousConsentREFUSED FaceConsentChangesPreviousConsent = "REFUSED"
+)
+
+func (e *FaceConsentChangesPreviousConsent) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = FaceConsentChangesPreviousConsent(s)
+	case string:
+		*e = FaceConsentChangesPreviousConsent(s)
+	default:
+		return fmt.Errorf("unsupported scan type for FaceConsentChangesPreviousConsent: %T", src)
+	}
+	return nil
+}
+
+type NullFaceConsentChangesPreviousConsent struct {
+	FaceConsentChangesPreviousConsent FaceConsentChangesPreviousConsent
+	Valid                             bool // Valid is true if FaceConsentChangesPreviousConsent is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullFaceConsentChangesPreviousConsent) Scan(value interface{}) error {
+	if value == nil {
+		ns.FaceConsentChangesPreviousConsent, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.FaceConsentChangesPreviousConsent.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullFaceConsentChangesPreviousConsent) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.FaceConsentChangesPreviousConsent), nil
+}
+
+type FaceConsentsConsent string
+
+const (
+	FaceConsentsConsentUNKNOWN FaceConsentsConsent = "UNKNOWN"
+	FaceConsentsConsentGRANTED FaceConsentsConsent = "GRANTED"
+	FaceConsentsConsentREFUSED FaceConsentsConsent = "REFUSED"
+)
+
+func (e *FaceConsentsConsent) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = FaceConsentsConsent(s)
+	case string:
+		*e = FaceConsentsConsent(s)
+	default:
+		return fmt.Errorf("unsupported scan type for FaceConsentsConsent: %T", src)
+	}
+	return nil
+}
+
+type NullFaceConsentsConsent struct {
+	FaceConsentsConsent FaceConsentsConsent
+	Valid               bool // Valid is true if FaceConsentsConsent is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullFaceConsentsConsent) Scan(value interface{}) error {
+	if value == nil {
+		ns.FaceConsentsConsent, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.FaceConsentsConsent.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullFaceConsentsConsent) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.FaceConsentsConsent), nil
+}
+
+type JobsKind string
+
+const (
+	JobsKindDERIVATIVES JobsKind = "DERIVATIVES"
+	JobsKindMETADATA    JobsKind = "METADATA"
+	JobsKindFACES       JobsKind = "FACES"
+	JobsKindRECOGNIZE   JobsKind = "RECOGNIZE"
+)
+
+func (e *JobsKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = JobsKind(s)
+	case string:
+		*e = JobsKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for JobsKind: %T", src)
+	}
+	return nil
+}
+
+type NullJobsKind struct {
+	JobsKind JobsKind
+	Valid    bool // Valid is true if JobsKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullJobsKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.JobsKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.JobsKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullJobsKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.JobsKind), nil
+}
+
+type JobsStatus string
+
+const (
+	JobsStatusPENDING JobsStatus = "PENDING"
+	JobsStatusRUNNING JobsStatus = "RUNNING"
+	JobsStatusDONE    JobsStatus = "DONE"
+	JobsStatusDEAD    JobsStatus = "DEAD"
+)
+
+func (e *JobsStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = JobsStatus(s)
+	case string:
+		*e = JobsStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for JobsStatus: %T", src)
+	}
+	return nil
+}
+
+type NullJobsStatus struct {
+	JobsStatus JobsStatus
+	Valid      bool // Valid is true if JobsStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullJobsStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.JobsStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.JobsStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullJobsStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.JobsStatus), nil
+}
+
+type StorageUsageKind string
+
+const (
+	StorageUsageKindORIGINAL  StorageUsageKind = "ORIGINAL"
+	StorageUsageKindTHUMBNAIL StorageUsageKind = "THUMBNAIL"
+	StorageUsageKindPREVIEW   StorageUsageKind = "PREVIEW"
+)
+
+func (e *StorageUsageKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = StorageUsageKind(s)
+	case string:
+		*e = StorageUsageKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for StorageUsageKind: %T", src)
+	}
+	return nil
+}
+
+type NullStorageUsageKind struct {
+	StorageUsageKind StorageUsageKind
+	Valid            bool // Valid is true if StorageUsageKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullStorageUsageKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.StorageUsageKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.StorageUsageKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullStorageUsageKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.StorageUsageKind), nil
+}
+
+type StorageUsageScope string
+
+const (
+	StorageUsageScopeGLOBAL   StorageUsageScope = "GLOBAL"
+	StorageUsageScopeEVENT    StorageUsageScope = "EVENT"
+	StorageUsageScopeUPLOADER StorageUsageScope = "UPLOADER"
+)
+
+func (e *StorageUsageScope) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = StorageUsageScope(s)
+	case string:
+		*e = StorageUsageScope(s)
+	default:
+		return fmt.Errorf("unsupported scan type for StorageUsageScope: %T", src)
+	}
+	return nil
+}
+
+type NullStorageUsageScope struct {
+	StorageUsageScope StorageUsageScope
+	Valid             bool // Valid is true if StorageUsageScope is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullStorageUsageScope) Scan(value interface{}) error {
+	if value == nil {
+		ns.StorageUsageScope, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.StorageUsageScope.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullStorageUsageScope) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.StorageUsageScope), nil
+}
+
 type UsersBusinessCategory string
 
 const (
@@ -53,20 +442,131 @@ func (ns NullUsersBusinessCategory) Value() (driver.Value, error) {
 	return string(ns.UsersBusinessCategory), nil
 }
 
+type DuplicateCandidate struct {
+	PhotoID            uint32
+	DuplicateOfPhotoID uint32
+	Distance           uint8
+	Status             DuplicateCandidatesStatus
+	CreationDate       time.Time
+}
+
 type Event struct {
-	EventID       uint32
-	Name          string
-	Description   string
-	EventDate     time.Time
-	CreationDate  sql.NullTime
-	ParentEventID sql.NullInt32
+	EventID        uint32
+	Name           string
+	Description    string
+	EventDate      time.Time
+	CreationDate   sql.NullTime
+	ParentEventID  sql.NullInt32
+	MetadataPolicy EventsMetadataPolicy
+	IsHidden       bool
+	PasswordHash   string
+}
+
+type FaceConsent struct {
+	UserID     uint32
+	Consent    FaceConsentsConsent
+	UpdateDate time.Time
+}
+
+type FaceConsentChange struct {
+	FaceConsentChangeID uint32
+	UserID              sql.NullInt32
+	Consent             FaceConsentChangesConsent
+	PreviousConsent     FaceConsentChangesPreviousConsent
+	ChangedBy           sql.NullInt32
+	Ip                  string
+	ChangeDate          time.Time
+}
+
+type FaceGroup struct {
+	FaceGroupID  uint32
+	Label        sql.NullString
+	UserID       sql.NullInt32
+	CreationDate time.Time
+}
+
+type FaceGroupClaim struct {
+	FaceGroupID uint32
+	UserID      uint32
+	ClaimDate   time.Time
+}
+
+type FaceScan struct {
+	PhotoID  uint32
+	Model    string
+	Faces    uint32
+	ScanDate time.Time
+}
+
+type ImageFace struct {
+	ImageFaceID   uint32
+	PhotoID       uint32
+	FaceGroupID   uint32
+	Model         string
+	Descriptor    []byte
+	MinX          float64
+	MinY          float64
+	MaxX          float64
+	MaxY          float64
+	DetectionDate time.Time
+}
+
+type Job struct {
+	JobID        uint32
+	Kind         JobsKind
+	PhotoID      sql.NullInt32
+	Status       JobsStatus
+	Attempts     uint32
+	LastError    sql.NullString
+	RunAfter     time.Time
+	LeasedUntil  sql.NullTime
+	CreationDate time.Time
+	UpdateDate   time.Time
+}
+
+type MissingPhoto struct {
+	PhotoID       uint32
+	DetectionDate time.Time
+}
+
+type PerceptualHashBand struct {
+	BandKey uint16
+	PhotoID uint32
 }
 
 type Photo struct {
+	PhotoID        uint32
+	PathToPhoto    string
+	FileHash       string
+	PerceptualHash sql.NullInt64
+	FileSize       int64
+	CreationDate   sql.NullTime
+	EventID        uint32
+	UploaderID     sql.NullInt32
+	IsHidden       bool
+}
+
+type PhotoMetadatum struct {
 	PhotoID      uint32
-	PathToPhoto  string
-	CreationDate sql.NullTime
-	EventID      uint32
+	CaptureDate  sql.NullTime
+	CameraMake   string
+	CameraModel  string
+	LensModel    string
+	ExposureTime string
+	FNumber      float64
+	Iso          uint32
+	FocalLength  float64
+	Orientation  uint16
+	Width        uint32
+	Height       uint32
+	Latitude     sql.NullFloat64
+	Longitude    sql.NullFloat64
+	Altitude     sql.NullFloat64
+}
+
+type PublicFaceName struct {
+	UserID    uint32
+	OptInDate time.Time
 }
 
 type RecognizedUser struct {
@@ -82,6 +582,19 @@ type Session struct {
 	SessionToken string
 }
 
+type StorageUsage struct {
+	Scope   StorageUsageScope
+	ScopeID uint32
+	Kind    StorageUsageKind
+	Bytes   int64
+	Files   int32
+}
+
+type UnlockedEvent struct {
+	SessionID uint32
+	EventID   uint32
+}
+
 type User struct {
 	UserID           uint32
 	SignupDate       time.Time
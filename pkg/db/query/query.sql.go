@@ -11,7 +11,41 @@ import (
 	"time"
 )
 
-const attemptCreatingUser = `-- name: AttemptCreatingUser :exec
+const addStorageUsage = `-- name: AddStorageUsage :exec
+INSERT INTO storage_usage (scope, scope_id, kind, bytes, files)
+VALUES (?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE bytes = bytes + VALUES(bytes), files = files + VALUES(files)
+`
+
+type AddStorageUsageParams struct {
+	Scope   StorageUsageScope
+	ScopeID uint32
+	Kind    StorageUsageKind
+	Bytes   int64
+	Files   int32
+}
+
+func (q *Queries) AddStorageUsage(ctx context.Context, arg AddStorageUsageParams) error {
+	_, err := q.db.ExecContext(ctx, addStorageUsage,
+		arg.Scope,
+		arg.ScopeID,
+		arg.Kind,
+		arg.Bytes,
+		arg.Files,
+	)
+	return err
+}
+
+const anonymizeFaceConsentChangesOfUser = `-- name: AnonymizeFaceConsentChangesOfUser :exec
+UPDATE face_consent_changes SET ip = '' WHERE user_id = ?
+`
+
+func (q *Queries) AnonymizeFaceConsentChangesOfUser(ctx context.Context, userID sql.NullInt32) error {
+	_, err := q.db.ExecContext(ctx, anonymizeFaceConsentChangesOfUser, userID)
+	return err
+}
+
+const attemptCreatingUser = `-- name: AttemptCreatingUser :execlastid
 INSERT INTO users (email, full_name, business_category, department_number)
 VALUES (?, ?, ?, ?)
 ON DUPLICATE KEY UPDATE user_id = LAST_INSERT_ID(user_id)
@@ -24,17 +58,149 @@ type AttemptCreatingUserParams struct {
 	DepartmentNumber string
 }
 
-func (q *Queries) AttemptCreatingUser(ctx context.Context, arg AttemptCreatingUserParams) error {
-	_, err := q.db.ExecContext(ctx, attemptCreatingUser,
+func (q *Queries) AttemptCreatingUser(ctx context.Context, arg AttemptCreatingUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, attemptCreatingUser,
 		arg.Email,
 		arg.FullName,
 		arg.BusinessCategory,
 		arg.DepartmentNumber,
 	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+const backfillPerceptualHashBands = `-- name: BackfillPerceptualHashBands :execrows
+INSERT INTO perceptual_hash_bands (band_key, photo_id)
+SELECT bands.band * 256 + ((p.perceptual_hash >> (8 * bands.band)) & 255), p.photo_id
+FROM photos p
+CROSS JOIN (
+    SELECT 0 AS band UNION ALL SELECT 1 UNION ALL SELECT 2 UNION ALL SELECT 3
+    UNION ALL SELECT 4 UNION ALL SELECT 5 UNION ALL SELECT 6 UNION ALL SELECT 7
+) bands
+WHERE p.perceptual_hash IS NOT NULL
+AND NOT EXISTS (SELECT 1 FROM perceptual_hash_bands i WHERE i.photo_id = p.photo_id)
+`
+
+func (q *Queries) BackfillPerceptualHashBands(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, backfillPerceptualHashBands)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const completeJob = `-- name: CompleteJob :execrows
+UPDATE jobs
+SET status = 'DONE', leased_until = NULL
+WHERE job_id = ? AND status = 'RUNNING' AND attempts = ?
+`
+
+type CompleteJobParams struct {
+	JobID    uint32
+	Attempts uint32
+}
+
+func (q *Queries) CompleteJob(ctx context.Context, arg CompleteJobParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, completeJob, arg.JobID, arg.Attempts)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countJobs = `-- name: CountJobs :many
+SELECT status, COUNT(*) AS jobs
+FROM jobs
+GROUP BY status
+ORDER BY status
+`
+
+type CountJobsRow struct {
+	Status JobsStatus
+	Jobs   int64
+}
+
+func (q *Queries) CountJobs(ctx context.Context) ([]CountJobsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountJobsRow
+	for rows.Next() {
+		var i CountJobsRow
+		if err := rows.Scan(&i.Status, &i.Jobs); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countPhotosWithPath = `-- name: CountPhotosWithPath :one
+SELECT COUNT(*)
+FROM photos
+WHERE path_to_photo = ?
+`
+
+func (q *Queries) CountPhotosWithPath(ctx context.Context, pathToPhoto string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPhotosWithPath, pathToPhoto)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSessionsSince = `-- name: CountSessionsSince :one
+SELECT COUNT(*)
+FROM sessions
+WHERE creation_date >= ?
+`
+
+func (q *Queries) CountSessionsSince(ctx context.Context, creationDate time.Time) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSessionsSince, creationDate)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUnsizedPhotosWithPath = `-- name: CountUnsizedPhotosWithPath :one
+SELECT COUNT(*)
+FROM photos
+WHERE path_to_photo = ? AND file_size = 0
+`
+
+func (q *Queries) CountUnsizedPhotosWithPath(ctx context.Context, pathToPhoto string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnsizedPhotosWithPath, pathToPhoto)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createDuplicateCandidate = `-- name: CreateDuplicateCandidate :exec
+INSERT IGNORE INTO duplicate_candidates (photo_id, duplicate_of_photo_id, distance)
+VALUES (?, ?, ?)
+`
+
+type CreateDuplicateCandidateParams struct {
+	PhotoID            uint32
+	DuplicateOfPhotoID uint32
+	Distance           uint8
+}
+
+func (q *Queries) CreateDuplicateCandidate(ctx context.Context, arg CreateDuplicateCandidateParams) error {
+	_, err := q.db.ExecContext(ctx, createDuplicateCandidate, arg.PhotoID, arg.DuplicateOfPhotoID, arg.Distance)
 	return err
 }
 
-const createEvent = `-- name: CreateEvent :exec
+const createEvent = `-- name: CreateEvent :execlastid
 INSERT INTO events (name, description, event_date, parent_event_id)
 VALUES (?, ?, ?, ?)
 `
@@ -46,28 +212,256 @@ type CreateEventParams struct {
 	ParentEventID sql.NullInt32
 }
 
-func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) error {
-	_, err := q.db.ExecContext(ctx, createEvent,
+func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createEvent,
 		arg.Name,
 		arg.Description,
 		arg.EventDate,
 		arg.ParentEventID,
 	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+const createFaceConsentChange = `-- name: CreateFaceConsentChange :exec
+INSERT INTO face_consent_changes (user_id, consent, previous_consent, changed_by, ip)
+VALUES (?, ?, ?, ?, ?)
+`
+
+type CreateFaceConsentChangeParams struct {
+	UserID          sql.NullInt32
+	Consent         FaceConsentChangesConsent
+	PreviousConsent FaceConsentChangesPreviousConsent
+	ChangedBy       sql.NullInt32
+	Ip              string
+}
+
+func (q *Queries) CreateFaceConsentChange(ctx context.Context, arg CreateFaceConsentChangeParams) error {
+	_, err := q.db.ExecContext(ctx, createFaceConsentChange,
+		arg.UserID,
+		arg.Consent,
+		arg.PreviousConsent,
+		arg.ChangedBy,
+		arg.Ip,
+	)
+	return err
+}
+
+const createFaceGroup = `-- name: CreateFaceGroup :execlastid
+INSERT INTO face_groups (label)
+VALUES (NULL)
+`
+
+func (q *Queries) CreateFaceGroup(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createFaceGroup)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+const createFaceGroupClaim = `-- name: CreateFaceGroupClaim :exec
+INSERT IGNORE INTO face_group_claims (face_group_id, user_id)
+VALUES (?, ?)
+`
+
+type CreateFaceGroupClaimParams struct {
+	FaceGroupID uint32
+	UserID      uint32
+}
+
+func (q *Queries) CreateFaceGroupClaim(ctx context.Context, arg CreateFaceGroupClaimParams) error {
+	_, err := q.db.ExecContext(ctx, createFaceGroupClaim, arg.FaceGroupID, arg.UserID)
 	return err
 }
 
-const createPhoto = `-- name: CreatePhoto :exec
-INSERT INTO photos (path_to_photo, event_id)
+const createImageFace = `-- name: CreateImageFace :execlastid
+INSERT INTO image_faces (photo_id, face_group_id, model, descriptor, min_x, min_y, max_x, max_y)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateImageFaceParams struct {
+	PhotoID     uint32
+	FaceGroupID uint32
+	Model       string
+	Descriptor  []byte
+	MinX        float64
+	MinY        float64
+	MaxX        float64
+	MaxY        float64
+}
+
+func (q *Queries) CreateImageFace(ctx context.Context, arg CreateImageFaceParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createImageFace,
+		arg.PhotoID,
+		arg.FaceGroupID,
+		arg.Model,
+		arg.Descriptor,
+		arg.MinX,
+		arg.MinY,
+		arg.MaxX,
+		arg.MaxY,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+const createJob = `-- name: CreateJob :exec
+INSERT INTO jobs (kind, photo_id)
 VALUES (?, ?)
 `
 
+type CreateJobParams struct {
+	Kind    JobsKind
+	PhotoID sql.NullInt32
+}
+
+func (q *Queries) CreateJob(ctx context.Context, arg CreateJobParams) error {
+	_, err := q.db.ExecContext(ctx, createJob, arg.Kind, arg.PhotoID)
+	return err
+}
+
+const createMissingPhoto = `-- name: CreateMissingPhoto :exec
+INSERT IGNORE INTO missing_photos (photo_id)
+VALUES (?)
+`
+
+func (q *Queries) CreateMissingPhoto(ctx context.Context, photoID uint32) error {
+	_, err := q.db.ExecContext(ctx, createMissingPhoto, photoID)
+	return err
+}
+
+const createPerceptualHashBands = `-- name: CreatePerceptualHashBands :exec
+INSERT INTO perceptual_hash_bands (band_key, photo_id)
+SELECT bands.band * 256 + ((p.perceptual_hash >> (8 * bands.band)) & 255), p.photo_id
+FROM photos p
+CROSS JOIN (
+    SELECT 0 AS band UNION ALL SELECT 1 UNION ALL SELECT 2 UNION ALL SELECT 3
+    UNION ALL SELECT 4 UNION ALL SELECT 5 UNION ALL SELECT 6 UNION ALL SELECT 7
+) bands
+WHERE p.photo_id = ? AND p.perceptual_hash IS NOT NULL
+`
+
+func (q *Queries) CreatePerceptualHashBands(ctx context.Context, photoID uint32) error {
+	_, err := q.db.ExecContext(ctx, createPerceptualHashBands, photoID)
+	return err
+}
+
+const createPhoto = `-- name: CreatePhoto :execlastid
+INSERT INTO photos (path_to_photo, file_hash, perceptual_hash, file_size, event_id, uploader_id)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
 type CreatePhotoParams struct {
-	PathToPhoto string
-	EventID     uint32
+	PathToPhoto    string
+	FileHash       string
+	PerceptualHash sql.NullInt64
+	FileSize       int64
+	EventID        uint32
+	UploaderID     sql.NullInt32
+}
+
+func (q *Queries) CreatePhoto(ctx context.Context, arg CreatePhotoParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createPhoto,
+		arg.PathToPhoto,
+		arg.FileHash,
+		arg.PerceptualHash,
+		arg.FileSize,
+		arg.EventID,
+		arg.UploaderID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
 }
 
-func (q *Queries) CreatePhoto(ctx context.Context, arg CreatePhotoParams) error {
-	_, err := q.db.ExecContext(ctx, createPhoto, arg.PathToPhoto, arg.EventID)
+const createPhotoMetadata = `-- name: CreatePhotoMetadata :exec
+INSERT INTO photo_metadata (
+    photo_id, capture_date, camera_make, camera_model, lens_model, exposure_time, f_number,
+    iso, focal_length, orientation, width, height, latitude, longitude, altitude
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreatePhotoMetadataParams struct {
+	PhotoID      uint32
+	CaptureDate  sql.NullTime
+	CameraMake   string
+	CameraModel  string
+	LensModel    string
+	ExposureTime string
+	FNumber      float64
+	Iso          uint32
+	FocalLength  float64
+	Orientation  uint16
+	Width        uint32
+	Height       uint32
+	Latitude     sql.NullFloat64
+	Longitude    sql.NullFloat64
+	Altitude     sql.NullFloat64
+}
+
+func (q *Queries) CreatePhotoMetadata(ctx context.Context, arg CreatePhotoMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, createPhotoMetadata,
+		arg.PhotoID,
+		arg.CaptureDate,
+		arg.CameraMake,
+		arg.CameraModel,
+		arg.LensModel,
+		arg.ExposureTime,
+		arg.FNumber,
+		arg.Iso,
+		arg.FocalLength,
+		arg.Orientation,
+		arg.Width,
+		arg.Height,
+		arg.Latitude,
+		arg.Longitude,
+		arg.Altitude,
+	)
+	return err
+}
+
+const createPublicFaceName = `-- name: CreatePublicFaceName :exec
+INSERT IGNORE INTO public_face_names (user_id)
+VALUES (?)
+`
+
+func (q *Queries) CreatePublicFaceName(ctx context.Context, userID uint32) error {
+	_, err := q.db.ExecContext(ctx, createPublicFaceName, userID)
+	return err
+}
+
+const createRecognizeJob = `-- name: CreateRecognizeJob :exec
+INSERT INTO jobs (kind)
+SELECT 'RECOGNIZE'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM jobs WHERE kind = 'RECOGNIZE' AND status = 'PENDING')
+`
+
+func (q *Queries) CreateRecognizeJob(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, createRecognizeJob)
+	return err
+}
+
+const createRecognizedUsersOfPhoto = `-- name: CreateRecognizedUsersOfPhoto :exec
+INSERT INTO recognized_users (user_id, photo_id)
+SELECT DISTINCT g.user_id, f.photo_id
+FROM image_faces f
+JOIN face_groups g
+ON g.face_group_id = f.face_group_id
+JOIN face_consents c
+ON c.user_id = g.user_id
+WHERE f.photo_id = ? AND c.consent = 'GRANTED'
+`
+
+func (q *Queries) CreateRecognizedUsersOfPhoto(ctx context.Context, photoID uint32) error {
+	_, err := q.db.ExecContext(ctx, createRecognizedUsersOfPhoto, photoID)
 	return err
 }
 
@@ -86,6 +480,34 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) er
 	return err
 }
 
+const createUnlockedEvent = `-- name: CreateUnlockedEvent :exec
+INSERT IGNORE INTO unlocked_events (session_id, event_id)
+VALUES (?, ?)
+`
+
+type CreateUnlockedEventParams struct {
+	SessionID uint32
+	EventID   uint32
+}
+
+func (q *Queries) CreateUnlockedEvent(ctx context.Context, arg CreateUnlockedEventParams) error {
+	_, err := q.db.ExecContext(ctx, createUnlockedEvent, arg.SessionID, arg.EventID)
+	return err
+}
+
+const deleteDoneJobs = `-- name: DeleteDoneJobs :execrows
+DELETE FROM jobs
+WHERE status = 'DONE' AND update_date < CURRENT_TIMESTAMP - INTERVAL CAST(? AS SIGNED) SECOND
+`
+
+func (q *Queries) DeleteDoneJobs(ctx context.Context, retentionSeconds int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteDoneJobs, retentionSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteEvent = `-- name: DeleteEvent :exec
 DELETE FROM events WHERE event_id = ?
 `
@@ -95,52 +517,1539 @@ func (q *Queries) DeleteEvent(ctx context.Context, eventID uint32) error {
 	return err
 }
 
+const deleteFaceGroupClaim = `-- name: DeleteFaceGroupClaim :execrows
+DELETE FROM face_group_claims WHERE face_group_id = ? AND user_id = ?
+`
+
+type DeleteFaceGroupClaimParams struct {
+	FaceGroupID uint32
+	UserID      uint32
+}
+
+func (q *Queries) DeleteFaceGroupClaim(ctx context.Context, arg DeleteFaceGroupClaimParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFaceGroupClaim, arg.FaceGroupID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteFaceGroupClaims = `-- name: DeleteFaceGroupClaims :exec
+DELETE FROM face_group_claims WHERE face_group_id = ?
+`
+
+func (q *Queries) DeleteFaceGroupClaims(ctx context.Context, faceGroupID uint32) error {
+	_, err := q.db.ExecContext(ctx, deleteFaceGroupClaims, faceGroupID)
+	return err
+}
+
+const deleteFaceGroupClaimsOfUser = `-- name: DeleteFaceGroupClaimsOfUser :exec
+DELETE FROM face_group_claims WHERE user_id = ?
+`
+
+func (q *Queries) DeleteFaceGroupClaimsOfUser(ctx context.Context, userID uint32) error {
+	_, err := q.db.ExecContext(ctx, deleteFaceGroupClaimsOfUser, userID)
+	return err
+}
+
+const deleteFaceGroupIfEmpty = `-- name: DeleteFaceGroupIfEmpty :execrows
+DELETE FROM face_groups
+WHERE face_groups.face_group_id = ?
+AND NOT EXISTS (SELECT 1 FROM image_faces WHERE image_faces.face_group_id = face_groups.face_group_id)
+`
+
+func (q *Queries) DeleteFaceGroupIfEmpty(ctx context.Context, faceGroupID uint32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFaceGroupIfEmpty, faceGroupID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteFaceGroupsOfUser = `-- name: DeleteFaceGroupsOfUser :exec
+DELETE FROM face_groups WHERE user_id = ?
+`
+
+func (q *Queries) DeleteFaceGroupsOfUser(ctx context.Context, userID sql.NullInt32) error {
+	_, err := q.db.ExecContext(ctx, deleteFaceGroupsOfUser, userID)
+	return err
+}
+
+const deleteImageFacesOfPhoto = `-- name: DeleteImageFacesOfPhoto :exec
+DELETE FROM image_faces WHERE photo_id = ?
+`
+
+func (q *Queries) DeleteImageFacesOfPhoto(ctx context.Context, photoID uint32) error {
+	_, err := q.db.ExecContext(ctx, deleteImageFacesOfPhoto, photoID)
+	return err
+}
+
+const deleteImageFacesOfUser = `-- name: DeleteImageFacesOfUser :exec
+DELETE FROM image_faces
+WHERE face_group_id IN (SELECT face_group_id FROM face_groups WHERE user_id = ?)
+`
+
+func (q *Queries) DeleteImageFacesOfUser(ctx context.Context, userID sql.NullInt32) error {
+	_, err := q.db.ExecContext(ctx, deleteImageFacesOfUser, userID)
+	return err
+}
+
+const deleteMissingPhoto = `-- name: DeleteMissingPhoto :exec
+DELETE FROM missing_photos WHERE photo_id = ?
+`
+
+func (q *Queries) DeleteMissingPhoto(ctx context.Context, photoID uint32) error {
+	_, err := q.db.ExecContext(ctx, deleteMissingPhoto, photoID)
+	return err
+}
+
 const deletePhoto = `-- name: DeletePhoto :exec
 DELETE FROM photos WHERE photo_id = ?
 `
 
-func (q *Queries) DeletePhoto(ctx context.Context, photoID uint32) error {
-	_, err := q.db.ExecContext(ctx, deletePhoto, photoID)
-	return err
+func (q *Queries) DeletePhoto(ctx context.Context, photoID uint32) error {
+	_, err := q.db.ExecContext(ctx, deletePhoto, photoID)
+	return err
+}
+
+const deletePhotoMetadata = `-- name: DeletePhotoMetadata :exec
+DELETE FROM photo_metadata WHERE photo_id = ?
+`
+
+func (q *Queries) DeletePhotoMetadata(ctx context.Context, photoID uint32) error {
+	_, err := q.db.ExecContext(ctx, deletePhotoMetadata, photoID)
+	return err
+}
+
+const deletePublicFaceName = `-- name: DeletePublicFaceName :exec
+DELETE FROM public_face_names WHERE user_id = ?
+`
+
+func (q *Queries) DeletePublicFaceName(ctx context.Context, userID uint32) error {
+	_, err := q.db.ExecContext(ctx, deletePublicFaceName, userID)
+	return err
+}
+
+const deleteRecognizedUsersOfPhoto = `-- name: DeleteRecognizedUsersOfPhoto :exec
+DELETE FROM recognized_users WHERE photo_id = ?
+`
+
+func (q *Queries) DeleteRecognizedUsersOfPhoto(ctx context.Context, photoID uint32) error {
+	_, err := q.db.ExecContext(ctx, deleteRecognizedUsersOfPhoto, photoID)
+	return err
+}
+
+const deleteRecognizedUsersOfUser = `-- name: DeleteRecognizedUsersOfUser :exec
+DELETE FROM recognized_users WHERE user_id = ?
+`
+
+func (q *Queries) DeleteRecognizedUsersOfUser(ctx context.Context, userID uint32) error {
+	_, err := q.db.ExecContext(ctx, deleteRecognizedUsersOfUser, userID)
+	return err
+}
+
+const deleteSessionWithToken = `-- name: DeleteSessionWithToken :exec
+DELETE FROM sessions WHERE session_token = ?
+`
+
+func (q *Queries) DeleteSessionWithToken(ctx context.Context, sessionToken string) error {
+	_, err := q.db.ExecContext(ctx, deleteSessionWithToken, sessionToken)
+	return err
+}
+
+const deleteSessionsOfUser = `-- name: DeleteSessionsOfUser :exec
+DELETE FROM sessions WHERE user_id = ?
+`
+
+func (q *Queries) DeleteSessionsOfUser(ctx context.Context, userID uint32) error {
+	_, err := q.db.ExecContext(ctx, deleteSessionsOfUser, userID)
+	return err
+}
+
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, userID uint32) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, userID)
+	return err
+}
+
+const deleteUserFoldersOfUser = `-- name: DeleteUserFoldersOfUser :exec
+DELETE FROM user_folders WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserFoldersOfUser(ctx context.Context, userID uint32) error {
+	_, err := q.db.ExecContext(ctx, deleteUserFoldersOfUser, userID)
+	return err
+}
+
+const detachUserFoldersOfUser = `-- name: DetachUserFoldersOfUser :exec
+UPDATE user_folders SET parent_folder_id = NULL WHERE user_id = ?
+`
+
+func (q *Queries) DetachUserFoldersOfUser(ctx context.Context, userID uint32) error {
+	_, err := q.db.ExecContext(ctx, detachUserFoldersOfUser, userID)
+	return err
+}
+
+const failJob = `-- name: FailJob :execrows
+UPDATE jobs
+SET status = ?, last_error = ?,
+    run_after = CURRENT_TIMESTAMP + INTERVAL CAST(? AS SIGNED) SECOND, leased_until = NULL
+WHERE job_id = ? AND status = 'RUNNING' AND attempts = ?
+`
+
+type FailJobParams struct {
+	Status         JobsStatus
+	LastError      sql.NullString
+	BackoffSeconds int64
+	JobID          uint32
+	Attempts       uint32
+}
+
+func (q *Queries) FailJob(ctx context.Context, arg FailJobParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, failJob,
+		arg.Status,
+		arg.LastError,
+		arg.BackoffSeconds,
+		arg.JobID,
+		arg.Attempts,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getEvent = `-- name: GetEvent :one
+SELECT event_id, name, description, event_date, creation_date, parent_event_id, metadata_policy, is_hidden, password_hash
+FROM events
+WHERE event_id = ?
+`
+
+func (q *Queries) GetEvent(ctx context.Context, eventID uint32) (Event, error) {
+	row := q.db.QueryRowContext(ctx, getEvent, eventID)
+	var i Event
+	err := row.Scan(
+		&i.EventID,
+		&i.Name,
+		&i.Description,
+		&i.EventDate,
+		&i.CreationDate,
+		&i.ParentEventID,
+		&i.MetadataPolicy,
+		&i.IsHidden,
+		&i.PasswordHash,
+	)
+	return i, err
+}
+
+const getEventStorageUsage = `-- name: GetEventStorageUsage :many
+SELECT u.scope_id AS event_id, e.name, u.kind, u.bytes, u.files
+FROM storage_usage u
+JOIN events e
+ON e.event_id = u.scope_id
+WHERE u.scope = 'EVENT'
+ORDER BY u.scope_id, u.kind
+`
+
+type GetEventStorageUsageRow struct {
+	EventID uint32
+	Name    string
+	Kind    StorageUsageKind
+	Bytes   int64
+	Files   int32
+}
+
+func (q *Queries) GetEventStorageUsage(ctx context.Context) ([]GetEventStorageUsageRow, error) {
+	rows, err := q.db.QueryContext(ctx, getEventStorageUsage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetEventStorageUsageRow
+	for rows.Next() {
+		var i GetEventStorageUsageRow
+		if err := rows.Scan(
+			&i.EventID,
+			&i.Name,
+			&i.Kind,
+			&i.Bytes,
+			&i.Files,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventWithNameAndParent = `-- name: GetEventWithNameAndParent :one
+SELECT event_id, name, description, event_date, creation_date, parent_event_id, metadata_policy, is_hidden, password_hash
+FROM events
+WHERE name = ? AND parent_event_id <=> ?
+LIMIT 1
+`
+
+type GetEventWithNameAndParentParams struct {
+	Name          string
+	ParentEventID sql.NullInt32
+}
+
+func (q *Queries) GetEventWithNameAndParent(ctx context.Context, arg GetEventWithNameAndParentParams) (Event, error) {
+	row := q.db.QueryRowContext(ctx, getEventWithNameAndParent, arg.Name, arg.ParentEventID)
+	var i Event
+	err := row.Scan(
+		&i.EventID,
+		&i.Name,
+		&i.Description,
+		&i.EventDate,
+		&i.CreationDate,
+		&i.ParentEventID,
+		&i.MetadataPolicy,
+		&i.IsHidden,
+		&i.PasswordHash,
+	)
+	return i, err
+}
+
+const getEvents = `-- name: GetEvents :many
+SELECT name, description, event_date, creation_date, parent_event_id
+FROM events
+WHERE is_hidden = false
+`
+
+type GetEventsRow struct {
+	Name          string
+	Description   string
+	EventDate     time.Time
+	CreationDate  sql.NullTime
+	ParentEventID sql.NullInt32
+}
+
+func (q *Queries) GetEvents(ctx context.Context) ([]GetEventsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getEvents)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetEventsRow
+	for rows.Next() {
+		var i GetEventsRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.Description,
+			&i.EventDate,
+			&i.CreationDate,
+			&i.ParentEventID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFaceConsent = `-- name: GetFaceConsent :one
+SELECT user_id, consent, update_date FROM face_consents WHERE user_id = ?
+`
+
+func (q *Queries) GetFaceConsent(ctx context.Context, userID uint32) (FaceConsent, error) {
+	row := q.db.QueryRowContext(ctx, getFaceConsent, userID)
+	var i FaceConsent
+	err := row.Scan(&i.UserID, &i.Consent, &i.UpdateDate)
+	return i, err
+}
+
+const getFaceConsentChanges = `-- name: GetFaceConsentChanges :many
+SELECT face_consent_change_id, user_id, consent, previous_consent, changed_by, ip, change_date
+FROM face_consent_changes
+WHERE user_id = ?
+ORDER BY change_date, face_consent_change_id
+`
+
+func (q *Queries) GetFaceConsentChanges(ctx context.Context, userID sql.NullInt32) ([]FaceConsentChange, error) {
+	rows, err := q.db.QueryContext(ctx, getFaceConsentChanges, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FaceConsentChange
+	for rows.Next() {
+		var i FaceConsentChange
+		if err := rows.Scan(
+			&i.FaceConsentChangeID,
+			&i.UserID,
+			&i.Consent,
+			&i.PreviousConsent,
+			&i.ChangedBy,
+			&i.Ip,
+			&i.ChangeDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFaceGroup = `-- name: GetFaceGroup :one
+SELECT face_group_id, label, user_id, creation_date FROM face_groups WHERE face_group_id = ?
+`
+
+func (q *Queries) GetFaceGroup(ctx context.Context, faceGroupID uint32) (FaceGroup, error) {
+	row := q.db.QueryRowContext(ctx, getFaceGroup, faceGroupID)
+	var i FaceGroup
+	err := row.Scan(
+		&i.FaceGroupID,
+		&i.Label,
+		&i.UserID,
+		&i.CreationDate,
+	)
+	return i, err
+}
+
+const getFaceGroupClaims = `-- name: GetFaceGroupClaims :many
+SELECT c.user_id, u.email, u.full_name, c.claim_date
+FROM face_group_claims c
+JOIN users u
+ON u.user_id = c.user_id
+WHERE c.face_group_id = ?
+ORDER BY c.claim_date, c.user_id
+`
+
+type GetFaceGroupClaimsRow struct {
+	UserID    uint32
+	Email     string
+	FullName  string
+	ClaimDate time.Time
+}
+
+func (q *Queries) GetFaceGroupClaims(ctx context.Context, faceGroupID uint32) ([]GetFaceGroupClaimsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFaceGroupClaims, faceGroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFaceGroupClaimsRow
+	for rows.Next() {
+		var i GetFaceGroupClaimsRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Email,
+			&i.FullName,
+			&i.ClaimDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFaceGroupClaimsOfUser = `-- name: GetFaceGroupClaimsOfUser :many
+SELECT face_group_id, user_id, claim_date FROM face_group_claims WHERE user_id = ? ORDER BY face_group_id
+`
+
+func (q *Queries) GetFaceGroupClaimsOfUser(ctx context.Context, userID uint32) ([]FaceGroupClaim, error) {
+	rows, err := q.db.QueryContext(ctx, getFaceGroupClaimsOfUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FaceGroupClaim
+	for rows.Next() {
+		var i FaceGroupClaim
+		if err := rows.Scan(&i.FaceGroupID, &i.UserID, &i.ClaimDate); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFaceGroups = `-- name: GetFaceGroups :many
+SELECT g.face_group_id, g.label, g.user_id, COUNT(*) AS faces, CAST(MIN(f.image_face_id) AS UNSIGNED) AS sample_face_id,
+    (SELECT COUNT(*) FROM face_group_claims c WHERE c.face_group_id = g.face_group_id) AS claims
+FROM face_groups g
+JOIN image_faces f
+ON f.face_group_id = g.face_group_id
+GROUP BY g.face_group_id
+ORDER BY faces DESC, g.face_group_id
+LIMIT ? OFFSET ?
+`
+
+type GetFaceGroupsParams struct {
+	Limit  int32
+	Offset int32
+}
+
+type GetFaceGroupsRow struct {
+	FaceGroupID  uint32
+	Label        sql.NullString
+	UserID       sql.NullInt32
+	Faces        int64
+	SampleFaceID int64
+	Claims       int64
+}
+
+func (q *Queries) GetFaceGroups(ctx context.Context, arg GetFaceGroupsParams) ([]GetFaceGroupsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFaceGroups, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFaceGroupsRow
+	for rows.Next() {
+		var i GetFaceGroupsRow
+		if err := rows.Scan(
+			&i.FaceGroupID,
+			&i.Label,
+			&i.UserID,
+			&i.Faces,
+			&i.SampleFaceID,
+			&i.Claims,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFaceGroupsOfUser = `-- name: GetFaceGroupsOfUser :many
+SELECT face_group_id, label, user_id, creation_date FROM face_groups WHERE user_id = ? ORDER BY face_group_id
+`
+
+func (q *Queries) GetFaceGroupsOfUser(ctx context.Context, userID sql.NullInt32) ([]FaceGroup, error) {
+	rows, err := q.db.QueryContext(ctx, getFaceGroupsOfUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FaceGroup
+	for rows.Next() {
+		var i FaceGroup
+		if err := rows.Scan(
+			&i.FaceGroupID,
+			&i.Label,
+			&i.UserID,
+			&i.CreationDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFacesOfPhoto = `-- name: GetFacesOfPhoto :many
+SELECT f.image_face_id, f.face_group_id, f.min_x, f.min_y, f.max_x, f.max_y, g.label, g.user_id, c.consent, n.opt_in_date AS name_opt_in_date
+FROM image_faces f
+JOIN face_groups g
+ON g.face_group_id = f.face_group_id
+LEFT JOIN face_consents c
+ON c.user_id = g.user_id
+LEFT JOIN public_face_names n
+ON n.user_id = g.user_id
+WHERE f.photo_id = ?
+ORDER BY f.image_face_id
+`
+
+type GetFacesOfPhotoRow struct {
+	ImageFaceID   uint32
+	FaceGroupID   uint32
+	MinX          float64
+	MinY          float64
+	MaxX          float64
+	MaxY          float64
+	Label         sql.NullString
+	UserID        sql.NullInt32
+	Consent       NullFaceConsentsConsent
+	NameOptInDate sql.NullTime
+}
+
+func (q *Queries) GetFacesOfPhoto(ctx context.Context, photoID uint32) ([]GetFacesOfPhotoRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFacesOfPhoto, photoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFacesOfPhotoRow
+	for rows.Next() {
+		var i GetFacesOfPhotoRow
+		if err := rows.Scan(
+			&i.ImageFaceID,
+			&i.FaceGroupID,
+			&i.MinX,
+			&i.MinY,
+			&i.MaxX,
+			&i.MaxY,
+			&i.Label,
+			&i.UserID,
+			&i.Consent,
+			&i.NameOptInDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGlobalStorageUsage = `-- name: GetGlobalStorageUsage :many
+SELECT kind, bytes, files
+FROM storage_usage
+WHERE scope = 'GLOBAL'
+ORDER BY kind
+`
+
+type GetGlobalStorageUsageRow struct {
+	Kind  StorageUsageKind
+	Bytes int64
+	Files int32
+}
+
+func (q *Queries) GetGlobalStorageUsage(ctx context.Context) ([]GetGlobalStorageUsageRow, error) {
+	rows, err := q.db.QueryContext(ctx, getGlobalStorageUsage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetGlobalStorageUsageRow
+	for rows.Next() {
+		var i GetGlobalStorageUsageRow
+		if err := rows.Scan(&i.Kind, &i.Bytes, &i.Files); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getImageFace = `-- name: GetImageFace :one
+SELECT image_face_id, photo_id, face_group_id, model, descriptor, min_x, min_y, max_x, max_y, detection_date FROM image_faces WHERE image_face_id = ?
+`
+
+func (q *Queries) GetImageFace(ctx context.Context, imageFaceID uint32) (ImageFace, error) {
+	row := q.db.QueryRowContext(ctx, getImageFace, imageFaceID)
+	var i ImageFace
+	err := row.Scan(
+		&i.ImageFaceID,
+		&i.PhotoID,
+		&i.FaceGroupID,
+		&i.Model,
+		&i.Descriptor,
+		&i.MinX,
+		&i.MinY,
+		&i.MaxX,
+		&i.MaxY,
+		&i.DetectionDate,
+	)
+	return i, err
+}
+
+const getImageFaceSamples = `-- name: GetImageFaceSamples :many
+SELECT image_face_id, face_group_id, descriptor
+FROM image_faces
+WHERE model = ?
+ORDER BY image_face_id
+`
+
+type GetImageFaceSamplesRow struct {
+	ImageFaceID uint32
+	FaceGroupID uint32
+	Descriptor  []byte
+}
+
+func (q *Queries) GetImageFaceSamples(ctx context.Context, model string) ([]GetImageFaceSamplesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getImageFaceSamples, model)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetImageFaceSamplesRow
+	for rows.Next() {
+		var i GetImageFaceSamplesRow
+		if err := rows.Scan(&i.ImageFaceID, &i.FaceGroupID, &i.Descriptor); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getImageFacesOfFaceGroup = `-- name: GetImageFacesOfFaceGroup :many
+SELECT image_face_id, photo_id, face_group_id, model, descriptor, min_x, min_y, max_x, max_y, detection_date
+FROM image_faces
+WHERE face_group_id = ?
+ORDER BY image_face_id
+`
+
+func (q *Queries) GetImageFacesOfFaceGroup(ctx context.Context, faceGroupID uint32) ([]ImageFace, error) {
+	rows, err := q.db.QueryContext(ctx, getImageFacesOfFaceGroup, faceGroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ImageFace
+	for rows.Next() {
+		var i ImageFace
+		if err := rows.Scan(
+			&i.ImageFaceID,
+			&i.PhotoID,
+			&i.FaceGroupID,
+			&i.Model,
+			&i.Descriptor,
+			&i.MinX,
+			&i.MinY,
+			&i.MaxX,
+			&i.MaxY,
+			&i.DetectionDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getImageFacesOfPhoto = `-- name: GetImageFacesOfPhoto :many
+SELECT image_face_id, photo_id, face_group_id, model, descriptor, min_x, min_y, max_x, max_y, detection_date
+FROM image_faces
+WHERE photo_id = ?
+ORDER BY image_face_id
+`
+
+func (q *Queries) GetImageFacesOfPhoto(ctx context.Context, photoID uint32) ([]ImageFace, error) {
+	rows, err := q.db.QueryContext(ctx, getImageFacesOfPhoto, photoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ImageFace
+	for rows.Next() {
+		var i ImageFace
+		if err := rows.Scan(
+			&i.ImageFaceID,
+			&i.PhotoID,
+			&i.FaceGroupID,
+			&i.Model,
+			&i.Descriptor,
+			&i.MinX,
+			&i.MinY,
+			&i.MaxX,
+			&i.MaxY,
+			&i.DetectionDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getJobs = `-- name: GetJobs :many
+SELECT job_id, kind, photo_id, status, attempts, last_error, run_after, leased_until, creation_date, update_date
+FROM jobs
+WHERE status = ?
+ORDER BY update_date DESC, job_id DESC
+LIMIT ?
+`
+
+type GetJobsParams struct {
+	Status JobsStatus
+	Limit  int32
+}
+
+func (q *Queries) GetJobs(ctx context.Context, arg GetJobsParams) ([]Job, error) {
+	rows, err := q.db.QueryContext(ctx, getJobs, arg.Status, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Job
+	for rows.Next() {
+		var i Job
+		if err := rows.Scan(
+			&i.JobID,
+			&i.Kind,
+			&i.PhotoID,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.RunAfter,
+			&i.LeasedUntil,
+			&i.CreationDate,
+			&i.UpdateDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMissingPhotoIDs = `-- name: GetMissingPhotoIDs :many
+SELECT photo_id
+FROM missing_photos
+ORDER BY photo_id
+`
+
+func (q *Queries) GetMissingPhotoIDs(ctx context.Context) ([]uint32, error) {
+	rows, err := q.db.QueryContext(ctx, getMissingPhotoIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uint32
+	for rows.Next() {
+		var photo_id uint32
+		if err := rows.Scan(&photo_id); err != nil {
+			return nil, err
+		}
+		items = append(items, photo_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNextJob = `-- name: GetNextJob :one
+SELECT job_id, kind, photo_id, status, attempts, last_error, run_after, leased_until, creation_date, update_date
+FROM jobs
+WHERE (status = 'PENDING' AND run_after <= CURRENT_TIMESTAMP)
+   OR (status = 'RUNNING' AND leased_until < CURRENT_TIMESTAMP)
+ORDER BY run_after, job_id
+LIMIT 1
+FOR UPDATE SKIP LOCKED
+`
+
+func (q *Queries) GetNextJob(ctx context.Context) (Job, error) {
+	row := q.db.QueryRowContext(ctx, getNextJob)
+	var i Job
+	err := row.Scan(
+		&i.JobID,
+		&i.Kind,
+		&i.PhotoID,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.RunAfter,
+		&i.LeasedUntil,
+		&i.CreationDate,
+		&i.UpdateDate,
+	)
+	return i, err
+}
+
+const getPendingDuplicateCandidates = `-- name: GetPendingDuplicateCandidates :many
+SELECT photo_id, duplicate_of_photo_id, distance, status, creation_date
+FROM duplicate_candidates
+WHERE status = 'PENDING'
+ORDER BY creation_date, photo_id
+`
+
+func (q *Queries) GetPendingDuplicateCandidates(ctx context.Context) ([]DuplicateCandidate, error) {
+	rows, err := q.db.QueryContext(ctx, getPendingDuplicateCandidates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DuplicateCandidate
+	for rows.Next() {
+		var i DuplicateCandidate
+		if err := rows.Scan(
+			&i.PhotoID,
+			&i.DuplicateOfPhotoID,
+			&i.Distance,
+			&i.Status,
+			&i.CreationDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPerceptualHashes = `-- name: GetPerceptualHashes :many
+SELECT photo_id, perceptual_hash
+FROM photos
+WHERE perceptual_hash IS NOT NULL AND file_hash <> ?
+`
+
+type GetPerceptualHashesRow struct {
+	PhotoID        uint32
+	PerceptualHash sql.NullInt64
+}
+
+func (q *Queries) GetPerceptualHashes(ctx context.Context, fileHash string) ([]GetPerceptualHashesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPerceptualHashes, fileHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPerceptualHashesRow
+	for rows.Next() {
+		var i GetPerceptualHashesRow
+		if err := rows.Scan(&i.PhotoID, &i.PerceptualHash); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPerceptualHashesInBands = `-- name: GetPerceptualHashesInBands :many
+SELECT DISTINCT p.photo_id, p.perceptual_hash
+FROM perceptual_hash_bands b
+JOIN photos p
+ON p.photo_id = b.photo_id
+WHERE b.band_key IN (?, ?, ?, ?, ?, ?, ?, ?) AND p.file_hash <> ?
+`
+
+type GetPerceptualHashesInBandsParams struct {
+	BandKey   uint16
+	BandKey_2 uint16
+	BandKey_3 uint16
+	BandKey_4 uint16
+	BandKey_5 uint16
+	BandKey_6 uint16
+	BandKey_7 uint16
+	BandKey_8 uint16
+	FileHash  string
+}
+
+type GetPerceptualHashesInBandsRow struct {
+	PhotoID        uint32
+	PerceptualHash sql.NullInt64
+}
+
+func (q *Queries) GetPerceptualHashesInBands(ctx context.Context, arg GetPerceptualHashesInBandsParams) ([]GetPerceptualHashesInBandsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPerceptualHashesInBands,
+		arg.BandKey,
+		arg.BandKey_2,
+		arg.BandKey_3,
+		arg.BandKey_4,
+		arg.BandKey_5,
+		arg.BandKey_6,
+		arg.BandKey_7,
+		arg.BandKey_8,
+		arg.FileHash,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPerceptualHashesInBandsRow
+	for rows.Next() {
+		var i GetPerceptualHashesInBandsRow
+		if err := rows.Scan(&i.PhotoID, &i.PerceptualHash); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPhoto = `-- name: GetPhoto :one
+SELECT photo_id, path_to_photo, file_hash, perceptual_hash, file_size, creation_date, event_id, uploader_id, is_hidden FROM photos WHERE photo_id = ?
+`
+
+func (q *Queries) GetPhoto(ctx context.Context, photoID uint32) (Photo, error) {
+	row := q.db.QueryRowContext(ctx, getPhoto, photoID)
+	var i Photo
+	err := row.Scan(
+		&i.PhotoID,
+		&i.PathToPhoto,
+		&i.FileHash,
+		&i.PerceptualHash,
+		&i.FileSize,
+		&i.CreationDate,
+		&i.EventID,
+		&i.UploaderID,
+		&i.IsHidden,
+	)
+	return i, err
+}
+
+const getPhotoIDsOfFaceGroup = `-- name: GetPhotoIDsOfFaceGroup :many
+SELECT DISTINCT photo_id
+FROM image_faces
+WHERE face_group_id = ?
+ORDER BY photo_id
+`
+
+func (q *Queries) GetPhotoIDsOfFaceGroup(ctx context.Context, faceGroupID uint32) ([]uint32, error) {
+	rows, err := q.db.QueryContext(ctx, getPhotoIDsOfFaceGroup, faceGroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uint32
+	for rows.Next() {
+		var photo_id uint32
+		if err := rows.Scan(&photo_id); err != nil {
+			return nil, err
+		}
+		items = append(items, photo_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPhotoMetadata = `-- name: GetPhotoMetadata :one
+SELECT photo_id, capture_date, camera_make, camera_model, lens_model, exposure_time, f_number, iso, focal_length, orientation, width, height, latitude, longitude, altitude
+FROM photo_metadata
+WHERE photo_id = ?
+`
+
+func (q *Queries) GetPhotoMetadata(ctx context.Context, photoID uint32) (PhotoMetadatum, error) {
+	row := q.db.QueryRowContext(ctx, getPhotoMetadata, photoID)
+	var i PhotoMetadatum
+	err := row.Scan(
+		&i.PhotoID,
+		&i.CaptureDate,
+		&i.CameraMake,
+		&i.CameraModel,
+		&i.LensModel,
+		&i.ExposureTime,
+		&i.FNumber,
+		&i.Iso,
+		&i.FocalLength,
+		&i.Orientation,
+		&i.Width,
+		&i.Height,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Altitude,
+	)
+	return i, err
+}
+
+const getPhotoPaths = `-- name: GetPhotoPaths :many
+SELECT photo_id, path_to_photo, file_size, event_id
+FROM photos
+ORDER BY photo_id
+`
+
+type GetPhotoPathsRow struct {
+	PhotoID     uint32
+	PathToPhoto string
+	FileSize    int64
+	EventID     uint32
+}
+
+func (q *Queries) GetPhotoPaths(ctx context.Context) ([]GetPhotoPathsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPhotoPaths)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPhotoPathsRow
+	for rows.Next() {
+		var i GetPhotoPathsRow
+		if err := rows.Scan(
+			&i.PhotoID,
+			&i.PathToPhoto,
+			&i.FileSize,
+			&i.EventID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPhotoWithEventAndHash = `-- name: GetPhotoWithEventAndHash :one
+SELECT photo_id, path_to_photo, file_hash, perceptual_hash, file_size, creation_date, event_id, uploader_id, is_hidden
+FROM photos
+WHERE event_id = ? AND file_hash = ?
+LIMIT 1
+`
+
+type GetPhotoWithEventAndHashParams struct {
+	EventID  uint32
+	FileHash string
+}
+
+func (q *Queries) GetPhotoWithEventAndHash(ctx context.Context, arg GetPhotoWithEventAndHashParams) (Photo, error) {
+	row := q.db.QueryRowContext(ctx, getPhotoWithEventAndHash, arg.EventID, arg.FileHash)
+	var i Photo
+	err := row.Scan(
+		&i.PhotoID,
+		&i.PathToPhoto,
+		&i.FileHash,
+		&i.PerceptualHash,
+		&i.FileSize,
+		&i.CreationDate,
+		&i.EventID,
+		&i.UploaderID,
+		&i.IsHidden,
+	)
+	return i, err
+}
+
+const getPhotoWithMetadataPolicy = `-- name: GetPhotoWithMetadataPolicy :one
+SELECT p.photo_id, p.path_to_photo, p.file_hash, p.perceptual_hash, p.file_size, p.creation_date, p.event_id, p.uploader_id, p.is_hidden, e.metadata_policy
+FROM photos p
+JOIN events e
+ON e.event_id = p.event_id
+WHERE p.photo_id = ?
+`
+
+type GetPhotoWithMetadataPolicyRow struct {
+	PhotoID        uint32
+	PathToPhoto    string
+	FileHash       string
+	PerceptualHash sql.NullInt64
+	FileSize       int64
+	CreationDate   sql.NullTime
+	EventID        uint32
+	UploaderID     sql.NullInt32
+	IsHidden       bool
+	MetadataPolicy EventsMetadataPolicy
+}
+
+func (q *Queries) GetPhotoWithMetadataPolicy(ctx context.Context, photoID uint32) (GetPhotoWithMetadataPolicyRow, error) {
+	row := q.db.QueryRowContext(ctx, getPhotoWithMetadataPolicy, photoID)
+	var i GetPhotoWithMetadataPolicyRow
+	err := row.Scan(
+		&i.PhotoID,
+		&i.PathToPhoto,
+		&i.FileHash,
+		&i.PerceptualHash,
+		&i.FileSize,
+		&i.CreationDate,
+		&i.EventID,
+		&i.UploaderID,
+		&i.IsHidden,
+		&i.MetadataPolicy,
+	)
+	return i, err
+}
+
+const getPhotosByEventID = `-- name: GetPhotosByEventID :many
+SELECT p.photo_id, p.path_to_photo, p.file_hash, p.perceptual_hash, p.file_size, p.creation_date, p.event_id, p.uploader_id, p.is_hidden
+FROM photos p
+LEFT JOIN photo_metadata pm
+ON pm.photo_id = p.photo_id
+WHERE p.event_id = ?
+ORDER BY COALESCE(pm.capture_date, p.creation_date), p.photo_id
+`
+
+func (q *Queries) GetPhotosByEventID(ctx context.Context, eventID uint32) ([]Photo, error) {
+	rows, err := q.db.QueryContext(ctx, getPhotosByEventID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Photo
+	for rows.Next() {
+		var i Photo
+		if err := rows.Scan(
+			&i.PhotoID,
+			&i.PathToPhoto,
+			&i.FileHash,
+			&i.PerceptualHash,
+			&i.FileSize,
+			&i.CreationDate,
+			&i.EventID,
+			&i.UploaderID,
+			&i.IsHidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPhotosSortedByDate = `-- name: GetPhotosSortedByDate :many
+SELECT p.photo_id, p.path_to_photo, p.file_hash, p.perceptual_hash, p.file_size, p.creation_date, p.event_id, p.uploader_id, p.is_hidden
+FROM photos p
+LEFT JOIN photo_metadata pm
+ON pm.photo_id = p.photo_id
+ORDER BY COALESCE(pm.capture_date, p.creation_date) DESC, p.photo_id DESC
+`
+
+func (q *Queries) GetPhotosSortedByDate(ctx context.Context) ([]Photo, error) {
+	rows, err := q.db.QueryContext(ctx, getPhotosSortedByDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Photo
+	for rows.Next() {
+		var i Photo
+		if err := rows.Scan(
+			&i.PhotoID,
+			&i.PathToPhoto,
+			&i.FileHash,
+			&i.PerceptualHash,
+			&i.FileSize,
+			&i.CreationDate,
+			&i.EventID,
+			&i.UploaderID,
+			&i.IsHidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPhotosWithHash = `-- name: GetPhotosWithHash :many
+SELECT photo_id, path_to_photo, file_hash, perceptual_hash, file_size, creation_date, event_id, uploader_id, is_hidden
+FROM photos
+WHERE file_hash = ?
+ORDER BY photo_id
+`
+
+func (q *Queries) GetPhotosWithHash(ctx context.Context, fileHash string) ([]Photo, error) {
+	rows, err := q.db.QueryContext(ctx, getPhotosWithHash, fileHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Photo
+	for rows.Next() {
+		var i Photo
+		if err := rows.Scan(
+			&i.PhotoID,
+			&i.PathToPhoto,
+			&i.FileHash,
+			&i.PerceptualHash,
+			&i.FileSize,
+			&i.CreationDate,
+			&i.EventID,
+			&i.UploaderID,
+			&i.IsHidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPhotosWithoutFaceScan = `-- name: GetPhotosWithoutFaceScan :many
+SELECT p.photo_id
+FROM photos p
+LEFT JOIN face_scans f
+ON f.photo_id = p.photo_id
+WHERE f.photo_id IS NULL OR f.model <> ?
+ORDER BY p.photo_id
+`
+
+func (q *Queries) GetPhotosWithoutFaceScan(ctx context.Context, model string) ([]uint32, error) {
+	rows, err := q.db.QueryContext(ctx, getPhotosWithoutFaceScan, model)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uint32
+	for rows.Next() {
+		var photo_id uint32
+		if err := rows.Scan(&photo_id); err != nil {
+			return nil, err
+		}
+		items = append(items, photo_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPublicFaceName = `-- name: GetPublicFaceName :one
+SELECT opt_in_date FROM public_face_names WHERE user_id = ?
+`
+
+func (q *Queries) GetPublicFaceName(ctx context.Context, userID uint32) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getPublicFaceName, userID)
+	var opt_in_date time.Time
+	err := row.Scan(&opt_in_date)
+	return opt_in_date, err
+}
+
+const getRecognizedPhotos = `-- name: GetRecognizedPhotos :many
+SELECT p.photo_id, p.path_to_photo, p.file_hash, p.perceptual_hash, p.file_size, p.creation_date, p.event_id, p.uploader_id, p.is_hidden
+FROM photos p
+JOIN recognized_users r
+ON r.photo_id = p.photo_id
+LEFT JOIN photo_metadata pm
+ON pm.photo_id = p.photo_id
+WHERE r.user_id = ?
+ORDER BY COALESCE(pm.capture_date, p.creation_date), p.photo_id
+`
+
+func (q *Queries) GetRecognizedPhotos(ctx context.Context, userID uint32) ([]Photo, error) {
+	rows, err := q.db.QueryContext(ctx, getRecognizedPhotos, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Photo
+	for rows.Next() {
+		var i Photo
+		if err := rows.Scan(
+			&i.PhotoID,
+			&i.PathToPhoto,
+			&i.FileHash,
+			&i.PerceptualHash,
+			&i.FileSize,
+			&i.CreationDate,
+			&i.EventID,
+			&i.UploaderID,
+			&i.IsHidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSessionWithToken = `-- name: GetSessionWithToken :one
+SELECT session_id, user_id, creation_date, session_token
+FROM sessions
+WHERE session_token = ?
+`
+
+func (q *Queries) GetSessionWithToken(ctx context.Context, sessionToken string) (Session, error) {
+	row := q.db.QueryRowContext(ctx, getSessionWithToken, sessionToken)
+	var i Session
+	err := row.Scan(
+		&i.SessionID,
+		&i.UserID,
+		&i.CreationDate,
+		&i.SessionToken,
+	)
+	return i, err
+}
+
+const getSessionsOfUser = `-- name: GetSessionsOfUser :many
+SELECT session_id, user_id, creation_date, session_token
+FROM sessions
+WHERE user_id = ?
+ORDER BY creation_date, session_id
+`
+
+func (q *Queries) GetSessionsOfUser(ctx context.Context, userID uint32) ([]Session, error) {
+	rows, err := q.db.QueryContext(ctx, getSessionsOfUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.SessionID,
+			&i.UserID,
+			&i.CreationDate,
+			&i.SessionToken,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const deleteSessionWithToken = `-- name: DeleteSessionWithToken :exec
-DELETE FROM sessions WHERE session_token = ?
+const getStorageUsage = `-- name: GetStorageUsage :one
+SELECT CAST(COALESCE(SUM(bytes), 0) AS SIGNED) AS bytes
+FROM storage_usage
+WHERE scope = ? AND scope_id = ?
 `
 
-func (q *Queries) DeleteSessionWithToken(ctx context.Context, sessionToken string) error {
-	_, err := q.db.ExecContext(ctx, deleteSessionWithToken, sessionToken)
-	return err
+type GetStorageUsageParams struct {
+	Scope   StorageUsageScope
+	ScopeID uint32
 }
 
-const getEvents = `-- name: GetEvents :many
-SELECT name, description, event_date, creation_date, parent_event_id
+func (q *Queries) GetStorageUsage(ctx context.Context, arg GetStorageUsageParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getStorageUsage, arg.Scope, arg.ScopeID)
+	var bytes int64
+	err := row.Scan(&bytes)
+	return bytes, err
+}
+
+const getSubEvents = `-- name: GetSubEvents :many
+SELECT event_id, name, description, event_date, creation_date, parent_event_id, metadata_policy, is_hidden, password_hash
 FROM events
+WHERE parent_event_id = ?
+ORDER BY event_date, event_id
 `
 
-type GetEventsRow struct {
-	Name          string
-	Description   string
-	EventDate     time.Time
-	CreationDate  sql.NullTime
-	ParentEventID sql.NullInt32
-}
-
-func (q *Queries) GetEvents(ctx context.Context) ([]GetEventsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getEvents)
+func (q *Queries) GetSubEvents(ctx context.Context, parentEventID sql.NullInt32) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, getSubEvents, parentEventID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetEventsRow
+	var items []Event
 	for rows.Next() {
-		var i GetEventsRow
+		var i Event
 		if err := rows.Scan(
+			&i.EventID,
 			&i.Name,
 			&i.Description,
 			&i.EventDate,
 			&i.CreationDate,
 			&i.ParentEventID,
+			&i.MetadataPolicy,
+			&i.IsHidden,
+			&i.PasswordHash,
 		); err != nil {
 			return nil, err
 		}
@@ -155,44 +2064,26 @@ func (q *Queries) GetEvents(ctx context.Context) ([]GetEventsRow, error) {
 	return items, nil
 }
 
-const getPhoto = `-- name: GetPhoto :one
-SELECT photo_id, path_to_photo, creation_date, event_id FROM photos WHERE photo_id = ?
-`
-
-func (q *Queries) GetPhoto(ctx context.Context, photoID uint32) (Photo, error) {
-	row := q.db.QueryRowContext(ctx, getPhoto, photoID)
-	var i Photo
-	err := row.Scan(
-		&i.PhotoID,
-		&i.PathToPhoto,
-		&i.CreationDate,
-		&i.EventID,
-	)
-	return i, err
-}
-
-const getPhotosByEventID = `-- name: GetPhotosByEventID :many
-SELECT photo_id, path_to_photo, creation_date, event_id FROM photos WHERE event_id = ?
+const getUnlabeledFaceGroupIDs = `-- name: GetUnlabeledFaceGroupIDs :many
+SELECT face_group_id
+FROM face_groups
+WHERE label IS NULL
+ORDER BY face_group_id
 `
 
-func (q *Queries) GetPhotosByEventID(ctx context.Context, eventID uint32) ([]Photo, error) {
-	rows, err := q.db.QueryContext(ctx, getPhotosByEventID, eventID)
+func (q *Queries) GetUnlabeledFaceGroupIDs(ctx context.Context) ([]uint32, error) {
+	rows, err := q.db.QueryContext(ctx, getUnlabeledFaceGroupIDs)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Photo
+	var items []uint32
 	for rows.Next() {
-		var i Photo
-		if err := rows.Scan(
-			&i.PhotoID,
-			&i.PathToPhoto,
-			&i.CreationDate,
-			&i.EventID,
-		); err != nil {
+		var face_group_id uint32
+		if err := rows.Scan(&face_group_id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, face_group_id)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -203,24 +2094,38 @@ func (q *Queries) GetPhotosByEventID(ctx context.Context, eventID uint32) ([]Pho
 	return items, nil
 }
 
-const getPhotosSortedByDate = `-- name: GetPhotosSortedByDate :many
-SELECT photo_id, path_to_photo, creation_date, event_id FROM photos ORDER BY creation_date DESC
+const getUploaderStorageUsage = `-- name: GetUploaderStorageUsage :many
+SELECT u.scope_id AS user_id, us.full_name, us.email, u.bytes, u.files
+FROM storage_usage u
+JOIN users us
+ON us.user_id = u.scope_id
+WHERE u.scope = 'UPLOADER' AND u.kind = 'ORIGINAL'
+ORDER BY u.bytes DESC
 `
 
-func (q *Queries) GetPhotosSortedByDate(ctx context.Context) ([]Photo, error) {
-	rows, err := q.db.QueryContext(ctx, getPhotosSortedByDate)
+type GetUploaderStorageUsageRow struct {
+	UserID   uint32
+	FullName string
+	Email    string
+	Bytes    int64
+	Files    int32
+}
+
+func (q *Queries) GetUploaderStorageUsage(ctx context.Context) ([]GetUploaderStorageUsageRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUploaderStorageUsage)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Photo
+	var items []GetUploaderStorageUsageRow
 	for rows.Next() {
-		var i Photo
+		var i GetUploaderStorageUsageRow
 		if err := rows.Scan(
-			&i.PhotoID,
-			&i.PathToPhoto,
-			&i.CreationDate,
-			&i.EventID,
+			&i.UserID,
+			&i.FullName,
+			&i.Email,
+			&i.Bytes,
+			&i.Files,
 		); err != nil {
 			return nil, err
 		}
@@ -235,24 +2140,6 @@ func (q *Queries) GetPhotosSortedByDate(ctx context.Context) ([]Photo, error) {
 	return items, nil
 }
 
-const getSessionWithToken = `-- name: GetSessionWithToken :one
-SELECT session_id, user_id, creation_date, session_token
-FROM sessions
-WHERE session_token = ?
-`
-
-func (q *Queries) GetSessionWithToken(ctx context.Context, sessionToken string) (Session, error) {
-	row := q.db.QueryRowContext(ctx, getSessionWithToken, sessionToken)
-	var i Session
-	err := row.Scan(
-		&i.SessionID,
-		&i.UserID,
-		&i.CreationDate,
-		&i.SessionToken,
-	)
-	return i, err
-}
-
 const getUser = `-- name: GetUser :one
 SELECT user_id, signup_date, last_signin_date, signin_locked, signin_locked_date, is_admin, email, full_name, business_category, department_number
 FROM users
@@ -277,26 +2164,42 @@ func (q *Queries) GetUser(ctx context.Context, userID uint32) (User, error) {
 	return i, err
 }
 
-const getUserLastInsertID = `-- name: GetUserLastInsertID :one
-SELECT user_id, signup_date, last_signin_date, signin_locked, signin_locked_date, is_admin, email, full_name, business_category, department_number FROM users WHERE user_id = LAST_INSERT_ID()
+const getUserFoldersOfUser = `-- name: GetUserFoldersOfUser :many
+SELECT user_folder_id, is_sub_folder, name, description, creation_date, user_id, parent_folder_id
+FROM user_folders
+WHERE user_id = ?
+ORDER BY user_folder_id
 `
 
-func (q *Queries) GetUserLastInsertID(ctx context.Context) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUserLastInsertID)
-	var i User
-	err := row.Scan(
-		&i.UserID,
-		&i.SignupDate,
-		&i.LastSigninDate,
-		&i.SigninLocked,
-		&i.SigninLockedDate,
-		&i.IsAdmin,
-		&i.Email,
-		&i.FullName,
-		&i.BusinessCategory,
-		&i.DepartmentNumber,
-	)
-	return i, err
+func (q *Queries) GetUserFoldersOfUser(ctx context.Context, userID uint32) ([]UserFolder, error) {
+	rows, err := q.db.QueryContext(ctx, getUserFoldersOfUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserFolder
+	for rows.Next() {
+		var i UserFolder
+		if err := rows.Scan(
+			&i.UserFolderID,
+			&i.IsSubFolder,
+			&i.Name,
+			&i.Description,
+			&i.CreationDate,
+			&i.UserID,
+			&i.ParentFolderID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getUserWithEmail = `-- name: GetUserWithEmail :one
@@ -349,6 +2252,151 @@ func (q *Queries) GetUserWithSession(ctx context.Context, sessionToken string) (
 	return i, err
 }
 
+const isEventUnlocked = `-- name: IsEventUnlocked :one
+SELECT EXISTS(
+    SELECT 1
+    FROM unlocked_events
+    WHERE session_id = ? AND event_id = ?
+) AS unlocked
+`
+
+type IsEventUnlockedParams struct {
+	SessionID uint32
+	EventID   uint32
+}
+
+func (q *Queries) IsEventUnlocked(ctx context.Context, arg IsEventUnlockedParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isEventUnlocked, arg.SessionID, arg.EventID)
+	var unlocked bool
+	err := row.Scan(&unlocked)
+	return unlocked, err
+}
+
+const leaseJob = `-- name: LeaseJob :exec
+UPDATE jobs
+SET status = 'RUNNING', attempts = attempts + 1,
+    leased_until = CURRENT_TIMESTAMP + INTERVAL CAST(? AS SIGNED) SECOND
+WHERE job_id = ?
+`
+
+type LeaseJobParams struct {
+	LeaseSeconds int64
+	JobID        uint32
+}
+
+func (q *Queries) LeaseJob(ctx context.Context, arg LeaseJobParams) error {
+	_, err := q.db.ExecContext(ctx, leaseJob, arg.LeaseSeconds, arg.JobID)
+	return err
+}
+
+const mergeFaceGroup = `-- name: MergeFaceGroup :exec
+UPDATE image_faces
+SET face_group_id = ?
+WHERE face_group_id = ?
+`
+
+type MergeFaceGroupParams struct {
+	FaceGroupID   uint32
+	FaceGroupID_2 uint32
+}
+
+func (q *Queries) MergeFaceGroup(ctx context.Context, arg MergeFaceGroupParams) error {
+	_, err := q.db.ExecContext(ctx, mergeFaceGroup, arg.FaceGroupID, arg.FaceGroupID_2)
+	return err
+}
+
+const reserveStorageUsage = `-- name: ReserveStorageUsage :execrows
+UPDATE storage_usage
+SET bytes = bytes + ?, files = files + 1
+WHERE scope = ? AND scope_id = ? AND kind = 'ORIGINAL' AND bytes + ? <= ?
+`
+
+type ReserveStorageUsageParams struct {
+	Bytes   int64
+	Scope   StorageUsageScope
+	ScopeID uint32
+	Bytes_2 int64
+	Bytes_3 int64
+}
+
+func (q *Queries) ReserveStorageUsage(ctx context.Context, arg ReserveStorageUsageParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, reserveStorageUsage,
+		arg.Bytes,
+		arg.Scope,
+		arg.ScopeID,
+		arg.Bytes_2,
+		arg.Bytes_3,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const retryJob = `-- name: RetryJob :execrows
+UPDATE jobs
+SET status = 'PENDING', attempts = 0, run_after = CURRENT_TIMESTAMP, leased_until = NULL
+WHERE job_id = ? AND status IN ('DONE', 'DEAD')
+`
+
+func (q *Queries) RetryJob(ctx context.Context, jobID uint32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, retryJob, jobID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const saveFaceConsent = `-- name: SaveFaceConsent :exec
+INSERT INTO face_consents (user_id, consent)
+VALUES (?, ?)
+ON DUPLICATE KEY UPDATE consent = VALUES(consent)
+`
+
+type SaveFaceConsentParams struct {
+	UserID  uint32
+	Consent FaceConsentsConsent
+}
+
+func (q *Queries) SaveFaceConsent(ctx context.Context, arg SaveFaceConsentParams) error {
+	_, err := q.db.ExecContext(ctx, saveFaceConsent, arg.UserID, arg.Consent)
+	return err
+}
+
+const saveFaceScan = `-- name: SaveFaceScan :exec
+INSERT INTO face_scans (photo_id, model, faces)
+VALUES (?, ?, ?)
+ON DUPLICATE KEY UPDATE model = VALUES(model), faces = VALUES(faces), scan_date = CURRENT_TIMESTAMP
+`
+
+type SaveFaceScanParams struct {
+	PhotoID uint32
+	Model   string
+	Faces   uint32
+}
+
+func (q *Queries) SaveFaceScan(ctx context.Context, arg SaveFaceScanParams) error {
+	_, err := q.db.ExecContext(ctx, saveFaceScan, arg.PhotoID, arg.Model, arg.Faces)
+	return err
+}
+
+const updateDuplicateCandidateStatus = `-- name: UpdateDuplicateCandidateStatus :exec
+UPDATE duplicate_candidates
+SET status = ?
+WHERE photo_id = ? AND duplicate_of_photo_id = ?
+`
+
+type UpdateDuplicateCandidateStatusParams struct {
+	Status             DuplicateCandidatesStatus
+	PhotoID            uint32
+	DuplicateOfPhotoID uint32
+}
+
+func (q *Queries) UpdateDuplicateCandidateStatus(ctx context.Context, arg UpdateDuplicateCandidateStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateDuplicateCandidateStatus, arg.Status, arg.PhotoID, arg.DuplicateOfPhotoID)
+	return err
+}
+
 const updateEvent = `-- name: UpdateEvent :exec
 UPDATE events
 SET name = ?, description = ?, event_date = ?, parent_event_id = ?
@@ -374,6 +2422,122 @@ func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) error
 	return err
 }
 
+const updateEventHidden = `-- name: UpdateEventHidden :exec
+UPDATE events
+SET is_hidden = ?
+WHERE event_id = ?
+`
+
+type UpdateEventHiddenParams struct {
+	IsHidden bool
+	EventID  uint32
+}
+
+func (q *Queries) UpdateEventHidden(ctx context.Context, arg UpdateEventHiddenParams) error {
+	_, err := q.db.ExecContext(ctx, updateEventHidden, arg.IsHidden, arg.EventID)
+	return err
+}
+
+const updateEventMetadataPolicy = `-- name: UpdateEventMetadataPolicy :exec
+UPDATE events
+SET metadata_policy = ?
+WHERE event_id = ?
+`
+
+type UpdateEventMetadataPolicyParams struct {
+	MetadataPolicy EventsMetadataPolicy
+	EventID        uint32
+}
+
+func (q *Queries) UpdateEventMetadataPolicy(ctx context.Context, arg UpdateEventMetadataPolicyParams) error {
+	_, err := q.db.ExecContext(ctx, updateEventMetadataPolicy, arg.MetadataPolicy, arg.EventID)
+	return err
+}
+
+const updateEventPasswordHash = `-- name: UpdateEventPasswordHash :exec
+UPDATE events
+SET password_hash = ?
+WHERE event_id = ?
+`
+
+type UpdateEventPasswordHashParams struct {
+	PasswordHash string
+	EventID      uint32
+}
+
+func (q *Queries) UpdateEventPasswordHash(ctx context.Context, arg UpdateEventPasswordHashParams) error {
+	_, err := q.db.ExecContext(ctx, updateEventPasswordHash, arg.PasswordHash, arg.EventID)
+	return err
+}
+
+const updateFaceGroupUser = `-- name: UpdateFaceGroupUser :exec
+UPDATE face_groups
+SET label = ?, user_id = ?
+WHERE face_group_id = ?
+`
+
+type UpdateFaceGroupUserParams struct {
+	Label       sql.NullString
+	UserID      sql.NullInt32
+	FaceGroupID uint32
+}
+
+func (q *Queries) UpdateFaceGroupUser(ctx context.Context, arg UpdateFaceGroupUserParams) error {
+	_, err := q.db.ExecContext(ctx, updateFaceGroupUser, arg.Label, arg.UserID, arg.FaceGroupID)
+	return err
+}
+
+const updateImageFaceGroup = `-- name: UpdateImageFaceGroup :exec
+UPDATE image_faces
+SET face_group_id = ?
+WHERE image_face_id = ?
+`
+
+type UpdateImageFaceGroupParams struct {
+	FaceGroupID uint32
+	ImageFaceID uint32
+}
+
+func (q *Queries) UpdateImageFaceGroup(ctx context.Context, arg UpdateImageFaceGroupParams) error {
+	_, err := q.db.ExecContext(ctx, updateImageFaceGroup, arg.FaceGroupID, arg.ImageFaceID)
+	return err
+}
+
+const updatePhotoFileSize = `-- name: UpdatePhotoFileSize :execrows
+UPDATE photos
+SET file_size = ?
+WHERE photo_id = ? AND file_size = 0
+`
+
+type UpdatePhotoFileSizeParams struct {
+	FileSize int64
+	PhotoID  uint32
+}
+
+func (q *Queries) UpdatePhotoFileSize(ctx context.Context, arg UpdatePhotoFileSizeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updatePhotoFileSize, arg.FileSize, arg.PhotoID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updatePhotoHidden = `-- name: UpdatePhotoHidden :exec
+UPDATE photos
+SET is_hidden = ?
+WHERE photo_id = ?
+`
+
+type UpdatePhotoHiddenParams struct {
+	IsHidden bool
+	PhotoID  uint32
+}
+
+func (q *Queries) UpdatePhotoHidden(ctx context.Context, arg UpdatePhotoHiddenParams) error {
+	_, err := q.db.ExecContext(ctx, updatePhotoHidden, arg.IsHidden, arg.PhotoID)
+	return err
+}
+
 const updatePhotoPath = `-- name: UpdatePhotoPath :exec
 UPDATE photos
 SET path_to_photo = ?
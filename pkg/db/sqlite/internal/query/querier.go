@@ -0,0 +1,125 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+
+package query
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type Querier interface {
+	AddStorageUsage(ctx context.Context, arg AddStorageUsageParams) error
+	AnonymizeFaceConsentChangesOfUser(ctx context.Context, userID sql.NullInt64) error
+	AttemptCreatingUser(ctx context.Context, arg AttemptCreatingUserParams) (int64, error)
+	BackfillPerceptualHashBands(ctx context.Context) (int64, error)
+	CompleteJob(ctx context.Context, arg CompleteJobParams) (int64, error)
+	CountJobs(ctx context.Context) ([]CountJobsRow, error)
+	CountPhotosWithPath(ctx context.Context, pathToPhoto string) (int64, error)
+	CountSessionsSince(ctx context.Context, creationDate time.Time) (int64, error)
+	CountUnsizedPhotosWithPath(ctx context.Context, pathToPhoto string) (int64, error)
+	CreateDuplicateCandidate(ctx context.Context, arg CreateDuplicateCandidateParams) error
+	CreateEvent(ctx context.Context, arg CreateEventParams) (int64, error)
+	CreateFaceConsentChange(ctx context.Context, arg CreateFaceConsentChangeParams) error
+	CreateFaceGroup(ctx context.Context) (int64, error)
+	CreateFaceGroupClaim(ctx context.Context, arg CreateFaceGroupClaimParams) error
+	CreateImageFace(ctx context.Context, arg CreateImageFaceParams) (int64, error)
+	CreateJob(ctx context.Context, arg CreateJobParams) error
+	CreateMissingPhoto(ctx context.Context, photoID int64) error
+	CreatePerceptualHashBands(ctx context.Context, photoID int64) error
+	CreatePhoto(ctx context.Context, arg CreatePhotoParams) (int64, error)
+	CreatePhotoMetadata(ctx context.Context, arg CreatePhotoMetadataParams) error
+	CreatePublicFaceName(ctx context.Context, userID int64) error
+	CreateRecognizeJob(ctx context.Context) error
+	CreateRecognizedUsersOfPhoto(ctx context.Context, photoID int64) error
+	CreateSession(ctx context.Context, arg CreateSessionParams) error
+	CreateUnlockedEvent(ctx context.Context, arg CreateUnlockedEventParams) error
+	DeleteDoneJobs(ctx context.Context, retentionSeconds int64) (int64, error)
+	DeleteEvent(ctx context.Context, eventID int64) error
+	DeleteFaceGroupClaim(ctx context.Context, arg DeleteFaceGroupClaimParams) (int64, error)
+	DeleteFaceGroupClaims(ctx context.Context, faceGroupID int64) error
+	DeleteFaceGroupClaimsOfUser(ctx context.Context, userID int64) error
+	DeleteFaceGroupIfEmpty(ctx context.Context, faceGroupID int64) (int64, error)
+	DeleteFaceGroupsOfUser(ctx context.Context, userID sql.NullInt64) error
+	DeleteImageFacesOfPhoto(ctx context.Context, photoID int64) error
+	DeleteImageFacesOfUser(ctx context.Context, userID sql.NullInt64) error
+	DeleteMissingPhoto(ctx context.Context, photoID int64) error
+	DeletePhoto(ctx context.Context, photoID int64) error
+	DeletePhotoMetadata(ctx context.Context, photoID int64) error
+	DeletePublicFaceName(ctx context.Context, userID int64) error
+	DeleteRecognizedUsersOfPhoto(ctx context.Context, photoID int64) error
+	DeleteRecognizedUsersOfUser(ctx context.Context, userID int64) error
+	DeleteSessionWithToken(ctx context.Context, sessionToken string) error
+	DeleteSessionsOfUser(ctx context.Context, userID int64) error
+	DeleteUser(ctx context.Context, userID int64) error
+	DeleteUserFoldersOfUser(ctx context.Context, userID int64) error
+	DetachUserFoldersOfUser(ctx context.Context, userID int64) error
+	FailJob(ctx context.Context, arg FailJobParams) (int64, error)
+	GetEvent(ctx context.Context, eventID int64) (Event, error)
+	GetEventStorageUsage(ctx context.Context) ([]GetEventStorageUsageRow, error)
+	GetEventWithNameAndParent(ctx context.Context, arg GetEventWithNameAndParentParams) (Event, error)
+	GetEvents(ctx context.Context) ([]GetEventsRow, error)
+	GetFaceConsent(ctx context.Context, userID int64) (FaceConsent, error)
+	GetFaceConsentChanges(ctx context.Context, userID sql.NullInt64) ([]FaceConsentChange, error)
+	GetFaceGroup(ctx context.Context, faceGroupID int64) (FaceGroup, error)
+	GetFaceGroupClaims(ctx context.Context, faceGroupID int64) ([]GetFaceGroupClaimsRow, error)
+	GetFaceGroupClaimsOfUser(ctx context.Context, userID int64) ([]FaceGroupClaim, error)
+	GetFaceGroups(ctx context.Context, arg GetFaceGroupsParams) ([]GetFaceGroupsRow, error)
+	GetFaceGroupsOfUser(ctx context.Context, userID sql.NullInt64) ([]FaceGroup, error)
+	GetFacesOfPhoto(ctx context.Context, photoID int64) ([]GetFacesOfPhotoRow, error)
+	GetGlobalStorageUsage(ctx context.Context) ([]GetGlobalStorageUsageRow, error)
+	GetImageFace(ctx context.Context, imageFaceID int64) (ImageFace, error)
+	GetImageFaceSamples(ctx context.Context, model string) ([]GetImageFaceSamplesRow, error)
+	GetImageFacesOfFaceGroup(ctx context.Context, faceGroupID int64) ([]ImageFace, error)
+	GetImageFacesOfPhoto(ctx context.Context, photoID int64) ([]ImageFace, error)
+	GetJobs(ctx context.Context, arg GetJobsParams) ([]Job, error)
+	GetMissingPhotoIDs(ctx context.Context) ([]int64, error)
+	// No row lock is needed: write transactions are immediate, so they run one at a time.
+	GetNextJob(ctx context.Context) (Job, error)
+	GetPendingDuplicateCandidates(ctx context.Context) ([]DuplicateCandidate, error)
+	GetPerceptualHashes(ctx context.Context, fileHash string) ([]GetPerceptualHashesRow, error)
+	GetPerceptualHashesInBands(ctx context.Context, arg GetPerceptualHashesInBandsParams) ([]GetPerceptualHashesInBandsRow, error)
+	GetPhoto(ctx context.Context, photoID int64) (Photo, error)
+	GetPhotoIDsOfFaceGroup(ctx context.Context, faceGroupID int64) ([]int64, error)
+	GetPhotoMetadata(ctx context.Context, photoID int64) (PhotoMetadatum, error)
+	GetPhotoPaths(ctx context.Context) ([]GetPhotoPathsRow, error)
+	GetPhotoWithEventAndHash(ctx context.Context, arg GetPhotoWithEventAndHashParams) (Photo, error)
+	GetPhotoWithMetadataPolicy(ctx context.Context, photoID int64) (GetPhotoWithMetadataPolicyRow, error)
+	GetPhotosByEventID(ctx context.Context, eventID int64) ([]Photo, error)
+	GetPhotosSortedByDate(ctx context.Context) ([]Photo, error)
+	GetPhotosWithHash(ctx context.Context, fileHash string) ([]Photo, error)
+	GetPhotosWithoutFaceScan(ctx context.Context, model string) ([]int64, error)
+	GetPublicFaceName(ctx context.Context, userID int64) (time.Time, error)
+	GetRecognizedPhotos(ctx context.Context, userID int64) ([]Photo, error)
+	GetSessionWithToken(ctx context.Context, sessionToken string) (Session, error)
+	GetSessionsOfUser(ctx context.Context, userID int64) ([]Session, error)
+	GetStorageUsage(ctx context.Context, arg GetStorageUsageParams) (int64, error)
+	GetSubEvents(ctx context.Context, parentEventID sql.NullInt64) ([]Event, error)
+	GetUnlabeledFaceGroupIDs(ctx context.Context) ([]int64, error)
+	GetUploaderStorageUsage(ctx context.Context) ([]GetUploaderStorageUsageRow, error)
+	GetUser(ctx context.Context, userID int64) (User, error)
+	GetUserFoldersOfUser(ctx context.Context, userID int64) ([]UserFolder, error)
+	GetUserWithEmail(ctx context.Context, email string) (User, error)
+	GetUserWithSession(ctx context.Context, sessionToken string) (User, error)
+	IsEventUnlocked(ctx context.Context, arg IsEventUnlockedParams) (bool, error)
+	LeaseJob(ctx context.Context, arg LeaseJobParams) error
+	MergeFaceGroup(ctx context.Context, arg MergeFaceGroupParams) error
+	ReserveStorageUsage(ctx context.Context, arg ReserveStorageUsageParams) (int64, error)
+	RetryJob(ctx context.Context, jobID int64) (int64, error)
+	SaveFaceConsent(ctx context.Context, arg SaveFaceConsentParams) error
+	SaveFaceScan(ctx context.Context, arg SaveFaceScanParams) error
+	UpdateDuplicateCandidateStatus(ctx context.Context, arg UpdateDuplicateCandidateStatusParams) error
+	UpdateEvent(ctx context.Context, arg UpdateEventParams) error
+	UpdateEventHidden(ctx context.Context, arg UpdateEventHiddenParams) error
+	UpdateEventMetadataPolicy(ctx context.Context, arg UpdateEventMetadataPolicyParams) error
+	UpdateEventPasswordHash(ctx context.Context, arg UpdateEventPasswordHashParams) error
+	UpdateFaceGroupUser(ctx context.Context, arg UpdateFaceGroupUserParams) error
+	UpdateImageFaceGroup(ctx context.Context, arg UpdateImageFaceGroupParams) error
+	UpdatePhotoFileSize(ctx context.Context, arg UpdatePhotoFileSizeParams) (int64, error)
+	UpdatePhotoHidden(ctx context.Context, arg UpdatePhotoHiddenParams) error
+	UpdatePhotoPath(ctx context.Context, arg UpdatePhotoPathParams) error
+}
+
+var _ Querier = (*Queries)(nil)
@@ -0,0 +1,1213 @@
+// Code generated by querygen. DO NOT EDIT.
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"photos/pkg/db/query"
+	sqlitequery "photos/pkg/db/sqlite/internal/query"
+)
+
+// queries runs the SQLite queries, converting their parameters and results from and to the types of the query
+// package.
+type queries struct {
+	q *sqlitequery.Queries
+}
+
+func (q queries) AddStorageUsage(ctx context.Context, arg query.AddStorageUsageParams) error {
+	return q.q.AddStorageUsage(ctx, fromQueryAddStorageUsageParams(arg))
+}
+
+func (q queries) AnonymizeFaceConsentChangesOfUser(ctx context.Context, userID sql.NullInt32) error {
+	return q.q.AnonymizeFaceConsentChangesOfUser(ctx, sql.NullInt64{Int64: int64(userID.Int32), Valid: userID.Valid})
+}
+
+func (q queries) AttemptCreatingUser(ctx context.Context, arg query.AttemptCreatingUserParams) (int64, error) {
+	return q.q.AttemptCreatingUser(ctx, fromQueryAttemptCreatingUserParams(arg))
+}
+
+func (q queries) BackfillPerceptualHashBands(ctx context.Context) (int64, error) {
+	return q.q.BackfillPerceptualHashBands(ctx)
+}
+
+func (q queries) CompleteJob(ctx context.Context, arg query.CompleteJobParams) (int64, error) {
+	return q.q.CompleteJob(ctx, fromQueryCompleteJobParams(arg))
+}
+
+func (q queries) CountJobs(ctx context.Context) ([]query.CountJobsRow, error) {
+	rows, err := q.q.CountJobs(ctx)
+	var items []query.CountJobsRow
+	for _, r := range rows {
+		items = append(items, toQueryCountJobsRow(r))
+	}
+	return items, err
+}
+
+func (q queries) CountPhotosWithPath(ctx context.Context, pathToPhoto string) (int64, error) {
+	return q.q.CountPhotosWithPath(ctx, pathToPhoto)
+}
+
+func (q queries) CountSessionsSince(ctx context.Context, creationDate time.Time) (int64, error) {
+	return q.q.CountSessionsSince(ctx, creationDate)
+}
+
+func (q queries) CountUnsizedPhotosWithPath(ctx context.Context, pathToPhoto string) (int64, error) {
+	return q.q.CountUnsizedPhotosWithPath(ctx, pathToPhoto)
+}
+
+func (q queries) CreateDuplicateCandidate(ctx context.Context, arg query.CreateDuplicateCandidateParams) error {
+	return q.q.CreateDuplicateCandidate(ctx, fromQueryCreateDuplicateCandidateParams(arg))
+}
+
+func (q queries) CreateEvent(ctx context.Context, arg query.CreateEventParams) (int64, error) {
+	return q.q.CreateEvent(ctx, fromQueryCreateEventParams(arg))
+}
+
+func (q queries) CreateFaceConsentChange(ctx context.Context, arg query.CreateFaceConsentChangeParams) error {
+	return q.q.CreateFaceConsentChange(ctx, fromQueryCreateFaceConsentChangeParams(arg))
+}
+
+func (q queries) CreateFaceGroup(ctx context.Context) (int64, error) {
+	return q.q.CreateFaceGroup(ctx)
+}
+
+func (q queries) CreateFaceGroupClaim(ctx context.Context, arg query.CreateFaceGroupClaimParams) error {
+	return q.q.CreateFaceGroupClaim(ctx, fromQueryCreateFaceGroupClaimParams(arg))
+}
+
+func (q queries) CreateImageFace(ctx context.Context, arg query.CreateImageFaceParams) (int64, error) {
+	return q.q.CreateImageFace(ctx, fromQueryCreateImageFaceParams(arg))
+}
+
+func (q queries) CreateJob(ctx context.Context, arg query.CreateJobParams) error {
+	return q.q.CreateJob(ctx, fromQueryCreateJobParams(arg))
+}
+
+func (q queries) CreateMissingPhoto(ctx context.Context, photoID uint32) error {
+	return q.q.CreateMissingPhoto(ctx, int64(photoID))
+}
+
+func (q queries) CreatePerceptualHashBands(ctx context.Context, photoID uint32) error {
+	return q.q.CreatePerceptualHashBands(ctx, int64(photoID))
+}
+
+func (q queries) CreatePhoto(ctx context.Context, arg query.CreatePhotoParams) (int64, error) {
+	return q.q.CreatePhoto(ctx, fromQueryCreatePhotoParams(arg))
+}
+
+func (q queries) CreatePhotoMetadata(ctx context.Context, arg query.CreatePhotoMetadataParams) error {
+	return q.q.CreatePhotoMetadata(ctx, fromQueryCreatePhotoMetadataParams(arg))
+}
+
+func (q queries) CreatePublicFaceName(ctx context.Context, userID uint32) error {
+	return q.q.CreatePublicFaceName(ctx, int64(userID))
+}
+
+func (q queries) CreateRecognizeJob(ctx context.Context) error {
+	return q.q.CreateRecognizeJob(ctx)
+}
+
+func (q queries) CreateRecognizedUsersOfPhoto(ctx context.Context, photoID uint32) error {
+	return q.q.CreateRecognizedUsersOfPhoto(ctx, int64(photoID))
+}
+
+func (q queries) CreateSession(ctx context.Context, arg query.CreateSessionParams) error {
+	return q.q.CreateSession(ctx, fromQueryCreateSessionParams(arg))
+}
+
+func (q queries) CreateUnlockedEvent(ctx context.Context, arg query.CreateUnlockedEventParams) error {
+	return q.q.CreateUnlockedEvent(ctx, fromQueryCreateUnlockedEventParams(arg))
+}
+
+func (q queries) DeleteDoneJobs(ctx context.Context, retentionSeconds int64) (int64, error) {
+	return q.q.DeleteDoneJobs(ctx, retentionSeconds)
+}
+
+func (q queries) DeleteEvent(ctx context.Context, eventID uint32) error {
+	return q.q.DeleteEvent(ctx, int64(eventID))
+}
+
+func (q queries) DeleteFaceGroupClaim(ctx context.Context, arg query.DeleteFaceGroupClaimParams) (int64, error) {
+	return q.q.DeleteFaceGroupClaim(ctx, fromQueryDeleteFaceGroupClaimParams(arg))
+}
+
+func (q queries) DeleteFaceGroupClaims(ctx context.Context, faceGroupID uint32) error {
+	return q.q.DeleteFaceGroupClaims(ctx, int64(faceGroupID))
+}
+
+func (q queries) DeleteFaceGroupClaimsOfUser(ctx context.Context, userID uint32) error {
+	return q.q.DeleteFaceGroupClaimsOfUser(ctx, int64(userID))
+}
+
+func (q queries) DeleteFaceGroupIfEmpty(ctx context.Context, faceGroupID uint32) (int64, error) {
+	return q.q.DeleteFaceGroupIfEmpty(ctx, int64(faceGroupID))
+}
+
+func (q queries) DeleteFaceGroupsOfUser(ctx context.Context, userID sql.NullInt32) error {
+	return q.q.DeleteFaceGroupsOfUser(ctx, sql.NullInt64{Int64: int64(userID.Int32), Valid: userID.Valid})
+}
+
+func (q queries) DeleteImageFacesOfPhoto(ctx context.Context, photoID uint32) error {
+	return q.q.DeleteImageFacesOfPhoto(ctx, int64(photoID))
+}
+
+func (q queries) DeleteImageFacesOfUser(ctx context.Context, userID sql.NullInt32) error {
+	return q.q.DeleteImageFacesOfUser(ctx, sql.NullInt64{Int64: int64(userID.Int32), Valid: userID.Valid})
+}
+
+func (q queries) DeleteMissingPhoto(ctx context.Context, photoID uint32) error {
+	return q.q.DeleteMissingPhoto(ctx, int64(photoID))
+}
+
+func (q queries) DeletePhoto(ctx context.Context, photoID uint32) error {
+	return q.q.DeletePhoto(ctx, int64(photoID))
+}
+
+func (q queries) DeletePhotoMetadata(ctx context.Context, photoID uint32) error {
+	return q.q.DeletePhotoMetadata(ctx, int64(photoID))
+}
+
+func (q queries) DeletePublicFaceName(ctx context.Context, userID uint32) error {
+	return q.q.DeletePublicFaceName(ctx, int64(userID))
+}
+
+func (q queries) DeleteRecognizedUsersOfPhoto(ctx context.Context, photoID uint32) error {
+	return q.q.DeleteRecognizedUsersOfPhoto(ctx, int64(photoID))
+}
+
+func (q queries) DeleteRecognizedUsersOfUser(ctx context.Context, userID uint32) error {
+	return q.q.DeleteRecognizedUsersOfUser(ctx, int64(userID))
+}
+
+func (q queries) DeleteSessionWithToken(ctx context.Context, sessionToken string) error {
+	return q.q.DeleteSessionWithToken(ctx, sessionToken)
+}
+
+func (q queries) DeleteSessionsOfUser(ctx context.Context, userID uint32) error {
+	return q.q.DeleteSessionsOfUser(ctx, int64(userID))
+}
+
+func (q queries) DeleteUser(ctx context.Context, userID uint32) error {
+	return q.q.DeleteUser(ctx, int64(userID))
+}
+
+func (q queries) DeleteUserFoldersOfUser(ctx context.Context, userID uint32) error {
+	return q.q.DeleteUserFoldersOfUser(ctx, int64(userID))
+}
+
+func (q queries) DetachUserFoldersOfUser(ctx context.Context, userID uint32) error {
+	return q.q.DetachUserFoldersOfUser(ctx, int64(userID))
+}
+
+func (q queries) FailJob(ctx context.Context, arg query.FailJobParams) (int64, error) {
+	return q.q.FailJob(ctx, fromQueryFailJobParams(arg))
+}
+
+func (q queries) GetEvent(ctx context.Context, eventID uint32) (query.Event, error) {
+	r, err := q.q.GetEvent(ctx, int64(eventID))
+	return toQueryEvent(r), err
+}
+
+func (q queries) GetEventStorageUsage(ctx context.Context) ([]query.GetEventStorageUsageRow, error) {
+	rows, err := q.q.GetEventStorageUsage(ctx)
+	var items []query.GetEventStorageUsageRow
+	for _, r := range rows {
+		items = append(items, toQueryGetEventStorageUsageRow(r))
+	}
+	return items, err
+}
+
+func (q queries) GetEventWithNameAndParent(ctx context.Context, arg query.GetEventWithNameAndParentParams) (query.Event, error) {
+	r, err := q.q.GetEventWithNameAndParent(ctx, fromQueryGetEventWithNameAndParentParams(arg))
+	return toQueryEvent(r), err
+}
+
+func (q queries) GetEvents(ctx context.Context) ([]query.GetEventsRow, error) {
+	rows, err := q.q.GetEvents(ctx)
+	var items []query.GetEventsRow
+	for _, r := range rows {
+		items = append(items, toQueryGetEventsRow(r))
+	}
+	return items, err
+}
+
+func (q queries) GetFaceConsent(ctx context.Context, userID uint32) (query.FaceConsent, error) {
+	r, err := q.q.GetFaceConsent(ctx, int64(userID))
+	return toQueryFaceConsent(r), err
+}
+
+func (q queries) GetFaceConsentChanges(ctx context.Context, userID sql.NullInt32) ([]query.FaceConsentChange, error) {
+	rows, err := q.q.GetFaceConsentChanges(ctx, sql.NullInt64{Int64: int64(userID.Int32), Valid: userID.Valid})
+	var items []query.FaceConsentChange
+	for _, r := range rows {
+		items = append(items, toQueryFaceConsentChange(r))
+	}
+	return items, err
+}
+
+func (q queries) GetFaceGroup(ctx context.Context, faceGroupID uint32) (query.FaceGroup, error) {
+	r, err := q.q.GetFaceGroup(ctx, int64(faceGroupID))
+	return toQueryFaceGroup(r), err
+}
+
+func (q queries) GetFaceGroupClaims(ctx context.Context, faceGroupID uint32) ([]query.GetFaceGroupClaimsRow, error) {
+	rows, err := q.q.GetFaceGroupClaims(ctx, int64(faceGroupID))
+	var items []query.GetFaceGroupClaimsRow
+	for _, r := range rows {
+		items = append(items, toQueryGetFaceGroupClaimsRow(r))
+	}
+	return items, err
+}
+
+func (q queries) GetFaceGroupClaimsOfUser(ctx context.Context, userID uint32) ([]query.FaceGroupClaim, error) {
+	rows, err := q.q.GetFaceGroupClaimsOfUser(ctx, int64(userID))
+	var items []query.FaceGroupClaim
+	for _, r := range rows {
+		items = append(items, toQueryFaceGroupClaim(r))
+	}
+	return items, err
+}
+
+func (q queries) GetFaceGroups(ctx context.Context, arg query.GetFaceGroupsParams) ([]query.GetFaceGroupsRow, error) {
+	rows, err := q.q.GetFaceGroups(ctx, fromQueryGetFaceGroupsParams(arg))
+	var items []query.GetFaceGroupsRow
+	for _, r := range rows {
+		items = append(items, toQueryGetFaceGroupsRow(r))
+	}
+	return items, err
+}
+
+func (q queries) GetFaceGroupsOfUser(ctx context.Context, userID sql.NullInt32) ([]query.FaceGroup, error) {
+	rows, err := q.q.GetFaceGroupsOfUser(ctx, sql.NullInt64{Int64: int64(userID.Int32), Valid: userID.Valid})
+	var items []query.FaceGroup
+	for _, r := range rows {
+		items = append(items, toQueryFaceGroup(r))
+	}
+	return items, err
+}
+
+func (q queries) GetFacesOfPhoto(ctx context.Context, photoID uint32) ([]query.GetFacesOfPhotoRow, error) {
+	rows, err := q.q.GetFacesOfPhoto(ctx, int64(photoID))
+	var items []query.GetFacesOfPhotoRow
+	for _, r := range rows {
+		items = append(items, toQueryGetFacesOfPhotoRow(r))
+	}
+	return items, err
+}
+
+func (q queries) GetGlobalStorageUsage(ctx context.Context) ([]query.GetGlobalStorageUsageRow, error) {
+	rows, err := q.q.GetGlobalStorageUsage(ctx)
+	var items []query.GetGlobalStorageUsageRow
+	for _, r := range rows {
+		items = append(items, toQueryGetGlobalStorageUsageRow(r))
+	}
+	return items, err
+}
+
+func (q queries) GetImageFace(ctx context.Context, imageFaceID uint32) (query.ImageFace, error) {
+	r, err := q.q.GetImageFace(ctx, int64(imageFaceID))
+	return toQueryImageFace(r), err
+}
+
+func (q queries) GetImageFaceSamples(ctx context.Context, model string) ([]query.GetImageFaceSamplesRow, error) {
+	rows, err := q.q.GetImageFaceSamples(ctx, model)
+	var items []query.GetImageFaceSamplesRow
+	for _, r := range rows {
+		items = append(items, toQueryGetImageFaceSamplesRow(r))
+	}
+	return items, err
+}
+
+func (q queries) GetImageFacesOfFaceGroup(ctx context.Context, faceGroupID uint32) ([]query.ImageFace, error) {
+	rows, err := q.q.GetImageFacesOfFaceGroup(ctx, int64(faceGroupID))
+	var items []query.ImageFace
+	for _, r := range rows {
+		items = append(items, toQueryImageFace(r))
+	}
+	return items, err
+}
+
+func (q queries) GetImageFacesOfPhoto(ctx context.Context, photoID uint32) ([]query.ImageFace, error) {
+	rows, err := q.q.GetImageFacesOfPhoto(ctx, int64(photoID))
+	var items []query.ImageFace
+	for _, r := range rows {
+		items = append(items, toQueryImageFace(r))
+	}
+	return items, err
+}
+
+func (q queries) GetJobs(ctx context.Context, arg query.GetJobsParams) ([]query.Job, error) {
+	rows, err := q.q.GetJobs(ctx, fromQueryGetJobsParams(arg))
+	var items []query.Job
+	for _, r := range rows {
+		items = append(items, toQueryJob(r))
+	}
+	return items, err
+}
+
+func (q queries) GetMissingPhotoIDs(ctx context.Context) ([]uint32, error) {
+	rows, err := q.q.GetMissingPhotoIDs(ctx)
+	var items []uint32
+	for _, r := range rows {
+		items = append(items, uint32(r))
+	}
+	return items, err
+}
+
+func (q queries) GetNextJob(ctx context.Context) (query.Job, error) {
+	r, err := q.q.GetNextJob(ctx)
+	return toQueryJob(r), err
+}
+
+func (q queries) GetPendingDuplicateCandidates(ctx context.Context) ([]query.DuplicateCandidate, error) {
+	rows, err := q.q.GetPendingDuplicateCandidates(ctx)
+	var items []query.DuplicateCandidate
+	for _, r := range rows {
+		items = append(items, toQueryDuplicateCandidate(r))
+	}
+	return items, err
+}
+
+func (q queries) GetPerceptualHashes(ctx context.Context, fileHash string) ([]query.GetPerceptualHashesRow, error) {
+	rows, err := q.q.GetPerceptualHashes(ctx, fileHash)
+	var items []query.GetPerceptualHashesRow
+	for _, r := range rows {
+		items = append(items, toQueryGetPerceptualHashesRow(r))
+	}
+	return items, err
+}
+
+func (q queries) GetPerceptualHashesInBands(ctx context.Context, arg query.GetPerceptualHashesInBandsParams) ([]query.GetPerceptualHashesInBandsRow, error) {
+	rows, err := q.q.GetPerceptualHashesInBands(ctx, fromQueryGetPerceptualHashesInBandsParams(arg))
+	var items []query.GetPerceptualHashesInBandsRow
+	for _, r := range rows {
+		items = append(items, toQueryGetPerceptualHashesInBandsRow(r))
+	}
+	return items, err
+}
+
+func (q queries) GetPhoto(ctx context.Context, photoID uint32) (query.Photo, error) {
+	r, err := q.q.GetPhoto(ctx, int64(photoID))
+	return toQueryPhoto(r), err
+}
+
+func (q queries) GetPhotoIDsOfFaceGroup(ctx context.Context, faceGroupID uint32) ([]uint32, error) {
+	rows, err := q.q.GetPhotoIDsOfFaceGroup(ctx, int64(faceGroupID))
+	var items []uint32
+	for _, r := range rows {
+		items = append(items, uint32(r))
+	}
+	return items, err
+}
+
+func (q queries) GetPhotoMetadata(ctx context.Context, photoID uint32) (query.PhotoMetadatum, error) {
+	r, err := q.q.GetPhotoMetadata(ctx, int64(photoID))
+	return toQueryPhotoMetadatum(r), err
+}
+
+func (q queries) GetPhotoPaths(ctx context.Context) ([]query.GetPhotoPathsRow, error) {
+	rows, err := q.q.GetPhotoPaths(ctx)
+	var items []query.GetPhotoPathsRow
+	for _, r := range rows {
+		items = append(items, toQueryGetPhotoPathsRow(r))
+	}
+	return items, err
+}
+
+func (q queries) GetPhotoWithEventAndHash(ctx context.Context, arg query.GetPhotoWithEventAndHashParams) (query.Photo, error) {
+	r, err := q.q.GetPhotoWithEventAndHash(ctx, fromQueryGetPhotoWithEventAndHashParams(arg))
+	return toQueryPhoto(r), err
+}
+
+func (q queries) GetPhotoWithMetadataPolicy(ctx context.Context, photoID uint32) (query.GetPhotoWithMetadataPolicyRow, error) {
+	r, err := q.q.GetPhotoWithMetadataPolicy(ctx, int64(photoID))
+	return toQueryGetPhotoWithMetadataPolicyRow(r), err
+}
+
+func (q queries) GetPhotosByEventID(ctx context.Context, eventID uint32) ([]query.Photo, error) {
+	rows, err := q.q.GetPhotosByEventID(ctx, int64(eventID))
+	var items []query.Photo
+	for _, r := range rows {
+		items = append(items, toQueryPhoto(r))
+	}
+	return items, err
+}
+
+func (q queries) GetPhotosSortedByDate(ctx context.Context) ([]query.Photo, error) {
+	rows, err := q.q.GetPhotosSortedByDate(ctx)
+	var items []query.Photo
+	for _, r := range rows {
+		items = append(items, toQueryPhoto(r))
+	}
+	return items, err
+}
+
+func (q queries) GetPhotosWithHash(ctx context.Context, fileHash string) ([]query.Photo, error) {
+	rows, err := q.q.GetPhotosWithHash(ctx, fileHash)
+	var items []query.Photo
+	for _, r := range rows {
+		items = append(items, toQueryPhoto(r))
+	}
+	return items, err
+}
+
+func (q queries) GetPhotosWithoutFaceScan(ctx context.Context, model string) ([]uint32, error) {
+	rows, err := q.q.GetPhotosWithoutFaceScan(ctx, model)
+	var items []uint32
+	for _, r := range rows {
+		items = append(items, uint32(r))
+	}
+	return items, err
+}
+
+func (q queries) GetPublicFaceName(ctx context.Context, userID uint32) (time.Time, error) {
+	return q.q.GetPublicFaceName(ctx, int64(userID))
+}
+
+func (q queries) GetRecognizedPhotos(ctx context.Context, userID uint32) ([]query.Photo, error) {
+	rows, err := q.q.GetRecognizedPhotos(ctx, int64(userID))
+	var items []query.Photo
+	for _, r := range rows {
+		items = append(items, toQueryPhoto(r))
+	}
+	return items, err
+}
+
+func (q queries) GetSessionWithToken(ctx context.Context, sessionToken string) (query.Session, error) {
+	r, err := q.q.GetSessionWithToken(ctx, sessionToken)
+	return toQuerySession(r), err
+}
+
+func (q queries) GetSessionsOfUser(ctx context.Context, userID uint32) ([]query.Session, error) {
+	rows, err := q.q.GetSessionsOfUser(ctx, int64(userID))
+	var items []query.Session
+	for _, r := range rows {
+		items = append(items, toQuerySession(r))
+	}
+	return items, err
+}
+
+func (q queries) GetStorageUsage(ctx context.Context, arg query.GetStorageUsageParams) (int64, error) {
+	return q.q.GetStorageUsage(ctx, fromQueryGetStorageUsageParams(arg))
+}
+
+func (q queries) GetSubEvents(ctx context.Context, parentEventID sql.NullInt32) ([]query.Event, error) {
+	rows, err := q.q.GetSubEvents(ctx, sql.NullInt64{Int64: int64(parentEventID.Int32), Valid: parentEventID.Valid})
+	var items []query.Event
+	for _, r := range rows {
+		items = append(items, toQueryEvent(r))
+	}
+	return items, err
+}
+
+func (q queries) GetUnlabeledFaceGroupIDs(ctx context.Context) ([]uint32, error) {
+	rows, err := q.q.GetUnlabeledFaceGroupIDs(ctx)
+	var items []uint32
+	for _, r := range rows {
+		items = append(items, uint32(r))
+	}
+	return items, err
+}
+
+func (q queries) GetUploaderStorageUsage(ctx context.Context) ([]query.GetUploaderStorageUsageRow, error) {
+	rows, err := q.q.GetUploaderStorageUsage(ctx)
+	var items []query.GetUploaderStorageUsageRow
+	for _, r := range rows {
+		items = append(items, toQueryGetUploaderStorageUsageRow(r))
+	}
+	return items, err
+}
+
+func (q queries) GetUser(ctx context.Context, userID uint32) (query.User, error) {
+	r, err := q.q.GetUser(ctx, int64(userID))
+	return toQueryUser(r), err
+}
+
+func (q queries) GetUserFoldersOfUser(ctx context.Context, userID uint32) ([]query.UserFolder, error) {
+	rows, err := q.q.GetUserFoldersOfUser(ctx, int64(userID))
+	var items []query.UserFolder
+	for _, r := range rows {
+		items = append(items, toQueryUserFolder(r))
+	}
+	return items, err
+}
+
+func (q queries) GetUserWithEmail(ctx context.Context, email string) (query.User, error) {
+	r, err := q.q.GetUserWithEmail(ctx, email)
+	return toQueryUser(r), err
+}
+
+func (q queries) GetUserWithSession(ctx context.Context, sessionToken string) (query.User, error) {
+	r, err := q.q.GetUserWithSession(ctx, sessionToken)
+	return toQueryUser(r), err
+}
+
+func (q queries) IsEventUnlocked(ctx context.Context, arg query.IsEventUnlockedParams) (bool, error) {
+	return q.q.IsEventUnlocked(ctx, fromQueryIsEventUnlockedParams(arg))
+}
+
+func (q queries) LeaseJob(ctx context.Context, arg query.LeaseJobParams) error {
+	return q.q.LeaseJob(ctx, fromQueryLeaseJobParams(arg))
+}
+
+func (q queries) MergeFaceGroup(ctx context.Context, arg query.MergeFaceGroupParams) error {
+	return q.q.MergeFaceGroup(ctx, fromQueryMergeFaceGroupParams(arg))
+}
+
+func (q queries) ReserveStorageUsage(ctx context.Context, arg query.ReserveStorageUsageParams) (int64, error) {
+	return q.q.ReserveStorageUsage(ctx, fromQueryReserveStorageUsageParams(arg))
+}
+
+func (q queries) RetryJob(ctx context.Context, jobID uint32) (int64, error) {
+	return q.q.RetryJob(ctx, int64(jobID))
+}
+
+func (q queries) SaveFaceConsent(ctx context.Context, arg query.SaveFaceConsentParams) error {
+	return q.q.SaveFaceConsent(ctx, fromQuerySaveFaceConsentParams(arg))
+}
+
+func (q queries) SaveFaceScan(ctx context.Context, arg query.SaveFaceScanParams) error {
+	return q.q.SaveFaceScan(ctx, fromQuerySaveFaceScanParams(arg))
+}
+
+func (q queries) UpdateDuplicateCandidateStatus(ctx context.Context, arg query.UpdateDuplicateCandidateStatusParams) error {
+	return q.q.UpdateDuplicateCandidateStatus(ctx, fromQueryUpdateDuplicateCandidateStatusParams(arg))
+}
+
+func (q queries) UpdateEvent(ctx context.Context, arg query.UpdateEventParams) error {
+	return q.q.UpdateEvent(ctx, fromQueryUpdateEventParams(arg))
+}
+
+func (q queries) UpdateEventHidden(ctx context.Context, arg query.UpdateEventHiddenParams) error {
+	return q.q.UpdateEventHidden(ctx, fromQueryUpdateEventHiddenParams(arg))
+}
+
+func (q queries) UpdateEventMetadataPolicy(ctx context.Context, arg query.UpdateEventMetadataPolicyParams) error {
+	return q.q.UpdateEventMetadataPolicy(ctx, fromQueryUpdateEventMetadataPolicyParams(arg))
+}
+
+func (q queries) UpdateEventPasswordHash(ctx context.Context, arg query.UpdateEventPasswordHashParams) error {
+	return q.q.UpdateEventPasswordHash(ctx, fromQueryUpdateEventPasswordHashParams(arg))
+}
+
+func (q queries) UpdateFaceGroupUser(ctx context.Context, arg query.UpdateFaceGroupUserParams) error {
+	return q.q.UpdateFaceGroupUser(ctx, fromQueryUpdateFaceGroupUserParams(arg))
+}
+
+func (q queries) UpdateImageFaceGroup(ctx context.Context, arg query.UpdateImageFaceGroupParams) error {
+	return q.q.UpdateImageFaceGroup(ctx, fromQueryUpdateImageFaceGroupParams(arg))
+}
+
+func (q queries) UpdatePhotoFileSize(ctx context.Context, arg query.UpdatePhotoFileSizeParams) (int64, error) {
+	return q.q.UpdatePhotoFileSize(ctx, fromQueryUpdatePhotoFileSizeParams(arg))
+}
+
+func (q queries) UpdatePhotoHidden(ctx context.Context, arg query.UpdatePhotoHiddenParams) error {
+	return q.q.UpdatePhotoHidden(ctx, fromQueryUpdatePhotoHiddenParams(arg))
+}
+
+func (q queries) UpdatePhotoPath(ctx context.Context, arg query.UpdatePhotoPathParams) error {
+	return q.q.UpdatePhotoPath(ctx, fromQueryUpdatePhotoPathParams(arg))
+}
+
+func fromQueryAddStorageUsageParams(v query.AddStorageUsageParams) sqlitequery.AddStorageUsageParams {
+	return sqlitequery.AddStorageUsageParams{
+		Scope:   string(v.Scope),
+		ScopeID: int64(v.ScopeID),
+		Kind:    string(v.Kind),
+		Bytes:   v.Bytes,
+		Files:   int64(v.Files),
+	}
+}
+
+func fromQueryAttemptCreatingUserParams(v query.AttemptCreatingUserParams) sqlitequery.AttemptCreatingUserParams {
+	return sqlitequery.AttemptCreatingUserParams{
+		Email:            v.Email,
+		FullName:         v.FullName,
+		BusinessCategory: string(v.BusinessCategory),
+		DepartmentNumber: v.DepartmentNumber,
+	}
+}
+
+func fromQueryCompleteJobParams(v query.CompleteJobParams) sqlitequery.CompleteJobParams {
+	return sqlitequery.CompleteJobParams{
+		JobID:    int64(v.JobID),
+		Attempts: int64(v.Attempts),
+	}
+}
+
+func toQueryCountJobsRow(v sqlitequery.CountJobsRow) query.CountJobsRow {
+	return query.CountJobsRow{
+		Status: query.JobsStatus(v.Status),
+		Jobs:   v.Jobs,
+	}
+}
+
+func fromQueryCreateDuplicateCandidateParams(v query.CreateDuplicateCandidateParams) sqlitequery.CreateDuplicateCandidateParams {
+	return sqlitequery.CreateDuplicateCandidateParams{
+		PhotoID:            int64(v.PhotoID),
+		DuplicateOfPhotoID: int64(v.DuplicateOfPhotoID),
+		Distance:           int64(v.Distance),
+	}
+}
+
+func fromQueryCreateEventParams(v query.CreateEventParams) sqlitequery.CreateEventParams {
+	return sqlitequery.CreateEventParams{
+		Name:          v.Name,
+		Description:   v.Description,
+		EventDate:     v.EventDate,
+		ParentEventID: sql.NullInt64{Int64: int64(v.ParentEventID.Int32), Valid: v.ParentEventID.Valid},
+	}
+}
+
+func fromQueryCreateFaceConsentChangeParams(v query.CreateFaceConsentChangeParams) sqlitequery.CreateFaceConsentChangeParams {
+	return sqlitequery.CreateFaceConsentChangeParams{
+		UserID:          sql.NullInt64{Int64: int64(v.UserID.Int32), Valid: v.UserID.Valid},
+		Consent:         string(v.Consent),
+		PreviousConsent: string(v.PreviousConsent),
+		ChangedBy:       sql.NullInt64{Int64: int64(v.ChangedBy.Int32), Valid: v.ChangedBy.Valid},
+		Ip:              v.Ip,
+	}
+}
+
+func fromQueryCreateFaceGroupClaimParams(v query.CreateFaceGroupClaimParams) sqlitequery.CreateFaceGroupClaimParams {
+	return sqlitequery.CreateFaceGroupClaimParams{
+		FaceGroupID: int64(v.FaceGroupID),
+		UserID:      int64(v.UserID),
+	}
+}
+
+func fromQueryCreateImageFaceParams(v query.CreateImageFaceParams) sqlitequery.CreateImageFaceParams {
+	return sqlitequery.CreateImageFaceParams{
+		PhotoID:     int64(v.PhotoID),
+		FaceGroupID: int64(v.FaceGroupID),
+		Model:       v.Model,
+		Descriptor:  v.Descriptor,
+		MinX:        v.MinX,
+		MinY:        v.MinY,
+		MaxX:        v.MaxX,
+		MaxY:        v.MaxY,
+	}
+}
+
+func fromQueryCreateJobParams(v query.CreateJobParams) sqlitequery.CreateJobParams {
+	return sqlitequery.CreateJobParams{
+		Kind:    string(v.Kind),
+		PhotoID: sql.NullInt64{Int64: int64(v.PhotoID.Int32), Valid: v.PhotoID.Valid},
+	}
+}
+
+func fromQueryCreatePhotoParams(v query.CreatePhotoParams) sqlitequery.CreatePhotoParams {
+	return sqlitequery.CreatePhotoParams{
+		PathToPhoto:    v.PathToPhoto,
+		FileHash:       v.FileHash,
+		PerceptualHash: v.PerceptualHash,
+		FileSize:       v.FileSize,
+		EventID:        int64(v.EventID),
+		UploaderID:     sql.NullInt64{Int64: int64(v.UploaderID.Int32), Valid: v.UploaderID.Valid},
+	}
+}
+
+func fromQueryCreatePhotoMetadataParams(v query.CreatePhotoMetadataParams) sqlitequery.CreatePhotoMetadataParams {
+	return sqlitequery.CreatePhotoMetadataParams{
+		PhotoID:      int64(v.PhotoID),
+		CaptureDate:  v.CaptureDate,
+		CameraMake:   v.CameraMake,
+		CameraModel:  v.CameraModel,
+		LensModel:    v.LensModel,
+		ExposureTime: v.ExposureTime,
+		FNumber:      v.FNumber,
+		Iso:          int64(v.Iso),
+		FocalLength:  v.FocalLength,
+		Orientation:  int64(v.Orientation),
+		Width:        int64(v.Width),
+		Height:       int64(v.Height),
+		Latitude:     v.Latitude,
+		Longitude:    v.Longitude,
+		Altitude:     v.Altitude,
+	}
+}
+
+func fromQueryCreateSessionParams(v query.CreateSessionParams) sqlitequery.CreateSessionParams {
+	return sqlitequery.CreateSessionParams{
+		UserID:       int64(v.UserID),
+		SessionToken: v.SessionToken,
+	}
+}
+
+func fromQueryCreateUnlockedEventParams(v query.CreateUnlockedEventParams) sqlitequery.CreateUnlockedEventParams {
+	return sqlitequery.CreateUnlockedEventParams{
+		SessionID: int64(v.SessionID),
+		EventID:   int64(v.EventID),
+	}
+}
+
+func fromQueryDeleteFaceGroupClaimParams(v query.DeleteFaceGroupClaimParams) sqlitequery.DeleteFaceGroupClaimParams {
+	return sqlitequery.DeleteFaceGroupClaimParams{
+		FaceGroupID: int64(v.FaceGroupID),
+		UserID:      int64(v.UserID),
+	}
+}
+
+func fromQueryFailJobParams(v query.FailJobParams) sqlitequery.FailJobParams {
+	return sqlitequery.FailJobParams{
+		Status:         string(v.Status),
+		LastError:      v.LastError,
+		BackoffSeconds: v.BackoffSeconds,
+		JobID:          int64(v.JobID),
+		Attempts:       int64(v.Attempts),
+	}
+}
+
+func toQueryEvent(v sqlitequery.Event) query.Event {
+	return query.Event{
+		EventID:        uint32(v.EventID),
+		Name:           v.Name,
+		Description:    v.Description,
+		EventDate:      v.EventDate,
+		CreationDate:   v.CreationDate,
+		ParentEventID:  sql.NullInt32{Int32: int32(v.ParentEventID.Int64), Valid: v.ParentEventID.Valid},
+		MetadataPolicy: query.EventsMetadataPolicy(v.MetadataPolicy),
+		IsHidden:       v.IsHidden,
+		PasswordHash:   v.PasswordHash,
+	}
+}
+
+func toQueryGetEventStorageUsageRow(v sqlitequery.GetEventStorageUsageRow) query.GetEventStorageUsageRow {
+	return query.GetEventStorageUsageRow{
+		EventID: uint32(v.EventID),
+		Name:    v.Name,
+		Kind:    query.StorageUsageKind(v.Kind),
+		Bytes:   v.Bytes,
+		Files:   int32(v.Files),
+	}
+}
+
+func fromQueryGetEventWithNameAndParentParams(v query.GetEventWithNameAndParentParams) sqlitequery.GetEventWithNameAndParentParams {
+	return sqlitequery.GetEventWithNameAndParentParams{
+		Name:          v.Name,
+		ParentEventID: sql.NullInt64{Int64: int64(v.ParentEventID.Int32), Valid: v.ParentEventID.Valid},
+	}
+}
+
+func toQueryGetEventsRow(v sqlitequery.GetEventsRow) query.GetEventsRow {
+	return query.GetEventsRow{
+		Name:          v.Name,
+		Description:   v.Description,
+		EventDate:     v.EventDate,
+		CreationDate:  v.CreationDate,
+		ParentEventID: sql.NullInt32{Int32: int32(v.ParentEventID.Int64), Valid: v.ParentEventID.Valid},
+	}
+}
+
+func toQueryFaceConsent(v sqlitequery.FaceConsent) query.FaceConsent {
+	return query.FaceConsent{
+		UserID:     uint32(v.UserID),
+		Consent:    query.FaceConsentsConsent(v.Consent),
+		UpdateDate: v.UpdateDate,
+	}
+}
+
+func toQueryFaceConsentChange(v sqlitequery.FaceConsentChange) query.FaceConsentChange {
+	return query.FaceConsentChange{
+		FaceConsentChangeID: uint32(v.FaceConsentChangeID),
+		UserID:              sql.NullInt32{Int32: int32(v.UserID.Int64), Valid: v.UserID.Valid},
+		Consent:             query.FaceConsentChangesConsent(v.Consent),
+		PreviousConsent:     query.FaceConsentChangesPreviousConsent(v.PreviousConsent),
+		ChangedBy:           sql.NullInt32{Int32: int32(v.ChangedBy.Int64), Valid: v.ChangedBy.Valid},
+		Ip:                  v.Ip,
+		ChangeDate:          v.ChangeDate,
+	}
+}
+
+func toQueryFaceGroup(v sqlitequery.FaceGroup) query.FaceGroup {
+	return query.FaceGroup{
+		FaceGroupID:  uint32(v.FaceGroupID),
+		Label:        v.Label,
+		UserID:       sql.NullInt32{Int32: int32(v.UserID.Int64), Valid: v.UserID.Valid},
+		CreationDate: v.CreationDate,
+	}
+}
+
+func toQueryGetFaceGroupClaimsRow(v sqlitequery.GetFaceGroupClaimsRow) query.GetFaceGroupClaimsRow {
+	return query.GetFaceGroupClaimsRow{
+		UserID:    uint32(v.UserID),
+		Email:     v.Email,
+		FullName:  v.FullName,
+		ClaimDate: v.ClaimDate,
+	}
+}
+
+func toQueryFaceGroupClaim(v sqlitequery.FaceGroupClaim) query.FaceGroupClaim {
+	return query.FaceGroupClaim{
+		FaceGroupID: uint32(v.FaceGroupID),
+		UserID:      uint32(v.UserID),
+		ClaimDate:   v.ClaimDate,
+	}
+}
+
+func fromQueryGetFaceGroupsParams(v query.GetFaceGroupsParams) sqlitequery.GetFaceGroupsParams {
+	return sqlitequery.GetFaceGroupsParams{
+		Limit:  int64(v.Limit),
+		Offset: int64(v.Offset),
+	}
+}
+
+func toQueryGetFaceGroupsRow(v sqlitequery.GetFaceGroupsRow) query.GetFaceGroupsRow {
+	return query.GetFaceGroupsRow{
+		FaceGroupID:  uint32(v.FaceGroupID),
+		Label:        v.Label,
+		UserID:       sql.NullInt32{Int32: int32(v.UserID.Int64), Valid: v.UserID.Valid},
+		Faces:        v.Faces,
+		SampleFaceID: v.SampleFaceID,
+		Claims:       v.Claims,
+	}
+}
+
+func toQueryGetFacesOfPhotoRow(v sqlitequery.GetFacesOfPhotoRow) query.GetFacesOfPhotoRow {
+	return query.GetFacesOfPhotoRow{
+		ImageFaceID:   uint32(v.ImageFaceID),
+		FaceGroupID:   uint32(v.FaceGroupID),
+		MinX:          v.MinX,
+		MinY:          v.MinY,
+		MaxX:          v.MaxX,
+		MaxY:          v.MaxY,
+		Label:         v.Label,
+		UserID:        sql.NullInt32{Int32: int32(v.UserID.Int64), Valid: v.UserID.Valid},
+		Consent:       query.NullFaceConsentsConsent{FaceConsentsConsent: query.FaceConsentsConsent(v.Consent.String), Valid: v.Consent.Valid},
+		NameOptInDate: v.NameOptInDate,
+	}
+}
+
+func toQueryGetGlobalStorageUsageRow(v sqlitequery.GetGlobalStorageUsageRow) query.GetGlobalStorageUsageRow {
+	return query.GetGlobalStorageUsageRow{
+		Kind:  query.StorageUsageKind(v.Kind),
+		Bytes: v.Bytes,
+		Files: int32(v.Files),
+	}
+}
+
+func toQueryImageFace(v sqlitequery.ImageFace) query.ImageFace {
+	return query.ImageFace{
+		ImageFaceID:   uint32(v.ImageFaceID),
+		PhotoID:       uint32(v.PhotoID),
+		FaceGroupID:   uint32(v.FaceGroupID),
+		Model:         v.Model,
+		Descriptor:    v.Descriptor,
+		MinX:          v.MinX,
+		MinY:          v.MinY,
+		MaxX:          v.MaxX,
+		MaxY:          v.MaxY,
+		DetectionDate: v.DetectionDate,
+	}
+}
+
+func toQueryGetImageFaceSamplesRow(v sqlitequery.GetImageFaceSamplesRow) query.GetImageFaceSamplesRow {
+	return query.GetImageFaceSamplesRow{
+		ImageFaceID: uint32(v.ImageFaceID),
+		FaceGroupID: uint32(v.FaceGroupID),
+		Descriptor:  v.Descriptor,
+	}
+}
+
+func fromQueryGetJobsParams(v query.GetJobsParams) sqlitequery.GetJobsParams {
+	return sqlitequery.GetJobsParams{
+		Status: string(v.Status),
+		Limit:  int64(v.Limit),
+	}
+}
+
+func toQueryJob(v sqlitequery.Job) query.Job {
+	return query.Job{
+		JobID:        uint32(v.JobID),
+		Kind:         query.JobsKind(v.Kind),
+		PhotoID:      sql.NullInt32{Int32: int32(v.PhotoID.Int64), Valid: v.PhotoID.Valid},
+		Status:       query.JobsStatus(v.Status),
+		Attempts:     uint32(v.Attempts),
+		LastError:    v.LastError,
+		RunAfter:     v.RunAfter,
+		LeasedUntil:  v.LeasedUntil,
+		CreationDate: v.CreationDate,
+		UpdateDate:   v.UpdateDate,
+	}
+}
+
+func toQueryDuplicateCandidate(v sqlitequery.DuplicateCandidate) query.DuplicateCandidate {
+	return query.DuplicateCandidate{
+		PhotoID:            uint32(v.PhotoID),
+		DuplicateOfPhotoID: uint32(v.DuplicateOfPhotoID),
+		Distance:           uint8(v.Distance),
+		Status:             query.DuplicateCandidatesStatus(v.Status),
+		CreationDate:       v.CreationDate,
+	}
+}
+
+func toQueryGetPerceptualHashesRow(v sqlitequery.GetPerceptualHashesRow) query.GetPerceptualHashesRow {
+	return query.GetPerceptualHashesRow{
+		PhotoID:        uint32(v.PhotoID),
+		PerceptualHash: v.PerceptualHash,
+	}
+}
+
+func fromQueryGetPerceptualHashesInBandsParams(v query.GetPerceptualHashesInBandsParams) sqlitequery.GetPerceptualHashesInBandsParams {
+	return sqlitequery.GetPerceptualHashesInBandsParams{
+		BandKey:   int64(v.BandKey),
+		BandKey_2: int64(v.BandKey_2),
+		BandKey_3: int64(v.BandKey_3),
+		BandKey_4: int64(v.BandKey_4),
+		BandKey_5: int64(v.BandKey_5),
+		BandKey_6: int64(v.BandKey_6),
+		BandKey_7: int64(v.BandKey_7),
+		BandKey_8: int64(v.BandKey_8),
+		FileHash:  v.FileHash,
+	}
+}
+
+func toQueryGetPerceptualHashesInBandsRow(v sqlitequery.GetPerceptualHashesInBandsRow) query.GetPerceptualHashesInBandsRow {
+	return query.GetPerceptualHashesInBandsRow{
+		PhotoID:        uint32(v.PhotoID),
+		PerceptualHash: v.PerceptualHash,
+	}
+}
+
+func toQueryPhoto(v sqlitequery.Photo) query.Photo {
+	return query.Photo{
+		PhotoID:        uint32(v.PhotoID),
+		PathToPhoto:    v.PathToPhoto,
+		FileHash:       v.FileHash,
+		PerceptualHash: v.PerceptualHash,
+		FileSize:       v.FileSize,
+		CreationDate:   v.CreationDate,
+		EventID:        uint32(v.EventID),
+		UploaderID:     sql.NullInt32{Int32: int32(v.UploaderID.Int64), Valid: v.UploaderID.Valid},
+		IsHidden:       v.IsHidden,
+	}
+}
+
+func toQueryPhotoMetadatum(v sqlitequery.PhotoMetadatum) query.PhotoMetadatum {
+	return query.PhotoMetadatum{
+		PhotoID:      uint32(v.PhotoID),
+		CaptureDate:  v.CaptureDate,
+		CameraMake:   v.CameraMake,
+		CameraModel:  v.CameraModel,
+		LensModel:    v.LensModel,
+		ExposureTime: v.ExposureTime,
+		FNumber:      v.FNumber,
+		Iso:          uint32(v.Iso),
+		FocalLength:  v.FocalLength,
+		Orientation:  uint16(v.Orientation),
+		Width:        uint32(v.Width),
+		Height:       uint32(v.Height),
+		Latitude:     v.Latitude,
+		Longitude:    v.Longitude,
+		Altitude:     v.Altitude,
+	}
+}
+
+func toQueryGetPhotoPathsRow(v sqlitequery.GetPhotoPathsRow) query.GetPhotoPathsRow {
+	return query.GetPhotoPathsRow{
+		PhotoID:     uint32(v.PhotoID),
+		PathToPhoto: v.PathToPhoto,
+		FileSize:    v.FileSize,
+		EventID:     uint32(v.EventID),
+	}
+}
+
+func fromQueryGetPhotoWithEventAndHashParams(v query.GetPhotoWithEventAndHashParams) sqlitequery.GetPhotoWithEventAndHashParams {
+	return sqlitequery.GetPhotoWithEventAndHashParams{
+		EventID:  int64(v.EventID),
+		FileHash: v.FileHash,
+	}
+}
+
+func toQueryGetPhotoWithMetadataPolicyRow(v sqlitequery.GetPhotoWithMetadataPolicyRow) query.GetPhotoWithMetadataPolicyRow {
+	return query.GetPhotoWithMetadataPolicyRow{
+		PhotoID:        uint32(v.PhotoID),
+		PathToPhoto:    v.PathToPhoto,
+		FileHash:       v.FileHash,
+		PerceptualHash: v.PerceptualHash,
+		FileSize:       v.FileSize,
+		CreationDate:   v.CreationDate,
+		EventID:        uint32(v.EventID),
+		UploaderID:     sql.NullInt32{Int32: int32(v.UploaderID.Int64), Valid: v.UploaderID.Valid},
+		IsHidden:       v.IsHidden,
+		MetadataPolicy: query.EventsMetadataPolicy(v.MetadataPolicy),
+	}
+}
+
+func toQuerySession(v sqlitequery.Session) query.Session {
+	return query.Session{
+		SessionID:    uint32(v.SessionID),
+		UserID:       uint32(v.UserID),
+		CreationDate: v.CreationDate,
+		SessionToken: v.SessionToken,
+	}
+}
+
+func fromQueryGetStorageUsageParams(v query.GetStorageUsageParams) sqlitequery.GetStorageUsageParams {
+	return sqlitequery.GetStorageUsageParams{
+		Scope:   string(v.Scope),
+		ScopeID: int64(v.ScopeID),
+	}
+}
+
+func toQueryGetUploaderStorageUsageRow(v sqlitequery.GetUploaderStorageUsageRow) query.GetUploaderStorageUsageRow {
+	return query.GetUploaderStorageUsageRow{
+		UserID:   uint32(v.UserID),
+		FullName: v.FullName,
+		Email:    v.Email,
+		Bytes:    v.Bytes,
+		Files:    int32(v.Files),
+	}
+}
+
+func toQueryUser(v sqlitequery.User) query.User {
+	return query.User{
+		UserID:           uint32(v.UserID),
+		SignupDate:       v.SignupDate,
+		LastSigninDate:   v.LastSigninDate,
+		SigninLocked:     v.SigninLocked,
+		SigninLockedDate: v.SigninLockedDate,
+		IsAdmin:          v.IsAdmin,
+		Email:            v.Email,
+		FullName:         v.FullName,
+		BusinessCategory: query.UsersBusinessCategory(v.BusinessCategory),
+		DepartmentNumber: v.DepartmentNumber,
+	}
+}
+
+func toQueryUserFolder(v sqlitequery.UserFolder) query.UserFolder {
+	return query.UserFolder{
+		UserFolderID:   uint32(v.UserFolderID),
+		IsSubFolder:    v.IsSubFolder,
+		Name:           v.Name,
+		Description:    v.Description,
+		CreationDate:   v.CreationDate,
+		UserID:         uint32(v.UserID),
+		ParentFolderID: sql.NullInt32{Int32: int32(v.ParentFolderID.Int64), Valid: v.ParentFolderID.Valid},
+	}
+}
+
+func fromQueryIsEventUnlockedParams(v query.IsEventUnlockedParams) sqlitequery.IsEventUnlockedParams {
+	return sqlitequery.IsEventUnlockedParams{
+		SessionID: int64(v.SessionID),
+		EventID:   int64(v.EventID),
+	}
+}
+
+func fromQueryLeaseJobParams(v query.LeaseJobParams) sqlitequery.LeaseJobParams {
+	return sqlitequery.LeaseJobParams{
+		LeaseSeconds: v.LeaseSeconds,
+		JobID:        int64(v.JobID),
+	}
+}
+
+func fromQueryMergeFaceGroupParams(v query.MergeFaceGroupParams) sqlitequery.MergeFaceGroupParams {
+	return sqlitequery.MergeFaceGroupParams{
+		FaceGroupID:   int64(v.FaceGroupID),
+		FaceGroupID_2: int64(v.FaceGroupID_2),
+	}
+}
+
+func fromQueryReserveStorageUsageParams(v query.ReserveStorageUsageParams) sqlitequery.ReserveStorageUsageParams {
+	return sqlitequery.ReserveStorageUsageParams{
+		Bytes:   v.Bytes,
+		Scope:   string(v.Scope),
+		ScopeID: int64(v.ScopeID),
+		Bytes_2: v.Bytes_2,
+		Bytes_3: v.Bytes_3,
+	}
+}
+
+func fromQuerySaveFaceConsentParams(v query.SaveFaceConsentParams) sqlitequery.SaveFaceConsentParams {
+	return sqlitequery.SaveFaceConsentParams{
+		UserID:  int64(v.UserID),
+		Consent: string(v.Consent),
+	}
+}
+
+func fromQuerySaveFaceScanParams(v query.SaveFaceScanParams) sqlitequery.SaveFaceScanParams {
+	return sqlitequery.SaveFaceScanParams{
+		PhotoID: int64(v.PhotoID),
+		Model:   v.Model,
+		Faces:   int64(v.Faces),
+	}
+}
+
+func fromQueryUpdateDuplicateCandidateStatusParams(v query.UpdateDuplicateCandidateStatusParams) sqlitequery.UpdateDuplicateCandidateStatusParams {
+	return sqlitequery.UpdateDuplicateCandidateStatusParams{
+		Status:             string(v.Status),
+		PhotoID:            int64(v.PhotoID),
+		DuplicateOfPhotoID: int64(v.DuplicateOfPhotoID),
+	}
+}
+
+func fromQueryUpdateEventParams(v query.UpdateEventParams) sqlitequery.UpdateEventParams {
+	return sqlitequery.UpdateEventParams{
+		Name:          v.Name,
+		Description:   v.Description,
+		EventDate:     v.EventDate,
+		ParentEventID: sql.NullInt64{Int64: int64(v.ParentEventID.Int32), Valid: v.ParentEventID.Valid},
+		EventID:       int64(v.EventID),
+	}
+}
+
+func fromQueryUpdateEventHiddenParams(v query.UpdateEventHiddenParams) sqlitequery.UpdateEventHiddenParams {
+	return sqlitequery.UpdateEventHiddenParams{
+		IsHidden: v.IsHidden,
+		EventID:  int64(v.EventID),
+	}
+}
+
+func fromQueryUpdateEventMetadataPolicyParams(v query.UpdateEventMetadataPolicyParams) sqlitequery.UpdateEventMetadataPolicyParams {
+	return sqlitequery.UpdateEventMetadataPolicyParams{
+		MetadataPolicy: string(v.MetadataPolicy),
+		EventID:        int64(v.EventID),
+	}
+}
+
+func fromQueryUpdateEventPasswordHashParams(v query.UpdateEventPasswordHashParams) sqlitequery.UpdateEventPasswordHashParams {
+	return sqlitequery.UpdateEventPasswordHashParams{
+		PasswordHash: v.PasswordHash,
+		EventID:      int64(v.EventID),
+	}
+}
+
+func fromQueryUpdateFaceGroupUserParams(v query.UpdateFaceGroupUserParams) sqlitequery.UpdateFaceGroupUserParams {
+	return sqlitequery.UpdateFaceGroupUserParams{
+		Label:       v.Label,
+		UserID:      sql.NullInt64{Int64: int64(v.UserID.Int32), Valid: v.UserID.Valid},
+		FaceGroupID: int64(v.FaceGroupID),
+	}
+}
+
+func fromQueryUpdateImageFaceGroupParams(v query.UpdateImageFaceGroupParams) sqlitequery.UpdateImageFaceGroupParams {
+	return sqlitequery.UpdateImageFaceGroupParams{
+		FaceGroupID: int64(v.FaceGroupID),
+		ImageFaceID: int64(v.ImageFaceID),
+	}
+}
+
+func fromQueryUpdatePhotoFileSizeParams(v query.UpdatePhotoFileSizeParams) sqlitequery.UpdatePhotoFileSizeParams {
+	return sqlitequery.UpdatePhotoFileSizeParams{
+		FileSize: v.FileSize,
+		PhotoID:  int64(v.PhotoID),
+	}
+}
+
+func fromQueryUpdatePhotoHiddenParams(v query.UpdatePhotoHiddenParams) sqlitequery.UpdatePhotoHiddenParams {
+	return sqlitequery.UpdatePhotoHiddenParams{
+		IsHidden: v.IsHidden,
+		PhotoID:  int64(v.PhotoID),
+	}
+}
+
+func fromQueryUpdatePhotoPathParams(v query.UpdatePhotoPathParams) sqlitequery.UpdatePhotoPathParams {
+	return sqlitequery.UpdatePhotoPathParams{
+		PathToPhoto: v.PathToPhoto,
+		PhotoID:     int64(v.PhotoID),
+	}
+}
@@ -0,0 +1,605 @@
+-- name: AttemptCreatingUser :one
+INSERT INTO users (email, full_name, business_category, department_number)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (email) DO UPDATE SET email = excluded.email
+RETURNING user_id;
+
+-- name: GetUser :one
+SELECT *
+FROM users
+WHERE user_id = ?;
+
+-- name: GetUserWithEmail :one
+SELECT *
+FROM users
+WHERE email = ?;
+
+-- name: GetUserWithSession :one
+SELECT u.*
+FROM users u
+JOIN sessions s
+ON s.user_id = u.user_id
+WHERE s.session_token = ?;
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE user_id = ?;
+
+
+
+
+-- name: CreateSession :exec
+INSERT INTO sessions (user_id, session_token)
+VALUES (?, ?);
+
+-- name: GetSessionWithToken :one
+SELECT *
+FROM sessions
+WHERE session_token = ?;
+
+-- name: DeleteSessionWithToken :exec
+DELETE FROM sessions WHERE session_token = ?;
+
+-- name: GetSessionsOfUser :many
+SELECT *
+FROM sessions
+WHERE user_id = ?
+ORDER BY creation_date, session_id;
+
+-- name: DeleteSessionsOfUser :exec
+DELETE FROM sessions WHERE user_id = ?;
+
+-- name: CountSessionsSince :one
+SELECT COUNT(*)
+FROM sessions
+WHERE creation_date >= ?;
+
+
+
+
+-- name: CreateEvent :execlastid
+INSERT INTO events (name, description, event_date, parent_event_id)
+VALUES (?, ?, ?, ?);
+
+-- name: GetEvent :one
+SELECT *
+FROM events
+WHERE event_id = ?;
+
+-- name: GetEventWithNameAndParent :one
+SELECT *
+FROM events
+WHERE name = ? AND parent_event_id IS ?
+LIMIT 1;
+
+-- name: GetEvents :many
+SELECT name, description, event_date, creation_date, parent_event_id
+FROM events
+WHERE is_hidden = false;
+
+-- name: UpdateEvent :exec
+UPDATE events
+SET name = ?, description = ?, event_date = ?, parent_event_id = ?
+WHERE event_id = ?;
+
+-- name: GetSubEvents :many
+SELECT *
+FROM events
+WHERE parent_event_id = ?
+ORDER BY event_date, event_id;
+
+-- name: UpdateEventMetadataPolicy :exec
+UPDATE events
+SET metadata_policy = ?
+WHERE event_id = ?;
+
+-- name: UpdateEventHidden :exec
+UPDATE events
+SET is_hidden = ?
+WHERE event_id = ?;
+
+-- name: UpdateEventPasswordHash :exec
+UPDATE events
+SET password_hash = ?
+WHERE event_id = ?;
+
+-- name: DeleteEvent :exec
+DELETE FROM events WHERE event_id = ?;
+
+
+
+
+-- name: CreateUnlockedEvent :exec
+INSERT OR IGNORE INTO unlocked_events (session_id, event_id)
+VALUES (?, ?);
+
+-- name: IsEventUnlocked :one
+SELECT CAST(EXISTS(
+    SELECT 1
+    FROM unlocked_events
+    WHERE session_id = ? AND event_id = ?
+) AS BOOLEAN) AS unlocked;
+
+
+
+
+-- name: CreatePhoto :execlastid
+INSERT INTO photos (path_to_photo, file_hash, perceptual_hash, file_size, event_id, uploader_id)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: GetPhoto :one
+SELECT * FROM photos WHERE photo_id = ?;
+
+-- name: GetPhotoWithMetadataPolicy :one
+SELECT p.*, e.metadata_policy
+FROM photos p
+JOIN events e
+ON e.event_id = p.event_id
+WHERE p.photo_id = ?;
+
+-- name: GetPhotoWithEventAndHash :one
+SELECT *
+FROM photos
+WHERE event_id = ? AND file_hash = ?
+LIMIT 1;
+
+-- name: GetPhotosWithHash :many
+SELECT *
+FROM photos
+WHERE file_hash = ?
+ORDER BY photo_id;
+
+-- name: CountPhotosWithPath :one
+SELECT COUNT(*)
+FROM photos
+WHERE path_to_photo = ?;
+
+-- name: CountUnsizedPhotosWithPath :one
+SELECT COUNT(*)
+FROM photos
+WHERE path_to_photo = ? AND file_size = 0;
+
+-- name: UpdatePhotoFileSize :execrows
+UPDATE photos
+SET file_size = ?
+WHERE photo_id = ? AND file_size = 0;
+
+-- name: GetPerceptualHashes :many
+SELECT photo_id, perceptual_hash
+FROM photos
+WHERE perceptual_hash IS NOT NULL AND file_hash <> ?;
+
+-- name: GetPerceptualHashesInBands :many
+SELECT DISTINCT p.photo_id, p.perceptual_hash
+FROM perceptual_hash_bands b
+JOIN photos p
+ON p.photo_id = b.photo_id
+WHERE b.band_key IN (?, ?, ?, ?, ?, ?, ?, ?) AND p.file_hash <> ?;
+
+-- name: CreatePerceptualHashBands :exec
+INSERT INTO perceptual_hash_bands (band_key, photo_id)
+SELECT bands.band * 256 + ((p.perceptual_hash >> (8 * bands.band)) & 255), p.photo_id
+FROM photos p
+CROSS JOIN (
+    SELECT 0 AS band UNION ALL SELECT 1 UNION ALL SELECT 2 UNION ALL SELECT 3
+    UNION ALL SELECT 4 UNION ALL SELECT 5 UNION ALL SELECT 6 UNION ALL SELECT 7
+) bands
+WHERE p.photo_id = ? AND p.perceptual_hash IS NOT NULL;
+
+-- name: BackfillPerceptualHashBands :execrows
+INSERT INTO perceptual_hash_bands (band_key, photo_id)
+SELECT bands.band * 256 + ((p.perceptual_hash >> (8 * bands.band)) & 255), p.photo_id
+FROM photos p
+CROSS JOIN (
+    SELECT 0 AS band UNION ALL SELECT 1 UNION ALL SELECT 2 UNION ALL SELECT 3
+    UNION ALL SELECT 4 UNION ALL SELECT 5 UNION ALL SELECT 6 UNION ALL SELECT 7
+) bands
+WHERE p.perceptual_hash IS NOT NULL
+AND NOT EXISTS (SELECT 1 FROM perceptual_hash_bands i WHERE i.photo_id = p.photo_id);
+
+-- name: GetPhotosByEventID :many
+SELECT p.*
+FROM photos p
+LEFT JOIN photo_metadata pm
+ON pm.photo_id = p.photo_id
+WHERE p.event_id = ?
+ORDER BY COALESCE(pm.capture_date, p.creation_date), p.photo_id;
+
+-- name: GetPhotosSortedByDate :many
+SELECT p.*
+FROM photos p
+LEFT JOIN photo_metadata pm
+ON pm.photo_id = p.photo_id
+ORDER BY COALESCE(pm.capture_date, p.creation_date) DESC, p.photo_id DESC;
+
+-- name: UpdatePhotoPath :exec
+UPDATE photos
+SET path_to_photo = ?
+WHERE photo_id = ?;
+
+-- name: UpdatePhotoHidden :exec
+UPDATE photos
+SET is_hidden = ?
+WHERE photo_id = ?;
+
+-- name: DeletePhoto :exec
+DELETE FROM photos WHERE photo_id = ?;
+
+-- name: GetPhotoPaths :many
+SELECT photo_id, path_to_photo, file_size, event_id
+FROM photos
+ORDER BY photo_id;
+
+
+
+
+-- name: CreateMissingPhoto :exec
+INSERT OR IGNORE INTO missing_photos (photo_id)
+VALUES (?);
+
+-- name: GetMissingPhotoIDs :many
+SELECT photo_id
+FROM missing_photos
+ORDER BY photo_id;
+
+-- name: DeleteMissingPhoto :exec
+DELETE FROM missing_photos WHERE photo_id = ?;
+
+
+
+
+-- name: CreatePhotoMetadata :exec
+INSERT INTO photo_metadata (
+    photo_id, capture_date, camera_make, camera_model, lens_model, exposure_time, f_number,
+    iso, focal_length, orientation, width, height, latitude, longitude, altitude
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetPhotoMetadata :one
+SELECT *
+FROM photo_metadata
+WHERE photo_id = ?;
+
+-- name: DeletePhotoMetadata :exec
+DELETE FROM photo_metadata WHERE photo_id = ?;
+
+
+
+
+-- name: CreateDuplicateCandidate :exec
+INSERT OR IGNORE INTO duplicate_candidates (photo_id, duplicate_of_photo_id, distance)
+VALUES (?, ?, ?);
+
+-- name: GetPendingDuplicateCandidates :many
+SELECT *
+FROM duplicate_candidates
+WHERE status = 'PENDING'
+ORDER BY creation_date, photo_id;
+
+-- name: UpdateDuplicateCandidateStatus :exec
+UPDATE duplicate_candidates
+SET status = ?
+WHERE photo_id = ? AND duplicate_of_photo_id = ?;
+
+
+
+
+-- name: AddStorageUsage :exec
+INSERT INTO storage_usage (scope, scope_id, kind, bytes, files)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (scope, scope_id, kind) DO UPDATE SET bytes = bytes + excluded.bytes, files = files + excluded.files;
+
+-- name: ReserveStorageUsage :execrows
+UPDATE storage_usage
+SET bytes = bytes + ?, files = files + 1
+WHERE scope = ? AND scope_id = ? AND kind = 'ORIGINAL' AND bytes + ? <= ?;
+
+-- name: GetStorageUsage :one
+SELECT CAST(COALESCE(SUM(bytes), 0) AS INTEGER) AS bytes
+FROM storage_usage
+WHERE scope = ? AND scope_id = ?;
+
+-- name: GetGlobalStorageUsage :many
+SELECT kind, bytes, files
+FROM storage_usage
+WHERE scope = 'GLOBAL'
+ORDER BY kind;
+
+-- name: GetEventStorageUsage :many
+SELECT u.scope_id AS event_id, e.name, u.kind, u.bytes, u.files
+FROM storage_usage u
+JOIN events e
+ON e.event_id = u.scope_id
+WHERE u.scope = 'EVENT'
+ORDER BY u.scope_id, u.kind;
+
+-- name: GetUploaderStorageUsage :many
+SELECT u.scope_id AS user_id, us.full_name, us.email, u.bytes, u.files
+FROM storage_usage u
+JOIN users us
+ON us.user_id = u.scope_id
+WHERE u.scope = 'UPLOADER' AND u.kind = 'ORIGINAL'
+ORDER BY u.bytes DESC;
+
+
+
+
+-- name: CreateFaceGroup :execlastid
+INSERT INTO face_groups (label)
+VALUES (NULL);
+
+-- name: GetUnlabeledFaceGroupIDs :many
+SELECT face_group_id
+FROM face_groups
+WHERE label IS NULL
+ORDER BY face_group_id;
+
+-- name: DeleteFaceGroupIfEmpty :execrows
+DELETE FROM face_groups
+WHERE face_groups.face_group_id = ?
+AND NOT EXISTS (SELECT 1 FROM image_faces WHERE image_faces.face_group_id = face_groups.face_group_id);
+
+-- name: GetFaceGroup :one
+SELECT * FROM face_groups WHERE face_group_id = ?;
+
+-- name: UpdateFaceGroupUser :exec
+UPDATE face_groups
+SET label = ?, user_id = ?
+WHERE face_group_id = ?;
+
+-- name: DeleteFaceGroupsOfUser :exec
+DELETE FROM face_groups WHERE user_id = ?;
+
+-- name: GetFaceGroupsOfUser :many
+SELECT * FROM face_groups WHERE user_id = ? ORDER BY face_group_id;
+
+-- name: CreateFaceGroupClaim :exec
+INSERT OR IGNORE INTO face_group_claims (face_group_id, user_id)
+VALUES (?, ?);
+
+-- name: GetFaceGroupClaims :many
+SELECT c.user_id, u.email, u.full_name, c.claim_date
+FROM face_group_claims c
+JOIN users u
+ON u.user_id = c.user_id
+WHERE c.face_group_id = ?
+ORDER BY c.claim_date, c.user_id;
+
+-- name: GetFaceGroupClaimsOfUser :many
+SELECT * FROM face_group_claims WHERE user_id = ? ORDER BY face_group_id;
+
+-- name: DeleteFaceGroupClaim :execrows
+DELETE FROM face_group_claims WHERE face_group_id = ? AND user_id = ?;
+
+-- name: DeleteFaceGroupClaims :exec
+DELETE FROM face_group_claims WHERE face_group_id = ?;
+
+-- name: DeleteFaceGroupClaimsOfUser :exec
+DELETE FROM face_group_claims WHERE user_id = ?;
+
+-- name: GetFaceGroups :many
+SELECT g.face_group_id, g.label, g.user_id, COUNT(*) AS faces, CAST(MIN(f.image_face_id) AS INTEGER) AS sample_face_id,
+    (SELECT COUNT(*) FROM face_group_claims c WHERE c.face_group_id = g.face_group_id) AS claims
+FROM face_groups g
+JOIN image_faces f
+ON f.face_group_id = g.face_group_id
+GROUP BY g.face_group_id
+ORDER BY faces DESC, g.face_group_id
+LIMIT ? OFFSET ?;
+
+
+
+
+-- name: CreateImageFace :execlastid
+INSERT INTO image_faces (photo_id, face_group_id, model, descriptor, min_x, min_y, max_x, max_y)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetImageFace :one
+SELECT * FROM image_faces WHERE image_face_id = ?;
+
+-- name: GetImageFacesOfPhoto :many
+SELECT *
+FROM image_faces
+WHERE photo_id = ?
+ORDER BY image_face_id;
+
+-- name: GetImageFaceSamples :many
+SELECT image_face_id, face_group_id, descriptor
+FROM image_faces
+WHERE model = ?
+ORDER BY image_face_id;
+
+-- name: UpdateImageFaceGroup :exec
+UPDATE image_faces
+SET face_group_id = ?
+WHERE image_face_id = ?;
+
+-- name: DeleteImageFacesOfPhoto :exec
+DELETE FROM image_faces WHERE photo_id = ?;
+
+-- name: DeleteImageFacesOfUser :exec
+DELETE FROM image_faces
+WHERE face_group_id IN (SELECT face_group_id FROM face_groups WHERE user_id = ?);
+
+-- name: GetImageFacesOfFaceGroup :many
+SELECT *
+FROM image_faces
+WHERE face_group_id = ?
+ORDER BY image_face_id;
+
+-- name: MergeFaceGroup :exec
+UPDATE image_faces
+SET face_group_id = ?
+WHERE face_group_id = ?;
+
+-- name: GetPhotoIDsOfFaceGroup :many
+SELECT DISTINCT photo_id
+FROM image_faces
+WHERE face_group_id = ?
+ORDER BY photo_id;
+
+-- name: GetFacesOfPhoto :many
+SELECT f.image_face_id, f.face_group_id, f.min_x, f.min_y, f.max_x, f.max_y, g.label, g.user_id, c.consent, n.opt_in_date AS name_opt_in_date
+FROM image_faces f
+JOIN face_groups g
+ON g.face_group_id = f.face_group_id
+LEFT JOIN face_consents c
+ON c.user_id = g.user_id
+LEFT JOIN public_face_names n
+ON n.user_id = g.user_id
+WHERE f.photo_id = ?
+ORDER BY f.image_face_id;
+
+
+
+
+-- name: SaveFaceScan :exec
+INSERT INTO face_scans (photo_id, model, faces)
+VALUES (?, ?, ?)
+ON CONFLICT (photo_id) DO UPDATE SET model = excluded.model, faces = excluded.faces, scan_date = CURRENT_TIMESTAMP;
+
+-- name: GetPhotosWithoutFaceScan :many
+SELECT p.photo_id
+FROM photos p
+LEFT JOIN face_scans f
+ON f.photo_id = p.photo_id
+WHERE f.photo_id IS NULL OR f.model <> ?
+ORDER BY p.photo_id;
+
+
+
+
+-- name: GetUserFoldersOfUser :many
+SELECT *
+FROM user_folders
+WHERE user_id = ?
+ORDER BY user_folder_id;
+
+-- name: DetachUserFoldersOfUser :exec
+UPDATE user_folders SET parent_folder_id = NULL WHERE user_id = ?;
+
+-- name: DeleteUserFoldersOfUser :exec
+DELETE FROM user_folders WHERE user_id = ?;
+
+
+
+
+-- name: CreateRecognizedUsersOfPhoto :exec
+INSERT INTO recognized_users (user_id, photo_id)
+SELECT DISTINCT g.user_id, f.photo_id
+FROM image_faces f
+JOIN face_groups g
+ON g.face_group_id = f.face_group_id
+JOIN face_consents c
+ON c.user_id = g.user_id
+WHERE f.photo_id = ? AND c.consent = 'GRANTED';
+
+-- name: DeleteRecognizedUsersOfPhoto :exec
+DELETE FROM recognized_users WHERE photo_id = ?;
+
+-- name: DeleteRecognizedUsersOfUser :exec
+DELETE FROM recognized_users WHERE user_id = ?;
+
+-- name: GetRecognizedPhotos :many
+SELECT p.*
+FROM photos p
+JOIN recognized_users r
+ON r.photo_id = p.photo_id
+LEFT JOIN photo_metadata pm
+ON pm.photo_id = p.photo_id
+WHERE r.user_id = ?
+ORDER BY COALESCE(pm.capture_date, p.creation_date), p.photo_id;
+
+
+
+
+-- name: GetFaceConsent :one
+SELECT * FROM face_consents WHERE user_id = ?;
+
+-- name: SaveFaceConsent :exec
+INSERT INTO face_consents (user_id, consent)
+VALUES (?, ?)
+ON CONFLICT (user_id) DO UPDATE SET consent = excluded.consent;
+
+-- name: CreateFaceConsentChange :exec
+INSERT INTO face_consent_changes (user_id, consent, previous_consent, changed_by, ip)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: GetFaceConsentChanges :many
+SELECT *
+FROM face_consent_changes
+WHERE user_id = ?
+ORDER BY change_date, face_consent_change_id;
+
+-- name: AnonymizeFaceConsentChangesOfUser :exec
+UPDATE face_consent_changes SET ip = '' WHERE user_id = ?;
+
+-- name: CreatePublicFaceName :exec
+INSERT OR IGNORE INTO public_face_names (user_id)
+VALUES (?);
+
+-- name: GetPublicFaceName :one
+SELECT opt_in_date FROM public_face_names WHERE user_id = ?;
+
+-- name: DeletePublicFaceName :exec
+DELETE FROM public_face_names WHERE user_id = ?;
+
+
+
+
+-- name: CreateJob :exec
+INSERT INTO jobs (kind, photo_id)
+VALUES (?, ?);
+
+-- name: CreateRecognizeJob :exec
+INSERT INTO jobs (kind)
+SELECT 'RECOGNIZE'
+WHERE NOT EXISTS (SELECT 1 FROM jobs WHERE kind = 'RECOGNIZE' AND status = 'PENDING');
+
+-- name: GetNextJob :one
+-- No row lock is needed: write transactions are immediate, so they run one at a time.
+SELECT *
+FROM jobs
+WHERE (status = 'PENDING' AND run_after <= CURRENT_TIMESTAMP)
+   OR (status = 'RUNNING' AND leased_until < CURRENT_TIMESTAMP)
+ORDER BY run_after, job_id
+LIMIT 1;
+
+-- name: LeaseJob :exec
+UPDATE jobs
+SET status = 'RUNNING', attempts = attempts + 1,
+    leased_until = datetime(CURRENT_TIMESTAMP, '+' || CAST(sqlc.arg(lease_seconds) AS INTEGER) || ' seconds')
+WHERE job_id = sqlc.arg(job_id);
+
+-- name: CompleteJob :execrows
+UPDATE jobs
+SET status = 'DONE', leased_until = NULL
+WHERE job_id = ? AND status = 'RUNNING' AND attempts = ?;
+
+-- name: FailJob :execrows
+UPDATE jobs
+SET status = sqlc.arg(status), last_error = sqlc.arg(last_error),
+    run_after = datetime(CURRENT_TIMESTAMP, '+' || CAST(sqlc.arg(backoff_seconds) AS INTEGER) || ' seconds'),
+    leased_until = NULL
+WHERE job_id = sqlc.arg(job_id) AND status = 'RUNNING' AND attempts = sqlc.arg(attempts);
+
+-- name: RetryJob :execrows
+UPDATE jobs
+SET status = 'PENDING', attempts = 0, run_after = CURRENT_TIMESTAMP, leased_until = NULL
+WHERE job_id = ? AND status IN ('DONE', 'DEAD');
+
+-- name: GetJobs :many
+SELECT *
+FROM jobs
+WHERE status = ?
+ORDER BY update_date DESC, job_id DESC
+LIMIT ?;
+
+-- name: CountJobs :many
+SELECT status, COUNT(*) AS jobs
+FROM jobs
+GROUP BY status
+ORDER BY status;
+
+-- name: DeleteDoneJobs :execrows
+DELETE FROM jobs
+WHERE status = 'DONE' AND update_date < datetime(CURRENT_TIMESTAMP, '-' || CAST(sqlc.arg(retention_seconds) AS INTEGER) || ' seconds');
@@ -0,0 +1,74 @@
+// Package sqlite runs the queries of the query package on SQLite, for small deployments and tests.
+//
+// The SQLite queries of query.sql have the names of the MySQL ones of the query package. sqlc generates their code in
+// internal/query, which queries.go adapts to the Querier interface of the query package, so that transactions and the
+// rest of the code are unchanged. The driver is modernc.org/sqlite, which needs no cgo.
+package sqlite
+
+//go:generate go run ../internal/querygen -local internal/query -engine SQLite -out queries.go
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"photos/pkg/db/query"
+	sqlitequery "photos/pkg/db/sqlite/internal/query"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// DriverName is the name the driver is registered under in database/sql.
+const DriverName = "sqlite"
+
+// Schema creates the tables of the database if they do not exist.
+//
+//go:embed schema.sql
+var Schema string
+
+// New returns the queries of query.sql run on a SQLite database or transaction.
+//
+// Parameters:
+//   - db: The database or the transaction.
+//
+// Returns:
+//   - query.Querier: The queries, with the parameters and the results of the query package.
+func New(db query.DBTX) query.Querier {
+	return queries{q: sqlitequery.New(utcDB{DBTX: db})}
+}
+
+// utcDB converts the times of the arguments of the queries to UTC, so that they compare with the dates SQLite stores
+// as text.
+type utcDB struct {
+	query.DBTX
+}
+
+// ExecContext runs a statement with its times in UTC.
+func (db utcDB) ExecContext(ctx context.Context, statement string, args ...interface{}) (sql.Result, error) {
+	return db.DBTX.ExecContext(ctx, statement, utcArgs(args)...)
+}
+
+// QueryContext runs a query with its times in UTC.
+func (db utcDB) QueryContext(ctx context.Context, statement string, args ...interface{}) (*sql.Rows, error) {
+	return db.DBTX.QueryContext(ctx, statement, utcArgs(args)...)
+}
+
+// QueryRowContext runs a query of a single row with its times in UTC.
+func (db utcDB) QueryRowContext(ctx context.Context, statement string, args ...interface{}) *sql.Row {
+	return db.DBTX.QueryRowContext(ctx, statement, utcArgs(args)...)
+}
+
+// utcArgs returns the arguments with their times and valid nullable times in UTC.
+func utcArgs(args []interface{}) []interface{} {
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch value := arg.(type) {
+		case time.Time:
+			arg = value.UTC()
+		case sql.NullTime:
+			arg = sql.NullTime{Time: value.Time.UTC(), Valid: value.Valid}
+		}
+		converted[i] = arg
+	}
+	return converted
+}
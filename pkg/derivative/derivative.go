@@ -0,0 +1,165 @@
+package derivative
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"path"
+	"photos/pkg/storage"
+	"strconv"
+
+	"golang.org/x/image/draw"
+
+	// Register the decoders of the supported photo formats.
+	_ "image/gif"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+// Kind is a type of derivative generated from an original photo.
+type Kind string
+
+const (
+	Thumbnail Kind = "thumbnail" // Small image displayed in galleries.
+	Preview   Kind = "preview"   // Large image displayed when a photo is opened.
+)
+
+// maxSizes holds the maximum width and height in pixels of each kind of derivative.
+var maxSizes = map[Kind]int{
+	Thumbnail: 400,
+	Preview:   1600,
+}
+
+const jpegQuality = 82
+
+// Generate decodes a photo, applies its EXIF orientation, scales it down and encodes it as JPEG.
+// Since the photo is re-encoded, the derivative never carries any metadata.
+//
+// Parameters:
+//   - w: Writer receiving the JPEG derivative.
+//   - r: The original photo.
+//   - orientation: EXIF orientation of the original photo, 0 if unknown.
+//   - kind: The kind of derivative to generate.
+//
+// Returns:
+//   - error: An error if the photo could not be decoded or the derivative written.
+func Generate(w io.Writer, r io.Reader, orientation uint16, kind Kind) error {
+	maxSize, ok := maxSizes[kind]
+	if !ok {
+		return fmt.Errorf("unknown derivative kind %q", kind)
+	}
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return fmt.Errorf("failed to decode photo: %w", err)
+	}
+	img = scale(orient(img, orientation), maxSize)
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+}
+
+// Key returns the storage key of the derivative of a photo.
+func Key(photoID uint32, kind Kind) string {
+	return path.Join("derivatives", strconv.FormatUint(uint64(photoID), 10), string(kind)+".jpg")
+}
+
+// Ensure returns the storage key of the derivative of a photo, generating and storing it first if needed.
+//
+// Parameters:
+//   - ctx: Context of the storage operations.
+//   - store: Storage holding the original photo and its derivatives.
+//   - originalKey: Storage key of the original photo.
+//   - photoID: ID of the photo.
+//   - orientation: EXIF orientation of the original photo, 0 if unknown.
+//   - kind: The kind of derivative.
+//
+// Returns:
+//   - string: Storage key of the derivative.
+//   - int64: Size of the derivative if it was generated by this call, 0 if it was already stored.
+//   - error: An error if the derivative could not be generated.
+func Ensure(ctx context.Context, store storage.Storage, originalKey string, photoID uint32, orientation uint16, kind Kind) (string, int64, error) {
+	key := Key(photoID, kind)
+	_, err := store.Stat(ctx, key)
+	if err == nil {
+		return key, 0, nil
+	}
+	if !errors.Is(err, storage.ErrNotExist) {
+		return "", 0, err
+	}
+	original, _, err := store.Get(ctx, originalKey)
+	if err != nil {
+		return "", 0, err
+	}
+	defer original.Close()
+
+	// Derivatives are small enough to be generated in memory, then stored in a single write.
+	var buf bytes.Buffer
+	if err := Generate(&buf, original, orientation, kind); err != nil {
+		return "", 0, err
+	}
+	size := int64(buf.Len())
+	if err := store.Put(ctx, key, &buf, size); err != nil {
+		return "", 0, fmt.Errorf("failed to store %s: %w", kind, err)
+	}
+	return key, size, nil
+}
+
+// scale shrinks an image so that it fits in a maxSize x maxSize square. Smaller images are left untouched.
+func scale(img image.Image, maxSize int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSize && height <= maxSize {
+		return img
+	}
+	if width >= height {
+		height = max(1, height*maxSize/width)
+		width = maxSize
+	} else {
+		width = max(1, width*maxSize/height)
+		height = maxSize
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// orient rotates and flips an image according to its EXIF orientation so that it is displayed upright.
+func orient(img image.Image, orientation uint16) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	// Orientations 5 to 8 swap the width and the height.
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Mirrored horizontally.
+				dx, dy = width-1-x, y
+			case 3: // Rotated 180°.
+				dx, dy = width-1-x, height-1-y
+			case 4: // Mirrored vertically.
+				dx, dy = x, height-1-y
+			case 5: // Mirrored along the top-left to bottom-right diagonal.
+				dx, dy = y, x
+			case 6: // Rotated 90° clockwise.
+				dx, dy = height-1-y, x
+			case 7: // Mirrored along the top-right to bottom-left diagonal.
+				dx, dy = height-1-y, width-1-x
+			case 8: // Rotated 90° counter-clockwise.
+				dx, dy = y, width-1-x
+			}
+			dst.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return dst
+}
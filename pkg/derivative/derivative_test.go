@@ -0,0 +1,81 @@
+package derivative
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"photos/pkg/storage"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOrient ensures that EXIF orientations are applied to the pixels.
+func TestOrient(t *testing.T) {
+	// 2x1 image: red on the left, blue on the right.
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, red)
+	img.Set(1, 0, blue)
+
+	oriented := orient(img, 6)
+	assert.Equal(t, image.Rect(0, 0, 1, 2), oriented.Bounds(), "Orientation 6 should swap width and height")
+	assert.Equal(t, red, oriented.At(0, 0), "Rotating clockwise should put the left pixel on top")
+	assert.Equal(t, blue, oriented.At(0, 1), "Rotating clockwise should put the right pixel at the bottom")
+
+	oriented = orient(img, 3)
+	assert.Equal(t, blue, oriented.At(0, 0), "Rotating 180° should swap the pixels")
+
+	assert.Equal(t, image.Image(img), orient(img, 1), "Orientation 1 should leave the image untouched")
+}
+
+// TestGenerate ensures that derivatives are scaled down to fit their maximum size.
+func TestGenerate(t *testing.T) {
+	original := &bytes.Buffer{}
+	err := png.Encode(original, image.NewRGBA(image.Rect(0, 0, 800, 200)))
+	assert.NoError(t, err)
+
+	thumbnail := &bytes.Buffer{}
+	err = Generate(thumbnail, bytes.NewReader(original.Bytes()), 8, Thumbnail)
+	assert.NoError(t, err, "Generate should not return an error")
+	cfg, err := jpeg.DecodeConfig(thumbnail)
+	assert.NoError(t, err, "The derivative should be a JPEG image")
+	assert.Equal(t, 100, cfg.Width, "The orientation should be applied before scaling")
+	assert.Equal(t, 400, cfg.Height, "The derivative should fit in the thumbnail size")
+
+	err = Generate(&bytes.Buffer{}, bytes.NewReader(original.Bytes()), 0, Kind("poster"))
+	assert.Error(t, err, "Generate should reject unknown kinds")
+}
+
+// TestEnsure ensures that derivatives are stored next to the originals and generated once.
+func TestEnsure(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewLocal(t.TempDir())
+	original := &bytes.Buffer{}
+	err := png.Encode(original, image.NewRGBA(image.Rect(0, 0, 800, 200)))
+	assert.NoError(t, err)
+	assert.NoError(t, store.Put(ctx, "ab/cd/photo.png", original, int64(original.Len())))
+
+	key, generated, err := Ensure(ctx, store, "ab/cd/photo.png", 7, 0, Preview)
+	assert.NoError(t, err, "Ensure should not return an error")
+	assert.Equal(t, "derivatives/7/preview.jpg", key)
+	info, err := store.Stat(ctx, key)
+	assert.NoError(t, err, "The derivative should be stored")
+	assert.Equal(t, info.Size, generated, "Ensure should report the size of the generated derivative")
+
+	assert.NoError(t, store.Delete(ctx, "ab/cd/photo.png"))
+	again, generated, err := Ensure(ctx, store, "ab/cd/photo.png", 7, 0, Preview)
+	assert.NoError(t, err, "A stored derivative should not be generated again")
+	assert.Equal(t, key, again)
+	assert.Zero(t, generated)
+	stored, err := store.Stat(ctx, again)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Size, stored.Size)
+
+	_, _, err = Ensure(ctx, store, "ab/cd/photo.png", 7, 0, Thumbnail)
+	assert.ErrorIs(t, err, storage.ErrNotExist, "Ensure should fail when the original is missing")
+}
@@ -0,0 +1,403 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoExif is returned when the image contains neither an EXIF nor an XMP segment.
+var ErrNoExif = errors.New("no exif data found")
+
+// Tags read from the EXIF directories.
+const (
+	tagMake               uint16 = 0x010F
+	tagModel              uint16 = 0x0110
+	tagOrientation        uint16 = 0x0112
+	tagDateTime           uint16 = 0x0132
+	tagExposureTime       uint16 = 0x829A
+	tagFNumber            uint16 = 0x829D
+	tagExifIFDPointer     uint16 = 0x8769
+	tagGPSIFDPointer      uint16 = 0x8825
+	tagISO                uint16 = 0x8827
+	tagDateTimeOriginal   uint16 = 0x9003
+	tagOffsetTimeOriginal uint16 = 0x9011
+	tagFocalLength        uint16 = 0x920A
+	tagPixelXDimension    uint16 = 0xA002
+	tagPixelYDimension    uint16 = 0xA003
+	tagLensModel          uint16 = 0xA434
+
+	tagGPSLatitudeRef  uint16 = 0x0001
+	tagGPSLatitude     uint16 = 0x0002
+	tagGPSLongitudeRef uint16 = 0x0003
+	tagGPSLongitude    uint16 = 0x0004
+	tagGPSAltitudeRef  uint16 = 0x0005
+	tagGPSAltitude     uint16 = 0x0006
+)
+
+// Field types defined by the TIFF 6.0 specification.
+const (
+	typeByte      uint16 = 1
+	typeASCII     uint16 = 2
+	typeShort     uint16 = 3
+	typeLong      uint16 = 4
+	typeRational  uint16 = 5
+	typeUndefined uint16 = 7
+	typeSLong     uint16 = 9
+	typeSRational uint16 = 10
+)
+
+// typeSizes maps a TIFF field type to the size in bytes of a single value.
+var typeSizes = map[uint16]uint32{
+	typeByte:      1,
+	typeASCII:     1,
+	typeShort:     2,
+	typeLong:      4,
+	typeRational:  8,
+	typeUndefined: 1,
+	typeSLong:     4,
+	typeSRational: 8,
+}
+
+const exifDateLayout = "2006:01:02 15:04:05"
+
+var (
+	exifHeader = []byte("Exif\x00\x00")
+	xmpHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+)
+
+// GPS holds the location where a photo was taken.
+type GPS struct {
+	Latitude  float64  // Latitude in decimal degrees, negative in the southern hemisphere.
+	Longitude float64  // Longitude in decimal degrees, negative west of Greenwich.
+	Altitude  *float64 // Altitude in meters, nil if unknown.
+}
+
+// Metadata holds the values extracted from the EXIF and XMP segments of an image.
+// Fields are left to their zero value when the image does not provide them.
+type Metadata struct {
+	DateTimeOriginal time.Time // Capture date of the photo.
+	Make             string    // Camera manufacturer.
+	Model            string    // Camera model.
+	LensModel        string    // Lens model.
+	ExposureTime     string    // Exposure time as written by photographers, such as "1/250".
+	FNumber          float64   // Aperture f-number.
+	ISO              uint32    // ISO speed.
+	FocalLength      float64   // Focal length in millimeters.
+	Orientation      uint16    // EXIF orientation, from 1 to 8.
+	Width            uint32    // Width in pixels.
+	Height           uint32    // Height in pixels.
+	GPS              *GPS      // Location of the photo, nil if unknown.
+}
+
+// entry is a single raw field of an image file directory.
+type entry struct {
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+// tiff is a parsed TIFF structure as embedded in the EXIF APP1 segment.
+type tiff struct {
+	order binary.ByteOrder
+	data  []byte
+}
+
+// Decode reads the EXIF and XMP segments of a JPEG image and extracts its metadata.
+// Values found in the EXIF segment take precedence over the XMP ones.
+//
+// Parameters:
+//   - r: A reader positioned at the start of the JPEG file.
+//
+// Returns:
+//   - Metadata: The extracted metadata.
+//   - error: ErrNoExif if the image has no metadata segment, or a parsing error.
+func Decode(r io.Reader) (Metadata, error) {
+	exifSegment, xmpSegment, err := findSegments(r)
+	if err != nil {
+		return Metadata{}, err
+	}
+	var md Metadata
+	if exifSegment != nil {
+		err = decodeExif(exifSegment, &md)
+		if err != nil {
+			return Metadata{}, err
+		}
+	}
+	if xmpSegment != nil {
+		err = decodeXMP(xmpSegment, &md)
+		if err != nil && exifSegment == nil {
+			return Metadata{}, err
+		}
+	}
+	return md, nil
+}
+
+// decodeExif fills the metadata with the values of the EXIF segment.
+func decodeExif(segment []byte, md *Metadata) error {
+	t, err := parseTIFF(segment)
+	if err != nil {
+		return err
+	}
+	ifd0, err := t.readIFD(t.firstIFDOffset())
+	if err != nil {
+		return err
+	}
+	exifIFD := map[uint16]entry{}
+	if e, ok := ifd0[tagExifIFDPointer]; ok {
+		exifIFD, err = t.readIFD(t.uint32(e))
+		if err != nil {
+			return err
+		}
+	}
+
+	if e, ok := exifIFD[tagDateTimeOriginal]; ok {
+		md.DateTimeOriginal = parseDate(t.ascii(e), t.ascii(exifIFD[tagOffsetTimeOriginal]))
+	}
+	if e, ok := ifd0[tagDateTime]; ok && md.DateTimeOriginal.IsZero() {
+		md.DateTimeOriginal = parseDate(t.ascii(e), "")
+	}
+	md.Make = t.ascii(ifd0[tagMake])
+	md.Model = t.ascii(ifd0[tagModel])
+	md.Orientation = uint16(t.uint32(ifd0[tagOrientation]))
+	md.LensModel = t.ascii(exifIFD[tagLensModel])
+	md.ISO = t.uint32(exifIFD[tagISO])
+	md.Width = t.uint32(exifIFD[tagPixelXDimension])
+	md.Height = t.uint32(exifIFD[tagPixelYDimension])
+	if num, den, ok := t.rational(exifIFD[tagExposureTime], 0); ok {
+		md.ExposureTime = formatExposure(num, den)
+	}
+	if num, den, ok := t.rational(exifIFD[tagFNumber], 0); ok {
+		md.FNumber = float64(num) / float64(den)
+	}
+	if num, den, ok := t.rational(exifIFD[tagFocalLength], 0); ok {
+		md.FocalLength = float64(num) / float64(den)
+	}
+
+	if e, ok := ifd0[tagGPSIFDPointer]; ok {
+		gpsIFD, err := t.readIFD(t.uint32(e))
+		if err != nil {
+			return err
+		}
+		md.GPS = t.gps(gpsIFD)
+	}
+	return nil
+}
+
+// findSegments walks the JPEG markers and returns the payloads of the EXIF and XMP APP1 segments.
+func findSegments(r io.Reader) (exifSegment, xmpSegment []byte, err error) {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil {
+		return nil, nil, fmt.Errorf("failed to read jpeg header: %w", err)
+	}
+	if soi[0] != 0xFF || soi[1] != 0xD8 {
+		return nil, nil, ErrNoExif
+	}
+	for {
+		var marker [4]byte
+		if _, err := io.ReadFull(r, marker[:2]); err != nil {
+			break
+		}
+		if marker[0] != 0xFF {
+			return nil, nil, fmt.Errorf("invalid jpeg marker %#x", marker[0])
+		}
+		// Start of scan or end of image: no metadata after this point.
+		if marker[1] == 0xDA || marker[1] == 0xD9 {
+			break
+		}
+		// Markers without payload.
+		if marker[1] == 0x01 || (marker[1] >= 0xD0 && marker[1] <= 0xD7) || marker[1] == 0xFF {
+			continue
+		}
+		if _, err := io.ReadFull(r, marker[2:]); err != nil {
+			return nil, nil, fmt.Errorf("failed to read jpeg segment length: %w", err)
+		}
+		length := int(binary.BigEndian.Uint16(marker[2:])) - 2
+		if length < 0 {
+			return nil, nil, fmt.Errorf("invalid jpeg segment length %d", length)
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, nil, fmt.Errorf("failed to read jpeg segment: %w", err)
+		}
+		if marker[1] != 0xE1 {
+			continue
+		}
+		if exifSegment == nil && bytes.HasPrefix(payload, exifHeader) {
+			exifSegment = payload[len(exifHeader):]
+		}
+		if xmpSegment == nil && bytes.HasPrefix(payload, xmpHeader) {
+			xmpSegment = payload[len(xmpHeader):]
+		}
+	}
+	if exifSegment == nil && xmpSegment == nil {
+		return nil, nil, ErrNoExif
+	}
+	return exifSegment, xmpSegment, nil
+}
+
+// parseTIFF validates the TIFF header and detects the byte order.
+func parseTIFF(data []byte) (*tiff, error) {
+	if len(data) < 8 {
+		return nil, errors.New("tiff header too short")
+	}
+	t := &tiff{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("invalid tiff byte order %q", data[:2])
+	}
+	if t.order.Uint16(data[2:4]) != 0x2A {
+		return nil, errors.New("invalid tiff magic number")
+	}
+	return t, nil
+}
+
+func (t *tiff) firstIFDOffset() uint32 {
+	return t.order.Uint32(t.data[4:8])
+}
+
+// readIFD reads every entry of the image file directory at the given offset.
+func (t *tiff) readIFD(offset uint32) (map[uint16]entry, error) {
+	if uint64(offset)+2 > uint64(len(t.data)) {
+		return nil, fmt.Errorf("ifd offset %d out of bounds", offset)
+	}
+	count := uint32(t.order.Uint16(t.data[offset:]))
+	start := offset + 2
+	if uint64(start)+uint64(count)*12 > uint64(len(t.data)) {
+		return nil, fmt.Errorf("ifd at offset %d is truncated", offset)
+	}
+
+	entries := make(map[uint16]entry, count)
+	for i := uint32(0); i < count; i++ {
+		raw := t.data[start+i*12 : start+(i+1)*12]
+		tag := t.order.Uint16(raw[0:2])
+		typ := t.order.Uint16(raw[2:4])
+		n := t.order.Uint32(raw[4:8])
+		size, ok := typeSizes[typ]
+		if !ok {
+			continue
+		}
+		total := uint64(size) * uint64(n)
+		var data []byte
+		if total <= 4 {
+			data = raw[8 : 8+total]
+		} else {
+			valueOffset := uint64(t.order.Uint32(raw[8:12]))
+			if valueOffset+total > uint64(len(t.data)) {
+				continue
+			}
+			data = t.data[valueOffset : valueOffset+total]
+		}
+		entries[tag] = entry{typ: typ, count: n, data: data}
+	}
+	return entries, nil
+}
+
+// ascii returns the string value of an ASCII entry, without its NUL terminator.
+func (t *tiff) ascii(e entry) string {
+	if e.typ != typeASCII {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.data), "\x00"))
+}
+
+// uint32 returns the first value of a SHORT or LONG entry.
+func (t *tiff) uint32(e entry) uint32 {
+	switch {
+	case e.typ == typeShort && len(e.data) >= 2:
+		return uint32(t.order.Uint16(e.data))
+	case e.typ == typeLong && len(e.data) >= 4:
+		return t.order.Uint32(e.data)
+	}
+	return 0
+}
+
+// parseDate parses an EXIF date, using the optional offset ("+02:00") as time zone.
+// Dates without an offset are interpreted as UTC.
+func parseDate(value, offset string) time.Time {
+	if offset != "" {
+		date, err := time.Parse(exifDateLayout+"-07:00", value+offset)
+		if err == nil {
+			return date.UTC()
+		}
+	}
+	date, err := time.Parse(exifDateLayout, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return date
+}
+
+// rational returns the numerator and denominator of the i-th value of a RATIONAL entry.
+func (t *tiff) rational(e entry, i int) (uint32, uint32, bool) {
+	if e.typ != typeRational || len(e.data) < (i+1)*8 {
+		return 0, 0, false
+	}
+	num := t.order.Uint32(e.data[i*8:])
+	den := t.order.Uint32(e.data[i*8+4:])
+	if den == 0 {
+		return 0, 0, false
+	}
+	return num, den, true
+}
+
+// degrees converts a degrees, minutes, seconds RATIONAL triplet to decimal degrees.
+func (t *tiff) degrees(e entry) (float64, bool) {
+	var value float64
+	for i, unit := range []float64{1, 60, 3600} {
+		num, den, ok := t.rational(e, i)
+		if !ok {
+			return 0, false
+		}
+		value += float64(num) / float64(den) / unit
+	}
+	return value, true
+}
+
+// gps reads the location stored in the GPS directory.
+func (t *tiff) gps(ifd map[uint16]entry) *GPS {
+	lat, ok := t.degrees(ifd[tagGPSLatitude])
+	if !ok {
+		return nil
+	}
+	lon, ok := t.degrees(ifd[tagGPSLongitude])
+	if !ok {
+		return nil
+	}
+	if t.ascii(ifd[tagGPSLatitudeRef]) == "S" {
+		lat = -lat
+	}
+	if t.ascii(ifd[tagGPSLongitudeRef]) == "W" {
+		lon = -lon
+	}
+	gps := &GPS{Latitude: lat, Longitude: lon}
+	if num, den, ok := t.rational(ifd[tagGPSAltitude], 0); ok {
+		alt := float64(num) / float64(den)
+		if ref := ifd[tagGPSAltitudeRef]; len(ref.data) > 0 && ref.data[0] == 1 {
+			alt = -alt
+		}
+		gps.Altitude = &alt
+	}
+	return gps
+}
+
+// formatExposure formats an exposure time the way cameras display it: "1/250" or "2.5".
+func formatExposure(num, den uint32) string {
+	if num == 0 {
+		return "0"
+	}
+	if num < den {
+		return fmt.Sprintf("1/%d", int(math.Round(float64(den)/float64(num))))
+	}
+	return strconv.FormatFloat(float64(num)/float64(den), 'f', -1, 64)
+}
@@ -0,0 +1,372 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testEntry is a field written by buildTIFF, values are already encoded in the byte order of the file.
+type testEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// buildTIFF lays out IFD0, followed by the EXIF and GPS directories when they are not empty.
+// Pointers to the EXIF and GPS directories are added to IFD0 automatically.
+func buildTIFF(order binary.ByteOrder, ifd0, exifIFD, gpsIFD []testEntry) []byte {
+	ifdSize := func(entries []testEntry) uint32 {
+		size := uint32(2 + 12*len(entries) + 4)
+		for _, e := range entries {
+			if len(e.value) > 4 {
+				size += uint32(len(e.value))
+			}
+		}
+		return size
+	}
+	long := func(v uint32) []byte {
+		b := make([]byte, 4)
+		order.PutUint32(b, v)
+		return b
+	}
+
+	ifd0 = append([]testEntry{}, ifd0...)
+	if len(exifIFD) > 0 {
+		ifd0 = append(ifd0, testEntry{tag: tagExifIFDPointer, typ: typeLong, count: 1})
+	}
+	if len(gpsIFD) > 0 {
+		ifd0 = append(ifd0, testEntry{tag: tagGPSIFDPointer, typ: typeLong, count: 1})
+	}
+	exifOffset := 8 + ifdSize(ifd0)
+	gpsOffset := exifOffset + ifdSize(exifIFD)
+	for i := range ifd0 {
+		switch ifd0[i].tag {
+		case tagExifIFDPointer:
+			ifd0[i].value = long(exifOffset)
+		case tagGPSIFDPointer:
+			ifd0[i].value = long(gpsOffset)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if order == binary.LittleEndian {
+		buf.WriteString("II")
+	} else {
+		buf.WriteString("MM")
+	}
+	_ = binary.Write(buf, order, uint16(0x2A))
+	_ = binary.Write(buf, order, uint32(8))
+	for _, entries := range [][]testEntry{ifd0, exifIFD, gpsIFD} {
+		if len(entries) == 0 {
+			continue
+		}
+		start := uint32(buf.Len())
+		dataOffset := start + uint32(2+12*len(entries)+4)
+		var data []byte
+		_ = binary.Write(buf, order, uint16(len(entries)))
+		for _, e := range entries {
+			_ = binary.Write(buf, order, []uint16{e.tag, e.typ})
+			_ = binary.Write(buf, order, e.count)
+			if len(e.value) > 4 {
+				_ = binary.Write(buf, order, dataOffset+uint32(len(data)))
+				data = append(data, e.value...)
+			} else {
+				buf.Write(append(e.value, make([]byte, 4-len(e.value))...))
+			}
+		}
+		_ = binary.Write(buf, order, uint32(0))
+		buf.Write(data)
+	}
+	return buf.Bytes()
+}
+
+// buildJPEG wraps APP1 payloads in a minimal JPEG stream.
+func buildJPEG(segments ...[]byte) []byte {
+	jpeg := &bytes.Buffer{}
+	jpeg.Write([]byte{0xFF, 0xD8})
+	for _, segment := range segments {
+		jpeg.Write([]byte{0xFF, 0xE1})
+		_ = binary.Write(jpeg, binary.BigEndian, uint16(2+len(segment)))
+		jpeg.Write(segment)
+	}
+	jpeg.Write([]byte{0xFF, 0xDA})
+	return jpeg.Bytes()
+}
+
+func asciiEntry(tag uint16, value string) testEntry {
+	return testEntry{tag: tag, typ: typeASCII, count: uint32(len(value) + 1), value: append([]byte(value), 0)}
+}
+
+func rationalEntry(order binary.ByteOrder, tag uint16, values ...uint32) testEntry {
+	b := make([]byte, 4*len(values))
+	for i, v := range values {
+		order.PutUint32(b[i*4:], v)
+	}
+	return testEntry{tag: tag, typ: typeRational, count: uint32(len(values) / 2), value: b}
+}
+
+func shortEntry(order binary.ByteOrder, tag uint16, value uint16) testEntry {
+	b := make([]byte, 2)
+	order.PutUint16(b, value)
+	return testEntry{tag: tag, typ: typeShort, count: 1, value: b}
+}
+
+// TestDecodeDateTimeOriginal ensures that the capture date is read in both byte orders.
+func TestDecodeDateTimeOriginal(t *testing.T) {
+	expected := time.Date(2024, 10, 5, 21, 30, 0, 0, time.UTC)
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		tiff := buildTIFF(order, nil, []testEntry{asciiEntry(tagDateTimeOriginal, "2024:10:05 21:30:00")}, nil)
+		md, err := Decode(bytes.NewReader(buildJPEG(append(append([]byte{}, exifHeader...), tiff...))))
+		assert.NoError(t, err, "Decode should not return an error")
+		assert.Equal(t, expected, md.DateTimeOriginal, "DateTimeOriginal should be parsed")
+	}
+}
+
+// TestDecodeCameraAndGPS ensures that camera settings and the location are extracted.
+func TestDecodeCameraAndGPS(t *testing.T) {
+	order := binary.BigEndian
+	tiff := buildTIFF(order,
+		[]testEntry{
+			asciiEntry(tagMake, "Canon"),
+			asciiEntry(tagModel, "Canon EOS R6"),
+			shortEntry(order, tagOrientation, 6),
+		},
+		[]testEntry{
+			asciiEntry(tagDateTimeOriginal, "2024:10:05 21:30:00"),
+			asciiEntry(tagOffsetTimeOriginal, "+02:00"),
+			rationalEntry(order, tagExposureTime, 1, 250),
+			rationalEntry(order, tagFNumber, 28, 10),
+			shortEntry(order, tagISO, 3200),
+			rationalEntry(order, tagFocalLength, 50, 1),
+			asciiEntry(tagLensModel, "RF50mm F1.8 STM"),
+		},
+		[]testEntry{
+			asciiEntry(tagGPSLatitudeRef, "N"),
+			rationalEntry(order, tagGPSLatitude, 45, 1, 26, 1, 2400, 100),
+			asciiEntry(tagGPSLongitudeRef, "E"),
+			rationalEntry(order, tagGPSLongitude, 4, 1, 23, 1, 3600, 100),
+		},
+	)
+	md, err := Decode(bytes.NewReader(buildJPEG(append(append([]byte{}, exifHeader...), tiff...))))
+	assert.NoError(t, err, "Decode should not return an error")
+	assert.Equal(t, time.Date(2024, 10, 5, 19, 30, 0, 0, time.UTC), md.DateTimeOriginal, "The offset should be applied")
+	assert.Equal(t, "Canon", md.Make)
+	assert.Equal(t, "Canon EOS R6", md.Model)
+	assert.Equal(t, "RF50mm F1.8 STM", md.LensModel)
+	assert.Equal(t, uint16(6), md.Orientation)
+	assert.Equal(t, "1/250", md.ExposureTime)
+	assert.InDelta(t, 2.8, md.FNumber, 1e-9)
+	assert.Equal(t, uint32(3200), md.ISO)
+	assert.InDelta(t, 50, md.FocalLength, 1e-9)
+	if assert.NotNil(t, md.GPS, "GPS should be parsed") {
+		assert.InDelta(t, 45.44, md.GPS.Latitude, 1e-6)
+		assert.InDelta(t, 4.3933333, md.GPS.Longitude, 1e-6)
+		assert.Nil(t, md.GPS.Altitude)
+	}
+}
+
+// TestDecodeXMP ensures that XMP properties fill the fields missing from the EXIF segment.
+func TestDecodeXMP(t *testing.T) {
+	packet := `<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description xmlns:exif="http://ns.adobe.com/exif/1.0/" xmlns:aux="http://ns.adobe.com/exif/1.0/aux/"
+    xmlns:tiff="http://ns.adobe.com/tiff/1.0/"
+    exif:DateTimeOriginal="2024-10-05T21:30:00+02:00" exif:GPSLatitude="45,26.4S" exif:GPSLongitude="4,23.6W"
+    tiff:Model="ILCE-7M3" aux:Lens="FE 24-70mm F2.8 GM">
+   <exif:ISOSpeedRatings><rdf:Seq><rdf:li>800</rdf:li></rdf:Seq></exif:ISOSpeedRatings>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>`
+	tiff := buildTIFF(binary.LittleEndian, []testEntry{asciiEntry(tagMake, "SONY")}, nil, nil)
+	md, err := Decode(bytes.NewReader(buildJPEG(
+		append(append([]byte{}, exifHeader...), tiff...),
+		append(append([]byte{}, xmpHeader...), packet...),
+	)))
+	assert.NoError(t, err, "Decode should not return an error")
+	assert.Equal(t, time.Date(2024, 10, 5, 19, 30, 0, 0, time.UTC), md.DateTimeOriginal)
+	assert.Equal(t, "SONY", md.Make)
+	assert.Equal(t, "ILCE-7M3", md.Model)
+	assert.Equal(t, "FE 24-70mm F2.8 GM", md.LensModel)
+	assert.Equal(t, uint32(800), md.ISO)
+	if assert.NotNil(t, md.GPS, "GPS should be parsed") {
+		assert.InDelta(t, -45.44, md.GPS.Latitude, 1e-6)
+		assert.InDelta(t, -4.3933333, md.GPS.Longitude, 1e-6)
+	}
+}
+
+// TestDecodeNoExif ensures that images without EXIF data return ErrNoExif.
+func TestDecodeNoExif(t *testing.T) {
+	_, err := Decode(bytes.NewReader([]byte{0xFF, 0xD8, 0xFF, 0xDA}))
+	assert.ErrorIs(t, err, ErrNoExif, "Decode should return ErrNoExif")
+
+	_, err = Decode(bytes.NewReader([]byte("\x89PNG\r\n")))
+	assert.ErrorIs(t, err, ErrNoExif, "Decode should return ErrNoExif for non JPEG files")
+}
+
+// TestFormatExposure ensures that exposure times are formatted like cameras do.
+func TestFormatExposure(t *testing.T) {
+	assert.Equal(t, "1/250", formatExposure(1, 250))
+	assert.Equal(t, "1/125", formatExposure(10, 1250))
+	assert.Equal(t, "2.5", formatExposure(5, 2))
+}
+
+// TestStripJPEG ensures that the location, then every metadata, are removed from JPEG files.
+func TestStripJPEG(t *testing.T) {
+	order := binary.LittleEndian
+	tiff := buildTIFF(order,
+		[]testEntry{asciiEntry(tagMake, "Canon"), shortEntry(order, tagOrientation, 6)},
+		[]testEntry{asciiEntry(tagDateTimeOriginal, "2024:10:05 21:30:00")},
+		[]testEntry{
+			asciiEntry(tagGPSLatitudeRef, "N"),
+			rationalEntry(order, tagGPSLatitude, 45, 1, 26, 1, 2400, 100),
+			asciiEntry(tagGPSLongitudeRef, "E"),
+			rationalEntry(order, tagGPSLongitude, 4, 1, 23, 1, 3600, 100),
+		},
+	)
+	packet := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description xmlns:exif="http://ns.adobe.com/exif/1.0/" exif:GPSLatitude="45,26.4N" exif:GPSLongitude="4,23.6E"/>` +
+		`</rdf:RDF></x:xmpmeta>`
+	original := buildJPEG(
+		append(append([]byte{}, exifHeader...), tiff...),
+		append(append([]byte{}, xmpHeader...), packet...),
+	)
+	original = append(original, []byte("scan data")...)
+
+	stripped := &bytes.Buffer{}
+	err := Strip(stripped, bytes.NewReader(original), StripGPS)
+	assert.NoError(t, err, "Strip should not return an error")
+	size, err := StrippedSize(bytes.NewReader(original), int64(len(original)), StripGPS)
+	assert.NoError(t, err, "StrippedSize should not return an error")
+	assert.Equal(t, int64(stripped.Len()), size, "StrippedSize should match the stripped image")
+	md, err := Decode(bytes.NewReader(stripped.Bytes()))
+	assert.NoError(t, err, "Decode should not return an error")
+	assert.Nil(t, md.GPS, "The location should be removed")
+	assert.Equal(t, "Canon", md.Make, "Other metadata should be kept")
+	assert.False(t, bytes.Contains(stripped.Bytes(), xmpHeader), "XMP packets should be removed")
+	assert.True(t, bytes.HasSuffix(stripped.Bytes(), []byte("scan data")), "Image data should be copied as is")
+
+	stripped.Reset()
+	err = Strip(stripped, bytes.NewReader(original), StripAll)
+	assert.NoError(t, err, "Strip should not return an error")
+	size, err = StrippedSize(bytes.NewReader(original), int64(len(original)), StripAll)
+	assert.NoError(t, err, "StrippedSize should not return an error")
+	assert.Equal(t, int64(stripped.Len()), size, "StrippedSize should match the stripped image")
+	md, err = Decode(bytes.NewReader(stripped.Bytes()))
+	assert.NoError(t, err, "Decode should not return an error")
+	assert.Equal(t, Metadata{Orientation: 6}, md, "Only the orientation should be kept")
+	assert.True(t, bytes.HasSuffix(stripped.Bytes(), []byte("scan data")), "Image data should be copied as is")
+}
+
+// TestStripPNG ensures that metadata chunks are removed from PNG files.
+func TestStripPNG(t *testing.T) {
+	chunk := func(typ, data string) []byte {
+		b := make([]byte, 8, 12+len(data))
+		binary.BigEndian.PutUint32(b, uint32(len(data)))
+		copy(b[4:], typ)
+		b = append(b, data...)
+		return append(b, 0, 0, 0, 0)
+	}
+	original := append([]byte{}, pngSignature...)
+	original = append(original, chunk("IHDR", "header")...)
+	original = append(original, chunk("tEXt", "Author\x00someone")...)
+	original = append(original, chunk("eXIf", "MM\x00\x2a")...)
+	original = append(original, chunk("IDAT", "pixels")...)
+	original = append(original, chunk("IEND", "")...)
+
+	stripped := &bytes.Buffer{}
+	err := Strip(stripped, bytes.NewReader(original), StripGPS)
+	assert.NoError(t, err, "Strip should not return an error")
+	size, err := StrippedSize(bytes.NewReader(original), int64(len(original)), StripGPS)
+	assert.NoError(t, err, "StrippedSize should not return an error")
+	assert.Equal(t, int64(stripped.Len()), size, "StrippedSize should match the stripped image")
+	assert.NotContains(t, stripped.String(), "eXIf", "The EXIF chunk should be removed")
+	assert.Contains(t, stripped.String(), "tEXt", "Text chunks should be kept")
+
+	stripped.Reset()
+	err = Strip(stripped, bytes.NewReader(original), StripAll)
+	assert.NoError(t, err, "Strip should not return an error")
+	size, err = StrippedSize(bytes.NewReader(original), int64(len(original)), StripAll)
+	assert.NoError(t, err, "StrippedSize should not return an error")
+	assert.Equal(t, int64(stripped.Len()), size, "StrippedSize should match the stripped image")
+	expected := append([]byte{}, pngSignature...)
+	expected = append(expected, chunk("IHDR", "header")...)
+	expected = append(expected, chunk("IDAT", "pixels")...)
+	expected = append(expected, chunk("IEND", "")...)
+	assert.Equal(t, expected, stripped.Bytes(), "Only image chunks should be kept")
+}
+
+// TestStripWebP ensures that the location, then every metadata, are removed from WebP files.
+func TestStripWebP(t *testing.T) {
+	chunk := func(fourCC string, data []byte) []byte {
+		b := make([]byte, 8, 9+len(data))
+		copy(b, fourCC)
+		binary.LittleEndian.PutUint32(b[4:], uint32(len(data)))
+		b = append(b, data...)
+		if len(data)%2 == 1 {
+			b = append(b, 0)
+		}
+		return b
+	}
+	// findChunk returns the payload of the first chunk of a WebP file with a FourCC, nil if there is none.
+	findChunk := func(webp []byte, fourCC string) []byte {
+		for offset := 12; offset+8 <= len(webp); {
+			size := int(binary.LittleEndian.Uint32(webp[offset+4:]))
+			if string(webp[offset:offset+4]) == fourCC {
+				return webp[offset+8 : offset+8+size]
+			}
+			offset += 8 + size + size&1
+		}
+		return nil
+	}
+	order := binary.BigEndian
+	tiff := buildTIFF(order,
+		[]testEntry{asciiEntry(tagMake, "Canon")},
+		nil,
+		[]testEntry{
+			asciiEntry(tagGPSLatitudeRef, "N"),
+			rationalEntry(order, tagGPSLatitude, 45, 1, 26, 1, 2400, 100),
+			asciiEntry(tagGPSLongitudeRef, "E"),
+			rationalEntry(order, tagGPSLongitude, 4, 1, 23, 1, 3600, 100),
+		},
+	)
+	body := []byte("WEBP")
+	body = append(body, chunk("VP8X", []byte{webpFlagExif | webpFlagXMP, 0, 0, 0, 0, 0, 0, 0, 0, 0})...)
+	body = append(body, chunk("VP8 ", []byte("frame"))...)
+	body = append(body, chunk("EXIF", tiff)...)
+	body = append(body, chunk("XMP ", []byte("<x:xmpmeta/>"))...)
+	original := binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body)))
+	original = append(original, body...)
+
+	stripped := &bytes.Buffer{}
+	err := Strip(stripped, bytes.NewReader(original), StripGPS)
+	assert.NoError(t, err, "Strip should not return an error")
+	size, err := StrippedSize(bytes.NewReader(original), int64(len(original)), StripGPS)
+	assert.NoError(t, err, "StrippedSize should not return an error")
+	assert.Equal(t, int64(stripped.Len()), size, "StrippedSize should match the stripped image")
+	webp := stripped.Bytes()
+	assert.Equal(t, uint32(len(webp)-8), binary.LittleEndian.Uint32(webp[4:]), "The RIFF size should match the file")
+	assert.Nil(t, findChunk(webp, "XMP "), "XMP packets should be removed")
+	assert.Equal(t, byte(webpFlagExif), findChunk(webp, "VP8X")[0], "Only the EXIF flag should be left")
+	var md Metadata
+	assert.NoError(t, decodeExif(findChunk(webp, "EXIF"), &md), "The EXIF chunk should be kept")
+	assert.Nil(t, md.GPS, "The location should be removed")
+	assert.Equal(t, "Canon", md.Make, "Other metadata should be kept")
+	assert.Equal(t, []byte("frame"), findChunk(webp, "VP8 "), "Image data should be copied as is")
+
+	stripped.Reset()
+	err = Strip(stripped, bytes.NewReader(original), StripAll)
+	assert.NoError(t, err, "Strip should not return an error")
+	size, err = StrippedSize(bytes.NewReader(original), int64(len(original)), StripAll)
+	assert.NoError(t, err, "StrippedSize should not return an error")
+	assert.Equal(t, int64(stripped.Len()), size, "StrippedSize should match the stripped image")
+	webp = stripped.Bytes()
+	assert.Equal(t, uint32(len(webp)-8), binary.LittleEndian.Uint32(webp[4:]), "The RIFF size should match the file")
+	assert.Nil(t, findChunk(webp, "EXIF"), "The EXIF chunk should be removed")
+	assert.Nil(t, findChunk(webp, "XMP "), "XMP packets should be removed")
+	assert.Equal(t, byte(0), findChunk(webp, "VP8X")[0], "No metadata flag should be left")
+}
@@ -0,0 +1,432 @@
+package exif
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// StripMode tells which metadata is removed by Strip.
+type StripMode int
+
+const (
+	StripNone StripMode = iota // Keep every metadata segment.
+	StripGPS                   // Remove the location of the photo.
+	StripAll                   // Remove every metadata segment, except the orientation and color profile.
+)
+
+var (
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+	xmpExtHeader = []byte("http://ns.adobe.com/xmp/extension/\x00")
+)
+
+// pngMetadataChunks lists the PNG chunks removed by StripAll, StripGPS only removes eXIf and iTXt.
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+// VP8X flags announcing the EXIF and XMP chunks of a WebP file.
+const (
+	webpFlagXMP  = 0x04
+	webpFlagExif = 0x08
+)
+
+// Strip copies an image to w, removing its metadata according to the strip mode.
+//
+// JPEG, PNG and WebP files are rewritten segment by segment, so the image data is copied
+// without being decoded or recompressed. Other formats are copied untouched.
+//
+// Note that XMP packets are removed by StripGPS as well, since they can hold a copy of the location.
+//
+// Parameters:
+//   - w: Writer receiving the stripped image.
+//   - r: The original image, positioned at its start.
+//   - mode: The metadata to remove.
+//
+// Returns:
+//   - error: An error if the image could not be read or written.
+func Strip(w io.Writer, r io.ReadSeeker, mode StripMode) error {
+	if mode == StripNone {
+		_, err := io.Copy(w, r)
+		return err
+	}
+	var magic [12]byte
+	n, err := io.ReadFull(r, magic[:])
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	switch {
+	case n >= 2 && magic[0] == 0xFF && magic[1] == 0xD8:
+		return stripJPEG(w, r, mode)
+	case n >= 8 && bytes.Equal(magic[:8], pngSignature):
+		return stripPNG(w, r, mode)
+	case n >= 12 && string(magic[:4]) == "RIFF" && string(magic[8:12]) == "WEBP":
+		return stripWebP(w, r, mode)
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// StrippedSize returns the number of bytes Strip writes for an image, without copying it. Only the metadata segments
+// of JPEG files and the chunk headers of WebP files are read, the image data is skipped. PNG files are read whole,
+// since their metadata chunks can follow the image data.
+//
+// Parameters:
+//   - r: The original image, positioned at its start.
+//   - size: The size of the original image.
+//   - mode: The metadata to remove.
+//
+// Returns:
+//   - int64: The size of the stripped image.
+//   - error: An error if the image could not be read.
+func StrippedSize(r io.ReadSeeker, size int64, mode StripMode) (int64, error) {
+	if mode == StripNone {
+		return size, nil
+	}
+	var magic [12]byte
+	n, err := io.ReadFull(r, magic[:])
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	switch {
+	case n >= 2 && magic[0] == 0xFF && magic[1] == 0xD8:
+		read := &countingReader{r: r}
+		br := bufio.NewReader(read)
+		header := &countingWriter{}
+		if err := stripJPEGHeader(header, br, mode); err != nil {
+			return 0, err
+		}
+		// The image data following the header is copied as is.
+		return header.n + size - (read.n - int64(br.Buffered())), nil
+	case n >= 8 && bytes.Equal(magic[:8], pngSignature):
+		stripped := &countingWriter{}
+		err := stripPNG(stripped, r, mode)
+		return stripped.n, err
+	case n >= 12 && string(magic[:4]) == "RIFF" && string(magic[8:12]) == "WEBP":
+		_, riffSize, err := keptWebPChunks(r, mode)
+		return 8 + int64(riffSize), err
+	}
+	return size, nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// countingWriter counts the bytes written to it and discards them.
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// stripJPEG rewrites the APP segments of a JPEG stream and copies the entropy-coded data as is.
+func stripJPEG(w io.Writer, r io.Reader, mode StripMode) error {
+	br := bufio.NewReader(r)
+	if err := stripJPEGHeader(w, br, mode); err != nil {
+		return err
+	}
+	_, err := io.Copy(w, br)
+	return err
+}
+
+// stripJPEGHeader rewrites the segments of a JPEG stream up to the start of its image data, which is left in br.
+func stripJPEGHeader(w io.Writer, br *bufio.Reader, mode StripMode) error {
+	var soi [2]byte
+	if _, err := io.ReadFull(br, soi[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(soi[:]); err != nil {
+		return err
+	}
+	for {
+		var marker [4]byte
+		if _, err := io.ReadFull(br, marker[:2]); err != nil {
+			return fmt.Errorf("failed to read jpeg marker: %w", err)
+		}
+		if marker[0] != 0xFF {
+			return fmt.Errorf("invalid jpeg marker %#x", marker[0])
+		}
+		// Start of scan: the rest of the file is image data.
+		if marker[1] == 0xDA || marker[1] == 0xD9 {
+			_, err := w.Write(marker[:2])
+			return err
+		}
+		if marker[1] == 0x01 || (marker[1] >= 0xD0 && marker[1] <= 0xD7) || marker[1] == 0xFF {
+			if _, err := w.Write(marker[:2]); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := io.ReadFull(br, marker[2:]); err != nil {
+			return fmt.Errorf("failed to read jpeg segment length: %w", err)
+		}
+		length := int(binary.BigEndian.Uint16(marker[2:])) - 2
+		if length < 0 {
+			return fmt.Errorf("invalid jpeg segment length %d", length)
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return fmt.Errorf("failed to read jpeg segment: %w", err)
+		}
+
+		payload, keep := filterJPEGSegment(marker[1], payload, mode)
+		if !keep {
+			continue
+		}
+		binary.BigEndian.PutUint16(marker[2:], uint16(len(payload)+2))
+		if _, err := w.Write(marker[:]); err != nil {
+			return err
+		}
+		if _, err := w.Write(payload); err != nil {
+			return err
+		}
+	}
+}
+
+// filterJPEGSegment decides whether a segment is kept, and returns its possibly rewritten payload.
+func filterJPEGSegment(marker byte, payload []byte, mode StripMode) ([]byte, bool) {
+	switch {
+	case marker == 0xE1 && bytes.HasPrefix(payload, exifHeader):
+		if mode == StripGPS {
+			return append(exifHeader[:len(exifHeader):len(exifHeader)], removeGPS(payload[len(exifHeader):])...), true
+		}
+		orientation := readOrientation(payload[len(exifHeader):])
+		if orientation <= 1 {
+			return nil, false
+		}
+		return append(exifHeader[:len(exifHeader):len(exifHeader)], orientationTIFF(orientation)...), true
+	case marker == 0xE1 && (bytes.HasPrefix(payload, xmpHeader) || bytes.HasPrefix(payload, xmpExtHeader)):
+		return nil, false
+	case mode == StripAll && (marker == 0xFE || marker == 0xED || (marker >= 0xE3 && marker <= 0xEC) || marker == 0xE1):
+		// Comments, Photoshop/IPTC and vendor segments. APP0 (JFIF), APP2 (ICC profile),
+		// APP14 (Adobe color transform) and APP15 are needed to render the image correctly.
+		return nil, false
+	}
+	return payload, true
+}
+
+// removeGPS empties the GPS directory of a TIFF structure in place and erases its values.
+// The layout of the structure is left untouched so no offset has to be rewritten.
+func removeGPS(segment []byte) []byte {
+	data := append([]byte{}, segment...)
+	t, err := parseTIFF(data)
+	if err != nil {
+		return data
+	}
+	ifd0, err := t.readIFD(t.firstIFDOffset())
+	if err != nil {
+		return data
+	}
+	pointer, ok := ifd0[tagGPSIFDPointer]
+	if !ok {
+		return data
+	}
+	offset := t.uint32(pointer)
+	gpsIFD, err := t.readIFD(offset)
+	if err != nil {
+		return data
+	}
+	// Values stored outside of the entries are slices of data, erase them first.
+	for _, e := range gpsIFD {
+		clear(e.data)
+	}
+	count := uint32(t.order.Uint16(data[offset:]))
+	clear(data[offset : offset+2+count*12])
+	// The next IFD offset now reads as the first erased entry, which is zero.
+	return data
+}
+
+// readOrientation returns the orientation stored in IFD0 of a TIFF structure, 0 if there is none.
+func readOrientation(segment []byte) uint16 {
+	t, err := parseTIFF(segment)
+	if err != nil {
+		return 0
+	}
+	ifd0, err := t.readIFD(t.firstIFDOffset())
+	if err != nil {
+		return 0
+	}
+	return uint16(t.uint32(ifd0[tagOrientation]))
+}
+
+// orientationTIFF builds a TIFF structure holding only the orientation tag.
+func orientationTIFF(orientation uint16) []byte {
+	data := make([]byte, 26)
+	copy(data, "MM")
+	binary.BigEndian.PutUint16(data[2:], 0x2A)
+	binary.BigEndian.PutUint32(data[4:], 8)
+	binary.BigEndian.PutUint16(data[8:], 1)
+	binary.BigEndian.PutUint16(data[10:], tagOrientation)
+	binary.BigEndian.PutUint16(data[12:], typeShort)
+	binary.BigEndian.PutUint32(data[14:], 1)
+	binary.BigEndian.PutUint16(data[18:], orientation)
+	// Remaining bytes: value padding and a zero next IFD offset.
+	return data
+}
+
+// stripPNG copies the chunks of a PNG stream, dropping the metadata ones.
+func stripPNG(w io.Writer, r io.Reader, mode StripMode) error {
+	br := bufio.NewReader(r)
+	signature := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(br, signature); err != nil {
+		return err
+	}
+	if _, err := w.Write(signature); err != nil {
+		return err
+	}
+	for {
+		var header [8]byte
+		_, err := io.ReadFull(br, header[:])
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read png chunk: %w", err)
+		}
+		length := int64(binary.BigEndian.Uint32(header[:4]))
+		chunkType := string(header[4:])
+		drop := pngMetadataChunks[chunkType]
+		if mode == StripGPS {
+			drop = chunkType == "eXIf" || chunkType == "iTXt"
+		}
+		// Chunk data is followed by a 4 bytes CRC.
+		if drop {
+			if _, err := io.CopyN(io.Discard, br, length+4); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := w.Write(header[:]); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(w, br, length+4); err != nil {
+			return err
+		}
+		if chunkType == "IEND" {
+			return nil
+		}
+	}
+}
+
+// webpChunk is a chunk of the RIFF container of a WebP file.
+type webpChunk struct {
+	fourCC string
+	offset int64 // Offset of the payload in the file.
+	size   uint32
+}
+
+// stripWebP rewrites the RIFF container of a WebP file without its XMP chunk. StripAll removes the EXIF chunk as well,
+// while StripGPS only empties its GPS directory, which keeps the size of the chunk. The file is read twice: once to
+// compute the new container size, once to copy the chunks.
+func stripWebP(w io.Writer, r io.ReadSeeker, mode StripMode) error {
+	chunks, riffSize, err := keptWebPChunks(r, mode)
+	if err != nil {
+		return err
+	}
+
+	var header [8]byte
+	out := make([]byte, 12)
+	copy(out, "RIFF")
+	binary.LittleEndian.PutUint32(out[4:], riffSize)
+	copy(out[8:], "WEBP")
+	if _, err := w.Write(out); err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if _, err := r.Seek(chunk.offset, io.SeekStart); err != nil {
+			return err
+		}
+		copy(header[:4], chunk.fourCC)
+		binary.LittleEndian.PutUint32(header[4:], chunk.size)
+		if _, err := w.Write(header[:]); err != nil {
+			return err
+		}
+		padded := int64(chunk.size) + int64(chunk.size&1)
+		if chunk.fourCC != "VP8X" && chunk.fourCC != "EXIF" {
+			if _, err := io.CopyN(w, r, padded); err != nil {
+				return err
+			}
+			continue
+		}
+		payload := make([]byte, padded)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return err
+		}
+		switch {
+		case chunk.fourCC == "EXIF":
+			// Some encoders keep the header of the JPEG segment before the TIFF structure.
+			tiffStart := 0
+			if bytes.HasPrefix(payload, exifHeader) {
+				tiffStart = len(exifHeader)
+			}
+			copy(payload[tiffStart:], removeGPS(payload[tiffStart:chunk.size]))
+		case len(payload) > 0 && mode == StripAll:
+			payload[0] &^= webpFlagExif | webpFlagXMP
+		case len(payload) > 0:
+			payload[0] &^= webpFlagXMP
+		}
+		if _, err := w.Write(payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keptWebPChunks walks the chunks of the RIFF container of a WebP file, seeking over their payloads, and returns the
+// chunks kept by a strip mode along with the size of the stripped container.
+func keptWebPChunks(r io.ReadSeeker, mode StripMode) ([]webpChunk, uint32, error) {
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	var chunks []webpChunk
+	var riffSize uint32 = 4
+	var header [8]byte
+	for {
+		_, err := io.ReadFull(r, header[:])
+		if errors.Is(err, io.EOF) {
+			return chunks, riffSize, nil
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read webp chunk: %w", err)
+		}
+		offset, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, 0, err
+		}
+		chunk := webpChunk{fourCC: string(header[:4]), offset: offset, size: binary.LittleEndian.Uint32(header[4:])}
+		padded := int64(chunk.size) + int64(chunk.size&1)
+		if _, err := r.Seek(padded, io.SeekCurrent); err != nil {
+			return nil, 0, err
+		}
+		if chunk.fourCC == "XMP " || (chunk.fourCC == "EXIF" && mode == StripAll) {
+			continue
+		}
+		chunks = append(chunks, chunk)
+		riffSize += 8 + uint32(padded)
+	}
+}
@@ -0,0 +1,207 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XMP namespaces holding the properties mapped to Metadata.
+const (
+	nsRDF       = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	nsXMP       = "http://ns.adobe.com/xap/1.0/"
+	nsExif      = "http://ns.adobe.com/exif/1.0/"
+	nsExifEX    = "http://cipa.jp/exif/1.0/"
+	nsAux       = "http://ns.adobe.com/exif/1.0/aux/"
+	nsTIFF      = "http://ns.adobe.com/tiff/1.0/"
+	nsPhotoshop = "http://ns.adobe.com/photoshop/1.0/"
+)
+
+// xmpDateLayouts lists the date formats allowed by the XMP specification.
+var xmpDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// decodeXMP fills the fields of the metadata left empty by the EXIF segment with the XMP properties.
+func decodeXMP(segment []byte, md *Metadata) error {
+	props, err := readXMPProperties(segment)
+	if err != nil {
+		return err
+	}
+	get := func(ns, name string) string {
+		return props[xml.Name{Space: ns, Local: name}]
+	}
+
+	if md.DateTimeOriginal.IsZero() {
+		for _, value := range []string{get(nsExif, "DateTimeOriginal"), get(nsPhotoshop, "DateCreated"), get(nsXMP, "CreateDate")} {
+			if date := parseXMPDate(value); !date.IsZero() {
+				md.DateTimeOriginal = date
+				break
+			}
+		}
+	}
+	if md.Make == "" {
+		md.Make = get(nsTIFF, "Make")
+	}
+	if md.Model == "" {
+		md.Model = get(nsTIFF, "Model")
+	}
+	if md.LensModel == "" {
+		md.LensModel = get(nsExifEX, "LensModel")
+	}
+	if md.LensModel == "" {
+		md.LensModel = get(nsAux, "Lens")
+	}
+	if md.Orientation == 0 {
+		value, _ := strconv.ParseUint(get(nsTIFF, "Orientation"), 10, 16)
+		md.Orientation = uint16(value)
+	}
+	if md.ISO == 0 {
+		value, _ := strconv.ParseUint(get(nsExif, "ISOSpeedRatings"), 10, 32)
+		md.ISO = uint32(value)
+	}
+	if md.Width == 0 {
+		value, _ := strconv.ParseUint(get(nsExif, "PixelXDimension"), 10, 32)
+		md.Width = uint32(value)
+	}
+	if md.Height == 0 {
+		value, _ := strconv.ParseUint(get(nsExif, "PixelYDimension"), 10, 32)
+		md.Height = uint32(value)
+	}
+	if md.ExposureTime == "" {
+		if num, den, ok := parseXMPRational(get(nsExif, "ExposureTime")); ok {
+			md.ExposureTime = formatExposure(num, den)
+		}
+	}
+	if md.FNumber == 0 {
+		if num, den, ok := parseXMPRational(get(nsExif, "FNumber")); ok {
+			md.FNumber = float64(num) / float64(den)
+		}
+	}
+	if md.FocalLength == 0 {
+		if num, den, ok := parseXMPRational(get(nsExif, "FocalLength")); ok {
+			md.FocalLength = float64(num) / float64(den)
+		}
+	}
+	if md.GPS == nil {
+		lat, okLat := parseXMPCoordinate(get(nsExif, "GPSLatitude"))
+		lon, okLon := parseXMPCoordinate(get(nsExif, "GPSLongitude"))
+		if okLat && okLon {
+			md.GPS = &GPS{Latitude: lat, Longitude: lon}
+		}
+	}
+	return nil
+}
+
+// readXMPProperties collects the simple properties of an XMP packet, written either as
+// attributes of rdf:Description or as child elements. For arrays, the first item is kept.
+func readXMPProperties(segment []byte) (map[xml.Name]string, error) {
+	props := map[xml.Name]string{}
+	decoder := xml.NewDecoder(bytes.NewReader(segment))
+	var stack []xml.Name
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return props, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse xmp packet: %w", err)
+		}
+		switch tok := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, tok.Name)
+			for _, attr := range tok.Attr {
+				if attr.Name.Space == nsRDF || attr.Name.Space == "xmlns" {
+					continue
+				}
+				if _, ok := props[attr.Name]; !ok {
+					props[attr.Name] = strings.TrimSpace(attr.Value)
+				}
+			}
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			value := strings.TrimSpace(string(tok))
+			if value == "" {
+				continue
+			}
+			// Skip rdf:Seq, rdf:Bag, rdf:Alt and rdf:li to find the property holding the value.
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].Space == nsRDF {
+					continue
+				}
+				if _, ok := props[stack[i]]; !ok {
+					props[stack[i]] = value
+				}
+				break
+			}
+		}
+	}
+}
+
+// parseXMPDate parses an ISO 8601 date as used by XMP, dates without time zone are interpreted as UTC.
+func parseXMPDate(value string) time.Time {
+	for _, layout := range xmpDateLayouts {
+		date, err := time.Parse(layout, value)
+		if err == nil {
+			return date.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+// parseXMPRational parses a rational such as "1/250", or an integer.
+func parseXMPRational(value string) (uint32, uint32, bool) {
+	numStr, denStr, found := strings.Cut(value, "/")
+	if !found {
+		denStr = "1"
+	}
+	num, err := strconv.ParseUint(numStr, 10, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	den, err := strconv.ParseUint(denStr, 10, 32)
+	if err != nil || den == 0 {
+		return 0, 0, false
+	}
+	return uint32(num), uint32(den), true
+}
+
+// parseXMPCoordinate parses a GPS coordinate written as "DDD,MM.mmk" or "DDD,MM,SSk",
+// where k is one of N, S, E or W.
+func parseXMPCoordinate(value string) (float64, bool) {
+	if len(value) < 2 {
+		return 0, false
+	}
+	ref := value[len(value)-1]
+	parts := strings.Split(value[:len(value)-1], ",")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+	var coord float64
+	for i, unit := range []float64{1, 60, 3600}[:len(parts)] {
+		part, err := strconv.ParseFloat(parts[i], 64)
+		if err != nil {
+			return 0, false
+		}
+		coord += part / unit
+	}
+	switch ref {
+	case 'S', 'W':
+		return -coord, true
+	case 'N', 'E':
+		return coord, true
+	}
+	return 0, false
+}
@@ -0,0 +1,140 @@
+package face_detection
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+)
+
+// ErrNoConsent is returned when linking or claiming a face group, or showing the name of a user on their faces, for a
+// user who did not consent to face recognition.
+var ErrNoConsent = errors.New("user did not consent to face recognition")
+
+// GetConsent returns the consent of a user to face recognition.
+//
+// Parameters:
+//   - ctx: Context of the request.
+//   - q: Queries reading the consent.
+//   - userID: ID of the user.
+//
+// Returns:
+//   - query.FaceConsentsConsent: The consent of the user, UNKNOWN if the user was never asked.
+//   - error: A database error, if any.
+func GetConsent(ctx context.Context, q query.Querier, userID uint32) (query.FaceConsentsConsent, error) {
+	consent, err := q.GetFaceConsent(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return query.FaceConsentsConsentUNKNOWN, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return consent.Consent, nil
+}
+
+// SetConsent saves the consent of a user to face recognition and records the change. Unless the consent is granted,
+// the faces of the user are deleted along with their face groups, and the photos the user was recognized on, the
+// claims of the user and the choice to show their name are forgotten. The face detectors must reload their faces afterwards.
+//
+// Parameters:
+//   - ctx: Context of the request.
+//   - database: Database holding the consents.
+//   - userID: ID of the user.
+//   - consent: New consent of the user.
+//   - changedBy: ID of the user making the change, the user or an administrator.
+//   - ip: Address the change was made from.
+//
+// Returns:
+//   - bool: Whether the consent changed.
+//   - error: A database error, if any.
+func SetConsent(ctx context.Context, database *db.DB, userID uint32, consent query.FaceConsentsConsent, changedBy uint32, ip string) (bool, error) {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := database.WithTx(tx)
+
+	previous, err := GetConsent(ctx, qtx, userID)
+	if err != nil {
+		return false, err
+	}
+	if previous == consent {
+		return false, nil
+	}
+	err = qtx.SaveFaceConsent(ctx, query.SaveFaceConsentParams{UserID: userID, Consent: consent})
+	if err != nil {
+		return false, fmt.Errorf("failed to save consent: %w", err)
+	}
+	err = qtx.CreateFaceConsentChange(ctx, query.CreateFaceConsentChangeParams{
+		UserID:          sql.NullInt32{Int32: int32(userID), Valid: true},
+		Consent:         query.FaceConsentChangesConsent(consent),
+		PreviousConsent: query.FaceConsentChangesPreviousConsent(previous),
+		ChangedBy:       sql.NullInt32{Int32: int32(changedBy), Valid: changedBy != 0},
+		Ip:              ip,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to record consent change: %w", err)
+	}
+	if consent != query.FaceConsentsConsentGRANTED {
+		if err := DeleteUserFaces(ctx, qtx, userID); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// DeleteUserFaces deletes the face groups linked to a user along with their faces, and forgets the photos the user
+// was recognized on, the face groups the user claimed and the choice to show their name. The face detectors must reload
+// their faces afterwards.
+//
+// Parameters:
+//   - ctx: Context of the request.
+//   - qtx: Queries of the transaction deleting the faces.
+//   - userID: ID of the user.
+//
+// Returns:
+//   - error: A database error, if any.
+func DeleteUserFaces(ctx context.Context, qtx query.Querier, userID uint32) error {
+	id := sql.NullInt32{Int32: int32(userID), Valid: true}
+	if err := qtx.DeleteImageFacesOfUser(ctx, id); err != nil {
+		return err
+	}
+	if err := qtx.DeleteFaceGroupsOfUser(ctx, id); err != nil {
+		return err
+	}
+	if err := qtx.DeleteFaceGroupClaimsOfUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := qtx.DeletePublicFaceName(ctx, userID); err != nil {
+		return err
+	}
+	return qtx.DeleteRecognizedUsersOfUser(ctx, userID)
+}
+
+// SetPublicName chooses whether the name of a user is shown to every viewer of the photos the user was recognized on.
+// Otherwise, only admins and the user see it.
+//
+// Parameters:
+//   - ctx: Context of the request.
+//   - q: Queries saving the choice.
+//   - userID: ID of the user.
+//   - public: Whether the name is shown to everyone.
+//
+// Returns:
+//   - error: ErrNoConsent if the user did not consent to face recognition, or a database error.
+func SetPublicName(ctx context.Context, q query.Querier, userID uint32, public bool) error {
+	if !public {
+		return q.DeletePublicFaceName(ctx, userID)
+	}
+	consent, err := GetConsent(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+	if consent != query.FaceConsentsConsentGRANTED {
+		return ErrNoConsent
+	}
+	return q.CreatePublicFaceName(ctx, userID)
+}
@@ -0,0 +1,96 @@
+package face_detection
+
+import (
+	"context"
+	"database/sql"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetConsent(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	q := query.New(mockDB)
+
+	mock.ExpectQuery("FROM face_consents").WithArgs(3).WillReturnError(sql.ErrNoRows)
+	consent, err := GetConsent(context.Background(), q, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, query.FaceConsentsConsentUNKNOWN, consent)
+
+	mock.ExpectQuery("FROM face_consents").WithArgs(3).WillReturnRows(sqlmock.NewRows(
+		[]string{"user_id", "consent", "update_date"}).AddRow(3, "GRANTED", time.Now()))
+	consent, err = GetConsent(context.Background(), q, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, query.FaceConsentsConsentGRANTED, consent)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetConsent(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	database := &db.DB{DB: mockDB, Querier: query.New(mockDB)}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM face_consents").WithArgs(3).WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO face_consents").WithArgs(3, "GRANTED").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO face_consent_changes").WithArgs(3, "GRANTED", "UNKNOWN", 3, "192.0.2.1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	changed, err := SetConsent(ctx, database, 3, query.FaceConsentsConsentGRANTED, 3, "192.0.2.1")
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM face_consents").WithArgs(3).WillReturnRows(sqlmock.NewRows(
+		[]string{"user_id", "consent", "update_date"}).AddRow(3, "GRANTED", time.Now()))
+	mock.ExpectRollback()
+	changed, err = SetConsent(ctx, database, 3, query.FaceConsentsConsentGRANTED, 3, "192.0.2.1")
+	assert.NoError(t, err)
+	assert.False(t, changed, "an unchanged consent must not be recorded")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM face_consents").WithArgs(3).WillReturnRows(sqlmock.NewRows(
+		[]string{"user_id", "consent", "update_date"}).AddRow(3, "GRANTED", time.Now()))
+	mock.ExpectExec("INSERT INTO face_consents").WithArgs(3, "REFUSED").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO face_consent_changes").WithArgs(3, "REFUSED", "GRANTED", 1, "").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("DELETE FROM image_faces").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM face_groups").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM face_group_claims").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM public_face_names").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM recognized_users").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	changed, err = SetConsent(ctx, database, 3, query.FaceConsentsConsentREFUSED, 1, "")
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetPublicName(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	q := query.New(mockDB)
+
+	mock.ExpectQuery("FROM face_consents").WithArgs(3).WillReturnRows(sqlmock.NewRows(
+		[]string{"user_id", "consent", "update_date"}).AddRow(3, "REFUSED", time.Now()))
+	assert.ErrorIs(t, SetPublicName(ctx, q, 3, true), ErrNoConsent)
+
+	mock.ExpectQuery("FROM face_consents").WithArgs(3).WillReturnRows(sqlmock.NewRows(
+		[]string{"user_id", "consent", "update_date"}).AddRow(3, "GRANTED", time.Now()))
+	mock.ExpectExec("INTO public_face_names").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, SetPublicName(ctx, q, 3, true))
+
+	mock.ExpectExec("DELETE FROM public_face_names").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, SetPublicName(ctx, q, 3, false), "names can be hidden without consent")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,48 @@
+package face_detection
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// EncodeDescriptor encodes a face descriptor as little-endian float32 values, the format of the descriptor column.
+func EncodeDescriptor(descriptor []float32) []byte {
+	data := make([]byte, 4*len(descriptor))
+	for i, value := range descriptor {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(value))
+	}
+	return data
+}
+
+// DecodeDescriptor decodes a face descriptor encoded by EncodeDescriptor.
+//
+// Parameters:
+//   - data: The encoded descriptor.
+//
+// Returns:
+//   - []float32: The descriptor.
+//   - error: An error if the length of data is not a multiple of 4.
+func DecodeDescriptor(data []byte) ([]float32, error) {
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("invalid face descriptor length %d", len(data))
+	}
+	descriptor := make([]float32, len(data)/4)
+	for i := range descriptor {
+		descriptor[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return descriptor, nil
+}
+
+// squaredDistance returns the squared euclidean distance between two descriptors, +Inf if their lengths differ.
+func squaredDistance(a, b []float32) float32 {
+	if len(a) != len(b) {
+		return float32(math.Inf(1))
+	}
+	var sum float32
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
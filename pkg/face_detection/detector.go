@@ -0,0 +1,345 @@
+package face_detection
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"photos/pkg/derivative"
+	"photos/pkg/metrics"
+	"photos/pkg/storage"
+	"photos/pkg/usage"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// sample is a known face the new faces are classified against.
+type sample struct {
+	imageFaceID uint32
+	faceGroupID uint32
+	descriptor  []float32
+}
+
+// faceDetector implements FaceDetector on top of a Backend, classifying the faces with their nearest known face.
+type faceDetector struct {
+	backendMutex sync.Mutex // Serializes the calls to the backend.
+	backend      Backend
+	threshold    float32
+	logger       zerolog.Logger
+
+	mutex   sync.Mutex // Guards the samples.
+	samples []sample
+}
+
+// New creates a face detector without any known face, ReloadFacesFromDatabase loads them.
+//
+// Parameters:
+//   - backend: Backend detecting the faces, closed by Close.
+//   - threshold: Maximum distance between two descriptors of the same person, 0 for the backend default.
+//   - logger: Logger receiving the detection events.
+//
+// Returns:
+//   - FaceDetector: The face detector.
+func New(backend Backend, threshold float32, logger zerolog.Logger) FaceDetector {
+	if threshold <= 0 {
+		threshold = backend.Threshold()
+	}
+	return &faceDetector{backend: backend, threshold: threshold, logger: logger}
+}
+
+func (fd *faceDetector) Model() string {
+	return fd.backend.Model()
+}
+
+func (fd *faceDetector) Close() error {
+	fd.backendMutex.Lock()
+	defer fd.backendMutex.Unlock()
+	return fd.backend.Close()
+}
+
+func (fd *faceDetector) ReloadFacesFromDatabase(ctx context.Context, database *db.DB) error {
+	rows, err := database.GetImageFaceSamples(ctx, fd.Model())
+	if err != nil {
+		return err
+	}
+	samples := make([]sample, 0, len(rows))
+	for _, row := range rows {
+		descriptor, err := DecodeDescriptor(row.Descriptor)
+		if err != nil {
+			return fmt.Errorf("image face %d: %w", row.ImageFaceID, err)
+		}
+		samples = append(samples, sample{imageFaceID: row.ImageFaceID, faceGroupID: row.FaceGroupID, descriptor: descriptor})
+	}
+
+	fd.mutex.Lock()
+	defer fd.mutex.Unlock()
+	fd.samples = samples
+	return nil
+}
+
+func (fd *faceDetector) DetectFaces(ctx context.Context, database *db.DB, store storage.Storage, photoID uint32) ([]query.ImageFace, error) {
+	photo, err := database.GetPhoto(ctx, photoID)
+	if err != nil {
+		return nil, err
+	}
+	img, err := previewImage(ctx, database, store, photo)
+	if err != nil {
+		return nil, err
+	}
+	fd.backendMutex.Lock()
+	start := time.Now()
+	faces, err := fd.backend.Detect(img)
+	metrics.FaceDetectionDuration.WithLabelValues(fd.Model()).Observe(time.Since(start).Seconds())
+	fd.backendMutex.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect faces: %w", err)
+	}
+
+	// The samples stay locked until the faces are saved, so that concurrent detections classify against each other.
+	fd.mutex.Lock()
+	defer fd.mutex.Unlock()
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := database.WithTx(tx)
+
+	previous, err := qtx.GetImageFacesOfPhoto(ctx, photoID)
+	if err != nil {
+		return nil, err
+	}
+	if err := qtx.DeleteImageFacesOfPhoto(ctx, photoID); err != nil {
+		return nil, err
+	}
+	removedFaces := make(map[uint32]bool, len(previous))
+	removedGroups := make(map[uint32]bool)
+	for _, face := range previous {
+		removedFaces[face.ImageFaceID] = true
+		if removedGroups[face.FaceGroupID] {
+			continue
+		}
+		deleted, err := qtx.DeleteFaceGroupIfEmpty(ctx, face.FaceGroupID)
+		if err != nil {
+			return nil, err
+		}
+		removedGroups[face.FaceGroupID] = deleted > 0
+	}
+	samples := make([]sample, 0, len(fd.samples)+len(faces))
+	for _, s := range fd.samples {
+		if !removedFaces[s.imageFaceID] && !removedGroups[s.faceGroupID] {
+			samples = append(samples, s)
+		}
+	}
+
+	bounds := img.Bounds()
+	created := make([]query.ImageFace, 0, len(faces))
+	for _, face := range faces {
+		faceGroupID, ok := classify(samples, face.Descriptor, fd.threshold)
+		if !ok {
+			id, err := qtx.CreateFaceGroup(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create face group: %w", err)
+			}
+			faceGroupID = uint32(id)
+		}
+		imageFace := query.ImageFace{
+			PhotoID:       photoID,
+			FaceGroupID:   faceGroupID,
+			Model:         fd.Model(),
+			Descriptor:    EncodeDescriptor(face.Descriptor),
+			MinX:          relative(face.Rectangle.Min.X, bounds.Min.X, bounds.Dx()),
+			MinY:          relative(face.Rectangle.Min.Y, bounds.Min.Y, bounds.Dy()),
+			MaxX:          relative(face.Rectangle.Max.X, bounds.Min.X, bounds.Dx()),
+			MaxY:          relative(face.Rectangle.Max.Y, bounds.Min.Y, bounds.Dy()),
+			DetectionDate: time.Now(),
+		}
+		id, err := qtx.CreateImageFace(ctx, query.CreateImageFaceParams{
+			PhotoID:     imageFace.PhotoID,
+			FaceGroupID: imageFace.FaceGroupID,
+			Model:       imageFace.Model,
+			Descriptor:  imageFace.Descriptor,
+			MinX:        imageFace.MinX,
+			MinY:        imageFace.MinY,
+			MaxX:        imageFace.MaxX,
+			MaxY:        imageFace.MaxY,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to save face: %w", err)
+		}
+		imageFace.ImageFaceID = uint32(id)
+		created = append(created, imageFace)
+		samples = append(samples, sample{imageFaceID: imageFace.ImageFaceID, faceGroupID: faceGroupID, descriptor: face.Descriptor})
+		fd.logger.Debug().Uint32("photo_id", photoID).Uint32("face_group_id", faceGroupID).Bool("matched", ok).Msg("face detected")
+	}
+	if err := syncRecognizedUsers(ctx, qtx, photoID); err != nil {
+		return nil, err
+	}
+	err = qtx.SaveFaceScan(ctx, query.SaveFaceScanParams{PhotoID: photoID, Model: fd.Model(), Faces: uint32(len(faces))})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save face scan: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit faces: %w", err)
+	}
+	fd.samples = samples
+	return created, nil
+}
+
+func (fd *faceDetector) MergeCategories(sourceID uint32, destID uint32) {
+	fd.mutex.Lock()
+	defer fd.mutex.Unlock()
+
+	for i := range fd.samples {
+		if fd.samples[i].faceGroupID == sourceID {
+			fd.samples[i].faceGroupID = destID
+		}
+	}
+}
+
+func (fd *faceDetector) MergeImageFaces(imageFaceIDs []uint32, destFaceGroupID uint32) {
+	moved := make(map[uint32]bool, len(imageFaceIDs))
+	for _, id := range imageFaceIDs {
+		moved[id] = true
+	}
+
+	fd.mutex.Lock()
+	defer fd.mutex.Unlock()
+
+	for i := range fd.samples {
+		if moved[fd.samples[i].imageFaceID] {
+			fd.samples[i].faceGroupID = destFaceGroupID
+		}
+	}
+}
+
+func (fd *faceDetector) RecognizeUnlabeledFaces(ctx context.Context, database *db.DB) ([]query.ImageFace, error) {
+	unlabeledIDs, err := database.GetUnlabeledFaceGroupIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	unlabeled := make(map[uint32]bool, len(unlabeledIDs))
+	for _, id := range unlabeledIDs {
+		unlabeled[id] = true
+	}
+
+	fd.mutex.Lock()
+	defer fd.mutex.Unlock()
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := database.WithTx(tx)
+
+	// Unlabeled faces are classified against the labeled ones first, then against the ones already classified again.
+	samples := make([]sample, 0, len(fd.samples))
+	var unrecognized []sample
+	for _, s := range fd.samples {
+		if unlabeled[s.faceGroupID] {
+			unrecognized = append(unrecognized, s)
+		} else {
+			samples = append(samples, s)
+		}
+	}
+	var updated []query.ImageFace
+	sourceGroups := make(map[uint32]bool)
+	for _, s := range unrecognized {
+		match, ok := classify(samples, s.descriptor, fd.threshold)
+		if ok && match != s.faceGroupID {
+			err := qtx.UpdateImageFaceGroup(ctx, query.UpdateImageFaceGroupParams{FaceGroupID: match, ImageFaceID: s.imageFaceID})
+			if err != nil {
+				return nil, fmt.Errorf("failed to move face %d: %w", s.imageFaceID, err)
+			}
+			imageFace, err := qtx.GetImageFace(ctx, s.imageFaceID)
+			if err != nil {
+				return nil, err
+			}
+			updated = append(updated, imageFace)
+			sourceGroups[s.faceGroupID] = true
+			s.faceGroupID = match
+		}
+		samples = append(samples, s)
+	}
+	synced := make(map[uint32]bool)
+	for _, imageFace := range updated {
+		if synced[imageFace.PhotoID] {
+			continue
+		}
+		synced[imageFace.PhotoID] = true
+		if err := syncRecognizedUsers(ctx, qtx, imageFace.PhotoID); err != nil {
+			return nil, err
+		}
+	}
+	for faceGroupID := range sourceGroups {
+		if _, err := qtx.DeleteFaceGroupIfEmpty(ctx, faceGroupID); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit recognized faces: %w", err)
+	}
+	fd.samples = samples
+	return updated, nil
+}
+
+// classify returns the face group of the nearest sample, if it is closer than the threshold.
+func classify(samples []sample, descriptor []float32, threshold float32) (uint32, bool) {
+	best := threshold * threshold
+	var faceGroupID uint32
+	found := false
+	for _, s := range samples {
+		if d := squaredDistance(s.descriptor, descriptor); d < best {
+			best = d
+			faceGroupID = s.faceGroupID
+			found = true
+		}
+	}
+	return faceGroupID, found
+}
+
+// relative converts a pixel coordinate into a fraction of the image size, so that rectangles do not depend on the preview size.
+func relative(coordinate, origin, size int) float64 {
+	if size <= 0 {
+		return 0
+	}
+	return max(0, min(1, float64(coordinate-origin)/float64(size)))
+}
+
+// previewImage decodes the preview of a photo, generating it first if needed. Previews are upright,
+// and small enough for the backends while keeping the faces of group photos.
+func previewImage(ctx context.Context, database *db.DB, store storage.Storage, photo query.Photo) (image.Image, error) {
+	var orientation uint16
+	metadata, err := database.GetPhotoMetadata(ctx, photo.PhotoID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err == nil {
+		orientation = metadata.Orientation
+	}
+	key, generated, err := derivative.Ensure(ctx, store, photo.PathToPhoto, photo.PhotoID, orientation, derivative.Preview)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate preview: %w", err)
+	}
+	if generated > 0 {
+		if err := usage.AddDerivative(ctx, database.Querier, photo.EventID, derivative.Preview, generated); err != nil {
+			return nil, err
+		}
+	}
+	file, _, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode preview: %w", err)
+	}
+	return img, nil
+}
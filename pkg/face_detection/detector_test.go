@@ -0,0 +1,178 @@
+package face_detection
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"photos/pkg/derivative"
+	"photos/pkg/storage"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBackend returns the same faces for every image.
+type fakeBackend struct {
+	faces []Face
+	seen  image.Rectangle
+}
+
+func (b *fakeBackend) Model() string      { return "fake" }
+func (b *fakeBackend) Threshold() float32 { return 0.5 }
+func (b *fakeBackend) Close() error       { return nil }
+
+func (b *fakeBackend) Detect(img image.Image) ([]Face, error) {
+	b.seen = img.Bounds()
+	return b.faces, nil
+}
+
+func TestDescriptor(t *testing.T) {
+	descriptor := []float32{0, 1.5, -2.25, 3e-7}
+	data := EncodeDescriptor(descriptor)
+	assert.Len(t, data, 16)
+	decoded, err := DecodeDescriptor(data)
+	assert.NoError(t, err)
+	assert.Equal(t, descriptor, decoded)
+
+	_, err = DecodeDescriptor(data[:15])
+	assert.Error(t, err, "a truncated descriptor must be rejected")
+}
+
+func TestClassify(t *testing.T) {
+	samples := []sample{
+		{imageFaceID: 1, faceGroupID: 10, descriptor: []float32{0, 0}},
+		{imageFaceID: 2, faceGroupID: 20, descriptor: []float32{1, 0}},
+	}
+
+	group, ok := classify(samples, []float32{0.7, 0}, 0.5)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(20), group, "the nearest sample must win")
+
+	_, ok = classify(samples, []float32{0.5, 0.6}, 0.5)
+	assert.False(t, ok, "faces farther than the threshold from every sample must not match")
+
+	_, ok = classify(samples, []float32{0, 0, 0}, 0.5)
+	assert.False(t, ok, "descriptors of another length must not match")
+}
+
+func TestDetectFaces(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewLocal(t.TempDir())
+	preview := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	preview.Set(0, 0, color.White)
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, preview, nil))
+	assert.NoError(t, store.Put(ctx, derivative.Key(1, derivative.Preview), &buf, int64(buf.Len())))
+
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	database := &db.DB{DB: mockDB, Querier: query.New(mockDB)}
+
+	backend := &fakeBackend{faces: []Face{
+		{Rectangle: image.Rect(50, 25, 100, 75), Descriptor: []float32{0.1, 0}},
+		{Rectangle: image.Rect(150, -10, 250, 50), Descriptor: []float32{1, 1}},
+	}}
+	fd := New(backend, 0, zerolog.Nop()).(*faceDetector)
+	fd.samples = []sample{
+		{imageFaceID: 1, faceGroupID: 7, descriptor: []float32{0, 0}},
+		{imageFaceID: 3, faceGroupID: 9, descriptor: []float32{1, 1}},
+	}
+
+	mock.ExpectQuery("FROM photos WHERE photo_id").WithArgs(1).WillReturnRows(sqlmock.NewRows(
+		[]string{"photo_id", "path_to_photo", "file_hash", "perceptual_hash", "file_size", "creation_date", "event_id", "uploader_id", "is_hidden"}).
+		AddRow(1, "ab/cd/photo.jpg", "abcd", nil, 1000, time.Now(), 2, nil, false))
+	mock.ExpectQuery("FROM photo_metadata").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"photo_id"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM image_faces").WithArgs(1).WillReturnRows(sqlmock.NewRows(
+		[]string{"image_face_id", "photo_id", "face_group_id", "model", "descriptor", "min_x", "min_y", "max_x", "max_y", "detection_date"}).
+		AddRow(3, 1, 9, "fake", EncodeDescriptor([]float32{1, 1}), 0, 0, 1, 1, time.Now()))
+	mock.ExpectExec("DELETE FROM image_faces").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM face_groups").WithArgs(9).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO image_faces").
+		WithArgs(1, 7, "fake", EncodeDescriptor([]float32{0.1, 0}), 0.25, 0.25, 0.5, 0.75).
+		WillReturnResult(sqlmock.NewResult(10, 1))
+	mock.ExpectExec("INSERT INTO face_groups").WillReturnResult(sqlmock.NewResult(11, 1))
+	mock.ExpectExec("INSERT INTO image_faces").
+		WithArgs(1, 11, "fake", EncodeDescriptor([]float32{1, 1}), 0.75, 0.0, 1.0, 0.5).
+		WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectExec("DELETE FROM recognized_users").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO recognized_users").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO face_scans").WithArgs(1, "fake", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	faces, err := fd.DetectFaces(ctx, database, store, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, image.Rect(0, 0, 200, 100), backend.seen, "faces must be detected on the preview")
+	if assert.Len(t, faces, 2) {
+		assert.Equal(t, uint32(10), faces[0].ImageFaceID)
+		assert.Equal(t, uint32(7), faces[0].FaceGroupID, "a face close to a known one must join its group")
+		assert.Equal(t, uint32(11), faces[1].FaceGroupID, "faces of the previous scan must not be matched")
+	}
+	assert.Equal(t, []sample{
+		{imageFaceID: 1, faceGroupID: 7, descriptor: []float32{0, 0}},
+		{imageFaceID: 10, faceGroupID: 7, descriptor: []float32{0.1, 0}},
+		{imageFaceID: 12, faceGroupID: 11, descriptor: []float32{1, 1}},
+	}, fd.samples)
+}
+
+func TestRecognizeUnlabeledFaces(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	database := &db.DB{DB: mockDB, Querier: query.New(mockDB)}
+
+	fd := New(&fakeBackend{}, 0, zerolog.Nop()).(*faceDetector)
+	fd.samples = []sample{
+		{imageFaceID: 1, faceGroupID: 7, descriptor: []float32{0, 0}},
+		{imageFaceID: 2, faceGroupID: 8, descriptor: []float32{0.1, 0}},
+		{imageFaceID: 3, faceGroupID: 9, descriptor: []float32{5, 5}},
+	}
+
+	mock.ExpectQuery("FROM face_groups").WillReturnRows(sqlmock.NewRows([]string{"face_group_id"}).AddRow(8).AddRow(9))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE image_faces").WithArgs(7, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM image_faces").WithArgs(2).WillReturnRows(sqlmock.NewRows(
+		[]string{"image_face_id", "photo_id", "face_group_id", "model", "descriptor", "min_x", "min_y", "max_x", "max_y", "detection_date"}).
+		AddRow(2, 4, 7, "fake", EncodeDescriptor([]float32{0.1, 0}), 0, 0, 1, 1, time.Now()))
+	mock.ExpectExec("DELETE FROM recognized_users").WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO recognized_users").WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM face_groups").WithArgs(8).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	updated, err := fd.RecognizeUnlabeledFaces(context.Background(), database)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	if assert.Len(t, updated, 1) {
+		assert.Equal(t, uint32(2), updated[0].ImageFaceID)
+		assert.Equal(t, uint32(7), updated[0].FaceGroupID)
+	}
+	assert.Equal(t, uint32(7), fd.samples[1].faceGroupID)
+	assert.Equal(t, uint32(9), fd.samples[2].faceGroupID, "faces matching no other group must stay in theirs")
+}
+
+func TestMerge(t *testing.T) {
+	fd := New(&fakeBackend{}, 0, zerolog.Nop()).(*faceDetector)
+	fd.samples = []sample{{imageFaceID: 1, faceGroupID: 1}, {imageFaceID: 2, faceGroupID: 2}, {imageFaceID: 3, faceGroupID: 2}}
+
+	fd.MergeCategories(2, 5)
+	assert.Equal(t, []uint32{1, 5, 5}, groupsOf(fd.samples))
+	fd.MergeImageFaces([]uint32{1, 3}, 6)
+	assert.Equal(t, []uint32{6, 5, 6}, groupsOf(fd.samples))
+}
+
+func groupsOf(samples []sample) []uint32 {
+	groups := make([]uint32, len(samples))
+	for i, s := range samples {
+		groups[i] = s.faceGroupID
+	}
+	return groups
+}
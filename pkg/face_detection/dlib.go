@@ -0,0 +1,66 @@
+//go:build dlib && !no_face_detection
+
+package face_detection
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+
+	"github.com/Kagami/go-face"
+	"github.com/pkg/errors"
+)
+
+// The dlib backend needs cgo and the dlib libraries, it is only built with the dlib tag.
+func init() {
+	register("dlib", newDlibBackend)
+}
+
+// dlibBackend detects faces with the CNN detector of dlib and describes them with its ResNet model.
+type dlibBackend struct {
+	rec *face.Recognizer
+}
+
+// newDlibBackend loads the dlib models: mmod_human_face_detector.dat, shape_predictor_5_face_landmarks.dat
+// and dlib_face_recognition_resnet_model_v1.dat.
+func newDlibBackend(models string) (Backend, error) {
+	rec, err := face.NewRecognizer(models)
+	if err != nil {
+		return nil, errors.Wrap(err, "initialize facedetect recognizer")
+	}
+	return dlibBackend{rec: rec}, nil
+}
+
+func (b dlibBackend) Model() string {
+	return "dlib_face_recognition_resnet_model_v1"
+}
+
+// Threshold is the distance dlib recommends for its ResNet model.
+func (b dlibBackend) Threshold() float32 {
+	return 0.6
+}
+
+func (b dlibBackend) Detect(img image.Image) ([]Face, error) {
+	// The recognizer only reads encoded images.
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		return nil, errors.Wrap(err, "encode image")
+	}
+	found, err := b.rec.RecognizeCNN(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "error read faces")
+	}
+	faces := make([]Face, len(found))
+	for i, f := range found {
+		faces[i] = Face{
+			Rectangle:  f.Rectangle.Add(img.Bounds().Min),
+			Descriptor: f.Descriptor[:],
+		}
+	}
+	return faces, nil
+}
+
+func (b dlibBackend) Close() error {
+	b.rec.Close()
+	return nil
+}
@@ -1,16 +1,110 @@
 package face_detection
 
 import (
-	"gorm.io/gorm"
-	"face_detection/models"
+	"context"
+	"fmt"
+	"image"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"photos/pkg/storage"
+	"sort"
+
+	"github.com/rs/zerolog"
 )
 
+// FaceDetector finds the faces of the photos and groups the faces of the same person.
 type FaceDetector interface {
-	ReloadFacesFromDatabase(db *gorm.DB) error
-	DetectFaces(db *gorm.DB, media *models.Media) error
-	MergeCategories(sourceID int32, destID int32)
-	MergeImageFaces(imageFaceIDs []int, destFaceGroupID int32)
-	RecognizeUnlabeledFaces(tx *gorm.DB, user *models.User) ([]*models.ImageFace, error)
+	// Model names the model computing the descriptors, photos scanned with another model must be scanned again.
+	Model() string
+	// ReloadFacesFromDatabase replaces the in-memory face descriptors with the ones in the database.
+	ReloadFacesFromDatabase(ctx context.Context, database *db.DB) error
+	// DetectFaces finds the faces of a photo, replacing the ones found by a previous scan, and saves them to the database.
+	DetectFaces(ctx context.Context, database *db.DB, store storage.Storage, photoID uint32) ([]query.ImageFace, error)
+	// MergeCategories moves the in-memory faces of a face group to another one.
+	MergeCategories(sourceID uint32, destID uint32)
+	// MergeImageFaces moves in-memory faces to a face group.
+	MergeImageFaces(imageFaceIDs []uint32, destFaceGroupID uint32)
+	// RecognizeUnlabeledFaces classifies again the faces of the unlabeled face groups, and returns the ones which moved.
+	RecognizeUnlabeledFaces(ctx context.Context, database *db.DB) ([]query.ImageFace, error)
+	// Close releases the backend.
+	Close() error
 }
 
+// GlobalFaceDetector is the face detector set up by Initialize, nil if face detection is disabled.
 var GlobalFaceDetector FaceDetector = nil
+
+// Face is a face found in an image.
+type Face struct {
+	Rectangle  image.Rectangle // Bounds of the face in the image, in pixels.
+	Descriptor []float32       // Descriptor of the face, descriptors of the same person are close to each other.
+}
+
+// Backend finds the faces of images and computes their descriptors. Backends are not safe for concurrent use.
+type Backend interface {
+	// Model names the model computing the descriptors, descriptors of different models cannot be compared.
+	Model() string
+	// Threshold is the default maximum distance between two descriptors of the same person.
+	Threshold() float32
+	// Detect finds the faces of an image.
+	Detect(img image.Image) ([]Face, error)
+	// Close releases the model.
+	Close() error
+}
+
+// backends holds the constructors of the backends built in, by name. Constructors receive the directory of the model files.
+var backends = map[string]func(models string) (Backend, error){}
+
+// register makes a backend available to Initialize.
+func register(name string, newBackend func(models string) (Backend, error)) {
+	backends[name] = newBackend
+}
+
+// Backends lists the names of the backends built in.
+func Backends() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Options configures the face detector.
+type Options struct {
+	Backend   string         // Name of the backend detecting the faces.
+	Models    string         // Directory holding the model files of the backend.
+	Threshold float32        // Maximum distance between two descriptors of the same person, 0 for the backend default.
+	Logger    zerolog.Logger // Logger receiving the detection events.
+}
+
+// Initialize sets up GlobalFaceDetector with the faces already in the database.
+//
+// Parameters:
+//   - ctx: Context of the database queries.
+//   - database: Database holding the faces.
+//   - opts: Backend and classification options.
+//
+// Returns:
+//   - error: An error if the backend is not built in or could not be loaded, or if the faces could not be read.
+func Initialize(ctx context.Context, database *db.DB, opts Options) error {
+	if !Enabled {
+		opts.Logger.Info().Msg("face detection disabled at build time")
+		return nil
+	}
+	newBackend, ok := backends[opts.Backend]
+	if !ok {
+		return fmt.Errorf("face detection backend %q is not built in, available backends: %v", opts.Backend, Backends())
+	}
+	opts.Logger.Info().Str("backend", opts.Backend).Msg("initializing face detector")
+	backend, err := newBackend(opts.Models)
+	if err != nil {
+		return fmt.Errorf("failed to load face detection backend %s: %w", opts.Backend, err)
+	}
+	fd := New(backend, opts.Threshold, opts.Logger)
+	if err := fd.ReloadFacesFromDatabase(ctx, database); err != nil {
+		backend.Close()
+		return fmt.Errorf("failed to get face detection samples from database: %w", err)
+	}
+	GlobalFaceDetector = fd
+	return nil
+}
@@ -0,0 +1,6 @@
+//go:build !no_face_detection
+
+package face_detection
+
+// Enabled tells whether face detection is built in. Initialize leaves GlobalFaceDetector nil when it is not.
+const Enabled = true
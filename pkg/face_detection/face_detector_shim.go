@@ -2,13 +2,5 @@
 
 package face_detection
 
-import (
-	"log"
-
-	"gorm.io/gorm"
-)
-
-func InitializeFaceDetector(db *gorm.DB) error {
-	log.Printf("Face detection disabled (at build-time)")
-	return nil
-}
+// Enabled tells whether face detection is built in. Initialize leaves GlobalFaceDetector nil when it is not.
+const Enabled = false
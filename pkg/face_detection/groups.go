@@ -0,0 +1,270 @@
+package face_detection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"photos/pkg/storage"
+	"sort"
+
+	"golang.org/x/image/draw"
+)
+
+// ErrLinkedToOtherUser is returned when merging two face groups linked to different users.
+var ErrLinkedToOtherUser = errors.New("face groups linked to different users")
+
+// ErrNotInFaceGroup is returned when moving faces out of a face group they are not part of.
+var ErrNotInFaceGroup = errors.New("face not in face group")
+
+// MergeFaceGroups moves the faces of a face group to another one and deletes it. The destination group takes the
+// label and the user of the source group if it has none.
+//
+// Parameters:
+//   - ctx: Context of the request.
+//   - database: Database holding the face groups.
+//   - detector: Face detector whose faces are kept in sync, nil if face detection is disabled.
+//   - sourceID: ID of the face group to merge.
+//   - destID: ID of the face group receiving the faces.
+//
+// Returns:
+//   - error: ErrLinkedToOtherUser if the groups are linked to different users, sql.ErrNoRows if a group does not
+//     exist, or a database error.
+func MergeFaceGroups(ctx context.Context, database *db.DB, detector FaceDetector, sourceID, destID uint32) error {
+	if sourceID == destID {
+		return nil
+	}
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := database.WithTx(tx)
+
+	source, err := qtx.GetFaceGroup(ctx, sourceID)
+	if err != nil {
+		return err
+	}
+	dest, err := qtx.GetFaceGroup(ctx, destID)
+	if err != nil {
+		return err
+	}
+	if source.UserID.Valid && dest.UserID.Valid && source.UserID.Int32 != dest.UserID.Int32 {
+		return ErrLinkedToOtherUser
+	}
+	if !dest.Label.Valid && !dest.UserID.Valid && (source.Label.Valid || source.UserID.Valid) {
+		err = qtx.UpdateFaceGroupUser(ctx, query.UpdateFaceGroupUserParams{
+			Label:       source.Label,
+			UserID:      source.UserID,
+			FaceGroupID: destID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	photoIDs, err := qtx.GetPhotoIDsOfFaceGroup(ctx, sourceID)
+	if err != nil {
+		return err
+	}
+	err = qtx.MergeFaceGroup(ctx, query.MergeFaceGroupParams{FaceGroupID: destID, FaceGroupID_2: sourceID})
+	if err != nil {
+		return fmt.Errorf("failed to move faces: %w", err)
+	}
+	if _, err := qtx.DeleteFaceGroupIfEmpty(ctx, sourceID); err != nil {
+		return err
+	}
+	// The photos of the destination group gain its user if it was taken from the source group.
+	destPhotoIDs, err := qtx.GetPhotoIDsOfFaceGroup(ctx, destID)
+	if err != nil {
+		return err
+	}
+	for _, photoID := range union(photoIDs, destPhotoIDs) {
+		if err := syncRecognizedUsers(ctx, qtx, photoID); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit merge: %w", err)
+	}
+	if detector != nil {
+		detector.MergeCategories(sourceID, destID)
+	}
+	return nil
+}
+
+// MoveImageFaces moves faces of a face group to another one, and deletes the source group if it is left empty.
+//
+// Parameters:
+//   - ctx: Context of the request.
+//   - database: Database holding the face groups.
+//   - detector: Face detector whose faces are kept in sync, nil if face detection is disabled.
+//   - sourceID: ID of the face group holding the faces.
+//   - imageFaceIDs: IDs of the faces to move.
+//   - destID: ID of the face group receiving the faces.
+//
+// Returns:
+//   - error: ErrNotInFaceGroup if a face is not in the source group, sql.ErrNoRows if the destination group or a face
+//     does not exist, or a database error.
+func MoveImageFaces(ctx context.Context, database *db.DB, detector FaceDetector, sourceID uint32, imageFaceIDs []uint32, destID uint32) error {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := database.WithTx(tx)
+
+	if _, err := qtx.GetFaceGroup(ctx, destID); err != nil {
+		return err
+	}
+	for _, imageFaceID := range imageFaceIDs {
+		face, err := qtx.GetImageFace(ctx, imageFaceID)
+		if err != nil {
+			return fmt.Errorf("image face %d: %w", imageFaceID, err)
+		}
+		if face.FaceGroupID != sourceID {
+			return fmt.Errorf("image face %d: %w", imageFaceID, ErrNotInFaceGroup)
+		}
+	}
+	if err := moveImageFaces(ctx, qtx, imageFaceIDs, destID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit move: %w", err)
+	}
+	if detector != nil {
+		detector.MergeImageFaces(imageFaceIDs, destID)
+	}
+	return nil
+}
+
+// SplitFaceGroup moves faces to a new unlabeled face group, and deletes the groups left empty.
+//
+// Parameters:
+//   - ctx: Context of the request.
+//   - database: Database holding the face groups.
+//   - detector: Face detector whose faces are kept in sync, nil if face detection is disabled.
+//   - imageFaceIDs: IDs of the faces of the new group.
+//
+// Returns:
+//   - uint32: ID of the new face group.
+//   - error: sql.ErrNoRows if a face does not exist, or a database error.
+func SplitFaceGroup(ctx context.Context, database *db.DB, detector FaceDetector, imageFaceIDs []uint32) (uint32, error) {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := database.WithTx(tx)
+
+	id, err := qtx.CreateFaceGroup(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create face group: %w", err)
+	}
+	destID := uint32(id)
+	if err := moveImageFaces(ctx, qtx, imageFaceIDs, destID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit split: %w", err)
+	}
+	if detector != nil {
+		detector.MergeImageFaces(imageFaceIDs, destID)
+	}
+	return destID, nil
+}
+
+// moveImageFaces moves faces to a face group, updates the users recognized on their photos and deletes the groups
+// left empty.
+func moveImageFaces(ctx context.Context, qtx query.Querier, imageFaceIDs []uint32, destID uint32) error {
+	var photoIDs, sourceIDs []uint32
+	for _, imageFaceID := range imageFaceIDs {
+		face, err := qtx.GetImageFace(ctx, imageFaceID)
+		if err != nil {
+			return fmt.Errorf("image face %d: %w", imageFaceID, err)
+		}
+		err = qtx.UpdateImageFaceGroup(ctx, query.UpdateImageFaceGroupParams{FaceGroupID: destID, ImageFaceID: imageFaceID})
+		if err != nil {
+			return fmt.Errorf("failed to move face %d: %w", imageFaceID, err)
+		}
+		photoIDs = append(photoIDs, face.PhotoID)
+		sourceIDs = append(sourceIDs, face.FaceGroupID)
+	}
+	for _, photoID := range union(photoIDs) {
+		if err := syncRecognizedUsers(ctx, qtx, photoID); err != nil {
+			return err
+		}
+	}
+	for _, sourceID := range union(sourceIDs) {
+		if sourceID == destID {
+			continue
+		}
+		if _, err := qtx.DeleteFaceGroupIfEmpty(ctx, sourceID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FaceThumbnail crops a face out of the preview of its photo, with a margin around it, and scales it to a square
+// of the given side.
+//
+// Parameters:
+//   - ctx: Context of the request.
+//   - database: Database holding the faces.
+//   - store: Storage holding the photos and their derivatives.
+//   - imageFaceID: ID of the face.
+//   - side: Side of the thumbnail in pixels.
+//
+// Returns:
+//   - image.Image: The thumbnail.
+//   - error: sql.ErrNoRows if the face does not exist, or an error if the preview could not be read.
+func FaceThumbnail(ctx context.Context, database *db.DB, store storage.Storage, imageFaceID uint32, side int) (image.Image, error) {
+	face, err := database.GetImageFace(ctx, imageFaceID)
+	if err != nil {
+		return nil, err
+	}
+	photo, err := database.GetPhoto(ctx, face.PhotoID)
+	if err != nil {
+		return nil, err
+	}
+	img, err := previewImage(ctx, database, store, photo)
+	if err != nil {
+		return nil, err
+	}
+	return cropFace(img, face, side), nil
+}
+
+// cropFace crops the square around a face, a quarter of its size larger on each side, and scales it.
+func cropFace(img image.Image, face query.ImageFace, side int) image.Image {
+	bounds := img.Bounds()
+	minX := bounds.Min.X + int(face.MinX*float64(bounds.Dx()))
+	minY := bounds.Min.Y + int(face.MinY*float64(bounds.Dy()))
+	maxX := bounds.Min.X + int(face.MaxX*float64(bounds.Dx()))
+	maxY := bounds.Min.Y + int(face.MaxY*float64(bounds.Dy()))
+	size := max(maxX-minX, maxY-minY, 1)
+	size += size / 2
+	centerX, centerY := (minX+maxX)/2, (minY+maxY)/2
+	rect := image.Rect(centerX-size/2, centerY-size/2, centerX-size/2+size, centerY-size/2+size)
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.ApproxBiLinear.Scale(thumbnail, thumbnail.Bounds(), img, rect, draw.Src, nil)
+	return thumbnail
+}
+
+// union returns the distinct IDs of several lists, sorted.
+func union(lists ...[]uint32) []uint32 {
+	seen := make(map[uint32]bool)
+	var ids []uint32
+	for _, list := range lists {
+		for _, id := range list {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
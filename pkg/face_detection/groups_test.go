@@ -0,0 +1,145 @@
+package face_detection
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	faceGroupColumns = []string{"face_group_id", "label", "user_id", "creation_date"}
+	imageFaceColumns = []string{"image_face_id", "photo_id", "face_group_id", "model", "descriptor", "min_x", "min_y", "max_x", "max_y", "detection_date"}
+)
+
+func TestMergeFaceGroups(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	database := &db.DB{DB: mockDB, Querier: query.New(mockDB)}
+	fd := New(&fakeBackend{}, 0, zerolog.Nop()).(*faceDetector)
+	fd.samples = []sample{{imageFaceID: 1, faceGroupID: 7}, {imageFaceID: 2, faceGroupID: 8}}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM face_groups").WithArgs(8).WillReturnRows(sqlmock.NewRows(faceGroupColumns).
+		AddRow(8, "Jeanne Martin", 3, time.Now()))
+	mock.ExpectQuery("FROM face_groups").WithArgs(7).WillReturnRows(sqlmock.NewRows(faceGroupColumns).
+		AddRow(7, nil, nil, time.Now()))
+	mock.ExpectExec("UPDATE face_groups").WithArgs("Jeanne Martin", 3, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM image_faces").WithArgs(8).WillReturnRows(sqlmock.NewRows([]string{"photo_id"}).AddRow(2))
+	mock.ExpectExec("UPDATE image_faces").WithArgs(7, 8).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM face_groups").WithArgs(8).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM image_faces").WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"photo_id"}).AddRow(1).AddRow(2))
+	for _, photoID := range []int{1, 2} {
+		mock.ExpectExec("DELETE FROM recognized_users").WithArgs(photoID).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO recognized_users").WithArgs(photoID).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+	assert.NoError(t, MergeFaceGroups(ctx, database, fd, 8, 7))
+	assert.Equal(t, []uint32{7, 7}, groupsOf(fd.samples))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM face_groups").WithArgs(8).WillReturnRows(sqlmock.NewRows(faceGroupColumns).
+		AddRow(8, "Jeanne Martin", 3, time.Now()))
+	mock.ExpectQuery("FROM face_groups").WithArgs(9).WillReturnRows(sqlmock.NewRows(faceGroupColumns).
+		AddRow(9, "Paul Durand", 4, time.Now()))
+	mock.ExpectRollback()
+	assert.ErrorIs(t, MergeFaceGroups(ctx, database, fd, 8, 9), ErrLinkedToOtherUser)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSplitFaceGroup(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	database := &db.DB{DB: mockDB, Querier: query.New(mockDB)}
+	fd := New(&fakeBackend{}, 0, zerolog.Nop()).(*faceDetector)
+	fd.samples = []sample{{imageFaceID: 1, faceGroupID: 7}, {imageFaceID: 2, faceGroupID: 7}, {imageFaceID: 3, faceGroupID: 8}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO face_groups").WillReturnResult(sqlmock.NewResult(12, 1))
+	for _, face := range [][2]int{{2, 7}, {3, 8}} {
+		mock.ExpectQuery("FROM image_faces").WithArgs(face[0]).WillReturnRows(sqlmock.NewRows(imageFaceColumns).
+			AddRow(face[0], 5, face[1], "fake", []byte{}, 0, 0, 1, 1, time.Now()))
+		mock.ExpectExec("UPDATE image_faces").WithArgs(12, face[0]).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec("DELETE FROM recognized_users").WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO recognized_users").WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM face_groups").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM face_groups").WithArgs(8).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	id, err := SplitFaceGroup(context.Background(), database, fd, []uint32{2, 3})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, uint32(12), id)
+	assert.Equal(t, []uint32{7, 12, 12}, groupsOf(fd.samples))
+}
+
+func TestMoveImageFaces(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	database := &db.DB{DB: mockDB, Querier: query.New(mockDB)}
+	fd := New(&fakeBackend{}, 0, zerolog.Nop()).(*faceDetector)
+	fd.samples = []sample{{imageFaceID: 1, faceGroupID: 7}, {imageFaceID: 2, faceGroupID: 8}}
+	expectFace := func(imageFaceID, faceGroupID int) {
+		mock.ExpectQuery("FROM image_faces").WithArgs(imageFaceID).WillReturnRows(sqlmock.NewRows(imageFaceColumns).
+			AddRow(imageFaceID, 5, faceGroupID, "fake", []byte{}, 0, 0, 1, 1, time.Now()))
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM face_groups").WithArgs(9).WillReturnRows(sqlmock.NewRows(faceGroupColumns).
+		AddRow(9, nil, nil, time.Now()))
+	expectFace(1, 7)
+	expectFace(2, 8)
+	mock.ExpectRollback()
+	err = MoveImageFaces(ctx, database, fd, 7, []uint32{1, 2}, 9)
+	assert.ErrorIs(t, err, ErrNotInFaceGroup, "faces of another group must not be moved")
+	assert.Equal(t, []uint32{7, 8}, groupsOf(fd.samples))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM face_groups").WithArgs(9).WillReturnRows(sqlmock.NewRows(faceGroupColumns).
+		AddRow(9, nil, nil, time.Now()))
+	expectFace(1, 7)
+	expectFace(1, 7)
+	mock.ExpectExec("UPDATE image_faces").WithArgs(9, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM recognized_users").WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO recognized_users").WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM face_groups").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, MoveImageFaces(ctx, database, fd, 7, []uint32{1}, 9))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []uint32{9, 8}, groupsOf(fd.samples))
+}
+
+func TestCropFace(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 200, 100))
+	for y := 40; y < 60; y++ {
+		for x := 100; x < 120; x++ {
+			img.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	face := query.ImageFace{MinX: 0.5, MinY: 0.4, MaxX: 0.6, MaxY: 0.6}
+	thumbnail := cropFace(img, face, 30)
+	assert.Equal(t, image.Rect(0, 0, 30, 30), thumbnail.Bounds())
+	// The face covers the center of the thumbnail, the margin around it stays dark.
+	r, _, _, _ := thumbnail.At(15, 15).RGBA()
+	assert.Equal(t, uint32(0xffff), r)
+	r, _, _, _ = thumbnail.At(2, 2).RGBA()
+	assert.Equal(t, uint32(0), r)
+}
+
+func TestUnion(t *testing.T) {
+	assert.Equal(t, []uint32{1, 2, 5}, union([]uint32{5, 1}, []uint32{2, 5}))
+	assert.Empty(t, union())
+}
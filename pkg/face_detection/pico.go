@@ -0,0 +1,256 @@
+//go:build !no_face_detection
+
+package face_detection
+
+import (
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// The pico backend is written in Go only, it is always built unless face detection is disabled.
+func init() {
+	register("pico", newPicoBackend)
+}
+
+const (
+	// cascadeFile is the name of the optional pico cascade in the models directory, which replaces facefinder.
+	cascadeFile = "facefinder"
+	// embeddingFile is the name of the optional linear embedding in the models directory.
+	embeddingFile = "embedding.bin"
+)
+
+// facefinder is the face detection cascade of pico, as distributed by pigo under the license of
+// models/facefinder.LICENSE, built in so that the backend needs no model file.
+//
+//go:embed models/facefinder
+var facefinder []byte
+
+// Detection parameters of the pico backend, the defaults of pico.
+const (
+	picoScaleFactor = 1.1  // Ratio between two window sizes.
+	picoShiftFactor = 0.1  // Step between two windows, as a fraction of their size.
+	picoIoU         = 0.2  // Overlap above which two detections are the same face.
+	picoMinQuality  = 5.0  // Score under which clustered detections are discarded.
+	picoMinFraction = 25.0 // Smallest face, as a fraction of the smaller side of the image.
+	picoMinSize     = 20   // Smallest face in pixels.
+)
+
+// cascade is a pico cascade of decision trees comparing pixel intensities.
+type cascade struct {
+	depth      int       // Depth of every tree.
+	trees      int       // Number of trees.
+	codes      []int8    // Pixel offsets compared by the nodes, 4 per node, with an unused node 0 in each tree.
+	preds      []float32 // Leaf predictions, 2^depth per tree.
+	thresholds []float32 // Cumulated score under which a window is rejected after each tree.
+}
+
+// parseCascade decodes a pico cascade: 8 bytes of version, the depth and the number of trees as little-endian int32,
+// then for each tree 4*(2^depth-1) bytes of node codes, 2^depth float32 leaf predictions and a float32 threshold.
+func parseCascade(data []byte) (*cascade, error) {
+	if len(data) < 16 {
+		return nil, errors.New("cascade too short")
+	}
+	c := &cascade{
+		depth: int(binary.LittleEndian.Uint32(data[8:])),
+		trees: int(binary.LittleEndian.Uint32(data[12:])),
+	}
+	if c.depth < 1 || c.depth > 16 {
+		return nil, fmt.Errorf("invalid cascade tree depth %d", c.depth)
+	}
+	leaves := 1 << c.depth
+	treeSize := 4*(leaves-1) + 4*leaves + 4
+	if c.trees < 1 || len(data)-16 != c.trees*treeSize {
+		return nil, fmt.Errorf("cascade of %d bytes does not hold %d trees of depth %d", len(data), c.trees, c.depth)
+	}
+	pos := 16
+	for t := 0; t < c.trees; t++ {
+		c.codes = append(c.codes, 0, 0, 0, 0)
+		for _, b := range data[pos : pos+4*(leaves-1)] {
+			c.codes = append(c.codes, int8(b))
+		}
+		pos += 4 * (leaves - 1)
+		for i := 0; i < leaves; i++ {
+			c.preds = append(c.preds, math.Float32frombits(binary.LittleEndian.Uint32(data[pos:])))
+			pos += 4
+		}
+		c.thresholds = append(c.thresholds, math.Float32frombits(binary.LittleEndian.Uint32(data[pos:])))
+		pos += 4
+	}
+	return c, nil
+}
+
+// classify scores the square window of the given size centered on (row, col), negative if it is not a face.
+func (c *cascade) classify(gray *image.Gray, row, col, size int) float32 {
+	leaves := 1 << c.depth
+	row, col = row*256, col*256
+	var out float32
+	root := 0
+	for t := 0; t < c.trees; t++ {
+		idx := 1
+		for d := 0; d < c.depth; d++ {
+			node := c.codes[root+4*idx:]
+			p1 := gray.Pix[((row+int(node[0])*size)>>8)*gray.Stride+((col+int(node[1])*size)>>8)]
+			p2 := gray.Pix[((row+int(node[2])*size)>>8)*gray.Stride+((col+int(node[3])*size)>>8)]
+			idx = 2 * idx
+			if p1 <= p2 {
+				idx++
+			}
+		}
+		out += c.preds[leaves*t+idx-leaves]
+		if out <= c.thresholds[t] {
+			return -1
+		}
+		root += 4 * leaves
+	}
+	return out - c.thresholds[c.trees-1]
+}
+
+// detection is a window classified as a face.
+type detection struct {
+	row, col, size int
+	score          float32
+}
+
+// detect scans the image with windows of growing sizes and returns the windows classified as faces.
+func (c *cascade) detect(gray *image.Gray, minSize, maxSize int) []detection {
+	height, width := gray.Rect.Dy(), gray.Rect.Dx()
+	var detections []detection
+	for size := minSize; size <= maxSize; size = int(float64(size) * picoScaleFactor) {
+		step := max(int(picoShiftFactor*float64(size)), 1)
+		offset := size/2 + 1
+		for row := offset; row <= height-offset; row += step {
+			for col := offset; col <= width-offset; col += step {
+				if score := c.classify(gray, row, col, size); score > 0 {
+					detections = append(detections, detection{row: row, col: col, size: size, score: score})
+				}
+			}
+		}
+	}
+	return detections
+}
+
+// cluster merges the detections overlapping the best ones, averaging their windows and summing their scores.
+func cluster(detections []detection) []detection {
+	sort.SliceStable(detections, func(i, j int) bool { return detections[i].score > detections[j].score })
+	assigned := make([]bool, len(detections))
+	var clusters []detection
+	for i := range detections {
+		if assigned[i] {
+			continue
+		}
+		var row, col, size, n int
+		var score float32
+		for j := range detections {
+			if !assigned[j] && iou(detections[i], detections[j]) > picoIoU {
+				assigned[j] = true
+				row += detections[j].row
+				col += detections[j].col
+				size += detections[j].size
+				score += detections[j].score
+				n++
+			}
+		}
+		clusters = append(clusters, detection{row: row / n, col: col / n, size: size / n, score: score})
+	}
+	return clusters
+}
+
+// iou returns the intersection over union of two windows.
+func iou(a, b detection) float64 {
+	ar, ac, as := float64(a.row), float64(a.col), float64(a.size)
+	br, bc, bs := float64(b.row), float64(b.col), float64(b.size)
+	overRow := max(0, min(ar+as/2, br+bs/2)-max(ar-as/2, br-bs/2))
+	overCol := max(0, min(ac+as/2, bc+bs/2)-max(ac-as/2, bc-bs/2))
+	return overRow * overCol / (as*as + bs*bs - overRow*overCol)
+}
+
+// picoBackend detects faces with a pico cascade, and describes them with an embedding of their pixels.
+type picoBackend struct {
+	cascade   *cascade
+	embedding *embedding
+}
+
+// newPicoBackend loads the cascade and the embedding of the models directory, using the built in facefinder cascade
+// and lbpEmbedding for the missing ones.
+func newPicoBackend(models string) (Backend, error) {
+	data, err := os.ReadFile(filepath.Join(models, cascadeFile))
+	if errors.Is(err, os.ErrNotExist) {
+		data, err = facefinder, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c, err := parseCascade(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", cascadeFile, err)
+	}
+	e := lbpEmbedding
+	data, err = os.ReadFile(filepath.Join(models, embeddingFile))
+	if err == nil {
+		if e, err = parseEmbedding(data); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", embeddingFile, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return &picoBackend{cascade: c, embedding: e}, nil
+}
+
+// Model depends on the embedding only, the cascade changes the rectangles but not how they are described.
+func (b *picoBackend) Model() string {
+	return "pico-" + b.embedding.name
+}
+
+// Threshold depends on the embedding, descriptors of unit length being 0 to 2 apart.
+func (b *picoBackend) Threshold() float32 {
+	return b.embedding.threshold
+}
+
+func (b *picoBackend) Detect(img image.Image) ([]Face, error) {
+	gray := grayscale(img)
+	minSide := min(gray.Rect.Dx(), gray.Rect.Dy())
+	minSize := max(picoMinSize, int(float64(minSide)/picoMinFraction))
+	var faces []Face
+	for _, d := range cluster(b.cascade.detect(gray, minSize, minSide)) {
+		if d.score < picoMinQuality {
+			continue
+		}
+		rect := image.Rect(d.col-d.size/2, d.row-d.size/2, d.col+d.size/2, d.row+d.size/2)
+		faces = append(faces, Face{
+			Rectangle:  rect.Add(img.Bounds().Min),
+			Descriptor: b.embedding.describe(gray, rect),
+		})
+	}
+	return faces, nil
+}
+
+func (b *picoBackend) Close() error {
+	return nil
+}
+
+// grayscale converts an image to gray levels with its origin at (0, 0). The luma plane of JPEG images is used as is.
+func grayscale(img image.Image) *image.Gray {
+	bounds := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	if ycbcr, ok := img.(*image.YCbCr); ok {
+		for y := 0; y < bounds.Dy(); y++ {
+			start := ycbcr.YOffset(bounds.Min.X, bounds.Min.Y+y)
+			copy(gray.Pix[y*gray.Stride:], ycbcr.Y[start:start+bounds.Dx()])
+		}
+		return gray
+	}
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			gray.Pix[y*gray.Stride+x] = uint8((19595*r + 38470*g + 7471*b + 1<<15) >> 24)
+		}
+	}
+	return gray
+}
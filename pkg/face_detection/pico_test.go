@@ -0,0 +1,155 @@
+//go:build !no_face_detection
+
+package face_detection
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"image"
+	_ "image/jpeg"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/draw"
+)
+
+var update = flag.Bool("update", false, "Rewrite the golden detections of the pico backend")
+
+// testCascade returns a cascade of 3 trees of depth 2, matching windows whose center is darker than their 4 edges,
+// and whose edges are brighter than the points halfway to the center.
+func testCascade() []byte {
+	var buf bytes.Buffer
+	write := func(v any) { binary.Write(&buf, binary.LittleEndian, v) }
+	write([]int32{0, 0, 2, 3})
+	for i, pairs := range [][2][4]int8{
+		{{-120, 0, 0, 0}, {0, -120, 0, 0}},
+		{{120, 0, 0, 0}, {0, 120, 0, 0}},
+		{{-120, 0, -60, 0}, {120, 0, 60, 0}},
+	} {
+		// Node 1 goes to node 2 when its first pixel is brighter than its second one, node 2 to leaf 4 likewise.
+		write(pairs[0])
+		write(pairs[1])
+		write([]int8{0, 0, 0, 0})
+		write([]float32{1, -1, -1, -1})
+		write(float32(i))
+	}
+	return buf.Bytes()
+}
+
+// loadPhoto decodes a photo of the testdata directory.
+func loadPhoto(t *testing.T, name string) image.Image {
+	file, err := os.Open(filepath.Join("testdata", name))
+	assert.NoError(t, err)
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	assert.NoError(t, err)
+	return img
+}
+
+// scalePhoto resizes a photo by a factor.
+func scalePhoto(img image.Image, factor float64) image.Image {
+	scaled := image.NewRGBA(image.Rect(0, 0, int(float64(img.Bounds().Dx())*factor), int(float64(img.Bounds().Dy())*factor)))
+	draw.CatmullRom.Scale(scaled, scaled.Rect, img, img.Bounds(), draw.Src, nil)
+	return scaled
+}
+
+// TestPicoGolden runs the built in cascade on photos of two people, the test photos of pigo and caire under the license
+// of photos.LICENSE: each face is found where it was found before, and described closer to its scaled copy than to the
+// face of the other person.
+func TestPicoGolden(t *testing.T) {
+	backend, err := newPicoBackend(t.TempDir())
+	assert.NoError(t, err)
+	assert.Equal(t, "pico-lbp7x7", backend.Model())
+
+	photos := []string{"portrait.jpg", "outdoor.jpg"}
+	found := make(map[string][]image.Rectangle)
+	descriptors := make(map[string][]float32)
+	for _, name := range photos {
+		img := loadPhoto(t, name)
+		faces, err := backend.Detect(img)
+		assert.NoError(t, err)
+		if !assert.Len(t, faces, 1, name) {
+			return
+		}
+		found[name] = []image.Rectangle{faces[0].Rectangle}
+		descriptors[name] = faces[0].Descriptor
+
+		scaled, err := backend.Detect(scalePhoto(img, 0.6))
+		assert.NoError(t, err)
+		if !assert.Len(t, scaled, 1, name) {
+			return
+		}
+		descriptors[name+" scaled"] = scaled[0].Descriptor
+	}
+
+	goldenPath := filepath.Join("testdata", "faces.golden.json")
+	if *update {
+		data, err := json.MarshalIndent(found, "", "  ")
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(goldenPath, append(data, '\n'), 0644))
+	}
+	data, err := os.ReadFile(goldenPath)
+	assert.NoError(t, err)
+	var golden map[string][]image.Rectangle
+	assert.NoError(t, json.Unmarshal(data, &golden))
+	assert.Equal(t, golden, found)
+
+	threshold := backend.Threshold() * backend.Threshold()
+	for _, name := range photos {
+		assert.Less(t, squaredDistance(descriptors[name], descriptors[name+" scaled"]), threshold, name)
+	}
+	assert.Greater(t, squaredDistance(descriptors[photos[0]], descriptors[photos[1]]), threshold)
+	assert.Greater(t, squaredDistance(descriptors[photos[0]+" scaled"], descriptors[photos[1]+" scaled"]), threshold)
+}
+
+func TestParseCascade(t *testing.T) {
+	c, err := parseCascade(testCascade())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, c.depth)
+	assert.Equal(t, 3, c.trees)
+	assert.Len(t, c.codes, 3*4*4, "a node 0 must be added to every tree")
+	assert.Equal(t, []int8{0, 0, 0, 0, -120, 0, 0, 0}, c.codes[:8])
+
+	_, err = parseCascade(testCascade()[:40])
+	assert.Error(t, err, "a truncated cascade must be rejected")
+}
+
+func TestEmbedding(t *testing.T) {
+	var buf bytes.Buffer
+	write := func(v any) { binary.Write(&buf, binary.LittleEndian, v) }
+	buf.Write(embeddingMagic)
+	write([]uint32{1, 4, 2})
+	write(make([]float32, 16))
+	// The first axis sums the top half of the crop, the second the left half.
+	basis := make([]float32, 32)
+	for i := 0; i < 16; i++ {
+		if i < 8 {
+			basis[i] = 1
+		}
+		if i%4 < 2 {
+			basis[16+i] = 1
+		}
+	}
+	write(basis)
+	e, err := parseEmbedding(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Len(t, e.name, 12)
+
+	// A crop darker at the bottom only projects on the first axis.
+	img := image.NewGray(image.Rect(0, 0, 8, 8))
+	for i := range img.Pix[:32] {
+		img.Pix[i] = 255
+	}
+	descriptor := e.describe(img, img.Rect)
+	assert.InDeltaSlice(t, []float32{1, 0}, descriptor, 1e-6)
+
+	_, err = parseEmbedding(buf.Bytes()[:buf.Len()-4])
+	assert.Error(t, err, "a truncated embedding must be rejected")
+	assert.InDelta(t, 1, math.Sqrt(float64(squaredDistance(lbpEmbedding.describe(img, img.Rect), make([]float32, lbpEmbedding.dims)))), 1e-6,
+		"local binary pattern descriptors must have unit length")
+}
@@ -0,0 +1,97 @@
+package face_detection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+)
+
+// LinkFaceGroup labels a face group with a user, and records the user as recognized on every photo of the group. The
+// pending claims of the group are settled by linking it to a user.
+//
+// Parameters:
+//   - ctx: Context of the request.
+//   - database: Database holding the face groups.
+//   - faceGroupID: ID of the face group.
+//   - user: User shown by the faces of the group, nil to remove the label of the group.
+//
+// Returns:
+//   - error: ErrNoConsent if the user did not consent to face recognition, sql.ErrNoRows if the face group does not exist, or a database error.
+func LinkFaceGroup(ctx context.Context, database *db.DB, faceGroupID uint32, user *query.User) error {
+	params := query.UpdateFaceGroupUserParams{FaceGroupID: faceGroupID}
+	if user != nil {
+		consent, err := GetConsent(ctx, database.Querier, user.UserID)
+		if err != nil {
+			return err
+		}
+		if consent != query.FaceConsentsConsentGRANTED {
+			return ErrNoConsent
+		}
+		params.Label = sql.NullString{String: user.FullName, Valid: true}
+		params.UserID = sql.NullInt32{Int32: int32(user.UserID), Valid: true}
+	}
+
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := database.WithTx(tx)
+
+	if _, err := qtx.GetFaceGroup(ctx, faceGroupID); err != nil {
+		return err
+	}
+	if err := qtx.UpdateFaceGroupUser(ctx, params); err != nil {
+		return err
+	}
+	if user != nil {
+		if err := qtx.DeleteFaceGroupClaims(ctx, faceGroupID); err != nil {
+			return err
+		}
+	}
+	photoIDs, err := qtx.GetPhotoIDsOfFaceGroup(ctx, faceGroupID)
+	if err != nil {
+		return err
+	}
+	for _, photoID := range photoIDs {
+		if err := syncRecognizedUsers(ctx, qtx, photoID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ClaimFaceGroup records that a user recognized themselves in a face group. The group is only linked to the user once
+// an admin accepts the claim, so that a user cannot take the faces of someone else.
+//
+// Parameters:
+//   - ctx: Context of the request.
+//   - q: Queries saving the claim.
+//   - faceGroupID: ID of the face group.
+//   - userID: ID of the user claiming the group.
+//
+// Returns:
+//   - error: ErrNoConsent if the user did not consent to face recognition, or a database error.
+func ClaimFaceGroup(ctx context.Context, q query.Querier, faceGroupID uint32, userID uint32) error {
+	consent, err := GetConsent(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+	if consent != query.FaceConsentsConsentGRANTED {
+		return ErrNoConsent
+	}
+	return q.CreateFaceGroupClaim(ctx, query.CreateFaceGroupClaimParams{FaceGroupID: faceGroupID, UserID: userID})
+}
+
+// syncRecognizedUsers replaces the users recognized on a photo by the users linked to the face groups of its faces.
+func syncRecognizedUsers(ctx context.Context, qtx query.Querier, photoID uint32) error {
+	if err := qtx.DeleteRecognizedUsersOfPhoto(ctx, photoID); err != nil {
+		return err
+	}
+	if err := qtx.CreateRecognizedUsersOfPhoto(ctx, photoID); err != nil {
+		return fmt.Errorf("failed to save recognized users: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package face_detection
+
+import (
+	"context"
+	"database/sql"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkFaceGroup(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	database := &db.DB{DB: mockDB, Querier: query.New(mockDB)}
+	user := &query.User{UserID: 3, FullName: "Jeanne Martin"}
+
+	mock.ExpectQuery("FROM face_consents").WithArgs(3).WillReturnRows(sqlmock.NewRows(
+		[]string{"user_id", "consent", "update_date"}).AddRow(3, "GRANTED", time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM face_groups").WithArgs(7).WillReturnRows(sqlmock.NewRows(
+		[]string{"face_group_id", "label", "user_id", "creation_date"}).AddRow(7, nil, nil, time.Now()))
+	mock.ExpectExec("UPDATE face_groups").WithArgs("Jeanne Martin", 3, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM face_group_claims").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("FROM image_faces").WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"photo_id"}).AddRow(1).AddRow(2))
+	for _, photoID := range []int{1, 2} {
+		mock.ExpectExec("DELETE FROM recognized_users").WithArgs(photoID).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO recognized_users").WithArgs(photoID).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+	assert.NoError(t, LinkFaceGroup(ctx, database, 7, user))
+
+	mock.ExpectQuery("FROM face_consents").WithArgs(3).WillReturnRows(sqlmock.NewRows(
+		[]string{"user_id", "consent", "update_date"}).AddRow(3, "REFUSED", time.Now()))
+	assert.ErrorIs(t, LinkFaceGroup(ctx, database, 7, user), ErrNoConsent)
+
+	mock.ExpectQuery("FROM face_consents").WithArgs(3).WillReturnError(sql.ErrNoRows)
+	assert.ErrorIs(t, LinkFaceGroup(ctx, database, 7, user), ErrNoConsent, "users never asked must not be linked")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM face_groups").WithArgs(8).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	assert.ErrorIs(t, LinkFaceGroup(ctx, database, 8, nil), sql.ErrNoRows, "unknown face groups must be reported")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimFaceGroup(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	q := query.New(mockDB)
+
+	mock.ExpectQuery("FROM face_consents").WithArgs(3).WillReturnRows(sqlmock.NewRows(
+		[]string{"user_id", "consent", "update_date"}).AddRow(3, "GRANTED", time.Now()))
+	mock.ExpectExec("INSERT IGNORE INTO face_group_claims").WithArgs(7, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, ClaimFaceGroup(ctx, q, 7, 3))
+
+	mock.ExpectQuery("FROM face_consents").WithArgs(3).WillReturnError(sql.ErrNoRows)
+	assert.ErrorIs(t, ClaimFaceGroup(ctx, q, 7, 3), ErrNoConsent, "claims need the consent of the user")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
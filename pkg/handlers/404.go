@@ -1,14 +1,17 @@
 package handlers
 
-import "net/http"
+import (
+	"net/http"
+	"photos/pkg/tracing"
+)
 
 func (cfg Config) ServeNotFoundHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 	w.WriteHeader(http.StatusNotFound)
 
-	err := cfg.Templates.ExecuteTemplate(w, "404.html", nil)
+	err := tracing.ExecuteTemplate(r.Context(), w, cfg.Live.Load().Templates, "404.html", nil)
 	if err != nil {
-		RespondWithMessage(w, err.Error(), http.StatusInternalServerError)
+		RespondWithMessage(w, r, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
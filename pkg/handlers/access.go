@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"photos/pkg/db/query"
+)
+
+var (
+	errHidden = errors.New("hidden from non admin users")
+	errLocked = errors.New("locked by a password")
+)
+
+// viewer is the user behind a request, along with the session remembering the events it unlocked.
+type viewer struct {
+	user      query.User
+	sessionID uint32
+}
+
+// Used after AuthRestricted
+func (cfg Config) viewerFromRequest(r *http.Request) (viewer, error) {
+	sessionToken, _ := r.Context().Value(cfg.Security.Session.CookieName).(string)
+	session, err := cfg.DB.GetSessionWithToken(r.Context(), sessionToken)
+	if err != nil {
+		return viewer{}, err
+	}
+	user, err := cfg.DB.GetUser(r.Context(), session.UserID)
+	if err != nil {
+		return viewer{}, err
+	}
+	// Admins of the configuration are admins everywhere, as if they were admins of the database.
+	user.IsAdmin = user.IsAdmin || cfg.Live.Load().IsAdmin(user.Email)
+	return viewer{user: user, sessionID: session.SessionID}, nil
+}
+
+// eventAccess tells which events a viewer can see. The result is cached per event, so the
+// parents shared by the events of an archive are only checked once.
+type eventAccess struct {
+	cfg     Config
+	viewer  viewer
+	checked map[uint32]error
+}
+
+func (cfg Config) newEventAccess(v viewer) *eventAccess {
+	return &eventAccess{cfg: cfg, viewer: v, checked: map[uint32]error{}}
+}
+
+// check returns errHidden if the event or one of its parents is hidden, and errLocked if one of them
+// is protected by a password the viewer has not entered. Admins can see every event.
+func (a *eventAccess) check(ctx context.Context, eventID uint32) error {
+	if a.viewer.user.IsAdmin {
+		return nil
+	}
+	if err, ok := a.checked[eventID]; ok {
+		return err
+	}
+	event, err := a.cfg.DB.GetEvent(ctx, eventID)
+	if err != nil {
+		return err
+	}
+	return a.checkEvent(ctx, event)
+}
+
+// checkEvent is check for an event already loaded from the database.
+func (a *eventAccess) checkEvent(ctx context.Context, event query.Event) error {
+	if a.viewer.user.IsAdmin {
+		return nil
+	}
+	if err, ok := a.checked[event.EventID]; ok {
+		return err
+	}
+	// Marked before the parents are walked, so a cycle in the hierarchy cannot loop forever.
+	a.checked[event.EventID] = errHidden
+	err := a.checkSelf(ctx, event)
+	if err == nil && event.ParentEventID.Valid {
+		err = a.check(ctx, uint32(event.ParentEventID.Int32))
+	}
+	a.checked[event.EventID] = err
+	return err
+}
+
+func (a *eventAccess) checkSelf(ctx context.Context, event query.Event) error {
+	if event.IsHidden {
+		return errHidden
+	}
+	if event.PasswordHash == "" {
+		return nil
+	}
+	unlocked, err := a.cfg.DB.IsEventUnlocked(ctx, query.IsEventUnlockedParams{
+		SessionID: a.viewer.sessionID,
+		EventID:   event.EventID,
+	})
+	if err != nil {
+		return err
+	}
+	if !unlocked {
+		return errLocked
+	}
+	return nil
+}
+
+// checkPhoto is check for a photo, which can also be hidden on its own.
+func (a *eventAccess) checkPhoto(ctx context.Context, eventID uint32, isHidden bool) error {
+	if isHidden && !a.viewer.user.IsAdmin {
+		return errHidden
+	}
+	return a.check(ctx, eventID)
+}
+
+// respondWithAccessError responds to a failed access check. Hidden content is reported as not found,
+// so its existence is not disclosed.
+func respondWithAccessError(w http.ResponseWriter, r *http.Request, err error, notFound string) {
+	switch {
+	case errors.Is(err, errHidden), errors.Is(err, sql.ErrNoRows):
+		RespondWithMessage(w, r, notFound, http.StatusNotFound)
+	case errors.Is(err, errLocked):
+		RespondWithMessage(w, r, "This event is protected by a password", http.StatusForbidden)
+	default:
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+	}
+}
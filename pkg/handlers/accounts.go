@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"photos/pkg/accounts"
+	"photos/pkg/face_detection"
+	"photos/pkg/zipstream"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/hlog"
+)
+
+// Used after AuthRestricted
+func (cfg Config) MyDataHandler(w http.ResponseWriter, r *http.Request) {
+	v, err := cfg.viewerFromRequest(r)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	export, recognized, err := accounts.Collect(r.Context(), cfg.DB.Querier, v.user.UserID)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Photos the user cannot see are left out, like on the photos of me page. The photos of the export are those of
+	// recognized, in the same order.
+	access := cfg.newEventAccess(v)
+	exported := export.Photos
+	export.Photos = export.Photos[:0]
+	photos := make([]archivePhoto, 0, len(recognized))
+	for i, recognizedPhoto := range recognized {
+		photo, err := cfg.DB.GetPhotoWithMetadataPolicy(r.Context(), recognizedPhoto.PhotoID)
+		if err != nil {
+			RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+			return
+		}
+		err = access.checkPhoto(r.Context(), photo.EventID, photo.IsHidden)
+		if errors.Is(err, errHidden) || errors.Is(err, errLocked) {
+			continue
+		}
+		if err != nil {
+			RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+			return
+		}
+		export.Photos = append(export.Photos, exported[i])
+		photos = append(photos, archivePhoto{
+			dir:          "photos",
+			photoID:      photo.PhotoID,
+			pathToPhoto:  photo.PathToPhoto,
+			creationDate: photo.CreationDate,
+			policy:       photo.MetadataPolicy,
+		})
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("Failed to encode data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	entry := zipstream.Entry{
+		Name:     "data.json",
+		Modified: time.Now(),
+		Size:     int64(len(data)),
+		WriteTo: func(w io.Writer) error {
+			_, err := io.Copy(w, bytes.NewReader(data))
+			return err
+		},
+	}
+	cfg.serveArchive(w, r, v, "my-data.zip", photos, entry)
+}
+
+// Used after AdminRestricted
+func (cfg Config) AdminUserEraseHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseUint(chi.URLParam(r, "user_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+	err = accounts.Erase(r.Context(), cfg.DB.DB, uint32(userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithMessage(w, r, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	hlog.FromRequest(r).Info().Uint64("erased_user_id", userID).Msg("user erased")
+	if face_detection.GlobalFaceDetector != nil {
+		err = face_detection.GlobalFaceDetector.ReloadFacesFromDatabase(r.Context(), cfg.DB.DB)
+		if err != nil {
+			hlog.FromRequest(r).Error().Err(err).Msg("failed to reload faces after a user erasure")
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
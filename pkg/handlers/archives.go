@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"photos/pkg/db/query"
+	"photos/pkg/exif"
+	"photos/pkg/zipstream"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/hlog"
+)
+
+// archivePhoto is a photo added to an archive, under the given directory.
+type archivePhoto struct {
+	dir          string
+	photoID      uint32
+	pathToPhoto  string
+	creationDate sql.NullTime
+	policy       query.EventsMetadataPolicy
+}
+
+// Used after AuthRestricted
+func (cfg Config) EventArchiveHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.ParseUint(chi.URLParam(r, "event_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid event id", http.StatusBadRequest)
+		return
+	}
+	recursive, _ := strconv.ParseBool(r.URL.Query().Get("recursive"))
+	v, err := cfg.viewerFromRequest(r)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	access := cfg.newEventAccess(v)
+	event, err := cfg.DB.GetEvent(r.Context(), uint32(eventID))
+	if err == nil {
+		err = access.checkEvent(r.Context(), event)
+	}
+	if err != nil {
+		respondWithAccessError(w, r, err, "Event not found")
+		return
+	}
+
+	// Sub-events the user cannot see are left out of the archive, instead of failing the whole download.
+	var photos []archivePhoto
+	events := []query.Event{event}
+	dirs := map[uint32]string{event.EventID: archiveName(event.Name, event.EventID)}
+	for len(events) > 0 {
+		current := events[0]
+		events = events[1:]
+		eventPhotos, err := cfg.DB.GetPhotosByEventID(r.Context(), current.EventID)
+		if err != nil {
+			RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+			return
+		}
+		for _, photo := range visiblePhotos(eventPhotos, v) {
+			photos = append(photos, archivePhoto{
+				dir:          dirs[current.EventID],
+				photoID:      photo.PhotoID,
+				pathToPhoto:  photo.PathToPhoto,
+				creationDate: photo.CreationDate,
+				policy:       current.MetadataPolicy,
+			})
+		}
+		if !recursive {
+			break
+		}
+		subEvents, err := cfg.DB.GetSubEvents(r.Context(), sql.NullInt32{Int32: int32(current.EventID), Valid: true})
+		if err != nil {
+			RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+			return
+		}
+		used := map[string]bool{}
+		for _, subEvent := range subEvents {
+			err := access.checkEvent(r.Context(), subEvent)
+			if errors.Is(err, errHidden) || errors.Is(err, errLocked) {
+				continue
+			}
+			if err != nil {
+				RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+				return
+			}
+			// Sub-events sharing a name get their ID appended, so their photos are not merged.
+			name := archiveName(subEvent.Name, subEvent.EventID)
+			if used[name] {
+				name = fmt.Sprintf("%s (%d)", name, subEvent.EventID)
+			}
+			used[name] = true
+			dirs[subEvent.EventID] = path.Join(dirs[current.EventID], name)
+			events = append(events, subEvent)
+		}
+	}
+	if cfg.Downloads.MaxArchivePhotos > 0 && len(photos) > cfg.Downloads.MaxArchivePhotos {
+		RespondWithMessage(w, r, fmt.Sprintf("Too many photos, at most %d can be downloaded at once", cfg.Downloads.MaxArchivePhotos), http.StatusBadRequest)
+		return
+	}
+	cfg.serveArchive(w, r, v, dirs[event.EventID]+".zip", photos)
+}
+
+// Used after AuthRestricted
+func (cfg Config) PhotosArchiveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.PostFormValue("photo_ids") == "" {
+		RespondWithMessage(w, r, "No photo selected", http.StatusBadRequest)
+		return
+	}
+	var photoIDs []uint32
+	seen := map[uint32]bool{}
+	for _, field := range strings.Split(r.PostFormValue("photo_ids"), ",") {
+		photoID, err := strconv.ParseUint(strings.TrimSpace(field), 10, 32)
+		if err != nil {
+			RespondWithMessage(w, r, fmt.Sprintf("Invalid photo id %q", field), http.StatusBadRequest)
+			return
+		}
+		if !seen[uint32(photoID)] {
+			seen[uint32(photoID)] = true
+			photoIDs = append(photoIDs, uint32(photoID))
+		}
+	}
+	if cfg.Downloads.MaxArchivePhotos > 0 && len(photoIDs) > cfg.Downloads.MaxArchivePhotos {
+		RespondWithMessage(w, r, fmt.Sprintf("Too many photos, at most %d can be downloaded at once", cfg.Downloads.MaxArchivePhotos), http.StatusBadRequest)
+		return
+	}
+	v, err := cfg.viewerFromRequest(r)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	access := cfg.newEventAccess(v)
+
+	photos := make([]archivePhoto, 0, len(photoIDs))
+	for _, photoID := range photoIDs {
+		photo, err := cfg.DB.GetPhotoWithMetadataPolicy(r.Context(), photoID)
+		if err == nil {
+			err = access.checkPhoto(r.Context(), photo.EventID, photo.IsHidden)
+		}
+		if err != nil {
+			respondWithAccessError(w, r, err, fmt.Sprintf("Photo %d not found", photoID))
+			return
+		}
+		photos = append(photos, archivePhoto{
+			photoID:      photo.PhotoID,
+			pathToPhoto:  photo.PathToPhoto,
+			creationDate: photo.CreationDate,
+			policy:       photo.MetadataPolicy,
+		})
+	}
+	cfg.serveArchive(w, r, v, "photos.zip", photos)
+}
+
+// serveArchive streams an uncompressed ZIP archive of the photos, with the metadata policy of their event applied.
+// The extra entries are written before the photos.
+func (cfg Config) serveArchive(w http.ResponseWriter, r *http.Request, v viewer, filename string, photos []archivePhoto, extra ...zipstream.Entry) {
+	if !cfg.Archives.Acquire(v.user.UserID) {
+		RespondWithMessage(w, r, "Too many archive downloads in progress, wait for them to end", http.StatusTooManyRequests)
+		return
+	}
+	defer cfg.Archives.Release(v.user.UserID)
+
+	// Large archives outlive the request context.
+	ctx := context.WithoutCancel(r.Context())
+	entries := make([]zipstream.Entry, 0, len(extra)+len(photos))
+	entries = append(entries, extra...)
+	for _, photo := range photos {
+		entry, err := cfg.archiveEntry(ctx, photo)
+		if err != nil {
+			RespondWithMessage(w, r, fmt.Sprintf("Failed to prepare photo %d: %v", photo.photoID, err), http.StatusInternalServerError)
+			return
+		}
+		entries = append(entries, entry)
+	}
+
+	// Large archives outlive the server write timeout.
+	err := http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	if err != nil {
+		hlog.FromRequest(r).Warn().Err(err).Msg("failed to clear write deadline of archive download")
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("Cache-Control", "private, no-store")
+	if size, ok := zipstream.Size(entries); ok {
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	err = zipstream.Write(w, entries)
+	if err != nil {
+		// Headers are already sent, the client sees a truncated download.
+		hlog.FromRequest(r).Error().Err(err).Msg("failed to write archive")
+	}
+}
+
+// archiveEntry prepares the archive entry of a photo. When metadata has to be stripped, the size of the stripped
+// photo is computed from its metadata segments, since the archive length is sent first, and the photo itself is
+// only read once, when the archive is written.
+func (cfg Config) archiveEntry(ctx context.Context, photo archivePhoto) (zipstream.Entry, error) {
+	mode := stripModeFor(photo.policy)
+	var size int64
+	if mode == exif.StripNone {
+		info, err := cfg.Storage.Stat(ctx, photo.pathToPhoto)
+		if err != nil {
+			return zipstream.Entry{}, err
+		}
+		size = info.Size
+	} else {
+		file, info, err := cfg.Storage.Get(ctx, photo.pathToPhoto)
+		if err != nil {
+			return zipstream.Entry{}, err
+		}
+		defer file.Close()
+		size, err = exif.StrippedSize(file, info.Size, mode)
+		if err != nil {
+			return zipstream.Entry{}, err
+		}
+	}
+
+	entry := zipstream.Entry{
+		Name: path.Join(photo.dir, fmt.Sprintf("photo-%d%s", photo.photoID, filepath.Ext(photo.pathToPhoto))),
+		Size: size,
+		WriteTo: func(w io.Writer) error {
+			file, _, err := cfg.Storage.Get(ctx, photo.pathToPhoto)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			return exif.Strip(w, file, mode)
+		},
+	}
+	if photo.creationDate.Valid {
+		entry.Modified = photo.creationDate.Time
+	}
+	return entry, nil
+}
+
+// archiveName turns an event name into a directory name usable on every system.
+func archiveName(name string, eventID uint32) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, " .")
+	if name == "" {
+		return fmt.Sprintf("event-%d", eventID)
+	}
+	return name
+}
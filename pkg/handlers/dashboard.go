@@ -2,15 +2,16 @@ package handlers
 
 import (
 	"net/http"
+	"photos/pkg/tracing"
 )
 
 func (cfg Config) ServeDashboardHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 	w.WriteHeader(http.StatusOK)
 
-	err := cfg.Templates.ExecuteTemplate(w, "dashboard.html", nil)
+	err := tracing.ExecuteTemplate(r.Context(), w, cfg.Live.Load().Templates, "dashboard.html", nil)
 	if err != nil {
-		RespondWithMessage(w, err.Error(), http.StatusInternalServerError)
+		RespondWithMessage(w, r, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"photos/pkg/db/query"
+	"photos/pkg/derivative"
+	"photos/pkg/exif"
+	"photos/pkg/storage"
+	"photos/pkg/usage"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/hlog"
+)
+
+// stripModes maps the metadata policy of an event to the metadata removed from its photos.
+var stripModes = map[query.EventsMetadataPolicy]exif.StripMode{
+	query.EventsMetadataPolicyKEEPALL:  exif.StripNone,
+	query.EventsMetadataPolicySTRIPGPS: exif.StripGPS,
+	query.EventsMetadataPolicySTRIPALL: exif.StripAll,
+}
+
+// stripModeFor returns the strip mode of a metadata policy, unknown policies strip everything.
+func stripModeFor(policy query.EventsMetadataPolicy) exif.StripMode {
+	mode, ok := stripModes[policy]
+	if !ok {
+		return exif.StripAll
+	}
+	return mode
+}
+
+func (cfg Config) PhotoDownloadHandler(w http.ResponseWriter, r *http.Request) {
+	photo, ok := cfg.photoFromURL(w, r)
+	if !ok {
+		return
+	}
+	// Large downloads outlive the request context.
+	file, info, err := cfg.Storage.Get(context.WithoutCancel(r.Context()), photo.PathToPhoto)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("Failed to open photo: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	ext := filepath.Ext(photo.PathToPhoto)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"photo-%d%s\"", photo.PhotoID, ext))
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	mode := stripModeFor(photo.MetadataPolicy)
+	if mode == exif.StripNone {
+		http.ServeContent(w, r, path.Base(photo.PathToPhoto), info.ModTime, file)
+		return
+	}
+	w.Header().Set("Content-Type", mime.TypeByExtension(ext))
+	err = exif.Strip(w, file, mode)
+	if err != nil {
+		// Headers are already sent, the client sees a truncated download.
+		hlog.FromRequest(r).Error().Err(err).Uint32("photo_id", photo.PhotoID).Msg("failed to strip photo metadata")
+	}
+}
+
+func (cfg Config) PhotoPreviewHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.serveDerivative(w, r, derivative.Preview)
+}
+
+func (cfg Config) PhotoThumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.serveDerivative(w, r, derivative.Thumbnail)
+}
+
+// serveDerivative serves a re-encoded version of a photo, which never carries metadata whatever the event policy.
+func (cfg Config) serveDerivative(w http.ResponseWriter, r *http.Request, kind derivative.Kind) {
+	photo, ok := cfg.photoFromURL(w, r)
+	if !ok {
+		return
+	}
+	var orientation uint16
+	metadata, err := cfg.DB.GetPhotoMetadata(r.Context(), photo.PhotoID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if err == nil {
+		orientation = metadata.Orientation
+	}
+	key, generated, err := derivative.Ensure(r.Context(), cfg.Storage, photo.PathToPhoto, photo.PhotoID, orientation, kind)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("Failed to generate %s: %v", kind, err), http.StatusInternalServerError)
+		return
+	}
+	if generated > 0 {
+		err = usage.AddDerivative(r.Context(), cfg.DB.Querier, photo.EventID, kind, generated)
+		if err != nil {
+			hlog.FromRequest(r).Error().Err(err).Uint32("photo_id", photo.PhotoID).Msg("failed to account for derivative")
+		}
+	}
+
+	// Drivers signing URLs serve the derivative themselves, the redirect is cached for half of the URL lifetime.
+	signedURL, err := cfg.Storage.SignedURL(r.Context(), key, cfg.Storage.SignedURLExpiry)
+	if err == nil {
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(cfg.Storage.SignedURLExpiry.Seconds()/2)))
+		http.Redirect(w, r, signedURL, http.StatusFound)
+		return
+	}
+	if !errors.Is(err, storage.ErrNotSupported) {
+		RespondWithMessage(w, r, fmt.Sprintf("Failed to sign %s URL: %v", kind, err), http.StatusInternalServerError)
+		return
+	}
+	file, info, err := cfg.Storage.Get(r.Context(), key)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("Failed to open %s: %v", kind, err), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	http.ServeContent(w, r, path.Base(key), info.ModTime, file)
+}
+
+// photoFromURL loads the photo whose ID is in the URL, and responds with an error if it does not exist
+// or if the user is not allowed to see it.
+func (cfg Config) photoFromURL(w http.ResponseWriter, r *http.Request) (query.GetPhotoWithMetadataPolicyRow, bool) {
+	photoID, err := strconv.ParseUint(chi.URLParam(r, "photo_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid photo id", http.StatusBadRequest)
+		return query.GetPhotoWithMetadataPolicyRow{}, false
+	}
+	photo, err := cfg.DB.GetPhotoWithMetadataPolicy(r.Context(), uint32(photoID))
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithMessage(w, r, "Photo not found", http.StatusNotFound)
+		return query.GetPhotoWithMetadataPolicyRow{}, false
+	}
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return query.GetPhotoWithMetadataPolicyRow{}, false
+	}
+	v, err := cfg.viewerFromRequest(r)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return query.GetPhotoWithMetadataPolicyRow{}, false
+	}
+	err = cfg.newEventAccess(v).checkPhoto(r.Context(), photo.EventID, photo.IsHidden)
+	if err != nil {
+		respondWithAccessError(w, r, err, "Photo not found")
+		return query.GetPhotoWithMetadataPolicyRow{}, false
+	}
+	return photo, true
+}
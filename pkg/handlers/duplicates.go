@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"photos/pkg/config"
+	"photos/pkg/db/query"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+)
+
+type duplicatesData struct {
+	Candidates []query.DuplicateCandidate
+	CSRFField  template.HTML
+	Routes     config.Routes
+}
+
+// Used after AdminRestricted
+func (cfg Config) ServeAdminDuplicatesHandler(w http.ResponseWriter, r *http.Request) {
+	candidates, err := cfg.DB.GetPendingDuplicateCandidates(r.Context())
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	renderTemplate(w, r, cfg.Live.Load().Templates, "duplicates.html", duplicatesData{Candidates: candidates, CSRFField: csrf.TemplateField(r), Routes: cfg.Routes})
+}
+
+// Used after AdminRestricted
+func (cfg Config) AdminDuplicateHandler(w http.ResponseWriter, r *http.Request) {
+	photoID, err := strconv.ParseUint(chi.URLParam(r, "photo_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid photo id", http.StatusBadRequest)
+		return
+	}
+	duplicateOfPhotoID, err := strconv.ParseUint(chi.URLParam(r, "duplicate_of_photo_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid photo id", http.StatusBadRequest)
+		return
+	}
+	params := query.UpdateDuplicateCandidateStatusParams{
+		PhotoID:            uint32(photoID),
+		DuplicateOfPhotoID: uint32(duplicateOfPhotoID),
+	}
+	switch r.PostFormValue("action") {
+	case "keep":
+		params.Status = query.DuplicateCandidatesStatusKEPT
+	case "hide":
+		params.Status = query.DuplicateCandidatesStatusHIDDEN
+	default:
+		RespondWithMessage(w, r, "Unknown action", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	if params.Status == query.DuplicateCandidatesStatusHIDDEN {
+		err = qtx.UpdatePhotoHidden(r.Context(), query.UpdatePhotoHiddenParams{IsHidden: true, PhotoID: uint32(photoID)})
+		if err != nil {
+			RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	err = qtx.UpdateDuplicateCandidateStatus(r.Context(), params)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, cfg.Routes.AdminDuplicates, http.StatusSeeOther)
+}
@@ -1,11 +1,19 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
+	"photos/pkg/db/query"
+	"strconv"
 	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/securecookie"
+	"github.com/rs/zerolog/hlog"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Used after AdminRestricted
@@ -26,7 +34,7 @@ func (cfg Config) CreateEventHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var data map[string]string
-	err = cfg.Security.Session.SecureCookie.Decode(cfg.Security.Session.CookieName, cookie.Value, &data)
+	err = securecookie.DecodeMulti(cfg.Security.Session.CookieName, cookie.Value, &data, cfg.Security.Session.Codecs...)
 	if err != nil {
 		http.Redirect(w, r, casLoginUrlWithCallback, http.StatusFound)
 		return
@@ -38,13 +46,127 @@ func (cfg Config) CreateEventHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	session, err := cfg.DB.GetSessionWithToken(r.Context(), sessionToken)
 	if err != nil {
-		log.Printf("DB Failure: %v", err)
+		hlog.FromRequest(r).Error().Err(err).Msg("database failure")
 		http.Redirect(w, r, casLoginUrlWithCallback, http.StatusFound)
 		return
 	}
-	if session.CreationDate.Add(cfg.Security.Session.CookieMaxAge).Before(time.Now()) {
+	if session.CreationDate.Add(cfg.Live.Load().SessionMaxAge).Before(time.Now()) {
 		http.Redirect(w, r, casLoginUrlWithCallback, http.StatusFound)
 		return
 	}
 	http.Redirect(w, r, cfg.Routes.Dashboard, http.StatusFound)
 }
+
+// Used after AdminRestricted
+func (cfg Config) AdminEventMetadataPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.ParseUint(chi.URLParam(r, "event_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid event id", http.StatusBadRequest)
+		return
+	}
+	policy := query.EventsMetadataPolicy(r.PostFormValue("policy"))
+	if _, ok := stripModes[policy]; !ok {
+		RespondWithMessage(w, r, fmt.Sprintf("Unknown metadata policy %q", policy), http.StatusBadRequest)
+		return
+	}
+	err = cfg.DB.UpdateEventMetadataPolicy(r.Context(), query.UpdateEventMetadataPolicyParams{
+		MetadataPolicy: policy,
+		EventID:        uint32(eventID),
+	})
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Used after AuthRestricted
+func (cfg Config) EventUnlockHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.ParseUint(chi.URLParam(r, "event_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid event id", http.StatusBadRequest)
+		return
+	}
+	v, err := cfg.viewerFromRequest(r)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	event, err := cfg.DB.GetEvent(r.Context(), uint32(eventID))
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && event.IsHidden && !v.user.IsAdmin) {
+		RespondWithMessage(w, r, "Event not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if event.PasswordHash == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(event.PasswordHash), []byte(r.PostFormValue("password")))
+	if err != nil {
+		RespondWithMessage(w, r, "Wrong password", http.StatusForbidden)
+		return
+	}
+	err = cfg.DB.CreateUnlockedEvent(r.Context(), query.CreateUnlockedEventParams{
+		SessionID: v.sessionID,
+		EventID:   event.EventID,
+	})
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Used after AdminRestricted
+func (cfg Config) AdminEventVisibilityHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.ParseUint(chi.URLParam(r, "event_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid event id", http.StatusBadRequest)
+		return
+	}
+	hidden, err := strconv.ParseBool(r.PostFormValue("hidden"))
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid hidden value", http.StatusBadRequest)
+		return
+	}
+	err = cfg.DB.UpdateEventHidden(r.Context(), query.UpdateEventHiddenParams{
+		IsHidden: hidden,
+		EventID:  uint32(eventID),
+	})
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Used after AdminRestricted, an empty password removes the protection of the event.
+func (cfg Config) AdminEventPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.ParseUint(chi.URLParam(r, "event_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid event id", http.StatusBadRequest)
+		return
+	}
+	var passwordHash string
+	if password := r.PostFormValue("password"); password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			RespondWithMessage(w, r, fmt.Sprintf("Invalid password: %v", err), http.StatusBadRequest)
+			return
+		}
+		passwordHash = string(hash)
+	}
+	err = cfg.DB.UpdateEventPasswordHash(r.Context(), query.UpdateEventPasswordHashParams{
+		PasswordHash: passwordHash,
+		EventID:      uint32(eventID),
+	})
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"image/jpeg"
+	"net/http"
+	"net/url"
+	"photos/pkg/config"
+	"photos/pkg/db/query"
+	"photos/pkg/face_detection"
+	"photos/pkg/jobs"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+	"github.com/rs/zerolog/hlog"
+)
+
+const (
+	faceGroupsPerPage = 60  // Face groups listed by a page of the admin face groups page.
+	faceThumbnailSide = 128 // Side of the face thumbnails in pixels.
+)
+
+type faceGroupsData struct {
+	Groups    []query.GetFaceGroupsRow
+	PrevPage  int
+	NextPage  int
+	CSRFField template.HTML
+	Routes    config.Routes
+}
+
+type faceGroupData struct {
+	Group     query.FaceGroup
+	Faces     []query.ImageFace
+	Claims    []query.GetFaceGroupClaimsRow
+	CSRFField template.HTML
+	Routes    config.Routes
+}
+
+// Used after AdminRestricted
+func (cfg Config) ServeAdminFaceGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	page := 1
+	if value := r.URL.Query().Get("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			RespondWithMessage(w, r, "Invalid page", http.StatusBadRequest)
+			return
+		}
+		page = parsed
+	}
+	groups, err := cfg.DB.GetFaceGroups(r.Context(), query.GetFaceGroupsParams{
+		Limit:  faceGroupsPerPage + 1,
+		Offset: int32((page - 1) * faceGroupsPerPage),
+	})
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	data := faceGroupsData{Groups: groups, PrevPage: page - 1, CSRFField: csrf.TemplateField(r), Routes: cfg.Routes}
+	if len(groups) > faceGroupsPerPage {
+		data.Groups = groups[:faceGroupsPerPage]
+		data.NextPage = page + 1
+	}
+	renderTemplate(w, r, cfg.Live.Load().Templates, "face_groups.html", data)
+}
+
+// Used after AdminRestricted
+func (cfg Config) ServeAdminFaceGroupHandler(w http.ResponseWriter, r *http.Request) {
+	faceGroupID, err := strconv.ParseUint(chi.URLParam(r, "face_group_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid face group id", http.StatusBadRequest)
+		return
+	}
+	group, err := cfg.DB.GetFaceGroup(r.Context(), uint32(faceGroupID))
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithMessage(w, r, "Face group not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	faces, err := cfg.DB.GetImageFacesOfFaceGroup(r.Context(), group.FaceGroupID)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	claims, err := cfg.DB.GetFaceGroupClaims(r.Context(), group.FaceGroupID)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	renderTemplate(w, r, cfg.Live.Load().Templates, "face_group.html", faceGroupData{Group: group, Faces: faces, Claims: claims, CSRFField: csrf.TemplateField(r), Routes: cfg.Routes})
+}
+
+// Used after AdminRestricted
+func (cfg Config) AdminImageFaceThumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	imageFaceID, err := strconv.ParseUint(chi.URLParam(r, "image_face_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid image face id", http.StatusBadRequest)
+		return
+	}
+	thumbnail, err := face_detection.FaceThumbnail(r.Context(), cfg.DB.DB, cfg.Storage.Storage, uint32(imageFaceID), faceThumbnailSide)
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithMessage(w, r, "Face not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("Failed to crop face: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	if err := jpeg.Encode(w, thumbnail, nil); err != nil {
+		hlog.FromRequest(r).Error().Err(err).Uint64("image_face_id", imageFaceID).Msg("failed to send face thumbnail")
+	}
+}
+
+// Used after AdminRestricted
+func (cfg Config) AdminFaceGroupMergeHandler(w http.ResponseWriter, r *http.Request) {
+	faceGroupID, err := strconv.ParseUint(chi.URLParam(r, "face_group_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid face group id", http.StatusBadRequest)
+		return
+	}
+	destID, err := strconv.ParseUint(r.PostFormValue("into"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid destination face group id", http.StatusBadRequest)
+		return
+	}
+	err = face_detection.MergeFaceGroups(r.Context(), cfg.DB.DB, face_detection.GlobalFaceDetector, uint32(faceGroupID), uint32(destID))
+	if !respondWithFaceGroupError(w, r, err) {
+		return
+	}
+	http.Redirect(w, r, faceGroupPath(cfg.Routes.AdminFaceGroup, uint32(destID)), http.StatusSeeOther)
+}
+
+// Used after AdminRestricted
+func (cfg Config) AdminImageFacesMoveHandler(w http.ResponseWriter, r *http.Request) {
+	faceGroupID, err := strconv.ParseUint(chi.URLParam(r, "face_group_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid face group id", http.StatusBadRequest)
+		return
+	}
+	destID, err := strconv.ParseUint(r.PostFormValue("to"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid destination face group id", http.StatusBadRequest)
+		return
+	}
+	imageFaceIDs, ok := parseImageFaceIDs(w, r)
+	if !ok {
+		return
+	}
+	err = face_detection.MoveImageFaces(r.Context(), cfg.DB.DB, face_detection.GlobalFaceDetector, uint32(faceGroupID), imageFaceIDs, uint32(destID))
+	if !respondWithFaceGroupError(w, r, err) {
+		return
+	}
+	cfg.redirectToFaceGroup(w, r, uint32(faceGroupID))
+}
+
+// Used after AdminRestricted
+func (cfg Config) AdminFaceGroupSplitHandler(w http.ResponseWriter, r *http.Request) {
+	imageFaceIDs, ok := parseImageFaceIDs(w, r)
+	if !ok {
+		return
+	}
+	destID, err := face_detection.SplitFaceGroup(r.Context(), cfg.DB.DB, face_detection.GlobalFaceDetector, imageFaceIDs)
+	if !respondWithFaceGroupError(w, r, err) {
+		return
+	}
+	http.Redirect(w, r, faceGroupPath(cfg.Routes.AdminFaceGroup, destID), http.StatusSeeOther)
+}
+
+// Used after AdminRestricted
+func (cfg Config) AdminFacesRecognizeHandler(w http.ResponseWriter, r *http.Request) {
+	if face_detection.GlobalFaceDetector == nil {
+		RespondWithMessage(w, r, "Face detection is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	// Recognition compares every unlabeled face, which outlasts a request, so a job runs it.
+	if err := jobs.EnqueueRecognition(r.Context(), cfg.DB.Querier); err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, cfg.Routes.AdminJobs+"?"+url.Values{"status": {string(query.JobsStatusPENDING)}}.Encode(), http.StatusSeeOther)
+}
+
+// respondWithFaceGroupError responds to a failed face group operation, and returns true if there was no error.
+func respondWithFaceGroupError(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, sql.ErrNoRows):
+		RespondWithMessage(w, r, "Face group or face not found", http.StatusNotFound)
+	case errors.Is(err, face_detection.ErrLinkedToOtherUser):
+		RespondWithMessage(w, r, "The face groups are linked to different users", http.StatusConflict)
+	case errors.Is(err, face_detection.ErrNotInFaceGroup):
+		RespondWithMessage(w, r, "A selected face is not in the face group", http.StatusBadRequest)
+	default:
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+	}
+	return false
+}
+
+// redirectToFaceGroup shows a face group again after a change, or the list of groups if it was left empty.
+func (cfg Config) redirectToFaceGroup(w http.ResponseWriter, r *http.Request, faceGroupID uint32) {
+	_, err := cfg.DB.GetFaceGroup(r.Context(), faceGroupID)
+	if err != nil {
+		http.Redirect(w, r, cfg.Routes.AdminFaceGroups, http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, faceGroupPath(cfg.Routes.AdminFaceGroup, faceGroupID), http.StatusSeeOther)
+}
+
+// parseImageFaceIDs reads the "image_face_id" values of a form, responding with an error if there is none.
+func parseImageFaceIDs(w http.ResponseWriter, r *http.Request) ([]uint32, bool) {
+	if err := r.ParseForm(); err != nil {
+		RespondWithMessage(w, r, "Invalid form", http.StatusBadRequest)
+		return nil, false
+	}
+	values := r.PostForm["image_face_id"]
+	if len(values) == 0 {
+		RespondWithMessage(w, r, "No face selected", http.StatusBadRequest)
+		return nil, false
+	}
+	ids := make([]uint32, 0, len(values))
+	for _, value := range values {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			RespondWithMessage(w, r, "Invalid image face id", http.StatusBadRequest)
+			return nil, false
+		}
+		ids = append(ids, uint32(id))
+	}
+	return ids, true
+}
+
+// faceGroupPath fills the face group of a route.
+func faceGroupPath(route string, faceGroupID uint32) string {
+	return strings.Replace(route, "{face_group_id}", strconv.FormatUint(uint64(faceGroupID), 10), 1)
+}
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/netip"
+	"photos/pkg/config"
+	"photos/pkg/db/query"
+	"photos/pkg/face_detection"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+	"github.com/rs/zerolog/hlog"
+)
+
+type faceResponse struct {
+	FaceGroupID uint32  `json:"face_group_id"`
+	MinX        float64 `json:"min_x"`
+	MinY        float64 `json:"min_y"`
+	MaxX        float64 `json:"max_x"`
+	MaxY        float64 `json:"max_y"`
+	Label       string  `json:"label,omitempty"`
+	Mine        bool    `json:"mine,omitempty"`
+}
+
+type myPhotosData struct {
+	Photos  []query.Photo
+	Consent query.FaceConsentsConsent
+	Routes  config.Routes
+}
+
+type faceConsentData struct {
+	Consent    query.FaceConsentsConsent
+	PublicName bool
+	Changes    []query.FaceConsentChange
+	CSRFField  template.HTML
+	Routes     config.Routes
+}
+
+func (cfg Config) ServeMyPhotosHandler(w http.ResponseWriter, r *http.Request) {
+	v, err := cfg.viewerFromRequest(r)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	photos, err := cfg.DB.GetRecognizedPhotos(r.Context(), v.user.UserID)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	access := cfg.newEventAccess(v)
+	visible := make([]query.Photo, 0, len(photos))
+	for _, photo := range photos {
+		err := access.checkPhoto(r.Context(), photo.EventID, photo.IsHidden)
+		if errors.Is(err, errHidden) || errors.Is(err, errLocked) {
+			continue
+		}
+		if err != nil {
+			RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+			return
+		}
+		visible = append(visible, photo)
+	}
+	consent, err := face_detection.GetConsent(r.Context(), cfg.DB.Querier, v.user.UserID)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	renderTemplate(w, r, cfg.Live.Load().Templates, "my_photos.html", myPhotosData{Photos: visible, Consent: consent, Routes: cfg.Routes})
+}
+
+func (cfg Config) ServeFaceConsentHandler(w http.ResponseWriter, r *http.Request) {
+	v, err := cfg.viewerFromRequest(r)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	consent, err := face_detection.GetConsent(r.Context(), cfg.DB.Querier, v.user.UserID)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	_, err = cfg.DB.GetPublicFaceName(r.Context(), v.user.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	publicName := err == nil
+	changes, err := cfg.DB.GetFaceConsentChanges(r.Context(), sql.NullInt32{Int32: int32(v.user.UserID), Valid: true})
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	renderTemplate(w, r, cfg.Live.Load().Templates, "consent.html", faceConsentData{
+		Consent:    consent,
+		PublicName: publicName,
+		Changes:    changes,
+		CSRFField:  csrf.TemplateField(r),
+		Routes:     cfg.Routes,
+	})
+}
+
+func (cfg Config) FaceGroupClaimHandler(w http.ResponseWriter, r *http.Request) {
+	faceGroupID, err := strconv.ParseUint(chi.URLParam(r, "face_group_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid face group id", http.StatusBadRequest)
+		return
+	}
+	v, err := cfg.viewerFromRequest(r)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	group, err := cfg.DB.GetFaceGroup(r.Context(), uint32(faceGroupID))
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithMessage(w, r, "Face group not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	// Users can only claim the faces they can see.
+	err = cfg.checkFaceGroup(r, v, group.FaceGroupID)
+	if err != nil {
+		respondWithAccessError(w, r, err, "Face group not found")
+		return
+	}
+	if group.UserID.Valid && uint32(group.UserID.Int32) == v.user.UserID {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	// Claims are reviewed by an admin, including the ones of groups linked to another user by mistake.
+	err = face_detection.ClaimFaceGroup(r.Context(), cfg.DB.Querier, group.FaceGroupID, v.user.UserID)
+	if errors.Is(err, face_detection.ErrNoConsent) {
+		RespondWithMessage(w, r, "You did not consent to face recognition", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	RespondWithMessage(w, r, "Your claim will be reviewed by an administrator", http.StatusAccepted)
+}
+
+func (cfg Config) FaceNameHandler(w http.ResponseWriter, r *http.Request) {
+	public, err := strconv.ParseBool(r.PostFormValue("public"))
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid public value", http.StatusBadRequest)
+		return
+	}
+	v, err := cfg.viewerFromRequest(r)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	err = face_detection.SetPublicName(r.Context(), cfg.DB.Querier, v.user.UserID, public)
+	if errors.Is(err, face_detection.ErrNoConsent) {
+		RespondWithMessage(w, r, "You did not consent to face recognition", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, cfg.Routes.FaceConsent, http.StatusSeeOther)
+}
+
+func (cfg Config) FaceConsentHandler(w http.ResponseWriter, r *http.Request) {
+	consent := query.FaceConsentsConsent(r.PostFormValue("consent"))
+	if consent != query.FaceConsentsConsentGRANTED && consent != query.FaceConsentsConsentREFUSED {
+		RespondWithMessage(w, r, "Invalid consent", http.StatusBadRequest)
+		return
+	}
+	v, err := cfg.viewerFromRequest(r)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	changed, err := face_detection.SetConsent(r.Context(), cfg.DB.DB, v.user.UserID, consent, v.user.UserID, remoteIP(r))
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	// The faces of the user were deleted, the detector must forget them too.
+	if changed && consent != query.FaceConsentsConsentGRANTED && face_detection.GlobalFaceDetector != nil {
+		err = face_detection.GlobalFaceDetector.ReloadFacesFromDatabase(r.Context(), cfg.DB.DB)
+		if err != nil {
+			hlog.FromRequest(r).Error().Err(err).Msg("failed to reload faces after a consent withdrawal")
+		}
+	}
+	http.Redirect(w, r, cfg.Routes.FaceConsent, http.StatusSeeOther)
+}
+
+// Used after AdminRestricted
+func (cfg Config) AdminFaceGroupUserHandler(w http.ResponseWriter, r *http.Request) {
+	faceGroupID, err := strconv.ParseUint(chi.URLParam(r, "face_group_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid face group id", http.StatusBadRequest)
+		return
+	}
+	var user *query.User
+	if email := r.PostFormValue("email"); email != "" {
+		found, err := cfg.DB.GetUserWithEmail(r.Context(), email)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithMessage(w, r, "User not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+			return
+		}
+		user = &found
+	}
+	err = face_detection.LinkFaceGroup(r.Context(), cfg.DB.DB, uint32(faceGroupID), user)
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithMessage(w, r, "Face group not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, face_detection.ErrNoConsent) {
+		RespondWithMessage(w, r, "This user did not consent to face recognition", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, faceGroupPath(cfg.Routes.AdminFaceGroup, uint32(faceGroupID)), http.StatusSeeOther)
+}
+
+// Used after AdminRestricted
+func (cfg Config) AdminFaceGroupClaimHandler(w http.ResponseWriter, r *http.Request) {
+	faceGroupID, err := strconv.ParseUint(chi.URLParam(r, "face_group_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid face group id", http.StatusBadRequest)
+		return
+	}
+	userID, err := strconv.ParseUint(chi.URLParam(r, "user_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+	accept, err := strconv.ParseBool(r.PostFormValue("accept"))
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid accept value", http.StatusBadRequest)
+		return
+	}
+	params := query.DeleteFaceGroupClaimParams{FaceGroupID: uint32(faceGroupID), UserID: uint32(userID)}
+	if accept {
+		user, err := cfg.DB.GetUser(r.Context(), params.UserID)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithMessage(w, r, "User not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+			return
+		}
+		err = face_detection.LinkFaceGroup(r.Context(), cfg.DB.DB, params.FaceGroupID, &user)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithMessage(w, r, "Face group not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, face_detection.ErrNoConsent) {
+			RespondWithMessage(w, r, "This user did not consent to face recognition", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		deleted, err := cfg.DB.DeleteFaceGroupClaim(r.Context(), params)
+		if err != nil {
+			RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if deleted == 0 {
+			RespondWithMessage(w, r, "Claim not found", http.StatusNotFound)
+			return
+		}
+	}
+	http.Redirect(w, r, faceGroupPath(cfg.Routes.AdminFaceGroup, params.FaceGroupID), http.StatusSeeOther)
+}
+
+// checkFaceGroup returns nil if the viewer can see one of the photos of a face group, and the access error
+// of the last photo otherwise.
+func (cfg Config) checkFaceGroup(r *http.Request, v viewer, faceGroupID uint32) error {
+	photoIDs, err := cfg.DB.GetPhotoIDsOfFaceGroup(r.Context(), faceGroupID)
+	if err != nil {
+		return err
+	}
+	access := cfg.newEventAccess(v)
+	last := sql.ErrNoRows
+	for _, photoID := range photoIDs {
+		photo, err := cfg.DB.GetPhoto(r.Context(), photoID)
+		if err != nil {
+			return err
+		}
+		if last = access.checkPhoto(r.Context(), photo.EventID, photo.IsHidden); last == nil {
+			return nil
+		}
+	}
+	return last
+}
+
+// newFaceResponses converts the faces of a photo, marking the ones of the viewer. The faces of users who did not
+// consent to face recognition are not named, and the faces of the other users are only named for admins and
+// themselves, unless they chose to show their name to everyone.
+func newFaceResponses(faces []query.GetFacesOfPhotoRow, v viewer) []faceResponse {
+	responses := make([]faceResponse, 0, len(faces))
+	for _, face := range faces {
+		response := faceResponse{
+			FaceGroupID: face.FaceGroupID,
+			MinX:        face.MinX,
+			MinY:        face.MinY,
+			MaxX:        face.MaxX,
+			MaxY:        face.MaxY,
+			Label:       face.Label.String,
+		}
+		if face.UserID.Valid {
+			if face.Consent.FaceConsentsConsent != query.FaceConsentsConsentGRANTED {
+				response.Label = ""
+			} else {
+				response.Mine = uint32(face.UserID.Int32) == v.user.UserID
+				if !v.user.IsAdmin && !response.Mine && !face.NameOptInDate.Valid {
+					response.Label = ""
+				}
+			}
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+// remoteIP returns the address of the client of a request, without its port. The server listens behind a reverse
+// proxy on the same host, so the requests coming from a loopback address are forwarded ones, whose client is the
+// address the proxy set in X-Real-Ip, or else the last one it appended to X-Forwarded-For. The headers of the other
+// requests are not trusted, since their clients can set them.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if addr, err := netip.ParseAddr(host); err != nil || !addr.Unmap().IsLoopback() {
+		return host
+	}
+	if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-Ip"))); err == nil {
+		return addr.Unmap().String()
+	}
+	forwarded := r.Header.Values("X-Forwarded-For")
+	if len(forwarded) > 0 {
+		clients := strings.Split(forwarded[len(forwarded)-1], ",")
+		if addr, err := netip.ParseAddr(strings.TrimSpace(clients[len(clients)-1])); err == nil {
+			return addr.Unmap().String()
+		}
+	}
+	return host
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http/httptest"
+	"photos/pkg/db/query"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaceResponseLabels(t *testing.T) {
+	granted := query.NullFaceConsentsConsent{FaceConsentsConsent: query.FaceConsentsConsentGRANTED, Valid: true}
+	faces := []query.GetFacesOfPhotoRow{
+		{FaceGroupID: 1, Label: sql.NullString{String: "Jeanne Martin", Valid: true},
+			UserID: sql.NullInt32{Int32: 3, Valid: true}, Consent: granted},
+		{FaceGroupID: 2, Label: sql.NullString{String: "Paul Durand", Valid: true},
+			UserID: sql.NullInt32{Int32: 4, Valid: true}, Consent: granted,
+			NameOptInDate: sql.NullTime{Time: time.Now(), Valid: true}},
+		{FaceGroupID: 3, Label: sql.NullString{String: "Marie Petit", Valid: true},
+			UserID: sql.NullInt32{Int32: 5, Valid: true}},
+		{FaceGroupID: 4, Label: sql.NullString{String: "Invité", Valid: true}},
+	}
+	labels := func(responses []faceResponse) []string {
+		var labels []string
+		for _, response := range responses {
+			labels = append(labels, response.Label)
+		}
+		return labels
+	}
+
+	other := newFaceResponses(faces, viewer{user: query.User{UserID: 9}})
+	assert.Equal(t, []string{"", "Paul Durand", "", "Invité"}, labels(other), "names are only shown to others on opt-in")
+
+	self := newFaceResponses(faces, viewer{user: query.User{UserID: 3}})
+	assert.Equal(t, []string{"Jeanne Martin", "Paul Durand", "", "Invité"}, labels(self))
+	assert.True(t, self[0].Mine)
+
+	admin := newFaceResponses(faces, viewer{user: query.User{UserID: 1, IsAdmin: true}})
+	assert.Equal(t, []string{"Jeanne Martin", "Paul Durand", "", "Invité"}, labels(admin))
+}
+
+func TestRemoteIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string][]string
+		expected   string
+	}{
+		{"direct", "203.0.113.7:51234", nil, "203.0.113.7"},
+		{"direct with forged headers", "203.0.113.7:51234",
+			map[string][]string{"X-Real-Ip": {"198.51.100.1"}, "X-Forwarded-For": {"198.51.100.1"}}, "203.0.113.7"},
+		{"proxy with X-Real-Ip", "127.0.0.1:40000",
+			map[string][]string{"X-Real-Ip": {"198.51.100.1"}, "X-Forwarded-For": {"192.0.2.9, 198.51.100.2"}}, "198.51.100.1"},
+		{"proxy with X-Forwarded-For", "[::1]:40000",
+			map[string][]string{"X-Forwarded-For": {"192.0.2.9", "10.0.0.1, 2001:db8::5"}}, "2001:db8::5"},
+		{"proxy with invalid headers", "127.0.0.1:40000",
+			map[string][]string{"X-Real-Ip": {"unknown"}, "X-Forwarded-For": {"unknown"}}, "127.0.0.1"},
+		{"proxy without headers", "127.0.0.1:40000", nil, "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/me/consent", nil)
+		r.RemoteAddr = tt.remoteAddr
+		for header, values := range tt.headers {
+			for _, value := range values {
+				r.Header.Add(header, value)
+			}
+		}
+		assert.Equal(t, tt.expected, remoteIP(r), tt.name)
+	}
+}
@@ -1,29 +1,45 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
 	"html/template"
-	"log"
 	"net/http"
 	"photos/pkg/config"
+	"photos/pkg/tracing"
+
+	"github.com/rs/zerolog/hlog"
 )
 
 type Config config.Config
 
-func RespondWithMessage(w http.ResponseWriter, error string, status int) {
+// RespondWithMessage writes a message with a status. Server errors are logged with the logger of the request and
+// their message is not shown to the client.
+func RespondWithMessage(w http.ResponseWriter, r *http.Request, error string, status int) {
 	if status >= 500 {
-		log.Printf("5xx error: %s", error)
+		hlog.FromRequest(r).Error().Int("status", status).Msg(error)
 		http.Error(w, "Internal server error", status)
 		return
 	}
 	http.Error(w, error, status)
 }
 
-func renderTemplate(w http.ResponseWriter, t *template.Template, name string, data interface{}) {
+func renderTemplate(w http.ResponseWriter, r *http.Request, t *template.Template, name string, data interface{}) {
 	w.Header().Set("Content-Type", "text/html")
-	err := t.ExecuteTemplate(w, name, data)
+	err := tracing.ExecuteTemplate(r.Context(), w, t, name, data)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("error executing template: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+func respondWithJSON(w http.ResponseWriter, r *http.Request, status int, payload interface{}) {
+	data, err := json.Marshal(payload)
 	if err != nil {
-		RespondWithMessage(w, fmt.Sprintf("error executing template: %v", err), http.StatusInternalServerError)
+		RespondWithMessage(w, r, fmt.Sprintf("error marshaling response: %v", err), http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
 }
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"photos/pkg/face_detection"
+	"photos/pkg/importer"
+	"photos/pkg/jobs"
+
+	"github.com/rs/zerolog/hlog"
+)
+
+// Used after AdminRestricted
+func (cfg Config) AdminImportHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("Invalid form: %v", err), http.StatusBadRequest)
+		return
+	}
+	mode, err := importer.ParseMode(r.PostFormValue("mode"))
+	if err != nil {
+		RespondWithMessage(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	policy := r.PostFormValue("duplicates")
+	if policy == "" {
+		policy = cfg.Storage.Duplicates
+	}
+	duplicates, err := importer.ParseDuplicatePolicy(policy)
+	if err != nil {
+		RespondWithMessage(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// The path is relative to the import directory, cleaning it as an absolute path prevents escaping it
+	source := filepath.Join(cfg.Storage.ImportDir, filepath.Clean("/"+r.PostFormValue("path")))
+	info, err := os.Stat(source)
+	if err != nil || !info.IsDir() {
+		RespondWithMessage(w, r, "Import path is not a directory", http.StatusBadRequest)
+		return
+	}
+
+	err = cfg.Importer.Start(cfg.DB.DB, importer.Options{
+		Source:      source,
+		Storage:     cfg.Storage.Storage,
+		Mode:        mode,
+		Duplicates:  duplicates,
+		MaxDistance: cfg.Storage.MaxDistance,
+		Enqueue:     jobs.Enqueuer(face_detection.GlobalFaceDetector != nil),
+		Logger:      *hlog.FromRequest(r),
+	})
+	if errors.Is(err, importer.ErrImportRunning) {
+		RespondWithMessage(w, r, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("Failed to start import: %v", err), http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(w, r, http.StatusAccepted, cfg.Importer.Status())
+}
+
+// Used after AdminRestricted
+func (cfg Config) AdminImportStatusHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, r, http.StatusOK, cfg.Importer.Status())
+}
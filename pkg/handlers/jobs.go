@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"photos/pkg/config"
+	"photos/pkg/db/query"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+)
+
+// maxListedJobs bounds the jobs listed by the admin page.
+const maxListedJobs = 200
+
+type jobsData struct {
+	Status    query.JobsStatus
+	Counts    []query.CountJobsRow
+	Jobs      []query.Job
+	CSRFField template.HTML
+	Routes    config.Routes
+}
+
+// Used after AdminRestricted
+func (cfg Config) ServeAdminJobsHandler(w http.ResponseWriter, r *http.Request) {
+	status := query.JobsStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "":
+		status = query.JobsStatusDEAD
+	case query.JobsStatusPENDING, query.JobsStatusRUNNING, query.JobsStatusDONE, query.JobsStatusDEAD:
+	default:
+		RespondWithMessage(w, r, "Invalid job status", http.StatusBadRequest)
+		return
+	}
+	counts, err := cfg.DB.CountJobs(r.Context())
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	jobs, err := cfg.DB.GetJobs(r.Context(), query.GetJobsParams{Status: status, Limit: maxListedJobs})
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	renderTemplate(w, r, cfg.Live.Load().Templates, "jobs.html", jobsData{
+		Status:    status,
+		Counts:    counts,
+		Jobs:      jobs,
+		CSRFField: csrf.TemplateField(r),
+		Routes:    cfg.Routes,
+	})
+}
+
+// Used after AdminRestricted
+func (cfg Config) AdminJobRetryHandler(w http.ResponseWriter, r *http.Request) {
+	jobID, err := strconv.ParseUint(chi.URLParam(r, "job_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid job id", http.StatusBadRequest)
+		return
+	}
+	retried, err := cfg.DB.RetryJob(r.Context(), uint32(jobID))
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if retried == 0 {
+		RespondWithMessage(w, r, "Job not found, or still pending", http.StatusNotFound)
+		return
+	}
+	http.Redirect(w, r, cfg.Routes.AdminJobs+"?"+url.Values{"status": {r.PostFormValue("status")}}.Encode(), http.StatusSeeOther)
+}
@@ -2,15 +2,16 @@ package handlers
 
 import (
 	"net/http"
+	"photos/pkg/tracing"
 )
 
 func (cfg Config) ServeLandingHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 	w.WriteHeader(http.StatusOK)
 
-	err := cfg.Templates.ExecuteTemplate(w, "landing.html", struct{ LOGIN_ROUTE string }{LOGIN_ROUTE: cfg.Routes.Login})
+	err := tracing.ExecuteTemplate(r.Context(), w, cfg.Live.Load().Templates, "landing.html", struct{ LOGIN_ROUTE string }{LOGIN_ROUTE: cfg.Routes.Login})
 	if err != nil {
-		RespondWithMessage(w, err.Error(), http.StatusInternalServerError)
+		RespondWithMessage(w, r, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"photos/pkg/config"
+	"photos/pkg/db/query"
+	"photos/pkg/exif"
+	"photos/pkg/importer"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type gpsResponse struct {
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Altitude  *float64 `json:"altitude,omitempty"`
+}
+
+type photoMetadataResponse struct {
+	CaptureDate  *time.Time   `json:"capture_date,omitempty"`
+	CameraMake   string       `json:"camera_make,omitempty"`
+	CameraModel  string       `json:"camera_model,omitempty"`
+	LensModel    string       `json:"lens_model,omitempty"`
+	ExposureTime string       `json:"exposure_time,omitempty"`
+	FNumber      float64      `json:"f_number,omitempty"`
+	ISO          uint32       `json:"iso,omitempty"`
+	FocalLength  float64      `json:"focal_length,omitempty"`
+	Orientation  uint16       `json:"orientation,omitempty"`
+	Width        uint32       `json:"width,omitempty"`
+	Height       uint32       `json:"height,omitempty"`
+	GPS          *gpsResponse `json:"gps,omitempty"`
+}
+
+type photoResponse struct {
+	PhotoID      uint32                 `json:"photo_id"`
+	EventID      uint32                 `json:"event_id"`
+	CreationDate *time.Time             `json:"creation_date,omitempty"`
+	Metadata     *photoMetadataResponse `json:"metadata,omitempty"`
+	Faces        []faceResponse         `json:"faces,omitempty"`
+}
+
+type eventPhotosData struct {
+	EventID uint32
+	Photos  []query.Photo
+	Routes  config.Routes
+}
+
+func (cfg Config) ServeEventPhotosHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.ParseUint(r.URL.Query().Get("event_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid event id", http.StatusBadRequest)
+		return
+	}
+	v, err := cfg.viewerFromRequest(r)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	err = cfg.newEventAccess(v).check(r.Context(), uint32(eventID))
+	if err != nil {
+		respondWithAccessError(w, r, err, "Event not found")
+		return
+	}
+	photos, err := cfg.DB.GetPhotosByEventID(r.Context(), uint32(eventID))
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	photos = visiblePhotos(photos, v)
+	renderTemplate(w, r, cfg.Live.Load().Templates, "photos.html", eventPhotosData{EventID: uint32(eventID), Photos: photos, Routes: cfg.Routes})
+}
+
+func (cfg Config) ServePhotoDetailsHandler(w http.ResponseWriter, r *http.Request) {
+	photoID, err := strconv.ParseUint(chi.URLParam(r, "photo_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid photo id", http.StatusBadRequest)
+		return
+	}
+	photo, err := cfg.DB.GetPhotoWithMetadataPolicy(r.Context(), uint32(photoID))
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithMessage(w, r, "Photo not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	v, err := cfg.viewerFromRequest(r)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	err = cfg.newEventAccess(v).checkPhoto(r.Context(), photo.EventID, photo.IsHidden)
+	if err != nil {
+		respondWithAccessError(w, r, err, "Photo not found")
+		return
+	}
+	response := photoResponse{PhotoID: photo.PhotoID, EventID: photo.EventID}
+	if photo.CreationDate.Valid {
+		response.CreationDate = &photo.CreationDate.Time
+	}
+
+	metadata, err := cfg.DB.GetPhotoMetadata(r.Context(), photo.PhotoID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if err == nil {
+		response.Metadata = newPhotoMetadataResponse(metadata, stripModeFor(photo.MetadataPolicy))
+	}
+	faces, err := cfg.DB.GetFacesOfPhoto(r.Context(), photo.PhotoID)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	response.Faces = newFaceResponses(faces, v)
+	respondWithJSON(w, r, http.StatusOK, response)
+}
+
+// Used after AdminRestricted
+func (cfg Config) AdminPhotoVisibilityHandler(w http.ResponseWriter, r *http.Request) {
+	photoID, err := strconv.ParseUint(chi.URLParam(r, "photo_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid photo id", http.StatusBadRequest)
+		return
+	}
+	hidden, err := strconv.ParseBool(r.PostFormValue("hidden"))
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid hidden value", http.StatusBadRequest)
+		return
+	}
+	err = cfg.DB.UpdatePhotoHidden(r.Context(), query.UpdatePhotoHiddenParams{
+		IsHidden: hidden,
+		PhotoID:  uint32(photoID),
+	})
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Used after AdminRestricted
+func (cfg Config) AdminPhotoDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	photoID, err := strconv.ParseUint(chi.URLParam(r, "photo_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid photo id", http.StatusBadRequest)
+		return
+	}
+	err = importer.Delete(r.Context(), cfg.DB.DB, cfg.Storage.Storage, uint32(photoID))
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithMessage(w, r, "Photo not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("Failed to delete photo: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// visiblePhotos removes the hidden photos from a list, unless the viewer is an admin.
+func visiblePhotos(photos []query.Photo, v viewer) []query.Photo {
+	if v.user.IsAdmin {
+		return photos
+	}
+	visible := make([]query.Photo, 0, len(photos))
+	for _, photo := range photos {
+		if !photo.IsHidden {
+			visible = append(visible, photo)
+		}
+	}
+	return visible
+}
+
+// newPhotoMetadataResponse converts the metadata of a photo, without the metadata its event policy strips from the
+// downloads: the GPS position for STRIP_GPS and everything for STRIP_ALL.
+func newPhotoMetadataResponse(metadata query.PhotoMetadatum, mode exif.StripMode) *photoMetadataResponse {
+	if mode == exif.StripAll {
+		return nil
+	}
+	response := &photoMetadataResponse{
+		CameraMake:   metadata.CameraMake,
+		CameraModel:  metadata.CameraModel,
+		LensModel:    metadata.LensModel,
+		ExposureTime: metadata.ExposureTime,
+		FNumber:      metadata.FNumber,
+		ISO:          metadata.Iso,
+		FocalLength:  metadata.FocalLength,
+		Orientation:  metadata.Orientation,
+		Width:        metadata.Width,
+		Height:       metadata.Height,
+	}
+	if metadata.CaptureDate.Valid {
+		response.CaptureDate = &metadata.CaptureDate.Time
+	}
+	if mode == exif.StripNone && metadata.Latitude.Valid && metadata.Longitude.Valid {
+		response.GPS = &gpsResponse{Latitude: metadata.Latitude.Float64, Longitude: metadata.Longitude.Float64}
+		if metadata.Altitude.Valid {
+			response.GPS.Altitude = &metadata.Altitude.Float64
+		}
+	}
+	return response
+}
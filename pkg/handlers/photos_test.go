@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"photos/pkg/config"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// servePhotoDetails imports a photo with GPS and camera metadata into an event with a metadata policy, and returns the
+// details a student gets for it.
+func servePhotoDetails(t *testing.T, policy query.EventsMetadataPolicy) map[string]interface{} {
+	database, err := db.New(db.Options{Driver: db.DriverSQLite, Path: filepath.Join(t.TempDir(), "photos.db"), MaxOpenConns: 1})
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close() })
+	ctx := context.Background()
+
+	userID, err := database.AttemptCreatingUser(ctx, query.AttemptCreatingUserParams{
+		Email:            "jane@example.com",
+		BusinessCategory: query.UsersBusinessCategorySTUDENT,
+	})
+	assert.NoError(t, err)
+	user, err := database.GetUser(ctx, uint32(userID))
+	assert.NoError(t, err)
+	assert.NoError(t, database.CreateSession(ctx, query.CreateSessionParams{UserID: user.UserID, SessionToken: "token"}))
+	eventID, err := database.CreateEvent(ctx, query.CreateEventParams{Name: "Gala", EventDate: time.Now()})
+	assert.NoError(t, err)
+	assert.NoError(t, database.UpdateEventMetadataPolicy(ctx, query.UpdateEventMetadataPolicyParams{
+		MetadataPolicy: policy,
+		EventID:        uint32(eventID),
+	}))
+	photoID, err := database.CreatePhoto(ctx, query.CreatePhotoParams{PathToPhoto: "a.jpg", FileHash: "hash", EventID: uint32(eventID)})
+	assert.NoError(t, err)
+	assert.NoError(t, database.CreatePhotoMetadata(ctx, query.CreatePhotoMetadataParams{
+		PhotoID:     uint32(photoID),
+		CameraMake:  "Canon",
+		CameraModel: "EOS R6",
+		Width:       6000,
+		Height:      4000,
+		Latitude:    sql.NullFloat64{Float64: 48.7, Valid: true},
+		Longitude:   sql.NullFloat64{Float64: 2.2, Valid: true},
+	}))
+
+	cfg := Config{Live: config.NewLive(&config.Reloadable{})}
+	cfg.DB.DB = database
+	cfg.Security.Session.CookieName = "session"
+	router := chi.NewRouter()
+	router.Get("/photos/{photo_id}", cfg.ServePhotoDetailsHandler)
+
+	r := httptest.NewRequest(http.MethodGet, "/photos/"+strconv.FormatInt(photoID, 10), nil)
+	r = r.WithContext(context.WithValue(r.Context(), cfg.Security.Session.CookieName, "token"))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var details map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+	return details
+}
+
+// TestPhotoDetailsKeepAll ensures that every metadata of a photo is shown when its event keeps them.
+func TestPhotoDetailsKeepAll(t *testing.T) {
+	details := servePhotoDetails(t, query.EventsMetadataPolicyKEEPALL)
+	metadata, ok := details["metadata"].(map[string]interface{})
+	assert.True(t, ok, "metadata is shown")
+	assert.Equal(t, "Canon", metadata["camera_make"])
+	assert.Equal(t, map[string]interface{}{"latitude": 48.7, "longitude": 2.2}, metadata["gps"])
+}
+
+// TestPhotoDetailsStripGPS ensures that the position of a photo is not shown when its event strips it.
+func TestPhotoDetailsStripGPS(t *testing.T) {
+	details := servePhotoDetails(t, query.EventsMetadataPolicySTRIPGPS)
+	metadata, ok := details["metadata"].(map[string]interface{})
+	assert.True(t, ok, "metadata is shown")
+	assert.Equal(t, "Canon", metadata["camera_make"])
+	assert.NotContains(t, metadata, "gps")
+}
+
+// TestPhotoDetailsStripAll ensures that no metadata of a photo is shown when its event strips them all.
+func TestPhotoDetailsStripAll(t *testing.T) {
+	details := servePhotoDetails(t, query.EventsMetadataPolicySTRIPALL)
+	assert.NotContains(t, details, "metadata")
+	assert.Contains(t, details, "photo_id")
+}
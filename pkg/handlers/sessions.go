@@ -4,13 +4,19 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
 	"photos/pkg/db/query"
+	"photos/pkg/face_detection"
+	"photos/pkg/logging"
+	"photos/pkg/metrics"
 	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/rs/zerolog/hlog"
 )
 
 type casResponse struct {
@@ -53,7 +59,7 @@ func (cfg Config) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var data map[string]string
-	err = cfg.Security.Session.SecureCookie.Decode(cfg.Security.Session.CookieName, cookie.Value, &data)
+	err = securecookie.DecodeMulti(cfg.Security.Session.CookieName, cookie.Value, &data, cfg.Security.Session.Codecs...)
 	if err != nil {
 		http.Redirect(w, r, casLoginUrlWithCallback, http.StatusFound)
 		return
@@ -65,11 +71,11 @@ func (cfg Config) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	session, err := cfg.DB.GetSessionWithToken(r.Context(), sessionToken)
 	if err != nil {
-		log.Printf("DB Failure: %v", err)
+		hlog.FromRequest(r).Error().Err(err).Msg("database failure")
 		http.Redirect(w, r, casLoginUrlWithCallback, http.StatusFound)
 		return
 	}
-	if session.CreationDate.Add(cfg.Security.Session.CookieMaxAge).Before(time.Now()) {
+	if session.CreationDate.Add(cfg.Live.Load().SessionMaxAge).Before(time.Now()) {
 		http.Redirect(w, r, casLoginUrlWithCallback, http.StatusFound)
 		return
 	}
@@ -87,7 +93,7 @@ func (cfg Config) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	http.SetCookie(w, cookie)
 	err := cfg.DB.DeleteSessionWithToken(r.Context(), ctx.Value(cfg.Security.Session.CookieName).(string))
 	if err != nil {
-		log.Printf("DB Failure: %v", err)
+		hlog.FromRequest(r).Error().Err(err).Msg("database failure")
 	}
 	http.Redirect(w, r, cfg.Routes.Landing, http.StatusFound)
 }
@@ -96,7 +102,7 @@ func (cfg Config) CasCallbackHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	ticket := r.URL.Query().Get("ticket")
 	if ticket == "" {
-		RespondWithMessage(w, "Ticket is missing", http.StatusBadRequest)
+		RespondWithMessage(w, r, "Ticket is missing", http.StatusBadRequest)
 		return
 	}
 
@@ -108,40 +114,58 @@ func (cfg Config) CasCallbackHandler(w http.ResponseWriter, r *http.Request) {
 		validationURL = fmt.Sprintf("%s/serviceValidate?service=%s&ticket=%s", cfg.BaseURLs.Prod.Cas, url.QueryEscape(cfg.BaseURLs.Prod.Service), url.QueryEscape(ticket))
 	}
 
-	resp, err := cfg.HttpClient.Get(validationURL)
+	// The validation carries the context of the request, so that its span is part of the trace of the login.
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, validationURL, nil)
+	var resp *http.Response
+	if err == nil {
+		resp, err = cfg.HttpClient.Do(req)
+	}
 	if err != nil {
-		RespondWithMessage(w, fmt.Sprintf("Error while validating CAS ticket: %v", err), http.StatusInternalServerError)
+		metrics.CASValidationFailures.WithLabelValues(metrics.CASFailureRequest).Inc()
+		// The errors of the client hold the URL, whose ticket must not be logged.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = logging.RedactURLString(urlErr.URL)
+		}
+		RespondWithMessage(w, r, fmt.Sprintf("Error while validating CAS ticket: %v", err), http.StatusInternalServerError)
 		return
 	}
+	metrics.CASValidationDuration.Observe(time.Since(start).Seconds())
 	if resp.StatusCode != http.StatusOK {
-		RespondWithMessage(w, fmt.Sprintf("Error while validating CAS ticket, got a non 200 status code: %d", resp.StatusCode), http.StatusInternalServerError)
+		metrics.CASValidationFailures.WithLabelValues(metrics.CASFailureStatus).Inc()
+		RespondWithMessage(w, r, fmt.Sprintf("Error while validating CAS ticket, got a non 200 status code: %d", resp.StatusCode), http.StatusInternalServerError)
 		return
 	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		RespondWithMessage(w, fmt.Sprintf("Error while validating CAS ticket: %v", err), http.StatusInternalServerError)
+		metrics.CASValidationFailures.WithLabelValues(metrics.CASFailureResponse).Inc()
+		RespondWithMessage(w, r, fmt.Sprintf("Error while validating CAS ticket: %v", err), http.StatusInternalServerError)
 		return
 	}
 	err = resp.Body.Close()
 	if err != nil {
-		RespondWithMessage(w, fmt.Sprintf("Error while validating CAS ticket: %v", err), http.StatusInternalServerError)
+		metrics.CASValidationFailures.WithLabelValues(metrics.CASFailureResponse).Inc()
+		RespondWithMessage(w, r, fmt.Sprintf("Error while validating CAS ticket: %v", err), http.StatusInternalServerError)
 		return
 	}
 	var casResponse casResponse
 	err = xml.Unmarshal(body, &casResponse)
 	if err != nil {
-		RespondWithMessage(w, fmt.Sprintf("Error while unmarshaling CAS response: %v", err), http.StatusInternalServerError)
+		metrics.CASValidationFailures.WithLabelValues(metrics.CASFailureResponse).Inc()
+		RespondWithMessage(w, r, fmt.Sprintf("Error while unmarshaling CAS response: %v", err), http.StatusInternalServerError)
 		return
 	}
 	if casResponse.AuthenticationFailure != nil {
-		RespondWithMessage(w, fmt.Sprintf("Authentification Failure: %s", casResponse.AuthenticationFailure.Message), http.StatusBadRequest)
+		metrics.CASValidationFailures.WithLabelValues(metrics.CASFailureRejected).Inc()
+		RespondWithMessage(w, r, fmt.Sprintf("Authentification Failure: %s", casResponse.AuthenticationFailure.Message), http.StatusBadRequest)
 		return
 	}
 
 	//Prepare transaction
 	tx, err := cfg.DB.BeginTx(ctx, nil)
 	if err != nil {
-		RespondWithMessage(w, fmt.Sprintf("DB Failure: %s", err), http.StatusInternalServerError)
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %s", err), http.StatusInternalServerError)
 		return
 	}
 	qtx := cfg.DB.WithTx(tx)
@@ -153,7 +177,7 @@ func (cfg Config) CasCallbackHandler(w http.ResponseWriter, r *http.Request) {
 	} else {
 		businessCategory = query.UsersBusinessCategoryTEACHER
 	}
-	err = qtx.AttemptCreatingUser(r.Context(), query.AttemptCreatingUserParams{
+	userID, err := qtx.AttemptCreatingUser(r.Context(), query.AttemptCreatingUserParams{
 		Email:            attributes.Email,
 		DepartmentNumber: attributes.DepartmentNumber,
 		BusinessCategory: businessCategory,
@@ -161,39 +185,39 @@ func (cfg Config) CasCallbackHandler(w http.ResponseWriter, r *http.Request) {
 	})
 	if err != nil {
 		_ = tx.Rollback()
-		RespondWithMessage(w, fmt.Sprintf("DB Failure: %s", err), http.StatusInternalServerError)
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %s", err), http.StatusInternalServerError)
 		return
 	}
-	userInfo, err := qtx.GetUserLastInsertID(ctx)
+	userInfo, err := qtx.GetUser(ctx, uint32(userID))
 	if err != nil {
 		_ = tx.Rollback()
-		RespondWithMessage(w, fmt.Sprintf("DB Failure: %s", err), http.StatusInternalServerError)
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %s", err), http.StatusInternalServerError)
 		return
 	}
 	err = tx.Commit()
 	if err != nil {
 		_ = tx.Rollback()
-		RespondWithMessage(w, fmt.Sprintf("DB Failure: %s", err), http.StatusInternalServerError)
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %s", err), http.StatusInternalServerError)
 		return
 	}
 
 	//Create session for user
 	sessionToken, err := generateSessionID(32)
 	if err != nil {
-		RespondWithMessage(w, fmt.Sprintf("Failed to to generate session token: %v", err), http.StatusInternalServerError)
+		RespondWithMessage(w, r, fmt.Sprintf("Failed to to generate session token: %v", err), http.StatusInternalServerError)
 		return
 	}
 	data := map[string]string{
 		cfg.Security.Session.CookieName: sessionToken,
 	}
-	encoded, err := cfg.Security.Session.SecureCookie.Encode(cfg.Security.Session.CookieName, data)
+	encoded, err := securecookie.EncodeMulti(cfg.Security.Session.CookieName, data, cfg.Security.Session.Codecs...)
 	if err != nil {
-		RespondWithMessage(w, fmt.Sprintf("Failed to set session: %v", err), http.StatusInternalServerError)
+		RespondWithMessage(w, r, fmt.Sprintf("Failed to set session: %v", err), http.StatusInternalServerError)
 		return
 	}
 	cookie := &http.Cookie{
 		Name:     cfg.Security.Session.CookieName,
-		MaxAge:   int(cfg.Security.Session.CookieMaxAge.Seconds()),
+		MaxAge:   int(cfg.Live.Load().SessionMaxAge.Seconds()),
 		Secure:   cfg.Security.Session.CookieSecure,
 		HttpOnly: cfg.Security.Session.CookieHTTPOnly,
 		SameSite: cfg.Security.Session.CookieSameSite,
@@ -202,10 +226,22 @@ func (cfg Config) CasCallbackHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	err = cfg.DB.CreateSession(r.Context(), query.CreateSessionParams{UserID: userInfo.UserID, SessionToken: sessionToken})
 	if err != nil {
-		RespondWithMessage(w, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
 		return
 	}
 	http.SetCookie(w, cookie)
+	// Users are asked about face recognition at their first login, before any of their faces is linked to them.
+	if cfg.Faces.Enabled {
+		consent, err := face_detection.GetConsent(ctx, cfg.DB.Querier, userInfo.UserID)
+		if err != nil {
+			RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if consent == query.FaceConsentsConsentUNKNOWN {
+			http.Redirect(w, r, cfg.Routes.FaceConsent, http.StatusFound)
+			return
+		}
+	}
 	http.Redirect(w, r, cfg.Routes.Dashboard, http.StatusFound)
 }
 
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"photos/pkg/face_detection"
+	"photos/pkg/importer"
+	"photos/pkg/jobs"
+	"photos/pkg/metrics"
+	"photos/pkg/storage"
+	"photos/pkg/usage"
+	"strconv"
+
+	"github.com/rs/zerolog"
+)
+
+type uploadResult struct {
+	Filename string `json:"filename"`
+	importer.Result
+	Error string `json:"error,omitempty"`
+}
+
+// Used after AdminRestricted
+//
+// Photos are sent as the "photos" files of a multipart form, which is read as a stream, so the CSRF
+// token has to be sent in the header. Photos stored before an invalid part, or before the photo
+// exceeding a quota, stay in the event.
+func (cfg Config) AdminUploadHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.ParseUint(r.URL.Query().Get("event_id"), 10, 32)
+	if err != nil {
+		RespondWithMessage(w, r, "Invalid event id", http.StatusBadRequest)
+		return
+	}
+	policy := r.URL.Query().Get("duplicates")
+	if policy == "" {
+		policy = cfg.Storage.Duplicates
+	}
+	duplicates, err := importer.ParseDuplicatePolicy(policy)
+	if err != nil {
+		RespondWithMessage(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, err = cfg.DB.GetEvent(r.Context(), uint32(eventID))
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithMessage(w, r, "Event not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	v, err := cfg.viewerFromRequest(r)
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("Invalid upload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Large uploads outlive the request context.
+	ctx := context.WithoutCancel(r.Context())
+	opts := importer.AddOptions{
+		Storage:     cfg.Storage.Storage,
+		Duplicates:  duplicates,
+		MaxDistance: cfg.Storage.MaxDistance,
+		UploaderID:  sql.NullInt32{Int32: int32(v.user.UserID), Valid: true},
+		Quotas:      usage.Quotas{Global: cfg.Storage.Quota, Uploader: cfg.Storage.UploaderQuota},
+		Enqueue:     jobs.Enqueuer(face_detection.GlobalFaceDetector != nil),
+	}
+	results := []uploadResult{}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			RespondWithMessage(w, r, fmt.Sprintf("Upload larger than %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			RespondWithMessage(w, r, fmt.Sprintf("Invalid upload: %v", err), http.StatusBadRequest)
+			return
+		}
+		if part.FormName() != "photos" || part.FileName() == "" {
+			_ = part.Close()
+			continue
+		}
+		upload, err := cfg.uploadPhoto(ctx, part, uint32(eventID), opts)
+		_ = part.Close()
+		results = append(results, upload)
+		if errors.Is(err, usage.ErrQuotaExceeded) {
+			respondWithJSON(w, r, http.StatusInsufficientStorage, results)
+			return
+		}
+		if errors.Is(err, usage.ErrUploaderQuotaExceeded) {
+			respondWithJSON(w, r, http.StatusRequestEntityTooLarge, results)
+			return
+		}
+	}
+	respondWithJSON(w, r, http.StatusOK, results)
+}
+
+// uploadPhoto stores an uploaded photo and adds it to the event. The error is only set if the photo exceeds a quota,
+// other failures are reported in the result.
+//
+// The photo is written to a temporary file first, since it has to be hashed before its storage key is known.
+func (cfg Config) uploadPhoto(ctx context.Context, part *multipart.Part, eventID uint32, opts importer.AddOptions) (uploadResult, error) {
+	upload := uploadResult{Filename: filepath.Base(part.FileName())}
+	if !importer.IsSupported(upload.Filename) {
+		upload.Error = "unsupported file type"
+		return upload, nil
+	}
+	tmpPath, err := spoolUpload(cfg.Storage.Storage, part, filepath.Ext(upload.Filename))
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("filename", upload.Filename).Msg("failed to receive uploaded photo")
+		upload.Error = "failed to receive photo"
+		return upload, nil
+	}
+	defer os.Remove(tmpPath)
+
+	file, err := importer.Inspect(tmpPath)
+	if err == nil {
+		upload.Result, err = importer.Add(ctx, cfg.DB.DB, file, eventID, opts)
+	}
+	if errors.Is(err, usage.ErrQuotaExceeded) || errors.Is(err, usage.ErrUploaderQuotaExceeded) {
+		upload.Error = err.Error()
+		return upload, err
+	}
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("filename", upload.Filename).Msg("failed to add uploaded photo")
+		upload.Error = "failed to add photo"
+		return upload, nil
+	}
+	return upload, nil
+}
+
+// spoolUpload copies an uploaded file to a temporary file of the storage, keeping its extension. The bytes received are
+// counted in the metrics, even if the copy fails.
+func spoolUpload(store storage.Storage, r io.Reader, ext string) (string, error) {
+	tmp, err := storage.CreateSpool(store, ext)
+	if err != nil {
+		return "", err
+	}
+	n, err := io.Copy(tmp, r)
+	metrics.UploadBytes.Add(float64(n))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
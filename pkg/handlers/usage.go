@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"photos/pkg/config"
+	"photos/pkg/db/query"
+	"sort"
+)
+
+// byteSize is a number of bytes, printed in a human readable unit by templates.
+type byteSize int64
+
+func (b byteSize) String() string {
+	const unit = 1024
+	if b < unit && b > -unit {
+		return fmt.Sprintf("%d o", int64(b))
+	}
+	value, exp := float64(b)/unit, 0
+	for (value >= unit || value <= -unit) && exp < 5 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %co", value, "KMGTP"[exp])
+}
+
+type kindUsage struct {
+	Kind  query.StorageUsageKind
+	Bytes byteSize
+	Files int32
+}
+
+type eventUsage struct {
+	EventID   uint32
+	Name      string
+	Photos    int32
+	Original  byteSize
+	Thumbnail byteSize
+	Preview   byteSize
+	Total     byteSize
+}
+
+type uploaderUsage struct {
+	FullName string
+	Email    string
+	Photos   int32
+	Bytes    byteSize
+}
+
+type usageData struct {
+	Total         byteSize
+	Quota         byteSize
+	UploaderQuota byteSize
+	Kinds         []kindUsage
+	Events        []eventUsage
+	Uploaders     []uploaderUsage
+	Routes        config.Routes
+}
+
+// Used after AdminRestricted
+func (cfg Config) ServeAdminUsageHandler(w http.ResponseWriter, r *http.Request) {
+	data := usageData{Quota: byteSize(cfg.Storage.Quota), UploaderQuota: byteSize(cfg.Storage.UploaderQuota), Routes: cfg.Routes}
+	kinds, err := cfg.DB.GetGlobalStorageUsage(r.Context())
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	for _, kind := range kinds {
+		data.Kinds = append(data.Kinds, kindUsage{Kind: kind.Kind, Bytes: byteSize(kind.Bytes), Files: kind.Files})
+		data.Total += byteSize(kind.Bytes)
+	}
+
+	rows, err := cfg.DB.GetEventStorageUsage(r.Context())
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	// Rows are sorted by event, one per kind of file.
+	for _, row := range rows {
+		if len(data.Events) == 0 || data.Events[len(data.Events)-1].EventID != row.EventID {
+			data.Events = append(data.Events, eventUsage{EventID: row.EventID, Name: row.Name})
+		}
+		event := &data.Events[len(data.Events)-1]
+		switch row.Kind {
+		case query.StorageUsageKindORIGINAL:
+			event.Original = byteSize(row.Bytes)
+			event.Photos = row.Files
+		case query.StorageUsageKindTHUMBNAIL:
+			event.Thumbnail = byteSize(row.Bytes)
+		case query.StorageUsageKindPREVIEW:
+			event.Preview = byteSize(row.Bytes)
+		}
+		event.Total += byteSize(row.Bytes)
+	}
+	sort.SliceStable(data.Events, func(i, j int) bool { return data.Events[i].Total > data.Events[j].Total })
+
+	uploaders, err := cfg.DB.GetUploaderStorageUsage(r.Context())
+	if err != nil {
+		RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+		return
+	}
+	for _, uploader := range uploaders {
+		data.Uploaders = append(data.Uploaders, uploaderUsage{
+			FullName: uploader.FullName,
+			Email:    uploader.Email,
+			Photos:   uploader.Files,
+			Bytes:    byteSize(uploader.Bytes),
+		})
+	}
+	renderTemplate(w, r, cfg.Live.Load().Templates, "usage.html", data)
+}
@@ -0,0 +1,361 @@
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"photos/pkg/exif"
+	"photos/pkg/phash"
+	"photos/pkg/storage"
+	"photos/pkg/usage"
+	"sort"
+	"strings"
+)
+
+// DuplicatePolicy tells what happens to a photo whose content is already stored in another event.
+//
+// Photos added with DuplicatesWarn or DuplicatesLink are photos of their own, with their own metadata, derivatives and
+// faces, which only share the file of their copies: the file is referenced by its path from every photo, and deleted
+// with the last of them. Its size counts in the usage of the event and the uploader of each photo, and once in the usage
+// of the whole storage.
+type DuplicatePolicy int
+
+const (
+	DuplicatesWarn   DuplicatePolicy = iota // Add the photo and queue it for review next to its copies.
+	DuplicatesLink                          // Add the photo silently, sharing the file of its copies, see below.
+	DuplicatesReject                        // Do not add the photo.
+)
+
+// ParseDuplicatePolicy converts "warn", "link" or "reject" into a DuplicatePolicy.
+func ParseDuplicatePolicy(value string) (DuplicatePolicy, error) {
+	switch strings.ToLower(value) {
+	case "", "warn":
+		return DuplicatesWarn, nil
+	case "link":
+		return DuplicatesLink, nil
+	case "reject":
+		return DuplicatesReject, nil
+	}
+	return DuplicatesWarn, fmt.Errorf("unknown duplicate policy %q", value)
+}
+
+// AddStatus is the outcome of adding a photo to an event.
+type AddStatus string
+
+const (
+	StatusAdded    AddStatus = "added"    // The photo was added to the event.
+	StatusLinked   AddStatus = "linked"   // The photo was added to the event, its file was already stored.
+	StatusExists   AddStatus = "exists"   // The event already holds the photo.
+	StatusRejected AddStatus = "rejected" // The photo is stored in another event and duplicates are rejected.
+)
+
+// maxSimilar bounds the number of near duplicates queued for review for a single photo.
+const maxSimilar = 10
+
+// File is a photo about to be added to an event.
+type File struct {
+	Path           string        // Location of the file on disk.
+	Hash           string        // Hex encoded SHA-256 of the file.
+	Size           int64         // Size of the file in bytes.
+	PerceptualHash sql.NullInt64 // Difference hash of the picture, not set if it could not be decoded.
+	Metadata       exif.Metadata // EXIF and XMP metadata of the photo.
+}
+
+// AddOptions configures how photos are added to an event.
+type AddOptions struct {
+	Storage     storage.Storage // Storage where the photos are stored.
+	Duplicates  DuplicatePolicy // What to do with photos already stored in another event.
+	MaxDistance int             // Perceptual hash distance under which photos are near duplicates, negative to disable.
+	UploaderID  sql.NullInt32   // User the photos are accounted to, not set for photos imported from the command line.
+	Quotas      usage.Quotas    // Quotas the photos must fit in, none for photos imported from the command line.
+	Enqueue     EnqueueFunc     // Adds the background jobs of the inserted photos, nil to add none.
+}
+
+// EnqueueFunc adds the background jobs of a photo, with the queries of the transaction inserting it so that no photo is
+// left without its jobs.
+type EnqueueFunc func(ctx context.Context, q query.Querier, photoID uint32) error
+
+// Result describes what happened to a photo added to an event.
+type Result struct {
+	Status     AddStatus `json:"status"`
+	PhotoID    uint32    `json:"photo_id,omitempty"`
+	Path       string    `json:"-"`                    // Storage key of the file referenced by the photo.
+	Duplicates []uint32  `json:"duplicates,omitempty"` // Photos of other events with the same content.
+	Similar    []uint32  `json:"similar,omitempty"`    // Near duplicates queued for review.
+}
+
+// Inspect hashes a photo and reads its metadata.
+//
+// Parameters:
+//   - path: Location of the photo on disk.
+//
+// Returns:
+//   - File: The photo, ready to be added to an event.
+//   - error: An error if the file could not be read.
+func Inspect(path string) (File, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return File{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return File{}, err
+	}
+	file := File{Path: path, Size: info.Size()}
+	file.Metadata, err = ReadMetadata(f)
+	if err != nil {
+		return File{}, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return File{}, err
+	}
+	// The picture is decoded while it is hashed, then the rest of the file is hashed.
+	h := sha256.New()
+	hash, err := phash.Read(io.TeeReader(f, h))
+	if err == nil {
+		file.PerceptualHash = sql.NullInt64{Int64: int64(hash), Valid: true}
+	}
+	if _, err := io.Copy(h, f); err != nil {
+		return File{}, fmt.Errorf("failed to hash file: %w", err)
+	}
+	file.Hash = hex.EncodeToString(h.Sum(nil))
+	return file, nil
+}
+
+// Add stores a photo and inserts it in an event.
+//
+// Photos are stored by content, so a photo already present in another event shares its file, and the
+// duplicate policy decides whether it is added. Photos looking like another photo of the library, such
+// as resized or re-encoded copies, are added and queued for review by the admins.
+//
+// Parameters:
+//   - ctx: Context of the database queries.
+//   - database: Database the photo is inserted into.
+//   - file: The photo to add, as returned by Inspect.
+//   - eventID: The event the photo is added to.
+//   - opts: Storage and duplicate detection options.
+//
+// Returns:
+//   - Result: What happened to the photo.
+//   - error: usage.ErrQuotaExceeded or usage.ErrUploaderQuotaExceeded if the photo does not fit in the quotas, or an
+//     error if the photo could not be stored or inserted.
+func Add(ctx context.Context, database *db.DB, file File, eventID uint32, opts AddOptions) (Result, error) {
+	defer lockContent(file.Hash)()
+	existing, err := database.GetPhotoWithEventAndHash(ctx, query.GetPhotoWithEventAndHashParams{
+		EventID:  eventID,
+		FileHash: file.Hash,
+	})
+	if err == nil {
+		return Result{Status: StatusExists, PhotoID: existing.PhotoID, Path: existing.PathToPhoto}, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Result{}, fmt.Errorf("failed to look up photo: %w", err)
+	}
+
+	copies, err := database.GetPhotosWithHash(ctx, file.Hash)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to look up duplicates: %w", err)
+	}
+	result := Result{Status: StatusAdded}
+	for _, photo := range copies {
+		result.Duplicates = append(result.Duplicates, photo.PhotoID)
+	}
+	if len(copies) > 0 && opts.Duplicates == DuplicatesReject {
+		result.Status = StatusRejected
+		return result, nil
+	}
+	if len(copies) > 0 && opts.Duplicates == DuplicatesLink {
+		result.Status = StatusLinked
+	}
+
+	// Copies stored before content addressing have their own path, which is reused as is.
+	var object storage.Object
+	if len(copies) > 0 {
+		object = storage.Object{Hash: file.Hash, Path: copies[0].PathToPhoto, Existed: true}
+	} else {
+		object, err = storeFile(ctx, database, file, opts)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+	result.Path = object.Path
+	// A file stored for the photo is deleted if the photo is not inserted.
+	discard := func() {
+		if !object.Existed {
+			_ = opts.Storage.Delete(ctx, object.Path)
+			_ = usage.RemoveFile(ctx, database.Querier, query.StorageUsageKindORIGINAL, file.Size)
+		}
+	}
+
+	candidates, err := findSimilar(ctx, database, file, opts.MaxDistance)
+	if err != nil {
+		discard()
+		return Result{}, err
+	}
+	for _, candidate := range candidates {
+		result.Similar = append(result.Similar, candidate.DuplicateOfPhotoID)
+	}
+	if opts.Duplicates == DuplicatesWarn {
+		for _, photo := range copies {
+			candidates = append(candidates, query.CreateDuplicateCandidateParams{DuplicateOfPhotoID: photo.PhotoID})
+		}
+	}
+	result.PhotoID, err = insertPhoto(ctx, database, object, file, eventID, opts, candidates)
+	if err != nil {
+		discard()
+		return Result{}, err
+	}
+	return result, nil
+}
+
+// storeFile stores the file of a photo whose content is referenced by no other photo. A file which is not stored yet
+// is accounted for in the usage of the whole storage before being written, if it fits in the quota.
+func storeFile(ctx context.Context, database *db.DB, file File, opts AddOptions) (storage.Object, error) {
+	_, err := opts.Storage.Stat(ctx, storage.ObjectPath(file.Hash, filepath.Ext(file.Path)))
+	if err == nil {
+		// The file is left by an interrupted upload or deletion, it is still accounted for.
+		return storage.PutFile(ctx, opts.Storage, file.Path, file.Hash)
+	}
+	if !errors.Is(err, storage.ErrNotExist) {
+		return storage.Object{}, fmt.Errorf("failed to stat object: %w", err)
+	}
+	if err := usage.ReserveFile(ctx, database.Querier, opts.Quotas.Global, file.Size); err != nil {
+		return storage.Object{}, err
+	}
+	object, err := storage.PutFile(ctx, opts.Storage, file.Path, file.Hash)
+	if err != nil || object.Existed {
+		_ = usage.RemoveFile(ctx, database.Querier, query.StorageUsageKindORIGINAL, file.Size)
+	}
+	return object, err
+}
+
+// findSimilar lists the photos whose perceptual hash is close to the one of the file, closest first. Up to
+// phash.MaxBandedDistance, only the photos sharing a band key with the file are compared with it, larger distances
+// compare the file with every photo.
+func findSimilar(ctx context.Context, database *db.DB, file File, maxDistance int) ([]query.CreateDuplicateCandidateParams, error) {
+	if !file.PerceptualHash.Valid || maxDistance < 0 {
+		return nil, nil
+	}
+	var hashes []query.GetPerceptualHashesRow
+	var err error
+	if maxDistance <= phash.MaxBandedDistance {
+		keys := phash.BandKeys(uint64(file.PerceptualHash.Int64))
+		var rows []query.GetPerceptualHashesInBandsRow
+		rows, err = database.GetPerceptualHashesInBands(ctx, query.GetPerceptualHashesInBandsParams{
+			BandKey:   keys[0],
+			BandKey_2: keys[1],
+			BandKey_3: keys[2],
+			BandKey_4: keys[3],
+			BandKey_5: keys[4],
+			BandKey_6: keys[5],
+			BandKey_7: keys[6],
+			BandKey_8: keys[7],
+			FileHash:  file.Hash,
+		})
+		for _, row := range rows {
+			hashes = append(hashes, query.GetPerceptualHashesRow(row))
+		}
+	} else {
+		hashes, err = database.GetPerceptualHashes(ctx, file.Hash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up near duplicates: %w", err)
+	}
+	var candidates []query.CreateDuplicateCandidateParams
+	for _, row := range hashes {
+		distance := phash.Distance(uint64(file.PerceptualHash.Int64), uint64(row.PerceptualHash.Int64))
+		if distance <= maxDistance {
+			candidates = append(candidates, query.CreateDuplicateCandidateParams{
+				DuplicateOfPhotoID: row.PhotoID,
+				Distance:           uint8(distance),
+			})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Distance < candidates[j].Distance })
+	if len(candidates) > maxSimilar {
+		candidates = candidates[:maxSimilar]
+	}
+	return candidates, nil
+}
+
+// insertPhoto inserts a photo, the band keys of its perceptual hash, its metadata and its duplicate candidates, and
+// accounts for it in its event and its uploader, in a single transaction.
+func insertPhoto(ctx context.Context, database *db.DB, object storage.Object, file File, eventID uint32, opts AddOptions, candidates []query.CreateDuplicateCandidateParams) (uint32, error) {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	qtx := database.WithTx(tx)
+	id, err := qtx.CreatePhoto(ctx, query.CreatePhotoParams{
+		PathToPhoto:    object.Path,
+		FileHash:       file.Hash,
+		PerceptualHash: file.PerceptualHash,
+		FileSize:       file.Size,
+		EventID:        eventID,
+		UploaderID:     opts.UploaderID,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, fmt.Errorf("failed to insert photo: %w", err)
+	}
+	photoID := uint32(id)
+	err = qtx.CreatePerceptualHashBands(ctx, photoID)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, fmt.Errorf("failed to index perceptual hash: %w", err)
+	}
+	err = qtx.CreatePhotoMetadata(ctx, PhotoMetadataParams(photoID, file.Metadata))
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, fmt.Errorf("failed to insert photo metadata: %w", err)
+	}
+	for _, candidate := range candidates {
+		candidate.PhotoID = photoID
+		err = qtx.CreateDuplicateCandidate(ctx, candidate)
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, fmt.Errorf("failed to insert duplicate candidate: %w", err)
+		}
+	}
+	err = usage.AddPhoto(ctx, qtx, opts.Quotas, eventID, opts.UploaderID, file.Size)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+	if opts.Enqueue != nil {
+		err = opts.Enqueue(ctx, qtx, photoID)
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+	}
+	return photoID, tx.Commit()
+}
+
+// IndexPerceptualHashes adds the band keys of the perceptual hashes of the photos stored before they were indexed, so
+// that uploads find them as near duplicates.
+//
+// Parameters:
+//   - ctx: Context of the queries.
+//   - database: Database holding the photos.
+//
+// Returns:
+//   - int64: The number of band keys added, phash.Bands per photo.
+//   - error: An error if the keys could not be added.
+func IndexPerceptualHashes(ctx context.Context, database *db.DB) (int64, error) {
+	keys, err := database.BackfillPerceptualHashBands(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to index perceptual hashes: %w", err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,118 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"photos/pkg/derivative"
+	"photos/pkg/storage"
+	"photos/pkg/usage"
+	"sync"
+)
+
+// contentLocks serializes the additions and deletions of the photos of a same content within the process, spreading
+// the contents over a fixed number of locks. Imports run by another process are not serialized with them, the
+// consistency check reports the photos whose file was deleted meanwhile.
+var contentLocks [64]sync.Mutex
+
+// lockContent locks the photos of a content until the returned function is called.
+func lockContent(hash string) func() {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(hash))
+	lock := &contentLocks[h.Sum32()%uint32(len(contentLocks))]
+	lock.Lock()
+	return lock.Unlock
+}
+
+// derivativeKinds lists the derivatives which can be stored for a photo.
+var derivativeKinds = []derivative.Kind{derivative.Thumbnail, derivative.Preview}
+
+// Delete removes a photo from its event with its derivatives, and its file once no other photo references it.
+//
+// Rows and usage counters are updated in a single transaction, files are deleted once it is committed,
+// so a failure leaves unreferenced files rather than photos without file. The usage of the whole storage is updated
+// as each file is deleted, the files left over stay counted until the consistency repair quarantines them. Photos of the same content are not added
+// meanwhile, so a file is not deleted while a new photo starts referencing it.
+//
+// Parameters:
+//   - ctx: Context of the database queries and storage operations.
+//   - database: Database the photo is deleted from.
+//   - store: Storage holding the file of the photo.
+//   - photoID: The photo to delete.
+//
+// Returns:
+//   - error: sql.ErrNoRows if the photo does not exist, or an error if it could not be deleted.
+func Delete(ctx context.Context, database *db.DB, store storage.Storage, photoID uint32) error {
+	// The lock is taken before the transaction, which would otherwise hold a connection an addition waits for.
+	photo, err := database.GetPhoto(ctx, photoID)
+	if err != nil {
+		return err
+	}
+	defer lockContent(photo.FileHash)()
+
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := database.WithTx(tx)
+
+	photo, err = qtx.GetPhoto(ctx, photoID)
+	if err != nil {
+		return err
+	}
+	if err := qtx.DeletePhoto(ctx, photoID); err != nil {
+		return fmt.Errorf("failed to delete photo: %w", err)
+	}
+	references, err := qtx.CountPhotosWithPath(ctx, photo.PathToPhoto)
+	if err != nil {
+		return fmt.Errorf("failed to count photo references: %w", err)
+	}
+	if err := usage.RemovePhoto(ctx, qtx, photo); err != nil {
+		return err
+	}
+	var files []storedFile
+	for _, kind := range derivativeKinds {
+		info, err := store.Stat(ctx, derivative.Key(photoID, kind))
+		if errors.Is(err, storage.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := usage.RemoveDerivative(ctx, qtx, photo.EventID, kind, info.Size); err != nil {
+			return err
+		}
+		files = append(files, storedFile{info.Key, usage.Kind(kind), info.Size})
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit photo deletion: %w", err)
+	}
+
+	if references == 0 {
+		files = append(files, storedFile{photo.PathToPhoto, query.StorageUsageKindORIGINAL, photo.FileSize})
+	}
+	for _, file := range files {
+		if err := store.Delete(ctx, file.key); err != nil {
+			return fmt.Errorf("photo deleted, but not its file %s: %w", file.key, err)
+		}
+		// Files stored before their size was recorded are not accounted for.
+		if file.size == 0 {
+			continue
+		}
+		if err := usage.RemoveFile(ctx, database.Querier, file.kind, file.size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storedFile is a file of a deleted photo, accounted for in the usage of the whole storage until it is deleted.
+type storedFile struct {
+	key  string
+	kind query.StorageUsageKind
+	size int64
+}
@@ -0,0 +1,317 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"photos/pkg/storage"
+	"photos/pkg/utils"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Mode tells whether the imported files are copied or moved into the storage.
+type Mode int
+
+const (
+	ModeCopy Mode = iota // Leave the source tree untouched.
+	ModeMove             // Remove the source files once they are stored.
+)
+
+// ParseMode converts "copy" or "move" into a Mode.
+func ParseMode(value string) (Mode, error) {
+	switch strings.ToLower(value) {
+	case "", "copy":
+		return ModeCopy, nil
+	case "move":
+		return ModeMove, nil
+	}
+	return ModeCopy, fmt.Errorf("unknown import mode %q", value)
+}
+
+// supportedExtensions lists the file extensions recognized as photos.
+var supportedExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".webp": true,
+}
+
+// IsSupported tells whether a file is a photo, according to its extension.
+func IsSupported(path string) bool {
+	return supportedExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// folderDatePattern matches folder names starting with a date such as "2024", "2024-10" or "2024_10_05".
+var folderDatePattern = regexp.MustCompile(`^(\d{4})(?:[-_.]?(\d{2})(?:[-_.]?(\d{2}))?)?(?:\D|$)`)
+
+// Options configures a bulk import.
+type Options struct {
+	Source      string          // Directory tree to import.
+	Storage     storage.Storage // Storage where the photos are copied or moved to.
+	Mode        Mode            // Copy or move the source files.
+	Duplicates  DuplicatePolicy // What to do with photos already stored in another event.
+	MaxDistance int             // Perceptual hash distance under which photos are near duplicates, negative to disable.
+	Enqueue     EnqueueFunc     // Adds the background jobs of the imported photos, nil to add none.
+	Logger      zerolog.Logger  // Logger used to report per-file failures.
+	OnProgress  func(Progress)  // Optional callback invoked after every processed file.
+}
+
+// Progress reports the state of an import.
+type Progress struct {
+	Directories int    `json:"directories"` // Number of folders mapped to an event.
+	Files       int    `json:"files"`       // Number of photos found.
+	Imported    int    `json:"imported"`    // Number of photos inserted.
+	Skipped     int    `json:"skipped"`     // Number of photos already imported by a previous run, or rejected as duplicates.
+	Duplicates  int    `json:"duplicates"`  // Number of photos already stored in another event.
+	Similar     int    `json:"similar"`     // Number of photos queued for review next to near duplicates.
+	Failed      int    `json:"failed"`      // Number of photos that could not be imported.
+	Current     string `json:"current"`     // Path of the file being processed.
+}
+
+// importer holds the state of a single import run.
+type importer struct {
+	db       *db.DB
+	opts     Options
+	progress Progress
+	visited  map[string]bool
+}
+
+// Run walks the source directory tree and imports it into the database.
+//
+// Every folder is mapped to an event nested under the event of its parent folder, and every
+// photo is stored in the storage and inserted in the event of its folder. Photos are
+// identified by the SHA-256 of their content, so running an import twice does not create
+// duplicates, and photos already stored in another event are handled by the duplicate policy.
+//
+// Parameters:
+//   - ctx: Context used to cancel the import.
+//   - database: Database the events and photos are inserted into.
+//   - opts: Import options.
+//
+// Returns:
+//   - Progress: The final counters of the import.
+//   - error: An error if the import was aborted.
+func Run(ctx context.Context, database *db.DB, opts Options) (Progress, error) {
+	info, err := os.Stat(opts.Source)
+	if err != nil {
+		return Progress{}, fmt.Errorf("failed to stat import source: %w", err)
+	}
+	if !info.IsDir() {
+		return Progress{}, fmt.Errorf("import source %s is not a directory", opts.Source)
+	}
+	imp := &importer{db: database, opts: opts, visited: map[string]bool{}}
+	err = imp.importDir(ctx, filepath.Clean(opts.Source), sql.NullInt32{}, time.Time{})
+	return imp.progress, err
+}
+
+// importDir maps a directory to an event, imports its photos, then recurses into its sub-directories.
+func (imp *importer) importDir(ctx context.Context, dir string, parentEventID sql.NullInt32, parentDate time.Time) error {
+	realPath, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	if imp.visited[realPath] {
+		imp.opts.Logger.Warn().Str("path", dir).Msg("skipping already visited directory")
+		return nil
+	}
+	imp.visited[realPath] = true
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var files []File
+	var subDirs []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if entry.IsDir() {
+			subDirs = append(subDirs, path)
+			continue
+		}
+		if entry.Type()&os.ModeSymlink != 0 {
+			isDir, err := utils.IsDirSymlink(path)
+			if err != nil {
+				imp.opts.Logger.Warn().Err(err).Str("path", path).Msg("skipping unresolvable symlink")
+				continue
+			}
+			if isDir {
+				subDirs = append(subDirs, path)
+				continue
+			}
+		}
+		if !IsSupported(path) {
+			continue
+		}
+		imp.progress.Files++
+		file, err := Inspect(path)
+		if err != nil {
+			imp.fail(path, err)
+			continue
+		}
+		files = append(files, file)
+	}
+
+	eventDate := eventDateFor(dir, files, parentDate)
+	eventID, err := imp.findOrCreateEvent(ctx, filepath.Base(dir), parentEventID, eventDate)
+	if err != nil {
+		return err
+	}
+	imp.progress.Directories++
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		imp.progress.Current = file.Path
+		err := imp.importFile(ctx, file, eventID)
+		if err != nil {
+			imp.fail(file.Path, err)
+		}
+		imp.report()
+	}
+
+	for _, subDir := range subDirs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := imp.importDir(ctx, subDir, sql.NullInt32{Int32: int32(eventID), Valid: true}, eventDate)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findOrCreateEvent returns the ID of the event with the given name and parent, creating it if needed.
+func (imp *importer) findOrCreateEvent(ctx context.Context, name string, parentEventID sql.NullInt32, date time.Time) (uint32, error) {
+	event, err := imp.db.GetEventWithNameAndParent(ctx, query.GetEventWithNameAndParentParams{
+		Name:          name,
+		ParentEventID: parentEventID,
+	})
+	if err == nil {
+		return event.EventID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to look up event %s: %w", name, err)
+	}
+	id, err := imp.db.CreateEvent(ctx, query.CreateEventParams{
+		Name:          name,
+		Description:   "",
+		EventDate:     date,
+		ParentEventID: parentEventID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create event %s: %w", name, err)
+	}
+	return uint32(id), nil
+}
+
+// importFile adds a photo to the event of its folder, then removes the source file in move mode.
+func (imp *importer) importFile(ctx context.Context, file File, eventID uint32) error {
+	result, err := Add(ctx, imp.db, file, eventID, AddOptions{
+		Storage:     imp.opts.Storage,
+		Duplicates:  imp.opts.Duplicates,
+		MaxDistance: imp.opts.MaxDistance,
+		Enqueue:     imp.opts.Enqueue,
+	})
+	if err != nil {
+		return err
+	}
+	if len(result.Duplicates) > 0 {
+		imp.progress.Duplicates++
+	}
+	if len(result.Similar) > 0 {
+		imp.progress.Similar++
+	}
+	switch result.Status {
+	case StatusExists, StatusRejected:
+		imp.progress.Skipped++
+		return nil
+	}
+	if imp.opts.Mode == ModeMove {
+		if err := os.Remove(file.Path); err != nil {
+			imp.opts.Logger.Warn().Err(err).Str("path", file.Path).Msg("failed to remove imported file")
+		}
+	}
+	imp.progress.Imported++
+	return nil
+}
+
+func (imp *importer) fail(path string, err error) {
+	imp.progress.Failed++
+	imp.opts.Logger.Error().Err(err).Str("path", path).Msg("failed to import photo")
+}
+
+func (imp *importer) report() {
+	if imp.opts.OnProgress != nil {
+		imp.opts.OnProgress(imp.progress)
+	}
+}
+
+// eventDateFor picks the date of the event of a folder: the earliest EXIF capture date of its
+// photos, then a date in the folder name, then the date of the parent event, then the folder
+// modification time.
+func eventDateFor(dir string, files []File, parentDate time.Time) time.Time {
+	var earliest time.Time
+	for _, file := range files {
+		date := file.Metadata.DateTimeOriginal
+		if !date.IsZero() && (earliest.IsZero() || date.Before(earliest)) {
+			earliest = date
+		}
+	}
+	if !earliest.IsZero() {
+		return earliest
+	}
+	if date, ok := parseFolderDate(filepath.Base(dir)); ok {
+		return date
+	}
+	if !parentDate.IsZero() {
+		return parentDate
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return time.Now().UTC()
+	}
+	return info.ModTime().UTC()
+}
+
+// parseFolderDate extracts a date from folder names such as "2024-WEI" or "2024-10-05 Gala".
+func parseFolderDate(name string) (time.Time, bool) {
+	match := folderDatePattern.FindStringSubmatch(name)
+	if match == nil {
+		return time.Time{}, false
+	}
+	year, _ := strconv.Atoi(match[1])
+	month, day := 1, 1
+	if match[2] != "" {
+		month, _ = strconv.Atoi(match[2])
+	}
+	if match[3] != "" {
+		day, _ = strconv.Atoi(match[3])
+	}
+	if year < 1900 || month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day {
+		return time.Time{}, false
+	}
+	return date, true
+}
@@ -0,0 +1,294 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"photos/pkg/exif"
+	"photos/pkg/phash"
+	"photos/pkg/storage"
+	"photos/pkg/usage"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseFolderDate ensures that dates are extracted from common folder naming schemes.
+func TestParseFolderDate(t *testing.T) {
+	cases := map[string]time.Time{
+		"2024-WEI":        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		"2024-10 Gala":    time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
+		"2024_10_05":      time.Date(2024, 10, 5, 0, 0, 0, 0, time.UTC),
+		"20241005 Soirée": time.Date(2024, 10, 5, 0, 0, 0, 0, time.UTC),
+	}
+	for name, expected := range cases {
+		date, ok := parseFolderDate(name)
+		assert.True(t, ok, "parseFolderDate should find a date in %q", name)
+		assert.Equal(t, expected, date, "parseFolderDate should parse %q", name)
+	}
+
+	for _, name := range []string{"Saturday", "Party", "2024-13-01", "2023-02-30", "123456"} {
+		_, ok := parseFolderDate(name)
+		assert.False(t, ok, "parseFolderDate should not find a date in %q", name)
+	}
+}
+
+// TestEventDateFor ensures that EXIF dates take precedence over the folder name and the parent date.
+func TestEventDateFor(t *testing.T) {
+	parent := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+	early := time.Date(2024, 10, 5, 20, 0, 0, 0, time.UTC)
+	late := time.Date(2024, 10, 5, 23, 0, 0, 0, time.UTC)
+
+	files := []File{
+		{Metadata: exif.Metadata{DateTimeOriginal: late}},
+		{},
+		{Metadata: exif.Metadata{DateTimeOriginal: early}},
+	}
+	assert.Equal(t, early, eventDateFor("2023-WEI", files, parent), "The earliest EXIF date should be used")
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), eventDateFor("2023-WEI", nil, parent), "The folder date should be used")
+	assert.Equal(t, parent, eventDateFor("Party", nil, parent), "The parent date should be used")
+}
+
+// TestParseMode ensures that import modes are parsed.
+func TestParseMode(t *testing.T) {
+	mode, err := ParseMode("move")
+	assert.NoError(t, err)
+	assert.Equal(t, ModeMove, mode)
+
+	mode, err = ParseMode("")
+	assert.NoError(t, err)
+	assert.Equal(t, ModeCopy, mode)
+
+	_, err = ParseMode("link")
+	assert.Error(t, err)
+}
+
+// TestParseDuplicatePolicy ensures that the duplicate policies are recognized, warning by default.
+func TestParseDuplicatePolicy(t *testing.T) {
+	policy, err := ParseDuplicatePolicy("reject")
+	assert.NoError(t, err)
+	assert.Equal(t, DuplicatesReject, policy)
+
+	policy, err = ParseDuplicatePolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, DuplicatesWarn, policy)
+
+	_, err = ParseDuplicatePolicy("merge")
+	assert.Error(t, err)
+}
+
+// TestInspect ensures that photos are hashed by content and by picture.
+func TestInspect(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 64, 48))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 48; y++ {
+			img.SetGray(x, y, color.Gray{Y: uint8(x * 4)})
+		}
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	path := filepath.Join(t.TempDir(), "photo.png")
+	assert.NoError(t, os.WriteFile(path, buf.Bytes(), 0600))
+
+	file, err := Inspect(path)
+	assert.NoError(t, err)
+	sum := sha256.Sum256(buf.Bytes())
+	assert.Equal(t, hex.EncodeToString(sum[:]), file.Hash)
+	assert.True(t, file.PerceptualHash.Valid)
+	assert.Equal(t, uint32(64), file.Metadata.Width)
+	assert.Equal(t, uint32(48), file.Metadata.Height)
+}
+
+// newLibrary opens an empty database with two events and a local storage, and writes a photo to import.
+func newLibrary(t *testing.T) (*db.DB, storage.Storage, [2]uint32, File) {
+	database, err := db.New(db.Options{Driver: db.DriverSQLite, Path: filepath.Join(t.TempDir(), "photos.db"), MaxOpenConns: 1})
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close() })
+	var events [2]uint32
+	for i := range events {
+		id, err := database.CreateEvent(context.Background(), query.CreateEventParams{Name: "Gala", EventDate: time.Now()})
+		assert.NoError(t, err)
+		events[i] = uint32(id)
+	}
+
+	img := image.NewGray(image.Rect(0, 0, 64, 48))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 48; y++ {
+			img.SetGray(x, y, color.Gray{Y: uint8(x * 4)})
+		}
+	}
+	path := filepath.Join(t.TempDir(), "photo.png")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	assert.NoError(t, png.Encode(f, img))
+	assert.NoError(t, f.Close())
+	file, err := Inspect(path)
+	assert.NoError(t, err)
+	return database, storage.NewLocal(t.TempDir()), events, file
+}
+
+// addLinked adds a photo to the first event, then links it into the second one.
+func addLinked(t *testing.T, database *db.DB, store storage.Storage, events [2]uint32, file File) (Result, Result) {
+	ctx := context.Background()
+	original, err := Add(ctx, database, file, events[0], AddOptions{Storage: store, Duplicates: DuplicatesLink})
+	assert.NoError(t, err)
+	assert.Equal(t, StatusAdded, original.Status)
+	linked, err := Add(ctx, database, file, events[1], AddOptions{Storage: store, Duplicates: DuplicatesLink})
+	assert.NoError(t, err)
+	assert.Equal(t, StatusLinked, linked.Status)
+	assert.Equal(t, []uint32{original.PhotoID}, linked.Duplicates)
+	assert.NotEqual(t, original.PhotoID, linked.PhotoID)
+	assert.Equal(t, original.Path, linked.Path)
+	return original, linked
+}
+
+// storedBytes returns the bytes counted in the usage of the whole storage.
+func storedBytes(t *testing.T, database *db.DB) int64 {
+	used, err := database.GetStorageUsage(context.Background(), query.GetStorageUsageParams{Scope: query.StorageUsageScopeGLOBAL, ScopeID: 0})
+	assert.NoError(t, err)
+	return used
+}
+
+// TestDeleteLinkedOriginal ensures that deleting the photo which stored a file keeps the photo linked to it, and the file
+// with its usage.
+func TestDeleteLinkedOriginal(t *testing.T) {
+	database, store, events, file := newLibrary(t)
+	ctx := context.Background()
+	original, linked := addLinked(t, database, store, events, file)
+	assert.Equal(t, file.Size, storedBytes(t, database), "a shared file counts once")
+
+	assert.NoError(t, Delete(ctx, database, store, original.PhotoID))
+	photo, err := database.GetPhoto(ctx, linked.PhotoID)
+	assert.NoError(t, err)
+	assert.Equal(t, original.Path, photo.PathToPhoto)
+	_, err = store.Stat(ctx, original.Path)
+	assert.NoError(t, err, "the file is still referenced")
+	assert.Equal(t, file.Size, storedBytes(t, database))
+
+	assert.NoError(t, Delete(ctx, database, store, linked.PhotoID))
+	_, err = store.Stat(ctx, original.Path)
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+	assert.Zero(t, storedBytes(t, database))
+}
+
+// TestDeleteLinkedCopy ensures that deleting a photo linked to the file of another keeps the other photo and the file.
+func TestDeleteLinkedCopy(t *testing.T) {
+	database, store, events, file := newLibrary(t)
+	ctx := context.Background()
+	original, linked := addLinked(t, database, store, events, file)
+
+	assert.NoError(t, Delete(ctx, database, store, linked.PhotoID))
+	_, err := database.GetPhoto(ctx, original.PhotoID)
+	assert.NoError(t, err)
+	_, err = store.Stat(ctx, original.Path)
+	assert.NoError(t, err, "the file is still referenced")
+
+	assert.NoError(t, Delete(ctx, database, store, original.PhotoID))
+	_, err = store.Stat(ctx, original.Path)
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+}
+
+// TestFindSimilar ensures that near duplicates are found through the band keys of their hash, including photos stored
+// before the keys were indexed, and by comparing every photo beyond the banded distance.
+func TestFindSimilar(t *testing.T) {
+	database, _, events, file := newLibrary(t)
+	ctx := context.Background()
+	hash := uint64(0x9AE3C5170F42D86B)
+	file.PerceptualHash = sql.NullInt64{Int64: int64(hash), Valid: true}
+	var ids []uint32
+	// The first photo only shares the last band of the hash, the second one shares none.
+	for i, stored := range []uint64{hash ^ 0x0002040810204080, hash ^ 0x0102040810204081} {
+		id, err := database.CreatePhoto(ctx, query.CreatePhotoParams{
+			PathToPhoto:    strconv.Itoa(i) + ".png",
+			FileHash:       strconv.Itoa(i),
+			PerceptualHash: sql.NullInt64{Int64: int64(stored), Valid: true},
+			EventID:        events[0],
+		})
+		assert.NoError(t, err)
+		ids = append(ids, uint32(id))
+	}
+
+	candidates, err := findSimilar(ctx, database, file, phash.MaxBandedDistance)
+	assert.NoError(t, err)
+	assert.Empty(t, candidates, "the photos are not indexed yet")
+
+	keys, err := IndexPerceptualHashes(ctx, database)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2*phash.Bands), keys)
+	keys, err = IndexPerceptualHashes(ctx, database)
+	assert.NoError(t, err)
+	assert.Zero(t, keys, "indexed photos are skipped")
+
+	candidates, err = findSimilar(ctx, database, file, phash.MaxBandedDistance)
+	assert.NoError(t, err)
+	assert.Equal(t, []query.CreateDuplicateCandidateParams{{DuplicateOfPhotoID: ids[0], Distance: 7}}, candidates)
+	candidates, err = findSimilar(ctx, database, file, 9)
+	assert.NoError(t, err)
+	assert.Equal(t, []query.CreateDuplicateCandidateParams{
+		{DuplicateOfPhotoID: ids[0], Distance: 7},
+		{DuplicateOfPhotoID: ids[1], Distance: 9},
+	}, candidates)
+}
+
+// TestAddQuota ensures that a photo exceeding the quota is neither stored nor inserted, and that a photo whose file is
+// already stored is not refused by the quota of the storage.
+func TestAddQuota(t *testing.T) {
+	database, store, events, file := newLibrary(t)
+	ctx := context.Background()
+
+	_, err := Add(ctx, database, file, events[0], AddOptions{Storage: store, Quotas: usage.Quotas{Global: file.Size - 1}})
+	assert.ErrorIs(t, err, usage.ErrQuotaExceeded)
+	_, err = store.Stat(ctx, storage.ObjectPath(file.Hash, ".png"))
+	assert.ErrorIs(t, err, storage.ErrNotExist, "the file of a refused photo is deleted")
+	assert.Zero(t, storedBytes(t, database))
+	photos, err := database.GetPhotosWithHash(ctx, file.Hash)
+	assert.NoError(t, err)
+	assert.Empty(t, photos)
+
+	quotas := usage.Quotas{Global: file.Size}
+	_, err = Add(ctx, database, file, events[0], AddOptions{Storage: store, Quotas: quotas})
+	assert.NoError(t, err)
+	result, err := Add(ctx, database, file, events[1], AddOptions{Storage: store, Duplicates: DuplicatesLink, Quotas: quotas})
+	assert.NoError(t, err)
+	assert.Equal(t, StatusLinked, result.Status)
+}
+
+// TestAddEnqueue ensures that the jobs of a photo are added with it, and that a failure to add them leaves no photo.
+func TestAddEnqueue(t *testing.T) {
+	database, store, events, file := newLibrary(t)
+	ctx := context.Background()
+
+	_, err := Add(ctx, database, file, events[0], AddOptions{
+		Storage: store,
+		Enqueue: func(ctx context.Context, q query.Querier, photoID uint32) error {
+			return errors.New("queue unavailable")
+		},
+	})
+	assert.Error(t, err)
+	photos, err := database.GetPhotosWithHash(ctx, file.Hash)
+	assert.NoError(t, err)
+	assert.Empty(t, photos, "a photo must not be inserted without its jobs")
+
+	var enqueued []uint32
+	result, err := Add(ctx, database, file, events[0], AddOptions{
+		Storage: store,
+		Enqueue: func(ctx context.Context, q query.Querier, photoID uint32) error {
+			enqueued = append(enqueued, photoID)
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{result.PhotoID}, enqueued)
+}
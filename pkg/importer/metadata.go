@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"photos/pkg/db/query"
+	"photos/pkg/exif"
+
+	// Register the decoders used to read the dimensions of photos without EXIF data.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// ReadMetadata extracts the EXIF and XMP metadata of a photo.
+// The dimensions are read from the image header when the metadata does not provide them.
+//
+// Parameters:
+//   - r: The photo file, positioned at its start.
+//
+// Returns:
+//   - exif.Metadata: The metadata of the photo, empty if it has none.
+//   - error: An error if the file could not be read.
+func ReadMetadata(r io.ReadSeeker) (exif.Metadata, error) {
+	md, err := exif.Decode(r)
+	if err != nil && !errors.Is(err, exif.ErrNoExif) {
+		// Broken metadata must not prevent the photo from being imported.
+		md = exif.Metadata{}
+	}
+	if md.Width != 0 && md.Height != 0 {
+		return md, nil
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return exif.Metadata{}, fmt.Errorf("failed to rewind photo: %w", err)
+	}
+	cfg, _, err := image.DecodeConfig(r)
+	if err == nil {
+		md.Width, md.Height = uint32(cfg.Width), uint32(cfg.Height)
+	}
+	return md, nil
+}
+
+// PhotoMetadataParams converts the metadata of a photo into the parameters of CreatePhotoMetadata.
+func PhotoMetadataParams(photoID uint32, md exif.Metadata) query.CreatePhotoMetadataParams {
+	params := query.CreatePhotoMetadataParams{
+		PhotoID:      photoID,
+		CaptureDate:  sql.NullTime{Time: md.DateTimeOriginal, Valid: !md.DateTimeOriginal.IsZero()},
+		CameraMake:   md.Make,
+		CameraModel:  md.Model,
+		LensModel:    md.LensModel,
+		ExposureTime: md.ExposureTime,
+		FNumber:      md.FNumber,
+		Iso:          md.ISO,
+		FocalLength:  md.FocalLength,
+		Orientation:  md.Orientation,
+		Width:        md.Width,
+		Height:       md.Height,
+	}
+	if md.GPS != nil {
+		params.Latitude = sql.NullFloat64{Float64: md.GPS.Latitude, Valid: true}
+		params.Longitude = sql.NullFloat64{Float64: md.GPS.Longitude, Valid: true}
+		if md.GPS.Altitude != nil {
+			params.Altitude = sql.NullFloat64{Float64: *md.GPS.Altitude, Valid: true}
+		}
+	}
+	return params
+}
@@ -0,0 +1,93 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"photos/pkg/db"
+	"sync"
+	"time"
+)
+
+// ErrImportRunning is returned when an import is started while another one is still running.
+var ErrImportRunning = errors.New("an import is already running")
+
+// Status describes the last import started through a Tracker.
+type Status struct {
+	Running  bool      `json:"running"`            // Whether the import is still running.
+	Source   string    `json:"source"`             // Directory being imported.
+	Started  time.Time `json:"started"`            // Start time of the import.
+	Finished time.Time `json:"finished,omitempty"` // End time of the import, zero while running.
+	Error    string    `json:"error,omitempty"`    // Error that aborted the import, if any.
+	Progress Progress  `json:"progress"`           // Counters of the import.
+}
+
+// Tracker runs imports in the background, one at a time, and keeps track of their progress.
+type Tracker struct {
+	ctx     context.Context
+	mux     sync.Mutex
+	status  Status
+	running sync.WaitGroup
+}
+
+// NewTracker creates a tracker whose imports run until they are done or until a context is cancelled.
+//
+// Parameters:
+//   - ctx: Context of the imports, such as the context of the server, cancelling the running import on shutdown.
+//
+// Returns:
+//   - *Tracker: The tracker, with no import started.
+func NewTracker(ctx context.Context) *Tracker {
+	return &Tracker{ctx: ctx}
+}
+
+// Start launches an import in the background.
+//
+// Parameters:
+//   - database: Database the events and photos are inserted into.
+//   - opts: Import options, OnProgress is overridden to update the tracker.
+//
+// Returns:
+//   - error: ErrImportRunning if another import has not finished yet.
+func (t *Tracker) Start(database *db.DB, opts Options) error {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if t.status.Running {
+		return ErrImportRunning
+	}
+	t.status = Status{Running: true, Source: opts.Source, Started: time.Now()}
+
+	opts.OnProgress = func(p Progress) {
+		t.mux.Lock()
+		t.status.Progress = p
+		t.mux.Unlock()
+	}
+	t.running.Add(1)
+	go func() {
+		defer t.running.Done()
+		progress, err := Run(t.ctx, database, opts)
+		t.mux.Lock()
+		defer t.mux.Unlock()
+		t.status.Running = false
+		t.status.Finished = time.Now()
+		t.status.Progress = progress
+		if err != nil {
+			t.status.Error = err.Error()
+			opts.Logger.Error().Err(err).Str("source", opts.Source).Msg("import failed")
+			return
+		}
+		opts.Logger.Info().Str("source", opts.Source).Interface("progress", progress).Msg("import finished")
+	}()
+	return nil
+}
+
+// Status returns the status of the last import.
+func (t *Tracker) Status() Status {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return t.status
+}
+
+// Wait waits for the running import to return, once the context of the tracker is cancelled or once it is done.
+func (t *Tracker) Wait() {
+	t.running.Wait()
+}
@@ -0,0 +1,263 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// maxErrorLength bounds the error saved with a failed job.
+const maxErrorLength = 2000
+
+// ErrLeaseLost is returned when a job is given to another worker before its result is saved, because its lease expired.
+// The result is dropped, the other worker runs the job again.
+var ErrLeaseLost = errors.New("job lease expired before the job was saved")
+
+// errLeaseExpired is the error of the jobs whose lease expired on their last attempt.
+var errLeaseExpired = errors.New("lease expired")
+
+// errNoHandler fails the jobs of a kind the pool has no handler for, which are dead at their first attempt.
+var errNoHandler = errors.New("no handler")
+
+// Handler runs a job. A returned error schedules another attempt, until the job runs out of attempts.
+type Handler func(ctx context.Context, job query.Job) error
+
+// Options configures a Pool.
+type Options struct {
+	Workers      int            // Number of jobs run concurrently.
+	PollInterval time.Duration  // Time between two looks for new jobs when the queue is empty.
+	Lease        time.Duration  // Time a job can run before it is given to another worker.
+	MaxAttempts  int            // Number of attempts before a job is dead.
+	Backoff      time.Duration  // Delay before the second attempt, doubled after every failure.
+	MaxBackoff   time.Duration  // Longest delay between two attempts.
+	Retention    time.Duration  // Time finished jobs are kept, 0 to keep them forever.
+	Logger       zerolog.Logger // Logger receiving the failures.
+}
+
+// Pool runs the jobs of the queue with a fixed number of workers. Jobs are leased, so the jobs of a worker which
+// died are run again once their lease expires, and several pools can share the same queue. Dates are taken from the
+// clock of the database, so that the pools of several hosts agree on them.
+type Pool struct {
+	database *db.DB
+	handlers map[query.JobsKind]Handler
+	opts     Options
+}
+
+// NewPool creates a pool running the jobs of the queue with the given handlers.
+//
+// Parameters:
+//   - database: Database holding the queue.
+//   - handlers: Handler of each kind of job, jobs without handler are dead at their first attempt.
+//   - opts: Pool options.
+//
+// Returns:
+//   - *Pool: The pool, started by Run.
+func NewPool(database *db.DB, handlers map[query.JobsKind]Handler, opts Options) *Pool {
+	return &Pool{database: database, handlers: handlers, opts: opts}
+}
+
+// Run processes jobs until the context is canceled, then waits for the running jobs.
+func (p *Pool) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < p.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx)
+		}()
+	}
+	if p.opts.Retention > 0 {
+		p.clean(ctx)
+	}
+	wg.Wait()
+}
+
+// work runs jobs as long as there are some, then polls the queue.
+func (p *Pool) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		ran, err := p.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			p.opts.Logger.Error().Err(err).Msg("failed to run job")
+		}
+		if ran && err == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(p.opts.PollInterval):
+		}
+	}
+}
+
+// clean deletes the finished jobs older than the retention, every hour.
+func (p *Pool) clean(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		deleted, err := p.database.DeleteDoneJobs(ctx, seconds(p.opts.Retention))
+		if err != nil && ctx.Err() == nil {
+			p.opts.Logger.Error().Err(err).Msg("failed to delete finished jobs")
+		}
+		if deleted > 0 {
+			p.opts.Logger.Info().Int64("jobs", deleted).Msg("finished jobs deleted")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce claims the next job of the queue and runs it.
+//
+// Parameters:
+//   - ctx: Context of the job.
+//
+// Returns:
+//   - bool: Whether a job was run, false if the queue is empty.
+//   - error: An error if the queue could not be read or updated, or ErrLeaseLost if the job was given to another worker
+//     meanwhile. Failures of the job itself are saved with the job.
+func (p *Pool) RunOnce(ctx context.Context) (bool, error) {
+	job, err := p.claim(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	handler, ok := p.handlers[job.Kind]
+	if !ok {
+		err = fmt.Errorf("%w for %s jobs", errNoHandler, job.Kind)
+	} else {
+		// The job must not outlive its lease, or another worker could run it at the same time.
+		jobCtx, cancel := context.WithTimeout(ctx, p.opts.Lease)
+		err = handler(jobCtx, job)
+		cancel()
+	}
+	if err == nil {
+		completed, err := p.database.CompleteJob(ctx, query.CompleteJobParams{JobID: job.JobID, Attempts: job.Attempts})
+		return true, leaseKept(job, completed, err)
+	}
+	return true, p.fail(ctx, job, err)
+}
+
+// leaseKept checks that the result of a job was saved, the attempts of the job telling whether it was leased again.
+func leaseKept(job query.Job, updated int64, err error) error {
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return fmt.Errorf("job %d: %w", job.JobID, ErrLeaseLost)
+	}
+	return nil
+}
+
+// claim leases the next job which is due, or whose lease expired. A job whose lease expired on its last attempt, its
+// worker having crashed or hung on every attempt, is dead instead of leased again.
+func (p *Pool) claim(ctx context.Context) (query.Job, error) {
+	for {
+		job, leased, err := p.claimNext(ctx)
+		if err != nil || leased {
+			return job, err
+		}
+	}
+}
+
+// claimNext leases the next job, or marks it dead if it ran out of attempts, in which case it returns false.
+func (p *Pool) claimNext(ctx context.Context) (query.Job, bool, error) {
+	tx, err := p.database.BeginTx(ctx, nil)
+	if err != nil {
+		return query.Job{}, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := p.database.WithTx(tx)
+
+	job, err := qtx.GetNextJob(ctx)
+	if err != nil {
+		return query.Job{}, false, err
+	}
+	if job.Status == query.JobsStatusRUNNING && int(job.Attempts) >= p.opts.MaxAttempts {
+		_, err := qtx.FailJob(ctx, query.FailJobParams{
+			Status:    query.JobsStatusDEAD,
+			LastError: sql.NullString{String: errLeaseExpired.Error(), Valid: true},
+			JobID:     job.JobID,
+			Attempts:  job.Attempts,
+		})
+		if err != nil {
+			return query.Job{}, false, err
+		}
+		if err := tx.Commit(); err != nil {
+			return query.Job{}, false, err
+		}
+		p.opts.Logger.Error().Err(errLeaseExpired).
+			Uint32("job_id", job.JobID).
+			Str("kind", string(job.Kind)).
+			Int32("photo_id", job.PhotoID.Int32).
+			Uint32("attempts", job.Attempts).
+			Str("status", string(query.JobsStatusDEAD)).
+			Msg("job failed")
+		return job, false, nil
+	}
+	err = qtx.LeaseJob(ctx, query.LeaseJobParams{LeaseSeconds: seconds(p.opts.Lease), JobID: job.JobID})
+	if err != nil {
+		return query.Job{}, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return query.Job{}, false, err
+	}
+	job.Status = query.JobsStatusRUNNING
+	job.Attempts++
+	return job, true, nil
+}
+
+// fail saves the error of a job, and schedules its next attempt or marks it dead.
+func (p *Pool) fail(ctx context.Context, job query.Job, jobErr error) error {
+	message := jobErr.Error()
+	if len(message) > maxErrorLength {
+		message = message[:maxErrorLength]
+	}
+	params := query.FailJobParams{
+		Status:         query.JobsStatusPENDING,
+		LastError:      sql.NullString{String: message, Valid: true},
+		BackoffSeconds: seconds(backoff(int(job.Attempts), p.opts.Backoff, p.opts.MaxBackoff)),
+		JobID:          job.JobID,
+		Attempts:       job.Attempts,
+	}
+	logger := p.opts.Logger.Warn()
+	if int(job.Attempts) >= p.opts.MaxAttempts || errors.Is(jobErr, errNoHandler) {
+		params.Status = query.JobsStatusDEAD
+		logger = p.opts.Logger.Error()
+	}
+	logger.Err(jobErr).
+		Uint32("job_id", job.JobID).
+		Str("kind", string(job.Kind)).
+		Int32("photo_id", job.PhotoID.Int32).
+		Uint32("attempts", job.Attempts).
+		Str("status", string(params.Status)).
+		Msg("job failed")
+	failed, err := p.database.FailJob(ctx, params)
+	return leaseKept(job, failed, err)
+}
+
+// backoff returns the delay before the next attempt of a job which failed the given number of times.
+func backoff(attempts int, base, maxDelay time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+// seconds converts a duration into the whole seconds the queries take, rounding up so that a lease is never shorter.
+func seconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,194 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+var jobColumns = []string{"job_id", "kind", "photo_id", "status", "attempts", "last_error", "run_after", "leased_until", "creation_date", "update_date"}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Minute, backoff(1, time.Minute, time.Hour))
+	assert.Equal(t, 2*time.Minute, backoff(2, time.Minute, time.Hour))
+	assert.Equal(t, 16*time.Minute, backoff(5, time.Minute, time.Hour))
+	assert.Equal(t, time.Hour, backoff(30, time.Minute, time.Hour), "delays must be capped")
+}
+
+func TestEnqueue(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+
+	mock.ExpectExec("INSERT INTO jobs").WithArgs("DERIVATIVES", 4).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO jobs").WithArgs("METADATA", 4).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("INSERT INTO jobs").WithArgs("FACES", 4).WillReturnResult(sqlmock.NewResult(3, 1))
+	assert.NoError(t, Enqueue(context.Background(), query.New(mockDB), 4, true))
+
+	mock.ExpectExec("INSERT INTO jobs").WithArgs("DERIVATIVES", 5).WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectExec("INSERT INTO jobs").WithArgs("METADATA", 5).WillReturnResult(sqlmock.NewResult(5, 1))
+	assert.NoError(t, Enqueue(context.Background(), query.New(mockDB), 5, false))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunOnce(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	database := &db.DB{DB: mockDB, Querier: query.New(mockDB)}
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var ran []uint32
+	pool := NewPool(database, map[query.JobsKind]Handler{
+		query.JobsKindDERIVATIVES: func(ctx context.Context, job query.Job) error {
+			ran = append(ran, job.JobID)
+			if job.PhotoID.Int32 == 2 {
+				return errors.New("broken photo")
+			}
+			return nil
+		},
+	}, Options{Lease: 90 * time.Second, MaxAttempts: 3, Backoff: time.Minute, MaxBackoff: time.Hour, Logger: zerolog.Nop()})
+	expectClaim := func(jobID uint32, kind string, photoID uint32, attempts uint32) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("FROM jobs").WithoutArgs().WillReturnRows(sqlmock.NewRows(jobColumns).
+			AddRow(jobID, kind, photoID, "PENDING", attempts, nil, now, nil, now, now))
+		mock.ExpectExec("UPDATE jobs").WithArgs(90, jobID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM jobs").WithoutArgs().WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	done, err := pool.RunOnce(ctx)
+	assert.NoError(t, err)
+	assert.False(t, done, "an empty queue must not run anything")
+
+	expectClaim(1, "DERIVATIVES", 1, 0)
+	mock.ExpectExec("UPDATE jobs SET status = 'DONE'").WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	done, err = pool.RunOnce(ctx)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	expectClaim(2, "DERIVATIVES", 2, 1)
+	mock.ExpectExec("UPDATE jobs SET status = \\?").
+		WithArgs("PENDING", "broken photo", 120, 2, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	done, err = pool.RunOnce(ctx)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	expectClaim(2, "DERIVATIVES", 2, 2)
+	mock.ExpectExec("UPDATE jobs SET status = \\?").
+		WithArgs("DEAD", "broken photo", 240, 2, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = pool.RunOnce(ctx)
+	assert.NoError(t, err)
+
+	expectClaim(3, "FACES", 3, 0)
+	mock.ExpectExec("UPDATE jobs SET status = \\?").
+		WithArgs("DEAD", "no handler for FACES jobs", sqlmock.AnyArg(), 3, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = pool.RunOnce(ctx)
+	assert.NoError(t, err)
+
+	expectClaim(4, "DERIVATIVES", 4, 0)
+	mock.ExpectExec("UPDATE jobs SET status = 'DONE'").WithArgs(4, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+	done, err = pool.RunOnce(ctx)
+	assert.ErrorIs(t, err, ErrLeaseLost, "a job leased again by another worker must not be completed")
+	assert.True(t, done)
+
+	assert.Equal(t, []uint32{1, 2, 2, 4}, ran)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpiredLease(t *testing.T) {
+	ctx := context.Background()
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	database := &db.DB{DB: mockDB, Querier: query.New(mockDB)}
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var ran []uint32
+	pool := NewPool(database, map[query.JobsKind]Handler{
+		query.JobsKindDERIVATIVES: func(ctx context.Context, job query.Job) error {
+			ran = append(ran, job.JobID)
+			return nil
+		},
+	}, Options{Lease: 90 * time.Second, MaxAttempts: 3, Backoff: time.Minute, MaxBackoff: time.Hour, Logger: zerolog.Nop()})
+
+	// The job whose lease expired on its last attempt is dead, the next one is run.
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM jobs").WithoutArgs().WillReturnRows(sqlmock.NewRows(jobColumns).
+		AddRow(1, "DERIVATIVES", 1, "RUNNING", 3, nil, now, now, now, now))
+	mock.ExpectExec("UPDATE jobs SET status = \\?").
+		WithArgs("DEAD", "lease expired", 0, 1, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM jobs").WithoutArgs().WillReturnRows(sqlmock.NewRows(jobColumns).
+		AddRow(2, "DERIVATIVES", 2, "RUNNING", 1, nil, now, now, now, now))
+	mock.ExpectExec("UPDATE jobs").WithArgs(90, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("UPDATE jobs SET status = 'DONE'").WithArgs(2, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	done, err := pool.RunOnce(ctx)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	assert.Equal(t, []uint32{2}, ran, "a job whose lease expired with attempts left must be run again")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueueClock(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.New(db.Options{Driver: db.DriverSQLite, Path: filepath.Join(t.TempDir(), "photos.db"), MaxOpenConns: 1})
+	assert.NoError(t, err)
+	defer database.Close()
+	eventID, err := database.CreateEvent(ctx, query.CreateEventParams{Name: "Gala", EventDate: time.Now()})
+	assert.NoError(t, err)
+	photoID, err := database.CreatePhoto(ctx, query.CreatePhotoParams{PathToPhoto: "a.jpg", FileHash: "hash", EventID: uint32(eventID)})
+	assert.NoError(t, err)
+	assert.NoError(t, Enqueue(ctx, database.Querier, uint32(photoID), false))
+
+	failures := 0
+	pool := NewPool(database, map[query.JobsKind]Handler{
+		query.JobsKindDERIVATIVES: func(ctx context.Context, job query.Job) error {
+			failures++
+			return errors.New("broken photo")
+		},
+		query.JobsKindMETADATA: func(ctx context.Context, job query.Job) error { return nil },
+	}, Options{Lease: time.Minute, MaxAttempts: 3, Backoff: time.Hour, MaxBackoff: time.Hour, Logger: zerolog.Nop()})
+	for {
+		done, err := pool.RunOnce(ctx)
+		assert.NoError(t, err)
+		if !done {
+			break
+		}
+	}
+	assert.Equal(t, 1, failures, "a failed job must wait for its backoff")
+	counts, err := database.CountJobs(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []query.CountJobsRow{{Status: query.JobsStatusDONE, Jobs: 1}, {Status: query.JobsStatusPENDING, Jobs: 1}}, counts)
+}
+
+func TestEnqueueRecognition(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.New(db.Options{Driver: db.DriverSQLite, Path: filepath.Join(t.TempDir(), "photos.db"), MaxOpenConns: 1})
+	assert.NoError(t, err)
+	defer database.Close()
+
+	assert.NoError(t, EnqueueRecognition(ctx, database.Querier))
+	assert.NoError(t, EnqueueRecognition(ctx, database.Querier))
+	jobs, err := database.GetJobs(ctx, query.GetJobsParams{Status: query.JobsStatusPENDING, Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, jobs, 1, "a recognition waiting to run must not be queued twice") {
+		assert.Equal(t, query.JobsKindRECOGNIZE, jobs[0].Kind)
+		assert.False(t, jobs[0].PhotoID.Valid)
+	}
+}
@@ -0,0 +1,170 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"photos/pkg/derivative"
+	"photos/pkg/face_detection"
+	"photos/pkg/importer"
+	"photos/pkg/storage"
+	"photos/pkg/usage"
+)
+
+// Enqueue adds the jobs processing a new photo to the queue: its derivatives, its metadata and, when the faces are
+// detected, its faces.
+//
+// Parameters:
+//   - ctx: Context of the database queries.
+//   - q: Queries adding the jobs, in the transaction inserting the photo.
+//   - photoID: ID of the new photo.
+//   - faces: Whether the faces of the photo are detected.
+//
+// Returns:
+//   - error: A database error, if any.
+func Enqueue(ctx context.Context, q query.Querier, photoID uint32, faces bool) error {
+	kinds := []query.JobsKind{query.JobsKindDERIVATIVES, query.JobsKindMETADATA}
+	if faces {
+		kinds = append(kinds, query.JobsKindFACES)
+	}
+	for _, kind := range kinds {
+		err := q.CreateJob(ctx, query.CreateJobParams{Kind: kind, PhotoID: sql.NullInt32{Int32: int32(photoID), Valid: true}})
+		if err != nil {
+			return fmt.Errorf("failed to enqueue %s job: %w", kind, err)
+		}
+	}
+	return nil
+}
+
+// EnqueueRecognition adds a job classifying the faces of the unlabeled face groups again, unless one is already waiting
+// to run.
+//
+// Parameters:
+//   - ctx: Context of the database query.
+//   - q: Queries adding the job.
+//
+// Returns:
+//   - error: A database error, if any.
+func EnqueueRecognition(ctx context.Context, q query.Querier) error {
+	if err := q.CreateRecognizeJob(ctx); err != nil {
+		return fmt.Errorf("failed to enqueue %s job: %w", query.JobsKindRECOGNIZE, err)
+	}
+	return nil
+}
+
+// Enqueuer returns the importer.EnqueueFunc adding the jobs of the photos with Enqueue.
+//
+// Parameters:
+//   - faces: Whether the faces of the photos are detected.
+//
+// Returns:
+//   - importer.EnqueueFunc: The function to set in the import options.
+func Enqueuer(faces bool) importer.EnqueueFunc {
+	return func(ctx context.Context, q query.Querier, photoID uint32) error {
+		return Enqueue(ctx, q, photoID, faces)
+	}
+}
+
+// Handlers returns the handlers of the jobs added by Enqueue and EnqueueRecognition.
+//
+// Parameters:
+//   - database: Database holding the photos.
+//   - store: Storage holding the photos and their derivatives.
+//   - detector: Face detector, nil if the faces are not detected.
+//
+// Returns:
+//   - map[query.JobsKind]Handler: The handler of each kind of job.
+func Handlers(database *db.DB, store storage.Storage, detector face_detection.FaceDetector) map[query.JobsKind]Handler {
+	handlers := map[query.JobsKind]Handler{
+		query.JobsKindDERIVATIVES: func(ctx context.Context, job query.Job) error {
+			return generateDerivatives(ctx, database, store, uint32(job.PhotoID.Int32))
+		},
+		query.JobsKindMETADATA: func(ctx context.Context, job query.Job) error {
+			return readMetadata(ctx, database, store, uint32(job.PhotoID.Int32))
+		},
+	}
+	if detector != nil {
+		handlers[query.JobsKindFACES] = func(ctx context.Context, job query.Job) error {
+			_, err := detector.DetectFaces(ctx, database, store, uint32(job.PhotoID.Int32))
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+		handlers[query.JobsKindRECOGNIZE] = func(ctx context.Context, job query.Job) error {
+			_, err := detector.RecognizeUnlabeledFaces(ctx, database)
+			return err
+		}
+	}
+	return handlers
+}
+
+// generateDerivatives stores the thumbnail and the preview of a photo, so that they are ready when it is first shown.
+// Photos deleted since the job was added are skipped.
+func generateDerivatives(ctx context.Context, database *db.DB, store storage.Storage, photoID uint32) error {
+	photo, err := database.GetPhoto(ctx, photoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var orientation uint16
+	metadata, err := database.GetPhotoMetadata(ctx, photoID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil {
+		orientation = metadata.Orientation
+	}
+	for _, kind := range []derivative.Kind{derivative.Thumbnail, derivative.Preview} {
+		_, generated, err := derivative.Ensure(ctx, store, photo.PathToPhoto, photoID, orientation, kind)
+		if err != nil {
+			return fmt.Errorf("failed to generate %s: %w", kind, err)
+		}
+		if generated > 0 {
+			if err := usage.AddDerivative(ctx, database.Querier, photo.EventID, kind, generated); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readMetadata reads the metadata of a stored photo again and replaces the one saved at upload.
+// Photos deleted since the job was added are skipped.
+func readMetadata(ctx context.Context, database *db.DB, store storage.Storage, photoID uint32) error {
+	photo, err := database.GetPhoto(ctx, photoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	file, _, err := store.Get(ctx, photo.PathToPhoto)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	md, err := importer.ReadMetadata(file)
+	if err != nil {
+		return err
+	}
+
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := database.WithTx(tx)
+	if err := qtx.DeletePhotoMetadata(ctx, photoID); err != nil {
+		return err
+	}
+	if err := qtx.CreatePhotoMetadata(ctx, importer.PhotoMetadataParams(photoID, md)); err != nil {
+		return fmt.Errorf("failed to save photo metadata: %w", err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,163 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the format of the time of rotation in the name of the rotated files, which sorts them.
+const backupTimeFormat = "20060102T150405.000"
+
+// FileOptions configures the rotation of a log file.
+type FileOptions struct {
+	Path       string        // Path of the log file, created along with its directory if needed.
+	MaxSize    int64         // Size in bytes the file is rotated at, 0 for no limit.
+	MaxAge     time.Duration // Time after which the file is rotated, counted from its opening, 0 for no limit.
+	MaxBackups int           // Number of rotated files kept, 0 for all of them.
+	Retention  time.Duration // Time the rotated files are kept, 0 to keep them forever.
+}
+
+// File is a log file rotated when it reaches its maximum size or age. The rotated files are renamed after the time of
+// their rotation, such as photos-20260102T030405.000.log, and deleted once they exceed the number of backups or the
+// retention.
+type File struct {
+	opts   FileOptions
+	mux    sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+	now    func() time.Time
+}
+
+// OpenFile opens a log file, appending to it if it exists.
+//
+// Parameters:
+//   - opts: The path of the file and its rotation.
+//
+// Returns:
+//   - *File: The log file.
+//   - error: An error if the file or its directory could not be created.
+func OpenFile(opts FileOptions) (*File, error) {
+	f := &File{opts: opts, now: time.Now}
+	if err := os.MkdirAll(filepath.Dir(opts.Path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write writes a message to the file, rotating it first if the message would exceed its maximum size or if it
+// reached its maximum age.
+//
+// Parameters:
+//   - p: The message.
+//
+// Returns:
+//   - int: The number of bytes written.
+//   - error: An error if the file could not be rotated or written.
+func (f *File) Write(p []byte) (int, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if f.size > 0 && ((f.opts.MaxSize > 0 && f.size+int64(len(p)) > f.opts.MaxSize) ||
+		(f.opts.MaxAge > 0 && f.now().Sub(f.opened) >= f.opts.MaxAge)) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the file.
+//
+// Returns:
+//   - error: An error if the file could not be closed.
+func (f *File) Close() error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return f.file.Close()
+}
+
+// open opens the file at the path of the options in append mode.
+func (f *File) open() error {
+	file, err := os.OpenFile(f.opts.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	f.file, f.size, f.opened = file, info.Size(), f.now()
+	return nil
+}
+
+// rotate renames the file after the time of rotation, opens a new one and deletes the rotated files which are not
+// kept anymore.
+func (f *File) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	now := f.now()
+	if err := os.Rename(f.opts.Path, f.backupPath(now)); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	return f.prune(now)
+}
+
+// backupPath returns the path of the file rotated at a time.
+func (f *File) backupPath(t time.Time) string {
+	ext := filepath.Ext(f.opts.Path)
+	return strings.TrimSuffix(f.opts.Path, ext) + "-" + t.UTC().Format(backupTimeFormat) + ext
+}
+
+// prune deletes the rotated files beyond the number of backups or older than the retention.
+func (f *File) prune(now time.Time) error {
+	ext := filepath.Ext(f.opts.Path)
+	prefix := filepath.Base(strings.TrimSuffix(f.opts.Path, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(f.opts.Path))
+	if err != nil {
+		return fmt.Errorf("failed to list rotated log files: %w", err)
+	}
+	type backup struct {
+		name    string
+		rotated time.Time
+	}
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		rotated, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{name: name, rotated: rotated})
+	}
+	slices.SortFunc(backups, func(a, b backup) int { return b.rotated.Compare(a.rotated) })
+
+	var errs []error
+	for i, b := range backups {
+		if (f.opts.MaxBackups <= 0 || i < f.opts.MaxBackups) && (f.opts.Retention <= 0 || now.Sub(b.rotated) < f.opts.Retention) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(filepath.Dir(f.opts.Path), b.name)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete rotated log file: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFileRotation ensures that the file is rotated at its maximum size and age, and that the rotated files beyond
+// the backups or the retention are deleted.
+func TestFileRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logs", "photos.log")
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	f, err := OpenFile(FileOptions{Path: path, MaxSize: 10, MaxAge: time.Hour, MaxBackups: 2, Retention: 24 * time.Hour})
+	assert.NoError(t, err)
+	defer f.Close()
+	f.now = func() time.Time { return now }
+	f.opened = now
+
+	write := func(message string) {
+		_, err := f.Write([]byte(message))
+		assert.NoError(t, err)
+	}
+	write("12345678")
+	write("90")
+	assert.Equal(t, []string{"photos.log"}, logFiles(t, path), "a file of exactly the maximum size should not rotate")
+
+	write("a")
+	assert.Equal(t, []string{"photos-20260102T030405.000.log", "photos.log"}, logFiles(t, path))
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", string(data))
+
+	now = now.Add(time.Hour)
+	write("b")
+	now = now.Add(time.Hour)
+	write("c")
+	assert.Equal(t, []string{"photos-20260102T040405.000.log", "photos-20260102T050405.000.log", "photos.log"}, logFiles(t, path),
+		"the oldest file should be deleted beyond the backups")
+
+	f.opts.MaxBackups = 0
+	now = now.Add(24 * time.Hour)
+	write("d")
+	assert.Equal(t, []string{"photos-20260103T050405.000.log", "photos.log"}, logFiles(t, path),
+		"the files rotated before the retention should be deleted")
+}
+
+// TestOpenFile ensures that an existing file is appended to and that its size counts towards the rotation.
+func TestOpenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "photos.log")
+	assert.NoError(t, os.WriteFile(path, []byte("previous\n"), 0600))
+	f, err := OpenFile(FileOptions{Path: path, MaxSize: 12})
+	assert.NoError(t, err)
+	_, err = f.Write([]byte("new\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	assert.Len(t, logFiles(t, path), 2)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "new\n", string(data))
+}
+
+// logFiles lists the files of the directory of a log file.
+func logFiles(t *testing.T, path string) []string {
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
@@ -0,0 +1,76 @@
+// Package logging builds the zerolog logger of the programs from the configuration: the format and the outputs of the
+// messages, the rotation of the log file, and the redaction of the secrets the logged URLs may hold.
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog"
+)
+
+// Formats of the messages.
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
+// Outputs of the messages.
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+)
+
+// Options configures the logger.
+type Options struct {
+	Format  string      // Format of the messages, console or json.
+	Outputs []string    // Outputs the messages are written to: stdout, stderr or file.
+	File    FileOptions // Log file of the file output.
+}
+
+// New creates a logger writing to the outputs of the options. The minimum level of the messages is the global level
+// of zerolog, which follows the reloads of the configuration.
+//
+// Parameters:
+//   - opts: The format and the outputs of the messages.
+//
+// Returns:
+//   - zerolog.Logger: The logger, adding the time to the messages.
+//   - error: An error if an output is unknown or the log file could not be opened.
+func New(opts Options) (zerolog.Logger, error) {
+	var writers []io.Writer
+	for _, output := range opts.Outputs {
+		switch output {
+		case OutputStdout:
+			writers = append(writers, formatWriter(os.Stdout, opts.Format, false))
+		case OutputStderr:
+			writers = append(writers, formatWriter(os.Stderr, opts.Format, false))
+		case OutputFile:
+			file, err := OpenFile(opts.File)
+			if err != nil {
+				return zerolog.Nop(), err
+			}
+			writers = append(writers, formatWriter(file, opts.Format, true))
+		default:
+			return zerolog.Nop(), fmt.Errorf("unknown log output %q, expected stdout, stderr or file", output)
+		}
+	}
+	if len(writers) == 0 {
+		return zerolog.Nop(), errors.New("no log output")
+	}
+	return zerolog.New(zerolog.MultiLevelWriter(writers...)).With().Timestamp().Logger(), nil
+}
+
+// formatWriter wraps a writer of JSON messages into a writer of the format, without colors for files.
+func formatWriter(w io.Writer, format string, noColor bool) io.Writer {
+	if format == FormatJSON {
+		return w
+	}
+	return zerolog.NewConsoleWriter(func(cw *zerolog.ConsoleWriter) {
+		cw.Out = w
+		cw.NoColor = noColor
+	})
+}
@@ -0,0 +1,35 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNew ensures that the messages are written to the file output in the format of the options, and that unknown
+// outputs are rejected.
+func TestNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "photos.log")
+	logger, err := New(Options{Format: FormatJSON, Outputs: []string{OutputFile}, File: FileOptions{Path: path}})
+	assert.NoError(t, err)
+	logger.Info().Str("request_id", "abc").Msg("hello")
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"request_id":"abc"`)
+	assert.Contains(t, string(data), `"time":`)
+
+	logger, err = New(Options{Format: FormatConsole, Outputs: []string{OutputFile}, File: FileOptions{Path: path}})
+	assert.NoError(t, err)
+	logger.Info().Msg("console")
+	data, err = os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "INF console")
+	assert.NotContains(t, string(data), "\x1b[", "files should not hold colors")
+
+	_, err = New(Options{Outputs: []string{"syslog"}})
+	assert.ErrorContains(t, err, "unknown log output")
+	_, err = New(Options{})
+	assert.ErrorContains(t, err, "no log output")
+}
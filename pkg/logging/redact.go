@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"net/url"
+	"strings"
+)
+
+// redacted replaces the values of the sensitive query parameters.
+const redacted = "REDACTED"
+
+// sensitiveParams are the parts of the names of the query parameters whose values are redacted: the tickets of CAS,
+// the tokens of sessions and links, the values of cookies and the signatures of presigned storage URLs.
+var sensitiveParams = []string{"ticket", "token", "session", "csrf", "cookie", "password", "secret", "signature", "credential"}
+
+// RedactURL returns a URL with the values of its sensitive query parameters and its password redacted. The URLs given
+// as parameters, such as the service of CAS, are redacted as well.
+//
+// Parameters:
+//   - u: The URL, left unchanged.
+//   - names: Names of other sensitive parameters, such as the names of the cookies of the configuration.
+//
+// Returns:
+//   - string: The redacted URL.
+func RedactURL(u *url.URL, names ...string) string {
+	if u == nil {
+		return ""
+	}
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+	redactedURL := *u
+	query := u.Query()
+	for key, values := range query {
+		for i, value := range values {
+			if isSensitive(key, names) {
+				values[i] = redacted
+			} else if nested, err := url.Parse(value); err == nil && nested.Scheme != "" && nested.RawQuery != "" {
+				values[i] = RedactURL(nested, names...)
+			}
+		}
+	}
+	redactedURL.RawQuery = query.Encode()
+	return redactedURL.Redacted()
+}
+
+// RedactURLString parses and redacts a URL like RedactURL. Values which are not URLs are redacted entirely, since
+// they could not be checked.
+//
+// Parameters:
+//   - raw: The URL.
+//   - names: Names of other sensitive parameters.
+//
+// Returns:
+//   - string: The redacted URL.
+func RedactURLString(raw string, names ...string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redacted
+	}
+	return RedactURL(u, names...)
+}
+
+// isSensitive tells whether the value of a query parameter is redacted.
+func isSensitive(key string, names []string) bool {
+	key = strings.ToLower(key)
+	for _, name := range names {
+		if name != "" && key == strings.ToLower(name) {
+			return true
+		}
+	}
+	for _, part := range sensitiveParams {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"context"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// collectTimeout bounds the queries counting the sessions and the jobs when the metrics are scraped.
+const collectTimeout = 5 * time.Second
+
+// jobStatuses are the statuses of the jobs, reported even when no job has them.
+var jobStatuses = []query.JobsStatus{query.JobsStatusPENDING, query.JobsStatusRUNNING, query.JobsStatusDONE, query.JobsStatusDEAD}
+
+var (
+	activeSessionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "active_sessions"),
+		"Sessions created within the maximum age of the sessions.",
+		nil, nil,
+	)
+	jobsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "jobs"),
+		"Jobs of the queue by status.",
+		[]string{"status"}, nil,
+	)
+)
+
+// RegisterDB registers the metrics of a database: the statistics of its connection pool, and the active sessions and
+// the jobs of the queue, counted when the metrics are scraped.
+//
+// Parameters:
+//   - database: The database of the server.
+//   - sessionMaxAge: Function returning the maximum age of the sessions, after which they are no longer active, read at
+//     every scrape since it follows the reloads of the configuration.
+//
+// Returns:
+//   - error: An error if the metrics of a database are already registered.
+func RegisterDB(database *db.DB, sessionMaxAge func() time.Duration) error {
+	if err := Registry.Register(collectors.NewDBStatsCollector(database.DB, namespace)); err != nil {
+		return err
+	}
+	return Registry.Register(&dbCollector{database: database, sessionMaxAge: sessionMaxAge})
+}
+
+// dbCollector counts the active sessions and the jobs of the queue in the database.
+type dbCollector struct {
+	database      *db.DB
+	sessionMaxAge func() time.Duration
+}
+
+// Describe sends the descriptions of the metrics of the collector.
+func (c *dbCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeSessionsDesc
+	ch <- jobsDesc
+}
+
+// Collect counts the active sessions and the jobs. A failed query is reported as an invalid metric, failing the
+// scrape.
+func (c *dbCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	sessions, err := c.database.CountSessionsSince(ctx, time.Now().Add(-c.sessionMaxAge()))
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(activeSessionsDesc, err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(activeSessionsDesc, prometheus.GaugeValue, float64(sessions))
+	}
+
+	counts, err := c.database.CountJobs(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(jobsDesc, err)
+		return
+	}
+	jobs := make(map[query.JobsStatus]int64, len(jobStatuses))
+	for _, status := range jobStatuses {
+		jobs[status] = 0
+	}
+	for _, count := range counts {
+		jobs[count.Status] = count.Jobs
+	}
+	for status, count := range jobs {
+		ch <- prometheus.MustNewConstMetric(jobsDesc, prometheus.GaugeValue, float64(count), string(status))
+	}
+}
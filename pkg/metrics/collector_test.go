@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDBCollector ensures that the active sessions and the jobs of every status are counted.
+func TestDBCollector(t *testing.T) {
+	database, err := db.New(db.Options{Driver: db.DriverSQLite, Path: filepath.Join(t.TempDir(), "photos.db"), MaxOpenConns: 1})
+	assert.NoError(t, err)
+	defer database.Close()
+	ctx := context.Background()
+
+	userID, err := database.AttemptCreatingUser(ctx, query.AttemptCreatingUserParams{
+		Email:            "jane@example.com",
+		BusinessCategory: query.UsersBusinessCategorySTUDENT,
+	})
+	assert.NoError(t, err)
+	user, err := database.GetUser(ctx, uint32(userID))
+	assert.NoError(t, err)
+	assert.NoError(t, database.CreateSession(ctx, query.CreateSessionParams{UserID: user.UserID, SessionToken: "token"}))
+	eventID, err := database.CreateEvent(ctx, query.CreateEventParams{Name: "Gala", EventDate: time.Now()})
+	assert.NoError(t, err)
+	photoID, err := database.CreatePhoto(ctx, query.CreatePhotoParams{PathToPhoto: "a.jpg", FileHash: "hash", EventID: uint32(eventID)})
+	assert.NoError(t, err)
+	assert.NoError(t, database.CreateJob(ctx, query.CreateJobParams{Kind: query.JobsKindDERIVATIVES, PhotoID: sql.NullInt32{Int32: int32(photoID), Valid: true}}))
+
+	maxAge := time.Hour
+	collector := &dbCollector{database: database, sessionMaxAge: func() time.Duration { return maxAge }}
+	expected := `
+# HELP photos_active_sessions Sessions created within the maximum age of the sessions.
+# TYPE photos_active_sessions gauge
+photos_active_sessions 1
+# HELP photos_jobs Jobs of the queue by status.
+# TYPE photos_jobs gauge
+photos_jobs{status="DEAD"} 0
+photos_jobs{status="DONE"} 0
+photos_jobs{status="PENDING"} 1
+photos_jobs{status="RUNNING"} 0
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+
+	// The sessions older than the maximum age are not active anymore.
+	maxAge = -time.Hour
+	expected = `
+# HELP photos_active_sessions Sessions created within the maximum age of the sessions.
+# TYPE photos_active_sessions gauge
+photos_active_sessions 0
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), "photos_active_sessions"))
+}
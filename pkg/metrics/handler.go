@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// forwardedHeaders are the headers a reverse proxy adds to the requests it forwards.
+var forwardedHeaders = []string{"Forwarded", "X-Forwarded-For", "X-Real-Ip"}
+
+// ParseAllowList parses the addresses allowed to scrape the metrics.
+//
+// Parameters:
+//   - list: IP addresses, such as 127.0.0.1, or CIDR ranges, such as 10.0.0.0/8.
+//
+// Returns:
+//   - []netip.Prefix: The ranges of the addresses, a single address being a range of its own.
+//   - error: An error naming the first value which is neither an address nor a range.
+func ParseAllowList(list []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(list))
+	for _, value := range list {
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR range %q: %w", value, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP address %q: %w", value, err)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// Handler serves the metrics of the registry to the allowed scrapers: the requests sent directly from an allowed
+// address, or bearing the token in their Authorization header. The server listens behind a reverse proxy whose address
+// is the remote address of the requests it forwards, so forwarded requests are only allowed with the token.
+//
+// Parameters:
+//   - allowed: The ranges of the addresses allowed to scrape the metrics, none to require the token.
+//   - token: The bearer token of the scrapers, empty to only allow the addresses.
+//
+// Returns:
+//   - http.Handler: The handler of the metrics, answering 403 to the other requests.
+func Handler(allowed []netip.Prefix, token string) http.Handler {
+	metrics := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAllowedAddr(r, allowed) && !hasToken(r, token) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		metrics.ServeHTTP(w, r)
+	})
+}
+
+// isAllowedAddr tells whether a request was sent directly from an allowed address.
+func isAllowedAddr(r *http.Request, allowed []netip.Prefix) bool {
+	for _, header := range forwardedHeaders {
+		if r.Header.Get(header) != "" {
+			return false
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasToken tells whether a request bears the token, compared in constant time.
+func hasToken(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseAllowList ensures that addresses and ranges are parsed into ranges.
+func TestParseAllowList(t *testing.T) {
+	prefixes, err := ParseAllowList([]string{"127.0.0.1", "::1", "10.1.2.3/8"})
+	assert.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("127.0.0.1/32"),
+		netip.MustParsePrefix("::1/128"),
+		netip.MustParsePrefix("10.0.0.0/8"),
+	}, prefixes)
+
+	_, err = ParseAllowList([]string{"localhost"})
+	assert.ErrorContains(t, err, `invalid IP address "localhost"`)
+	_, err = ParseAllowList([]string{"10.0.0.0/33"})
+	assert.ErrorContains(t, err, `invalid CIDR range "10.0.0.0/33"`)
+}
+
+// TestHandler ensures that the metrics are only served to the allowed addresses connecting directly and to the
+// requests bearing the token.
+func TestHandler(t *testing.T) {
+	allowed, err := ParseAllowList([]string{"127.0.0.1", "10.0.0.0/8"})
+	assert.NoError(t, err)
+	handler := Handler(allowed, "secret")
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		status     int
+	}{
+		{name: "allowed address", remoteAddr: "127.0.0.1:4000", status: http.StatusOK},
+		{name: "allowed range", remoteAddr: "10.2.3.4:4000", status: http.StatusOK},
+		{name: "mapped address", remoteAddr: "[::ffff:127.0.0.1]:4000", status: http.StatusOK},
+		{name: "other address", remoteAddr: "192.0.2.1:4000", status: http.StatusForbidden},
+		{name: "forwarded", remoteAddr: "127.0.0.1:4000", header: http.Header{"X-Forwarded-For": {"192.0.2.1"}}, status: http.StatusForbidden},
+		{name: "token", remoteAddr: "192.0.2.1:4000", header: http.Header{"Authorization": {"Bearer secret"}}, status: http.StatusOK},
+		{name: "forwarded token", remoteAddr: "127.0.0.1:4000", header: http.Header{"X-Forwarded-For": {"192.0.2.1"}, "Authorization": {"Bearer secret"}}, status: http.StatusOK},
+		{name: "wrong token", remoteAddr: "192.0.2.1:4000", header: http.Header{"Authorization": {"Bearer other"}}, status: http.StatusForbidden},
+		{name: "basic credentials", remoteAddr: "192.0.2.1:4000", header: http.Header{"Authorization": {"Basic secret"}}, status: http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			r.RemoteAddr = test.remoteAddr
+			for key, values := range test.header {
+				r.Header[key] = values
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, test.status, w.Code)
+			if test.status == http.StatusOK {
+				assert.Contains(t, w.Body.String(), "go_goroutines")
+			}
+		})
+	}
+
+	// Without a token, only the addresses are allowed.
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	r.RemoteAddr = "192.0.2.1:4000"
+	r.Header.Set("Authorization", "Bearer ")
+	Handler(allowed, "").ServeHTTP(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
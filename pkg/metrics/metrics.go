@@ -0,0 +1,132 @@
+// Package metrics holds the Prometheus metrics of the server: the requests by route, the connection pool of the
+// database, the validations of CAS tickets, the active sessions, the uploads, the job queue and the face detection.
+// They are registered in their own registry, served by Handler to the allowed scrapers only.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// namespace prefixes the names of the metrics of the server.
+const namespace = "photos"
+
+// Registry holds the metrics of the server, along with the metrics of the Go runtime and of the process.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts the requests by method, route pattern and status code.
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Requests by method, route pattern and status code.",
+	}, []string{"method", "route", "code"})
+	// HTTPDuration observes the time the requests took by method and route pattern.
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Time the requests took by method and route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+	// CASValidationDuration observes the time the CAS server took to answer the validations of the tickets.
+	CASValidationDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "cas",
+		Name:      "validation_duration_seconds",
+		Help:      "Time the CAS server took to answer the validations of the tickets.",
+		Buckets:   prometheus.DefBuckets,
+	})
+	// CASValidationFailures counts the tickets which could not be validated, by reason: request, status, response or
+	// rejected.
+	CASValidationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cas",
+		Name:      "validation_failures_total",
+		Help:      "Tickets which could not be validated, by reason.",
+	}, []string{"reason"})
+	// UploadBytes counts the bytes of the photos uploaded by the admins.
+	UploadBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "upload",
+		Name:      "bytes_total",
+		Help:      "Bytes of the uploaded photos.",
+	})
+	// FaceDetectionDuration observes the time the backends took to detect the faces of a photo, by model.
+	FaceDetectionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "face_detection",
+		Name:      "duration_seconds",
+		Help:      "Time the face detection took for a photo, by model.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"model"})
+)
+
+// Reasons of the failures of the validations of CAS tickets.
+const (
+	CASFailureRequest  = "request"  // The CAS server could not be reached.
+	CASFailureStatus   = "status"   // The CAS server answered with an error status.
+	CASFailureResponse = "response" // The response of the CAS server could not be read.
+	CASFailureRejected = "rejected" // The CAS server rejected the ticket.
+)
+
+// unmatchedRoute labels the requests which matched no route, so that unknown paths do not create new series.
+const unmatchedRoute = "unmatched"
+
+// methods are the methods labeled as such, the others are labeled OTHER so that clients cannot create new series.
+var methods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		CASValidationDuration,
+		CASValidationFailures,
+		UploadBytes,
+		FaceDetectionDuration,
+	)
+}
+
+// Middleware counts the requests and observes their duration, labeled with the route pattern of chi rather than
+// their path, which would create a series per photo. It must be used by the root router, before the handlers of the
+// requests write their response.
+//
+// Parameters:
+//   - next: The handler of the requests.
+//
+// Returns:
+//   - http.Handler: The handler recording the requests.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := unmatchedRoute
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		method := r.Method
+		if !methods[method] {
+			method = "OTHER"
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		HTTPRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+		HTTPDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	})
+}
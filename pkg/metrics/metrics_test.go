@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMiddleware ensures that the requests are labeled with their route pattern rather than their path.
+func TestMiddleware(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/photos/{photo_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	r.Post("/upload", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	requests := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/photos/1"},
+		{http.MethodGet, "/photos/2"},
+		{http.MethodPost, "/upload"},
+		{http.MethodGet, "/unknown"},
+		{"PROPFIND", "/upload"},
+	}
+	for _, request := range requests {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(request.method, request.path, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "/photos/{photo_id}", "204")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(HTTPRequests.WithLabelValues("POST", "/upload", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", unmatchedRoute, "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(HTTPRequests.WithLabelValues("OTHER", unmatchedRoute, "405")))
+	assert.Equal(t, 4, testutil.CollectAndCount(HTTPDuration), "the durations should have a series per method and route")
+}
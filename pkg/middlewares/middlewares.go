@@ -5,7 +5,14 @@ import (
 	"fmt"
 	"net/http"
 	"photos/pkg/handlers"
+	"strings"
 	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/httprate"
+	"github.com/gorilla/securecookie"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
 )
 
 func MaxBodySize(size int64) func(http.Handler) http.Handler {
@@ -18,6 +25,87 @@ func MaxBodySize(size int64) func(http.Handler) http.Handler {
 	}
 }
 
+// BodyLimits restricts the content type and the size of request bodies. Multipart bodies are only
+// accepted on the upload path, with a larger size and more time to be read.
+func BodyLimits(uploadPath string, maxBodySize, maxUploadSize int64, uploadTimeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		forms := middleware.AllowContentType("application/json", "application/x-www-form-urlencoded")(MaxBodySize(maxBodySize)(next))
+		uploads := middleware.AllowContentType("multipart/form-data")(MaxBodySize(maxUploadSize)(next))
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != uploadPath {
+				forms.ServeHTTP(w, r)
+				return
+			}
+			_ = http.NewResponseController(w).SetReadDeadline(time.Now().Add(uploadTimeout))
+			uploads.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimit limits the requests of a client to an endpoint to the number returned by limit every window. The limit
+// is read for every request, so that it follows the reloads of the configuration, and 0 disables it.
+func RateLimit(limit func() int, window time.Duration) func(http.Handler) http.Handler {
+	limiter := httprate.Limit(
+		1,
+		window,
+		httprate.WithKeyFuncs(httprate.KeyByIP, httprate.KeyByEndpoint),
+		httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+		}),
+	)
+	return func(next http.Handler) http.Handler {
+		limited := limiter(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestLimit := limit()
+			if requestLimit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			limited.ServeHTTP(w, r.WithContext(httprate.WithRequestLimit(r.Context(), requestLimit)))
+		})
+	}
+}
+
+// ResignCookie re-encodes with the first codec a cookie encoded with one of the other codecs, before the next
+// handler reads it. It lets gorilla/csrf, which verifies its cookie with a single key, accept the cookies signed with
+// the former keys after a key rotation.
+func ResignCookie(name string, codecs []securecookie.Codec) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cookie, err := r.Cookie(name)
+			if err != nil || len(codecs) < 2 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var value []byte
+			if codecs[0].Decode(name, cookie.Value, &value) == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if securecookie.DecodeMulti(name, cookie.Value, &value, codecs[1:]...) != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			encoded, err := codecs[0].Encode(name, value)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cookies := r.Cookies()
+			pairs := make([]string, 0, len(cookies))
+			for _, c := range cookies {
+				if c.Name == name {
+					c.Value = encoded
+				}
+				pairs = append(pairs, c.Name+"="+c.Value)
+			}
+			r = r.Clone(r.Context())
+			r.Header.Set("Cookie", strings.Join(pairs, "; "))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func AuthRestricted(cfg handlers.Config) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -27,7 +115,7 @@ func AuthRestricted(cfg handlers.Config) func(http.Handler) http.Handler {
 				return
 			}
 			var data map[string]string
-			err = cfg.Security.Session.SecureCookie.Decode(cfg.Security.Session.CookieName, cookie.Value, &data)
+			err = securecookie.DecodeMulti(cfg.Security.Session.CookieName, cookie.Value, &data, cfg.Security.Session.Codecs...)
 			if err != nil {
 				redirectToLanding(w, r, cfg)
 				return
@@ -42,10 +130,14 @@ func AuthRestricted(cfg handlers.Config) func(http.Handler) http.Handler {
 				redirectToLanding(w, r, cfg)
 				return
 			}
-			if session.CreationDate.Add(cfg.Security.Session.CookieMaxAge).Before(time.Now()) {
+			if session.CreationDate.Add(cfg.Live.Load().SessionMaxAge).Before(time.Now()) {
 				redirectToLanding(w, r, cfg)
 				return
 			}
+			// The messages of the rest of the request, the access log included, name the user.
+			hlog.FromRequest(r).UpdateContext(func(c zerolog.Context) zerolog.Context {
+				return c.Uint32("user_id", session.UserID)
+			})
 			ctx := context.WithValue(r.Context(), cfg.Security.Session.CookieName, sessionToken)
 			r = r.WithContext(ctx)
 			next.ServeHTTP(w, r)
@@ -60,11 +152,11 @@ func AdminRestricted(cfg handlers.Config) func(http.Handler) http.Handler {
 			sessionToken := r.Context().Value(cfg.Security.Session.CookieName)
 			userInfo, err := cfg.DB.GetUserWithSession(r.Context(), sessionToken.(string))
 			if err != nil {
-				handlers.RespondWithMessage(w, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
+				handlers.RespondWithMessage(w, r, fmt.Sprintf("DB Failure: %v", err), http.StatusInternalServerError)
 				return
 			}
-			if !userInfo.IsAdmin {
-				handlers.RespondWithMessage(w, "Sorry you're not an admin", http.StatusUnauthorized)
+			if !userInfo.IsAdmin && !cfg.Live.Load().IsAdmin(userInfo.Email) {
+				handlers.RespondWithMessage(w, r, "Sorry you're not an admin", http.StatusUnauthorized)
 				return
 			}
 			next.ServeHTTP(w, r)
@@ -0,0 +1,92 @@
+package phash
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"math/bits"
+
+	"golang.org/x/image/draw"
+
+	// Register the decoders of the supported photo formats.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+// DefaultMaxDistance is the distance under which two hashes are considered as the same picture.
+// Resized or re-encoded copies usually stay under 5, unrelated pictures are around 32.
+const DefaultMaxDistance = 6
+
+// Bands is the number of bands a hash is split into to look up close hashes, see BandKeys.
+const Bands = 8
+
+// MaxBandedDistance is the largest distance up to which two hashes always share a band key: the bits differing between
+// them cannot spread over all the bands.
+const MaxBandedDistance = Bands - 1
+
+// DHash computes the difference hash of an image.
+//
+// The image is reduced to a 9x8 grayscale thumbnail, and each bit of the hash tells whether a pixel
+// is brighter than its right neighbour. The hash only depends on the gradients of the picture, so it
+// survives resizing, re-encoding and small color adjustments.
+//
+// Parameters:
+//   - img: The image to hash.
+//
+// Returns:
+//   - uint64: The hash of the image.
+func DHash(img image.Image) uint64 {
+	small := image.NewGray(image.Rect(0, 0, 9, 8))
+	draw.BiLinear.Scale(small, small.Bounds(), img, img.Bounds(), draw.Src, nil)
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if small.GrayAt(x, y).Y > small.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// Read decodes an image and computes its difference hash.
+//
+// Parameters:
+//   - r: The encoded image, in one of the supported formats.
+//
+// Returns:
+//   - uint64: The hash of the image.
+//   - error: An error if the image could not be decoded.
+func Read(r io.Reader) (uint64, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return DHash(img), nil
+}
+
+// Distance returns the number of bits differing between two hashes.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// BandKeys splits a hash into bands of 8 bits, and numbers each band with its position so that equal bytes at different
+// positions do not match. Hashes within MaxBandedDistance of each other share at least one key, which lets the close
+// hashes of a photo be looked up in an index of the keys instead of by comparing the photo with every other.
+//
+// Parameters:
+//   - hash: The hash to split.
+//
+// Returns:
+//   - [Bands]uint16: The keys of the bands, the position of the band times 256 plus its byte.
+func BandKeys(hash uint64) [Bands]uint16 {
+	var keys [Bands]uint16
+	for band := range keys {
+		keys[band] = uint16(band)<<8 | uint16(uint8(hash>>(8*band)))
+	}
+	return keys
+}
@@ -0,0 +1,73 @@
+package phash
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/draw"
+)
+
+// scene draws a picture made of a gradient and a few shapes, so that its hash is not trivial.
+func scene(width, height int, shift int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			fx, fy := (x+shift)*256/width, y*256/height
+			c := color.RGBA{R: uint8(fx), G: uint8(fy), B: uint8((fx * fy) % 256), A: 255}
+			if (fx/64+fy/64)%2 == 0 {
+				c.R, c.G = c.G, 255-c.R
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestDHashResizedCopy(t *testing.T) {
+	original := scene(640, 480, 0)
+	resized := image.NewRGBA(image.Rect(0, 0, 200, 150))
+	draw.CatmullRom.Scale(resized, resized.Bounds(), original, original.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, resized, &jpeg.Options{Quality: 40}))
+	reencoded, err := Read(&buf)
+	assert.NoError(t, err)
+
+	assert.LessOrEqual(t, Distance(DHash(original), reencoded), DefaultMaxDistance)
+}
+
+func TestDHashDifferentPicture(t *testing.T) {
+	assert.Greater(t, Distance(DHash(scene(640, 480, 0)), DHash(scene(640, 480, 320))), DefaultMaxDistance)
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, Distance(0xFF, 0xFF))
+	assert.Equal(t, 64, Distance(0, ^uint64(0)))
+	assert.Equal(t, 2, Distance(0b1010, 0b0110))
+}
+
+func TestBandKeys(t *testing.T) {
+	assert.Equal(t, [Bands]uint16{0x0001, 0x0102, 0x0203, 0x0304, 0x0405, 0x0506, 0x0607, 0x0708}, BandKeys(0x0807060504030201))
+
+	// Flipping one bit in all but one band keeps the key of that band.
+	hash := uint64(0x9AE3C5170F42D86B)
+	for kept := 0; kept < Bands; kept++ {
+		close := hash
+		for band := 0; band < Bands; band++ {
+			if band != kept {
+				close ^= 1 << (8*band + band)
+			}
+		}
+		assert.Equal(t, MaxBandedDistance, Distance(hash, close))
+		assert.Equal(t, BandKeys(hash)[kept], BandKeys(close)[kept])
+	}
+}
+
+func TestReadInvalidImage(t *testing.T) {
+	_, err := Read(bytes.NewReader([]byte("not an image")))
+	assert.Error(t, err)
+}
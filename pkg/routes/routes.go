@@ -3,13 +3,15 @@ package routes
 import (
 	"net/http"
 	"photos/pkg/handlers"
+	"photos/pkg/logging"
+	"photos/pkg/metrics"
 	"photos/pkg/middlewares"
+	"photos/pkg/tracing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
-	"github.com/go-chi/httprate"
 	"github.com/gorilla/csrf"
 	"github.com/rs/zerolog/hlog"
 )
@@ -21,16 +23,14 @@ func Service(cfg handlers.Config) http.Handler {
 	r.NotFound(cfg.ServeNotFoundHandler)
 	r.Get(cfg.Routes.Favicon, handlers.ServeFaviconHandler)
 	r.Get(cfg.Routes.Landing, cfg.ServeLandingHandler)
+	if cfg.Metrics.Enabled {
+		// The allowed addresses were validated with the configuration.
+		allowed, _ := metrics.ParseAllowList(cfg.Metrics.AllowedIPs)
+		r.Method(http.MethodGet, cfg.Metrics.Path, metrics.Handler(allowed, cfg.Metrics.BearerToken))
+	}
 
 	r.Group(func(r chi.Router) {
-		r.Use(httprate.Limit(
-			10,
-			time.Minute,
-			httprate.WithKeyFuncs(httprate.KeyByIP, httprate.KeyByEndpoint),
-			httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
-				http.Error(w, "Too many requests", http.StatusTooManyRequests)
-			}),
-		))
+		r.Use(middlewares.RateLimit(func() int { return cfg.Live.Load().RateLimits.Login }, time.Minute))
 		r.Get(cfg.Routes.Login, cfg.LoginHandler)
 		r.Get(cfg.Routes.CasCallback, cfg.CasCallbackHandler)
 	})
@@ -38,37 +38,91 @@ func Service(cfg handlers.Config) http.Handler {
 		r.Use(middlewares.AuthRestricted(cfg))
 		r.Get(cfg.Routes.Dashboard, cfg.ServeDashboardHandler)
 		r.Get(cfg.Routes.Logout, cfg.LogoutHandler)
+		r.Get(cfg.Routes.Photos, cfg.ServeEventPhotosHandler)
+		r.Get(cfg.Routes.PhotoDetails, cfg.ServePhotoDetailsHandler)
+		r.Get(cfg.Routes.PhotoThumbnail, cfg.PhotoThumbnailHandler)
+		r.Get(cfg.Routes.PhotoPreview, cfg.PhotoPreviewHandler)
+		r.Get(cfg.Routes.PhotoDownload, cfg.PhotoDownloadHandler)
+		r.Post(cfg.Routes.PhotosArchive, cfg.PhotosArchiveHandler)
+		r.Get(cfg.Routes.EventArchive, cfg.EventArchiveHandler)
+		r.Get(cfg.Routes.MyPhotos, cfg.ServeMyPhotosHandler)
+		r.Get(cfg.Routes.FaceConsent, cfg.ServeFaceConsentHandler)
+		r.Post(cfg.Routes.FaceConsent, cfg.FaceConsentHandler)
+		r.Post(cfg.Routes.FaceName, cfg.FaceNameHandler)
+		r.Post(cfg.Routes.FaceGroupClaim, cfg.FaceGroupClaimHandler)
+		r.Get(cfg.Routes.MyData, cfg.MyDataHandler)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.AuthRestricted(cfg))
+		r.Use(middlewares.RateLimit(func() int { return cfg.Live.Load().RateLimits.Unlock }, time.Minute))
+		r.Post(cfg.Routes.EventUnlock, cfg.EventUnlockHandler)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.AuthRestricted(cfg))
+		r.Use(middlewares.AdminRestricted(cfg))
+		r.Get(cfg.Routes.AdminImport, cfg.AdminImportStatusHandler)
+		r.Post(cfg.Routes.AdminImport, cfg.AdminImportHandler)
+		r.Post(cfg.Routes.AdminMetadataPolicy, cfg.AdminEventMetadataPolicyHandler)
+		r.Post(cfg.Routes.AdminEventPassword, cfg.AdminEventPasswordHandler)
+		r.Post(cfg.Routes.AdminEventVisibility, cfg.AdminEventVisibilityHandler)
+		r.Post(cfg.Routes.AdminPhotoVisibility, cfg.AdminPhotoVisibilityHandler)
+		r.Post(cfg.Routes.AdminUpload, cfg.AdminUploadHandler)
+		r.Get(cfg.Routes.AdminDuplicates, cfg.ServeAdminDuplicatesHandler)
+		r.Post(cfg.Routes.AdminDuplicate, cfg.AdminDuplicateHandler)
+		r.Post(cfg.Routes.AdminPhotoDelete, cfg.AdminPhotoDeleteHandler)
+		r.Get(cfg.Routes.AdminUsage, cfg.ServeAdminUsageHandler)
+		r.Post(cfg.Routes.AdminFaceGroupUser, cfg.AdminFaceGroupUserHandler)
+		r.Post(cfg.Routes.AdminFaceGroupClaim, cfg.AdminFaceGroupClaimHandler)
+		r.Get(cfg.Routes.AdminFaceGroups, cfg.ServeAdminFaceGroupsHandler)
+		r.Get(cfg.Routes.AdminFaceGroup, cfg.ServeAdminFaceGroupHandler)
+		r.Post(cfg.Routes.AdminFaceGroupMerge, cfg.AdminFaceGroupMergeHandler)
+		r.Post(cfg.Routes.AdminImageFacesMove, cfg.AdminImageFacesMoveHandler)
+		r.Post(cfg.Routes.AdminFaceGroupSplit, cfg.AdminFaceGroupSplitHandler)
+		r.Post(cfg.Routes.AdminFacesRecognize, cfg.AdminFacesRecognizeHandler)
+		r.Get(cfg.Routes.AdminFaceThumbnail, cfg.AdminImageFaceThumbnailHandler)
+		r.Get(cfg.Routes.AdminJobs, cfg.ServeAdminJobsHandler)
+		r.Post(cfg.Routes.AdminJobRetry, cfg.AdminJobRetryHandler)
+		r.Post(cfg.Routes.AdminUserErase, cfg.AdminUserEraseHandler)
 	})
 	return r
 }
 
 func loadGlobalMiddlewares(r *chi.Mux, cfg handlers.Config) {
-	r.Use(hlog.RemoteAddrHandler("ip"), hlog.UserAgentHandler("ua"), hlog.RefererHandler("referer"), hlog.RequestIDHandler("req-id", "X-Request-Id"))
+	// The handlers adding fields update the logger of the request, which must be set first, tracing included. The
+	// cookie and form field names of the configuration are redacted from the logged URLs along with the tickets and
+	// tokens.
+	sensitive := []string{cfg.Security.Session.CookieName, cfg.Security.Csrf.CookieName, cfg.Security.Csrf.FieldName}
 	r.Use(hlog.NewHandler(cfg.Logger))
+	r.Use(hlog.RemoteAddrHandler("ip"), hlog.UserAgentHandler("ua"), hlog.RequestIDHandler("request_id", "X-Request-Id"))
+	r.Use(tracing.Middleware)
+	r.Use(metrics.Middleware)
 	r.Use(hlog.AccessHandler(func(r *http.Request, status, size int, duration time.Duration) {
 		hlog.FromRequest(r).Info().
 			Str("method", r.Method).
-			Stringer("url", r.URL).
+			Str("url", logging.RedactURL(r.URL, sensitive...)).
+			Str("referer", logging.RedactURLString(r.Referer(), sensitive...)).
 			Int("status", status).
 			Int("size", size).
 			Dur("duration", duration).
 			Msg("")
 	}))
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
+		AllowOriginFunc: func(r *http.Request, origin string) bool {
+			return cfg.Live.Load().AllowOrigin(origin)
+		},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 	r.Use(middleware.AllowContentEncoding("gzip", "deflate", "gzip/deflate", "deflate/gzip"))
-	r.Use(middleware.AllowContentType("application/json", "application/x-www-form-urlencoded"))
 	r.Use(middleware.CleanPath, middleware.RedirectSlashes)
 	r.Use(middleware.Compress(4, "application/json", "application/x-www-form-urlencoded"))
 	r.Use(middleware.Timeout(cfg.Server.RequestContextTimeout))
-	r.Use(middlewares.MaxBodySize(cfg.Server.MaxBodySize))
+	r.Use(middlewares.BodyLimits(cfg.Routes.AdminUpload, cfg.Server.MaxBodySize, cfg.Server.MaxUploadSize, cfg.Server.UploadTimeout))
+	r.Use(middlewares.ResignCookie(cfg.Security.Csrf.CookieName, cfg.Security.Csrf.Codecs))
 	r.Use(csrf.Protect(
-		cfg.Security.Csrf.Secret,
+		cfg.Security.Csrf.Keys[0].Secret,
 		csrf.MaxAge(int(cfg.Security.Csrf.CookieMaxAge.Seconds())),
 		csrf.HttpOnly(cfg.Security.Csrf.CookieHTTPOnly),
 		csrf.Secure(cfg.Security.Csrf.CookieSecure),
@@ -77,12 +131,5 @@ func loadGlobalMiddlewares(r *chi.Mux, cfg handlers.Config) {
 		csrf.FieldName(cfg.Security.Csrf.FieldName),
 		csrf.CookieName(cfg.Security.Csrf.CookieName),
 	))
-	r.Use(httprate.Limit(
-		60,
-		time.Minute,
-		httprate.WithKeyFuncs(httprate.KeyByIP, httprate.KeyByEndpoint),
-		httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
-			http.Error(w, "Too many requests", http.StatusTooManyRequests)
-		}),
-	))
+	r.Use(middlewares.RateLimit(func() int { return cfg.Live.Load().RateLimits.Global }, time.Minute))
 }
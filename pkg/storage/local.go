@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tempPrefix starts the name of the files being written by the local driver.
+const tempPrefix = ".upload-"
+
+// Local stores objects as files under a root directory.
+type Local struct {
+	root string
+}
+
+// NewLocal creates a driver storing objects under a root directory, which is created on the first write.
+func NewLocal(root string) *Local {
+	return &Local{root: root}
+}
+
+// FullPath returns the location on disk of an object. The key is cleaned as an absolute path,
+// so it cannot escape the storage root.
+func (l *Local) FullPath(key string) string {
+	return filepath.Join(l.root, filepath.FromSlash(cleanKey(key)))
+}
+
+// Put writes the object to a temporary file which is then renamed, so readers never see a partial object.
+func (l *Local) Put(_ context.Context, key string, r io.Reader, size int64) error {
+	dest := l.FullPath(key)
+	if err := os.MkdirAll(filepath.Dir(dest), 0750); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), tempPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	n, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size >= 0 && n != size {
+		err = fmt.Errorf("wrote %d bytes instead of %d", n, size)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dest)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// Get opens the file of an object.
+func (l *Local) Get(_ context.Context, key string) (io.ReadSeekCloser, Info, error) {
+	file, err := os.Open(l.FullPath(key))
+	if err != nil {
+		return nil, Info{}, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, Info{}, err
+	}
+	return file, Info{Key: cleanKey(key), Size: stat.Size(), ModTime: stat.ModTime()}, nil
+}
+
+// Stat describes the file of an object.
+func (l *Local) Stat(_ context.Context, key string) (Info, error) {
+	stat, err := os.Stat(l.FullPath(key))
+	if err != nil {
+		return Info{}, err
+	}
+	if stat.IsDir() {
+		return Info{}, fmt.Errorf("%s is a directory: %w", key, ErrNotExist)
+	}
+	return Info{Key: cleanKey(key), Size: stat.Size(), ModTime: stat.ModTime()}, nil
+}
+
+// Delete removes the file of an object.
+func (l *Local) Delete(_ context.Context, key string) error {
+	err := os.Remove(l.FullPath(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// List walks the directory holding the prefix, skipping the files being written.
+func (l *Local) List(ctx context.Context, prefix string, fn func(Info) error) error {
+	dir := ""
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = cleanKey(prefix[:i])
+	}
+	if _, err := os.Stat(l.FullPath(dir)); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return filepath.WalkDir(l.FullPath(dir), func(fullPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), tempPrefix) {
+			return nil
+		}
+		rel, err := filepath.Rel(l.root, fullPath)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		stat, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return fn(Info{Key: key, Size: stat.Size(), ModTime: stat.ModTime()})
+	})
+}
+
+// SignedURL is not supported, files on disk are served by the application.
+func (l *Local) SignedURL(context.Context, string, time.Duration) (string, error) {
+	return "", ErrNotSupported
+}
+
+// Ensure Local implements Storage.
+var _ Storage = (*Local)(nil)
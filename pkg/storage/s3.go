@@ -0,0 +1,293 @@
+package storage
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Options configures a driver for an S3 compatible object storage, such as AWS S3, MinIO or Garage.
+type S3Options struct {
+	Endpoint        string       // URL of the service, such as https://s3.eu-west-3.amazonaws.com.
+	Region          string       // Region of the bucket, us-east-1 if empty.
+	Bucket          string       // Bucket holding the objects.
+	AccessKeyID     string       // Access key of the credentials.
+	SecretAccessKey string       // Secret key of the credentials.
+	PathStyle       bool         // Address the bucket in the path instead of the host name, as most self-hosted services expect.
+	HTTPClient      *http.Client // Client sending the requests, http.DefaultClient if nil.
+}
+
+// S3 stores objects in a bucket of an S3 compatible object storage.
+type S3 struct {
+	endpoint  *url.URL
+	bucket    string
+	pathStyle bool
+	signer    signer
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewS3 creates a driver for an S3 compatible object storage.
+//
+// Parameters:
+//   - opts: Location of the bucket and credentials.
+//
+// Returns:
+//   - *S3: The driver.
+//   - error: An error if the options are incomplete.
+func NewS3(opts S3Options) (*S3, error) {
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", opts.Endpoint)
+	}
+	if opts.Bucket == "" {
+		return nil, errors.New("the S3 bucket must be specified")
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	return &S3{
+		endpoint:  endpoint,
+		bucket:    opts.Bucket,
+		pathStyle: opts.PathStyle,
+		signer:    signer{accessKeyID: opts.AccessKeyID, secretAccessKey: opts.SecretAccessKey, region: opts.Region},
+		client:    opts.HTTPClient,
+		now:       time.Now,
+	}, nil
+}
+
+// Put uploads an object in a single request.
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, nil, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Get returns a reader fetching the object with range requests, so that it can be seeked without being downloaded.
+func (s *S3) Get(ctx context.Context, key string) (io.ReadSeekCloser, Info, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	return &s3Reader{ctx: ctx, s3: s, key: info.Key, size: info.Size}, info, nil
+}
+
+// Stat sends a HEAD request for an object.
+func (s *S3) Stat(ctx context.Context, key string) (Info, error) {
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil, nil)
+	if err != nil {
+		return Info{}, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return Info{}, err
+	}
+	_ = resp.Body.Close()
+	info := Info{Key: cleanKey(key), Size: resp.ContentLength}
+	info.ModTime, _ = http.ParseTime(resp.Header.Get("Last-Modified"))
+	return info, nil
+}
+
+// Delete removes an object.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if errors.Is(err, ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// listBucketResult is the response of a ListObjectsV2 request.
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List pages through the objects of the bucket with ListObjectsV2 requests.
+func (s *S3) List(ctx context.Context, prefix string, fn func(Info) error) error {
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		req, err := s.newRequest(ctx, http.MethodGet, "", q, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := s.do(req)
+		if err != nil {
+			return err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		_ = resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("invalid S3 listing: %w", err)
+		}
+		for _, object := range result.Contents {
+			if err := fn(Info{Key: object.Key, Size: object.Size, ModTime: object.LastModified}); err != nil {
+				return err
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// SignedURL returns a presigned GET URL of an object.
+func (s *S3) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	s.signer.presign(req, expiry, s.now())
+	return req.URL.String(), nil
+}
+
+// newRequest creates a request for an object of the bucket, or for the bucket itself if the key is empty.
+func (s *S3) newRequest(ctx context.Context, method, key string, q url.Values, body io.Reader) (*http.Request, error) {
+	u := *s.endpoint
+	objectPath := strings.TrimSuffix(u.Path, "/") + "/"
+	if s.pathStyle {
+		objectPath += s.bucket + "/"
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	objectPath += cleanKey(key)
+	u.Path = objectPath
+	u.RawPath = uriEncode(objectPath, false)
+	u.RawQuery = canonicalQuery(q)
+	return http.NewRequestWithContext(ctx, method, u.String(), body)
+}
+
+// s3Error is the body of an S3 error response.
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// do signs and sends a request. Missing objects are reported as ErrNotExist, other failures with the S3 error code.
+func (s *S3) do(req *http.Request) (*http.Response, error) {
+	s.signer.sign(req, unsignedPayload, s.now())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", req.URL.Path, ErrNotExist)
+	}
+	var body s3Error
+	_ = xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body)
+	if body.Code == "" {
+		body.Code = resp.Status
+	}
+	return nil, fmt.Errorf("S3 %s %s failed: %s %s", req.Method, req.URL.Path, body.Code, body.Message)
+}
+
+// s3Reader reads an object of a bucket. A GET request is sent on the first read after every seek.
+type s3Reader struct {
+	ctx    context.Context
+	s3     *S3
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *s3Reader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		req, err := r.s3.newRequest(r.ctx, http.MethodGet, r.key, nil, nil)
+		if err != nil {
+			return 0, err
+		}
+		if r.offset > 0 {
+			req.Header.Set("Range", "bytes="+strconv.FormatInt(r.offset, 10)+"-")
+		}
+		resp, err := r.s3.do(req)
+		if err != nil {
+			return 0, err
+		}
+		r.body = resp.Body
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	if errors.Is(err, io.EOF) && r.offset < r.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (r *s3Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	if offset != r.offset && r.body != nil {
+		_ = r.body.Close()
+		r.body = nil
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+func (r *s3Reader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
+
+// Ensure S3 implements Storage.
+var _ Storage = (*S3)(nil)
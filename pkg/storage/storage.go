@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotExist is returned when an object is not stored.
+	ErrNotExist = fs.ErrNotExist
+	// ErrNotSupported is returned by drivers which cannot sign URLs, in which case objects are served by the application.
+	ErrNotSupported = errors.New("operation not supported by the storage driver")
+)
+
+// Info describes a stored object.
+type Info struct {
+	Key     string    // Key of the object, with forward slashes.
+	Size    int64     // Size of the object in bytes.
+	ModTime time.Time // Last time the object was written.
+}
+
+// Storage is where photos and their derivatives are stored, keyed by paths with forward slashes.
+type Storage interface {
+	// Put stores an object, replacing any object with the same key. Size is the length of r.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get opens an object for reading. The reader has to be closed.
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, Info, error)
+	// Stat describes an object.
+	Stat(ctx context.Context, key string) (Info, error)
+	// Delete removes an object, a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// List calls fn for every object whose key starts with prefix.
+	List(ctx context.Context, prefix string, fn func(Info) error) error
+	// SignedURL returns a URL giving read access to an object until it expires.
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// Object is a file stored by content, named after the SHA-256 of its content.
+type Object struct {
+	Hash    string // Hex encoded SHA-256 of the content.
+	Path    string // Key of the object in the storage.
+	Existed bool   // True if the content was already stored before the call.
+}
+
+// ObjectPath returns the key of a content.
+//
+// Objects are spread over two levels of directories named after the first bytes of the hash,
+// so no directory ends up holding every photo. The same content is always stored once, whatever
+// the number of events it belongs to.
+//
+// Parameters:
+//   - hash: Hex encoded SHA-256 of the content.
+//   - ext: Extension of the file, such as ".jpg".
+//
+// Returns:
+//   - string: The key of the object, with forward slashes.
+func ObjectPath(hash, ext string) string {
+	return path.Join(hash[0:2], hash[2:4], hash+strings.ToLower(ext))
+}
+
+// PutFile stores a copy of a file whose hash is already known, nothing is copied if it is already stored.
+//
+// Parameters:
+//   - ctx: Context of the storage operations.
+//   - s: The storage.
+//   - src: Path of the file to store.
+//   - hash: Hex encoded SHA-256 of the file.
+//
+// Returns:
+//   - Object: The stored object.
+//   - error: An error if the file could not be copied, or if its content does not match the hash.
+func PutFile(ctx context.Context, s Storage, src, hash string) (Object, error) {
+	object := Object{Hash: hash, Path: ObjectPath(hash, filepath.Ext(src))}
+	_, err := s.Stat(ctx, object.Path)
+	if err == nil {
+		object.Existed = true
+		return object, nil
+	}
+	if !errors.Is(err, ErrNotExist) {
+		return Object{}, fmt.Errorf("failed to stat object: %w", err)
+	}
+	in, err := os.Open(filepath.Clean(src))
+	if err != nil {
+		return Object{}, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return Object{}, err
+	}
+	h := sha256.New()
+	if err := s.Put(ctx, object.Path, io.TeeReader(in, h), info.Size()); err != nil {
+		return Object{}, fmt.Errorf("failed to store %s: %w", src, err)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != hash {
+		_ = s.Delete(ctx, object.Path)
+		return Object{}, fmt.Errorf("%s changed while being stored", src)
+	}
+	return object, nil
+}
+
+// CreateSpool creates the temporary file an incoming file is written to before being stored. The local driver spools
+// under its root, with the prefix of its temporary files so they are not listed as objects, which keeps large uploads
+// on the disk of the photos rather than in the system temporary directory. Other drivers spool to the system temporary
+// directory.
+//
+// Parameters:
+//   - s: The storage the file is going to be stored in.
+//   - ext: Extension of the file, kept by the temporary file.
+//
+// Returns:
+//   - *os.File: The temporary file, to close and remove once stored.
+//   - error: An error if the file could not be created.
+func CreateSpool(s Storage, ext string) (*os.File, error) {
+	if local, ok := s.(*Local); ok {
+		if err := os.MkdirAll(local.root, 0750); err != nil {
+			return nil, fmt.Errorf("failed to create storage directory: %w", err)
+		}
+		return os.CreateTemp(local.root, tempPrefix+"*"+strings.ToLower(ext))
+	}
+	return os.CreateTemp("", "photo-upload-*"+strings.ToLower(ext))
+}
+
+// cleanKey turns a key into a relative path with forward slashes which cannot escape the storage.
+func cleanKey(key string) string {
+	return strings.TrimPrefix(path.Clean("/"+key), "/")
+}
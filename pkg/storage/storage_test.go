@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Hash of "photo".
+const photoHash = "55c64d0fcd6f9d5f7c828093857e3fdfda68478bb4e9bd24d481ef391c7804e8"
+
+func TestObjectPath(t *testing.T) {
+	assert.Equal(t, "55/c6/"+photoHash+".jpg", ObjectPath(photoHash, ".JPG"))
+}
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	local := NewLocal(root)
+
+	assert.NoError(t, local.Put(ctx, "ab/cd/photo.jpg", strings.NewReader("photo"), 5))
+	assert.NoError(t, local.Put(ctx, "derivatives/1/thumbnail.jpg", strings.NewReader("thumb"), 5))
+	assert.Error(t, local.Put(ctx, "ab/cd/short.jpg", strings.NewReader("photo"), 10), "a truncated content must be rejected")
+
+	info, err := local.Stat(ctx, "ab/cd/photo.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), info.Size)
+
+	file, info, err := local.Get(ctx, "ab/cd/photo.jpg")
+	assert.NoError(t, err)
+	data, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+	assert.Equal(t, "photo", string(data))
+	assert.Equal(t, "ab/cd/photo.jpg", info.Key)
+
+	var keys []string
+	assert.NoError(t, local.List(ctx, "", func(info Info) error {
+		keys = append(keys, info.Key)
+		return nil
+	}))
+	assert.ElementsMatch(t, []string{"ab/cd/photo.jpg", "derivatives/1/thumbnail.jpg"}, keys, "temporary files must not be listed")
+	keys = nil
+	assert.NoError(t, local.List(ctx, "derivatives/", func(info Info) error {
+		keys = append(keys, info.Key)
+		return nil
+	}))
+	assert.Equal(t, []string{"derivatives/1/thumbnail.jpg"}, keys)
+	assert.NoError(t, local.List(ctx, "missing/", func(Info) error { return nil }))
+
+	_, err = local.SignedURL(ctx, "ab/cd/photo.jpg", 0)
+	assert.ErrorIs(t, err, ErrNotSupported)
+
+	assert.NoError(t, local.Delete(ctx, "ab/cd/photo.jpg"))
+	assert.NoError(t, local.Delete(ctx, "ab/cd/photo.jpg"), "deleting a missing object is not an error")
+	_, err = local.Stat(ctx, "ab/cd/photo.jpg")
+	assert.ErrorIs(t, err, ErrNotExist)
+}
+
+func TestPutFile(t *testing.T) {
+	ctx := context.Background()
+	local := NewLocal(t.TempDir())
+	src := filepath.Join(t.TempDir(), "IMG_0001.jpg")
+	assert.NoError(t, os.WriteFile(src, []byte("photo"), 0600))
+
+	object, err := PutFile(ctx, local, src, photoHash)
+	assert.NoError(t, err)
+	assert.False(t, object.Existed)
+	assert.FileExists(t, local.FullPath(object.Path))
+
+	object, err = PutFile(ctx, local, src, photoHash)
+	assert.NoError(t, err)
+	assert.True(t, object.Existed)
+
+	other := strings.Repeat("0", 64)
+	_, err = PutFile(ctx, local, src, other)
+	assert.Error(t, err, "a content not matching its hash must be rejected")
+	assert.NoFileExists(t, local.FullPath(ObjectPath(other, ".jpg")))
+}
+
+func TestCreateSpool(t *testing.T) {
+	ctx := context.Background()
+	root := filepath.Join(t.TempDir(), "photos")
+	local := NewLocal(root)
+
+	spool, err := CreateSpool(local, ".JPG")
+	assert.NoError(t, err)
+	assert.NoError(t, spool.Close())
+	assert.Equal(t, root, filepath.Dir(spool.Name()), "uploads are spooled under the root")
+	assert.Equal(t, ".jpg", filepath.Ext(spool.Name()))
+	assert.NoError(t, local.List(ctx, "", func(info Info) error {
+		t.Errorf("spooled file %s listed as an object", info.Key)
+		return nil
+	}))
+
+	object, err := PutFile(ctx, local, spool.Name(), "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855")
+	assert.NoError(t, err)
+	assert.Equal(t, ".jpg", filepath.Ext(object.Path))
+}
+
+func TestFullPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("root", "etc", "passwd"), NewLocal("root").FullPath("../../etc/passwd"))
+}
@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"photos/pkg/db/query"
+	"regexp"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryName matches the name sqlc gives a query in the comment starting its statement.
+var queryName = regexp.MustCompile(`^-- name: (\w+)`)
+
+// DBTX wraps a database or a transaction to trace the queries run on it.
+//
+// Parameters:
+//   - db: The database or the transaction.
+//   - system: The database system, such as mysql, sqlite or postgresql.
+//
+// Returns:
+//   - query.DBTX: The traced database, to give to query.New.
+func DBTX(db query.DBTX, system string) query.DBTX {
+	return &tracedDB{DBTX: db, system: system}
+}
+
+// tracedDB traces the queries of the query package run on a database or a transaction.
+type tracedDB struct {
+	query.DBTX        // Database or transaction running the queries.
+	system     string // Database system of the spans.
+}
+
+// ExecContext runs a statement in a span named after its query.
+func (d *tracedDB) ExecContext(ctx context.Context, statement string, args ...interface{}) (sql.Result, error) {
+	ctx, span := d.start(ctx, statement)
+	defer span.End()
+	result, err := d.DBTX.ExecContext(ctx, statement, args...)
+	recordError(span, err)
+	return result, err
+}
+
+// PrepareContext prepares a statement in a span named after its query.
+func (d *tracedDB) PrepareContext(ctx context.Context, statement string) (*sql.Stmt, error) {
+	ctx, span := d.start(ctx, statement)
+	defer span.End()
+	stmt, err := d.DBTX.PrepareContext(ctx, statement)
+	recordError(span, err)
+	return stmt, err
+}
+
+// QueryContext runs a query in a span named after it. The span ends once the query returns its rows, before they are
+// read.
+func (d *tracedDB) QueryContext(ctx context.Context, statement string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := d.start(ctx, statement)
+	defer span.End()
+	rows, err := d.DBTX.QueryContext(ctx, statement, args...)
+	recordError(span, err)
+	return rows, err
+}
+
+// QueryRowContext runs a query returning a single row in a span named after it. A missing row is not an error of the
+// span.
+func (d *tracedDB) QueryRowContext(ctx context.Context, statement string, args ...interface{}) *sql.Row {
+	ctx, span := d.start(ctx, statement)
+	defer span.End()
+	row := d.DBTX.QueryRowContext(ctx, statement, args...)
+	if err := row.Err(); !errors.Is(err, sql.ErrNoRows) {
+		recordError(span, err)
+	}
+	return row
+}
+
+// start starts the client span of a statement, named after its query, or after the database system for statements of
+// other packages, whose text is not recorded.
+func (d *tracedDB) start(ctx context.Context, statement string) (context.Context, trace.Span) {
+	name := d.system
+	attributes := []attribute.KeyValue{attribute.String("db.system", d.system)}
+	if match := queryName.FindStringSubmatch(statement); match != nil {
+		name = match[1]
+		attributes = append(attributes, attribute.String("db.operation.name", match[1]))
+	}
+	return Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+}
+
+// recordError marks a span as failed if its operation failed.
+func recordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
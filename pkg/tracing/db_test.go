@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	_ "modernc.org/sqlite"
+)
+
+// TestDBTX ensures that the queries are traced under their name, in the trace of the context.
+func TestDBTX(t *testing.T) {
+	recorder := recordSpans(t)
+	sqlDB, err := sql.Open("sqlite", ":memory:")
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+	sqlDB.SetMaxOpenConns(1)
+	db := DBTX(sqlDB, "sqlite")
+
+	ctx, parent := Start(context.Background(), "GET /photos")
+	_, err = db.ExecContext(ctx, "-- name: CreateTable :exec\nCREATE TABLE photos (photo_id INTEGER PRIMARY KEY)")
+	assert.NoError(t, err)
+	var count int
+	assert.NoError(t, db.QueryRowContext(ctx, "-- name: CountPhotos :one\nSELECT COUNT(*) FROM photos").Scan(&count))
+	rows, err := db.QueryContext(ctx, "-- name: GetPhotos :many\nSELECT photo_id FROM missing")
+	assert.Error(t, err)
+	if rows != nil {
+		_ = rows.Close()
+	}
+	_, err = db.ExecContext(ctx, "DELETE FROM photos")
+	assert.NoError(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 5) {
+		return
+	}
+	var names []string
+	for _, span := range spans[:4] {
+		names = append(names, span.Name())
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+		assert.Equal(t, "sqlite", attributes(span)["db.system"].AsString())
+	}
+	assert.Equal(t, []string{"CreateTable", "CountPhotos", "GetPhotos", "sqlite"}, names)
+	assert.Equal(t, "CountPhotos", attributes(spans[1])["db.operation.name"].AsString())
+	assert.Equal(t, codes.Error, spans[2].Status().Code)
+	assert.Equal(t, codes.Unset, spans[3].Status().Code)
+}
@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"net/http"
+	"photos/pkg/logging"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware traces the requests in a span named after their method and chi route pattern, such as
+// GET /photos/{photo_id}, continuing the trace of the client if its request carries one. The span holds the request
+// ID of the logger, whose messages hold the trace ID in turn. It must be used by the root router, after the handlers
+// of hlog setting the logger and the request ID.
+//
+// Parameters:
+//   - next: The handler of the requests.
+//
+// Returns:
+//   - http.Handler: The handler tracing the requests.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+		))
+		defer span.End()
+		if id, ok := hlog.IDFromRequest(r); ok {
+			span.SetAttributes(attribute.String("request_id", id.String()))
+		}
+		if sc := span.SpanContext(); sc.IsValid() {
+			hlog.FromRequest(r).UpdateContext(func(c zerolog.Context) zerolog.Context {
+				return c.Str("trace_id", sc.TraceID().String())
+			})
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(attribute.String("http.route", rctx.RoutePattern()))
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// Transport traces the requests of an HTTP client in client spans, and propagates their trace to the server. The
+// sensitive query parameters of the URLs, such as the tickets of CAS, are redacted from the spans.
+type Transport struct {
+	Base http.RoundTripper // Transport sending the requests, http.DefaultTransport if nil.
+}
+
+// RoundTrip sends a request in a span named after its method and host.
+//
+// Parameters:
+//   - req: The request, whose context holds the parent span.
+//
+// Returns:
+//   - *http.Response: The response.
+//   - error: The error of the base transport.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	ctx, span := Start(req.Context(), req.Method+" "+req.URL.Host, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("http.request.method", req.Method),
+		attribute.String("server.address", req.URL.Hostname()),
+		attribute.String("url.full", logging.RedactURL(req.URL)),
+	))
+	defer span.End()
+
+	// The request must not be modified by the transports, the headers of the trace are set on a copy.
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "request failed")
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
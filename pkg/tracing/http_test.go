@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// attributes returns the attributes of a span by key.
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	values := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+// TestMiddleware ensures that the requests are traced under their route pattern, along with the outgoing calls they
+// make, and that the request ID and the trace ID correlate the spans and the logs.
+func TestMiddleware(t *testing.T) {
+	recorder := recordSpans(t)
+	var traceparent string
+	cas := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer cas.Close()
+	client := &http.Client{Transport: &Transport{}}
+
+	var logs bytes.Buffer
+	r := chi.NewRouter()
+	r.Use(hlog.NewHandler(zerolog.New(&logs)))
+	r.Use(hlog.RequestIDHandler("request_id", "X-Request-Id"))
+	r.Use(Middleware)
+	r.Get("/cas", func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, cas.URL+"/serviceValidate?ticket=ST-1", nil)
+		resp, err := client.Do(req)
+		if assert.NoError(t, err) {
+			_ = resp.Body.Close()
+		}
+		hlog.FromRequest(r).Info().Msg("validated")
+	})
+	r.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cas", nil))
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 2) {
+		return
+	}
+	outgoing, server := spans[0], spans[1]
+	assert.Equal(t, "GET /cas", server.Name())
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	serverAttributes := attributes(server)
+	assert.Equal(t, "/cas", serverAttributes["http.route"].AsString())
+	assert.Equal(t, int64(http.StatusOK), serverAttributes["http.response.status_code"].AsInt64())
+	assert.Equal(t, w.Header().Get("X-Request-Id"), serverAttributes["request_id"].AsString())
+
+	assert.Equal(t, trace.SpanKindClient, outgoing.SpanKind())
+	assert.Equal(t, server.SpanContext().SpanID(), outgoing.Parent().SpanID())
+	assert.Contains(t, traceparent, outgoing.SpanContext().SpanID().String(), "the trace should be propagated to the server")
+	assert.NotContains(t, attributes(outgoing)["url.full"].AsString(), "ST-1", "the ticket should be redacted")
+
+	assert.Contains(t, logs.String(), `"trace_id":"`+server.SpanContext().TraceID().String()+`"`)
+	assert.Contains(t, logs.String(), `"request_id":"`+w.Header().Get("X-Request-Id")+`"`)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	spans = recorder.Ended()
+	if assert.Len(t, spans, 4) {
+		assert.Equal(t, "GET /fail", spans[2].Name())
+		assert.Equal(t, codes.Error, spans[2].Status().Code)
+		assert.Equal(t, "GET", spans[3].Name(), "requests matching no route should be named after their method only")
+	}
+}
+
+// TestMiddlewareParent ensures that the trace of the client is continued.
+func TestMiddlewareParent(t *testing.T) {
+	recorder := recordSpans(t)
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	}
+}
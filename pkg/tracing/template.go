@@ -0,0 +1,29 @@
+package tracing
+
+import (
+	"context"
+	"html/template"
+	"io"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ExecuteTemplate executes a template in a span named after it.
+//
+// Parameters:
+//   - ctx: Context holding the span of the request.
+//   - w: Writer of the output of the template.
+//   - t: Set of templates holding the template.
+//   - name: Name of the template.
+//   - data: Data of the template.
+//
+// Returns:
+//   - error: The error of the execution of the template.
+func ExecuteTemplate(ctx context.Context, w io.Writer, t *template.Template, name string, data interface{}) error {
+	_, span := Start(ctx, "template "+name, trace.WithAttributes(attribute.String("template.name", name)))
+	defer span.End()
+	err := t.ExecuteTemplate(w, name, data)
+	recordError(span, err)
+	return err
+}
@@ -0,0 +1,30 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// TestExecuteTemplate ensures that the templates are executed in a span named after them.
+func TestExecuteTemplate(t *testing.T) {
+	recorder := recordSpans(t)
+	templates := template.Must(template.New("page.html").Parse(`{{.Name}}`))
+
+	var out bytes.Buffer
+	assert.NoError(t, ExecuteTemplate(context.Background(), &out, templates, "page.html", struct{ Name string }{"Gala"}))
+	assert.Equal(t, "Gala", out.String())
+	assert.Error(t, ExecuteTemplate(context.Background(), &out, templates, "missing.html", nil))
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "template page.html", spans[0].Name())
+		assert.Equal(t, codes.Unset, spans[0].Status().Code)
+		assert.Equal(t, "template missing.html", spans[1].Name())
+		assert.Equal(t, codes.Error, spans[1].Status().Code)
+	}
+}
@@ -0,0 +1,91 @@
+// Package tracing sets up the OpenTelemetry tracing of the server and creates its spans: the requests, named after
+// their chi route pattern, the outgoing HTTP calls such as the validations of CAS tickets, the queries of the query
+// package and the execution of the templates. The spans are exported with OTLP over HTTP to a collector. Until Setup is
+// called, or when tracing is disabled, the spans are not recorded.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation names the tracer of the spans of the server.
+const instrumentation = "photos"
+
+// tracesPath is the path the OTLP collectors receive the spans at over HTTP.
+const tracesPath = "/v1/traces"
+
+// Options configures the export of the spans.
+type Options struct {
+	Endpoint    string         // URL of the OTLP HTTP receiver of the collector, its path defaulting to /v1/traces.
+	ServiceName string         // Name of the service the spans are attributed to.
+	SampleRatio float64        // Ratio of the traces started by the server which are recorded, from 0 to 1.
+	Logger      zerolog.Logger // Logger of the errors of the export.
+}
+
+// Setup registers the global tracer provider exporting the spans to the endpoint of the options, and the W3C trace
+// context propagation of the traces across HTTP calls. The spans are exported in batches, in the background. Headers
+// such as the credentials of the collector are read from OTEL_EXPORTER_OTLP_HEADERS.
+//
+// Parameters:
+//   - ctx: Context of the creation of the exporter.
+//   - opts: The endpoint, the service and the sampling of the traces.
+//
+// Returns:
+//   - func(context.Context) error: Function exporting the remaining spans and stopping the export, to call on
+//     shutdown.
+//   - error: An error if the endpoint is invalid.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q, expected an http or https URL", opts.Endpoint)
+	}
+	if endpoint.Path == "" || endpoint.Path == "/" {
+		endpoint.Path = tracesPath
+	}
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", opts.ServiceName)))
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to create tracing resource: %w", err), exporter.Shutdown(ctx))
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	logger := opts.Logger
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn().Err(err).Msg("failed to export spans")
+	}))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Start starts a span of the server, child of the span of the context.
+//
+// Parameters:
+//   - ctx: Context holding the parent span, if any.
+//   - name: Name of the span.
+//   - opts: Options of the span, such as its kind or attributes.
+//
+// Returns:
+//   - context.Context: The context holding the new span.
+//   - trace.Span: The span, to end once the operation is done.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, opts...)
+}
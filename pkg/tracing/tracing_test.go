@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// recordSpans replaces the global tracer provider and propagator for the duration of a test, recording the spans.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+	return recorder
+}
+
+// collector is a stub of the OTLP HTTP receiver of a collector, keeping the names of the spans it receives.
+type collector struct {
+	mux      sync.Mutex
+	paths    []string
+	services []string
+	spans    []string
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var request coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(body, &request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mux.Lock()
+	c.paths = append(c.paths, r.URL.Path)
+	for _, resourceSpans := range request.ResourceSpans {
+		for _, attribute := range resourceSpans.Resource.Attributes {
+			if attribute.Key == "service.name" {
+				c.services = append(c.services, attribute.Value.GetStringValue())
+			}
+		}
+		for _, scopeSpans := range resourceSpans.ScopeSpans {
+			for _, span := range scopeSpans.Spans {
+				c.spans = append(c.spans, span.Name)
+			}
+		}
+	}
+	c.mux.Unlock()
+	response, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(response)
+}
+
+// TestSetup ensures that the spans are exported to the collector of the endpoint on shutdown.
+func TestSetup(t *testing.T) {
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+	stub := &collector{}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	shutdown, err := Setup(context.Background(), Options{
+		Endpoint:    server.URL,
+		ServiceName: "photos-test",
+		SampleRatio: 1,
+		Logger:      zerolog.Nop(),
+	})
+	assert.NoError(t, err)
+	ctx, parent := Start(context.Background(), "GET /login")
+	_, child := Start(ctx, "GetUserWithSession")
+	child.End()
+	parent.End()
+	assert.NoError(t, shutdown(context.Background()))
+
+	stub.mux.Lock()
+	defer stub.mux.Unlock()
+	assert.ElementsMatch(t, []string{"GET /login", "GetUserWithSession"}, stub.spans)
+	assert.Contains(t, stub.services, "photos-test")
+	assert.Contains(t, stub.paths, "/v1/traces", "the default path of the receiver should be used")
+
+	_, err = Setup(context.Background(), Options{Endpoint: "localhost:4318"})
+	assert.ErrorContains(t, err, "invalid OTLP endpoint")
+}
+
+// TestSetupSampling ensures that no span is exported with a sample ratio of 0.
+func TestSetupSampling(t *testing.T) {
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+	stub := &collector{}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	shutdown, err := Setup(context.Background(), Options{Endpoint: server.URL, ServiceName: "photos", Logger: zerolog.Nop()})
+	assert.NoError(t, err)
+	_, span := Start(context.Background(), "GET /login")
+	assert.False(t, span.SpanContext().IsSampled())
+	span.End()
+	assert.NoError(t, shutdown(context.Background()))
+	assert.Empty(t, stub.spans)
+}
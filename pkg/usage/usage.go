@@ -0,0 +1,180 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"photos/pkg/db/query"
+	"photos/pkg/derivative"
+	"strings"
+)
+
+var (
+	// ErrQuotaExceeded is returned when the storage is full.
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+	// ErrUploaderQuotaExceeded is returned when a user uploaded as many bytes as allowed.
+	ErrUploaderQuotaExceeded = errors.New("uploader quota exceeded")
+)
+
+// Quotas bounds the bytes stored, 0 meaning no limit.
+type Quotas struct {
+	Global   int64 // Bytes stored in total, originals and derivatives.
+	Uploader int64 // Bytes of photos a single user can upload.
+}
+
+// AddPhoto accounts for a new photo in its event and its uploader. The bytes of the uploader are reserved by an update
+// which only applies while they fit in the quota, so concurrent uploads cannot exceed it together. The file of the
+// photo is accounted for separately, by ReserveFile when it is stored.
+//
+// Parameters:
+//   - ctx: Context of the database queries.
+//   - q: Queries of the transaction inserting the photo.
+//   - quotas: The quotas to enforce.
+//   - eventID: The event of the photo.
+//   - uploaderID: User the photo is accounted to, not set for photos without uploader.
+//   - size: Size of the file in bytes.
+//
+// Returns:
+//   - error: ErrUploaderQuotaExceeded wrapped with the current usage, or an error if the counters could not be updated.
+func AddPhoto(ctx context.Context, q query.Querier, quotas Quotas, eventID uint32, uploaderID sql.NullInt32, size int64) error {
+	scopes := photoScopes(eventID, uploaderID)
+	if uploaderID.Valid && quotas.Uploader > 0 {
+		if err := reserve(ctx, q, scopes[1], size, quotas.Uploader, ErrUploaderQuotaExceeded); err != nil {
+			return err
+		}
+		scopes = scopes[:1]
+	}
+	return add(ctx, q, scopes, query.StorageUsageKindORIGINAL, size, 1)
+}
+
+// ReserveFile accounts for an original file in the usage of the whole storage before it is written, if it fits in the
+// quota. The usage of the whole storage counts the files of the storage: they are accounted for before being written
+// and until they are deleted, so the files left by an interrupted upload or deletion are counted until the consistency
+// repair moves them to the quarantine.
+//
+// Parameters:
+//   - ctx: Context of the database queries.
+//   - q: Queries updating the counters.
+//   - quota: Bytes the storage can hold, originals and derivatives, 0 for no limit.
+//   - size: Size of the file in bytes.
+//
+// Returns:
+//   - error: ErrQuotaExceeded wrapped with the current usage, or an error if the counters could not be updated.
+func ReserveFile(ctx context.Context, q query.Querier, quota int64, size int64) error {
+	global := scope{query.StorageUsageScopeGLOBAL, 0}
+	if quota <= 0 {
+		return add(ctx, q, []scope{global}, query.StorageUsageKindORIGINAL, size, 1)
+	}
+	derivatives, err := derivativeBytes(ctx, q)
+	if err != nil {
+		return err
+	}
+	return reserve(ctx, q, global, size, quota-derivatives, ErrQuotaExceeded)
+}
+
+// RemoveFile reverts ReserveFile or AddDerivative in the usage of the whole storage, once a file is deleted or was not
+// written.
+func RemoveFile(ctx context.Context, q query.Querier, kind query.StorageUsageKind, size int64) error {
+	return add(ctx, q, []scope{{query.StorageUsageScopeGLOBAL, 0}}, kind, -size, -1)
+}
+
+// derivativeBytes returns the bytes of the thumbnails and previews of the storage, which count in the global quota
+// without being reserved.
+func derivativeBytes(ctx context.Context, q query.Querier) (int64, error) {
+	rows, err := q.GetGlobalStorageUsage(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read storage usage: %w", err)
+	}
+	var bytes int64
+	for _, row := range rows {
+		if row.Kind != query.StorageUsageKindORIGINAL {
+			bytes += row.Bytes
+		}
+	}
+	return bytes, nil
+}
+
+// reserve adds the bytes of an original photo to a counter if they fit in its limit.
+func reserve(ctx context.Context, q query.Querier, s scope, size, limit int64, quotaErr error) error {
+	// The counter has to exist for the reservation to update it.
+	err := q.AddStorageUsage(ctx, query.AddStorageUsageParams{Scope: s.scope, ScopeID: s.id, Kind: query.StorageUsageKindORIGINAL})
+	if err != nil {
+		return fmt.Errorf("failed to update storage usage: %w", err)
+	}
+	reserved, err := q.ReserveStorageUsage(ctx, query.ReserveStorageUsageParams{
+		Bytes:   size,
+		Scope:   s.scope,
+		ScopeID: s.id,
+		Bytes_2: size,
+		Bytes_3: limit,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update storage usage: %w", err)
+	}
+	if reserved == 0 {
+		used, err := q.GetStorageUsage(ctx, query.GetStorageUsageParams{Scope: s.scope, ScopeID: s.id})
+		if err != nil {
+			return fmt.Errorf("failed to read storage usage: %w", err)
+		}
+		return fmt.Errorf("%w: %d bytes used, %d bytes requested", quotaErr, used, size)
+	}
+	return nil
+}
+
+// RemovePhoto reverts AddPhoto for a deleted photo. Photos stored before their size was recorded, which the consistency
+// repair has not accounted for yet, are left out.
+func RemovePhoto(ctx context.Context, q query.Querier, photo query.Photo) error {
+	if photo.FileSize == 0 {
+		return nil
+	}
+	return add(ctx, q, photoScopes(photo.EventID, photo.UploaderID), query.StorageUsageKindORIGINAL, -photo.FileSize, -1)
+}
+
+// AddDerivative accounts for a stored derivative in the event of its photo and the whole storage.
+func AddDerivative(ctx context.Context, q query.Querier, eventID uint32, kind derivative.Kind, size int64) error {
+	return add(ctx, q, []scope{{query.StorageUsageScopeEVENT, eventID}, {query.StorageUsageScopeGLOBAL, 0}}, Kind(kind), size, 1)
+}
+
+// RemoveDerivative reverts AddDerivative in the event of the photo of a deleted derivative, the usage of the whole
+// storage is reverted by RemoveFile once the derivative is deleted.
+func RemoveDerivative(ctx context.Context, q query.Querier, eventID uint32, kind derivative.Kind, size int64) error {
+	return add(ctx, q, []scope{{query.StorageUsageScopeEVENT, eventID}}, Kind(kind), -size, -1)
+}
+
+// Kind returns the usage kind of a derivative kind.
+func Kind(kind derivative.Kind) query.StorageUsageKind {
+	return query.StorageUsageKind(strings.ToUpper(string(kind)))
+}
+
+// scope is a usage counter of an event, a user or the whole storage.
+type scope struct {
+	scope query.StorageUsageScope
+	id    uint32
+}
+
+// photoScopes lists the counters of a photo, other than the whole storage which counts its file.
+func photoScopes(eventID uint32, uploaderID sql.NullInt32) []scope {
+	scopes := []scope{{query.StorageUsageScopeEVENT, eventID}}
+	if uploaderID.Valid {
+		scopes = append(scopes, scope{query.StorageUsageScopeUPLOADER, uint32(uploaderID.Int32)})
+	}
+	return scopes
+}
+
+// add adds bytes and files to counters.
+func add(ctx context.Context, q query.Querier, scopes []scope, kind query.StorageUsageKind, bytes int64, files int32) error {
+	for _, s := range scopes {
+		err := q.AddStorageUsage(ctx, query.AddStorageUsageParams{
+			Scope:   s.scope,
+			ScopeID: s.id,
+			Kind:    kind,
+			Bytes:   bytes,
+			Files:   files,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update storage usage: %w", err)
+		}
+	}
+	return nil
+}
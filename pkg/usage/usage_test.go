@@ -0,0 +1,64 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"photos/pkg/db"
+	"photos/pkg/db/query"
+	"photos/pkg/derivative"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAddPhoto ensures that photos are refused once their bytes exceed the quota of their uploader, with the counters
+// left as they were, and that files are refused once they exceed the global quota.
+func TestAddPhoto(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.New(db.Options{Driver: db.DriverSQLite, Path: filepath.Join(t.TempDir(), "photos.db"), MaxOpenConns: 1})
+	assert.NoError(t, err)
+	defer database.Close()
+	uploader := sql.NullInt32{Int32: 3, Valid: true}
+	used := func(scope query.StorageUsageScope, id uint32) int64 {
+		bytes, err := database.GetStorageUsage(ctx, query.GetStorageUsageParams{Scope: scope, ScopeID: id})
+		assert.NoError(t, err)
+		return bytes
+	}
+
+	assert.NoError(t, ReserveFile(ctx, database.Querier, 0, 1<<40), "no quota means no limit")
+	assert.Equal(t, int64(1<<40), used(query.StorageUsageScopeGLOBAL, 0))
+	assert.NoError(t, RemoveFile(ctx, database.Querier, query.StorageUsageKindORIGINAL, 1<<40))
+
+	quotas := Quotas{Global: 1000, Uploader: 500}
+	assert.NoError(t, AddDerivative(ctx, database.Querier, 7, derivative.Thumbnail, 400))
+	assert.NoError(t, AddPhoto(ctx, database.Querier, quotas, 7, uploader, 500))
+	assert.ErrorIs(t, AddPhoto(ctx, database.Querier, quotas, 7, uploader, 1), ErrUploaderQuotaExceeded)
+	assert.NoError(t, AddPhoto(ctx, database.Querier, quotas, 8, sql.NullInt32{}, 300), "photos without uploader have no quota")
+	assert.NoError(t, ReserveFile(ctx, database.Querier, quotas.Global, 500))
+	assert.ErrorIs(t, ReserveFile(ctx, database.Querier, quotas.Global, 101), ErrQuotaExceeded, "derivatives count in the global quota")
+	assert.NoError(t, ReserveFile(ctx, database.Querier, quotas.Global, 100))
+
+	assert.Equal(t, int64(1000), used(query.StorageUsageScopeGLOBAL, 0))
+	assert.Equal(t, int64(500), used(query.StorageUsageScopeUPLOADER, 3))
+	assert.Equal(t, int64(900), used(query.StorageUsageScopeEVENT, 7))
+	assert.Equal(t, int64(300), used(query.StorageUsageScopeEVENT, 8))
+
+	assert.NoError(t, RemoveDerivative(ctx, database.Querier, 7, derivative.Thumbnail, 400))
+	assert.Equal(t, int64(1000), used(query.StorageUsageScopeGLOBAL, 0), "files count until they are deleted")
+	assert.NoError(t, RemoveFile(ctx, database.Querier, query.StorageUsageKindTHUMBNAIL, 400))
+	assert.Equal(t, int64(600), used(query.StorageUsageScopeGLOBAL, 0))
+}
+
+func TestKind(t *testing.T) {
+	assert.Equal(t, query.StorageUsageKindTHUMBNAIL, Kind(derivative.Thumbnail))
+	assert.Equal(t, query.StorageUsageKindPREVIEW, Kind(derivative.Preview))
+}
+
+func TestPhotoScopes(t *testing.T) {
+	assert.Equal(t, []scope{{query.StorageUsageScopeEVENT, 7}}, photoScopes(7, sql.NullInt32{}))
+	assert.Equal(t, []scope{
+		{query.StorageUsageScopeEVENT, 7},
+		{query.StorageUsageScopeUPLOADER, 3},
+	}, photoScopes(7, sql.NullInt32{Int32: 3, Valid: true}))
+}
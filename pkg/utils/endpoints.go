@@ -2,10 +2,11 @@ package utils
 
 import (
 	"fmt"
-	"log"
 	"net/url"
 	"path"
 	"strconv"
+
+	"github.com/rs/zerolog/log"
 )
 
 func ApiListenUrl() *url.URL {
@@ -32,12 +33,12 @@ func ApiListenUrl() *url.URL {
 
 	listenPort, err := strconv.Atoi(listenPortStr)
 	if err != nil {
-		log.Fatalf("%s must be a number: '%s'\n%s", EnvListenPort.GetName(), listenPortStr, err)
+		log.Fatal().Err(err).Str("value", listenPortStr).Msgf("%s must be a number", EnvListenPort.GetName())
 	}
 
 	apiUrl, err := url.Parse(fmt.Sprintf("http://%s:%d", listenAddr, listenPort))
 	if err != nil {
-		log.Fatalf("Could not format api url: %s", err)
+		log.Fatal().Err(err).Msg("could not format api url")
 	}
 	apiUrl.Path = apiPrefix
 
@@ -56,8 +57,7 @@ func ApiEndpointUrl() *url.URL {
 
 	apiEndpointURL, err := url.Parse(apiEndpointStr)
 	if err != nil {
-		log.Fatalf("ERROR: Environment variable %s is not a proper url (%s)",
-			EnvAPIEndpoint.GetName(), EnvAPIEndpoint.GetValue())
+		log.Fatal().Err(err).Str("value", EnvAPIEndpoint.GetValue()).Msgf("environment variable %s is not a proper url", EnvAPIEndpoint.GetName())
 	}
 
 	if shouldServeUI {
@@ -75,8 +75,7 @@ func UiEndpointUrl() *url.URL {
 
 	uiEndpointURL, err := url.Parse(EnvUIEndpoint.GetValue())
 	if err != nil {
-		log.Fatalf("ERROR: Environment variable %s is not a proper url (%s)",
-			EnvUIEndpoint.GetName(), EnvUIEndpoint.GetValue())
+		log.Fatal().Err(err).Str("value", EnvUIEndpoint.GetValue()).Msgf("environment variable %s is not a proper url", EnvUIEndpoint.GetName())
 	}
 
 	return uiEndpointURL
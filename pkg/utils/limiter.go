@@ -0,0 +1,39 @@
+package utils
+
+import "sync"
+
+// KeyedLimiter bounds the number of concurrent operations per key, such as a user ID.
+type KeyedLimiter struct {
+	mux     sync.Mutex
+	max     int
+	running map[uint32]int
+}
+
+func NewKeyedLimiter(max int) *KeyedLimiter {
+	return &KeyedLimiter{
+		max:     max,
+		running: map[uint32]int{},
+	}
+}
+
+// Acquire reserves a slot for the key, it returns false if all the slots of the key are taken.
+// A limit lower than 1 disables the limiter.
+func (l *KeyedLimiter) Acquire(key uint32) bool {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.max > 0 && l.running[key] >= l.max {
+		return false
+	}
+	l.running[key]++
+	return true
+}
+
+// Release frees a slot reserved by Acquire.
+func (l *KeyedLimiter) Release(key uint32) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.running[key]--
+	if l.running[key] <= 0 {
+		delete(l.running, key)
+	}
+}
@@ -0,0 +1,24 @@
+package utils_test
+
+import (
+	"testing"
+
+	"photos/pkg/utils"
+)
+
+func TestKeyedLimiter(t *testing.T) {
+	limiter := utils.NewKeyedLimiter(2)
+	if !limiter.Acquire(1) || !limiter.Acquire(1) {
+		t.Fatalf("expected two slots for key 1")
+	}
+	if limiter.Acquire(1) {
+		t.Errorf("expected a third slot for key 1 to be refused")
+	}
+	if !limiter.Acquire(2) {
+		t.Errorf("expected key 2 not to be limited by key 1")
+	}
+	limiter.Release(1)
+	if !limiter.Acquire(1) {
+		t.Errorf("expected a released slot to be available again")
+	}
+}
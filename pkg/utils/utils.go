@@ -3,13 +3,13 @@ package utils
 import (
 	"crypto/rand"
 	"fmt"
-	"log"
 	"math/big"
 	"os"
 	"path"
 	"path/filepath"
 
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 )
 
 func GenerateToken() string {
@@ -23,7 +23,7 @@ func GenerateToken() string {
 
 		n, err := rand.Int(rand.Reader, charLen)
 		if err != nil {
-			log.Fatalf("Could not generate random number: %s\n", err)
+			log.Fatal().Err(err).Msg("could not generate random number")
 		}
 		b[i] = charset[n.Int64()]
 	}
@@ -40,7 +40,7 @@ func (e PhotoviewError) Error() string {
 }
 
 func HandleError(message string, err error) PhotoviewError {
-	log.Printf("ERROR: %s: %s", message, err)
+	log.Error().Err(err).Msg(message)
 	return PhotoviewError{
 		message:  message,
 		original: err,
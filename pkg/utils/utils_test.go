@@ -5,29 +5,19 @@ import (
 	"path"
 	"testing"
 
-	"github.com/photoview/photoview/api/test_utils"
-	"github.com/photoview/photoview/api/utils"
+	"photos/pkg/utils"
 )
 
-func TestMain(m *testing.M) {
-	os.Exit(test_utils.IntegrationTestRun(m))
-}
-
 func TestIsDirSymlink(t *testing.T) {
-	test_utils.FilesystemTest(t)
-
 	// Prepare a temporary directory for testing purposes
-	dir, err := os.MkdirTemp("", "testing")
-	if err != nil {
-		t.Fatalf("unable to create temp directory for testing")
-	}
-	defer os.RemoveAll(dir)
+	dir := t.TempDir()
 
 	// Create regular file
-	_, err = os.Create(path.Join(dir, "regular_file"))
+	f, err := os.Create(path.Join(dir, "regular_file"))
 	if err != nil {
 		t.Fatalf("unable to create regular file for testing")
 	}
+	f.Close()
 
 	// Create directory
 	err = os.Mkdir(path.Join(dir, "directory"), 0755)
@@ -0,0 +1,107 @@
+package zipstream
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Sizes of the records written by archive/zip for a stored file with a data descriptor.
+const (
+	localHeaderLen     = 30
+	centralHeaderLen   = 46
+	dataDescriptorLen  = 16
+	endOfDirectoryLen  = 22
+	extendedTimeLength = 9 // Extra field holding the modification time, written when it is set.
+)
+
+// ErrSizeMismatch is returned when an entry writes a different number of bytes than announced.
+var ErrSizeMismatch = errors.New("zip entry size mismatch")
+
+// Entry is a file of an archive. Its content is produced by WriteTo when the archive is written,
+// so nothing is held in memory.
+type Entry struct {
+	Name     string                  // Path of the file in the archive, with forward slashes.
+	Modified time.Time               // Modification date of the file.
+	Size     int64                   // Exact number of bytes written by WriteTo.
+	WriteTo  func(w io.Writer) error // Writes the content of the file.
+}
+
+// Size returns the exact length of the archive written by Write for the given entries.
+//
+// The length is only known in advance for archives that do not need ZIP64 records,
+// that is with less than 65535 entries and less than 4GiB in total.
+//
+// Parameters:
+//   - entries: The files of the archive.
+//
+// Returns:
+//   - int64: The length of the archive in bytes.
+//   - bool: False if the archive needs ZIP64 records and its length cannot be computed.
+func Size(entries []Entry) (int64, bool) {
+	if len(entries) >= math.MaxUint16 {
+		return 0, false
+	}
+	var total int64 = endOfDirectoryLen
+	for _, entry := range entries {
+		extra := int64(0)
+		if !entry.Modified.IsZero() {
+			extra = extendedTimeLength
+		}
+		name := int64(len(entry.Name))
+		total += localHeaderLen + name + extra + entry.Size + dataDescriptorLen
+		total += centralHeaderLen + name + extra
+	}
+	if total >= math.MaxUint32 {
+		return 0, false
+	}
+	return total, true
+}
+
+// Write streams an uncompressed archive of the entries to w.
+//
+// Photos are already compressed, so storing them keeps the CPU usage low and lets Size
+// announce the length of the archive before it is written.
+//
+// Parameters:
+//   - w: Writer receiving the archive.
+//   - entries: The files of the archive, written in order.
+//
+// Returns:
+//   - error: An error if an entry failed to be written, or wrote another size than announced.
+func Write(w io.Writer, entries []Entry) error {
+	zw := zip.NewWriter(w)
+	for _, entry := range entries {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     entry.Name,
+			Modified: entry.Modified,
+			Method:   zip.Store,
+		})
+		if err != nil {
+			return err
+		}
+		counter := &countingWriter{w: fw}
+		if err := entry.WriteTo(counter); err != nil {
+			return fmt.Errorf("failed to write %s: %w", entry.Name, err)
+		}
+		if counter.n != entry.Size {
+			return fmt.Errorf("%w: %s wrote %d bytes instead of %d", ErrSizeMismatch, entry.Name, counter.n, entry.Size)
+		}
+	}
+	return zw.Close()
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,70 @@
+package zipstream
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func entryWithContent(name string, modified time.Time, content string) Entry {
+	return Entry{
+		Name:     name,
+		Modified: modified,
+		Size:     int64(len(content)),
+		WriteTo: func(w io.Writer) error {
+			_, err := io.WriteString(w, content)
+			return err
+		},
+	}
+}
+
+func TestSize(t *testing.T) {
+	date := time.Date(2024, 5, 14, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		entries []Entry
+	}{
+		{"Empty archive", nil},
+		{"Single file", []Entry{entryWithContent("photo-1.jpg", date, "jpeg data")}},
+		{"Without date", []Entry{entryWithContent("photo-1.jpg", time.Time{}, "jpeg data")}},
+		{"Nested and non ASCII names", []Entry{
+			entryWithContent("Gala/Soirée/photo-1.jpg", date, strings.Repeat("a", 5000)),
+			entryWithContent("Gala/photo-2.png", date, ""),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.NoError(t, Write(&buf, tt.entries))
+			size, ok := Size(tt.entries)
+			assert.True(t, ok)
+			assert.Equal(t, int64(buf.Len()), size)
+
+			reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			assert.NoError(t, err)
+			assert.Len(t, reader.File, len(tt.entries))
+			for i, file := range reader.File {
+				assert.Equal(t, tt.entries[i].Name, file.Name)
+				assert.Equal(t, zip.Store, file.Method)
+				assert.Equal(t, uint64(tt.entries[i].Size), file.UncompressedSize64)
+			}
+		})
+	}
+}
+
+func TestSizeTooLarge(t *testing.T) {
+	_, ok := Size([]Entry{{Name: "large.jpg", Size: 5 << 30}})
+	assert.False(t, ok)
+}
+
+func TestWriteSizeMismatch(t *testing.T) {
+	entry := entryWithContent("photo-1.jpg", time.Time{}, "jpeg data")
+	entry.Size++
+	err := Write(io.Discard, []Entry{entry})
+	assert.ErrorIs(t, err, ErrSizeMismatch)
+}
@@ -36,6 +36,9 @@ CREATE TABLE events (
     creation_date DATETIME DEFAULT CURRENT_TIMESTAMP,
 
     parent_event_id INT UNSIGNED,
+    metadata_policy ENUM('KEEP_ALL', 'STRIP_GPS', 'STRIP_ALL') NOT NULL DEFAULT 'STRIP_GPS',
+    is_hidden BOOL NOT NULL DEFAULT false,
+    password_hash VARCHAR(255) NOT NULL DEFAULT '',
 
     PRIMARY KEY (event_id),
     FOREIGN KEY (parent_event_id) REFERENCES events(event_id)
@@ -45,12 +48,134 @@ CREATE TABLE photos (
     photo_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
 
     path_to_photo VARCHAR(255) NOT NULL,
+    file_hash CHAR(64) NOT NULL,
+    perceptual_hash BIGINT,
+    file_size BIGINT NOT NULL DEFAULT 0,
     creation_date DATETIME DEFAULT CURRENT_TIMESTAMP,
 
     event_id INT UNSIGNED NOT NULL,
+    uploader_id INT UNSIGNED,
+    is_hidden BOOL NOT NULL DEFAULT false,
 
     PRIMARY KEY (photo_id),
-    FOREIGN KEY (event_id) REFERENCES events(event_id)
+    INDEX (file_hash),
+    INDEX (path_to_photo),
+    FOREIGN KEY (event_id) REFERENCES events(event_id),
+    FOREIGN KEY (uploader_id) REFERENCES users(user_id) ON DELETE SET NULL
+);
+
+CREATE TABLE perceptual_hash_bands (
+    band_key SMALLINT UNSIGNED NOT NULL,
+    photo_id INT UNSIGNED NOT NULL,
+
+    PRIMARY KEY (band_key, photo_id),
+    FOREIGN KEY (photo_id) REFERENCES photos(photo_id) ON DELETE CASCADE
+);
+
+CREATE TABLE duplicate_candidates (
+    photo_id INT UNSIGNED NOT NULL,
+    duplicate_of_photo_id INT UNSIGNED NOT NULL,
+
+    distance TINYINT UNSIGNED NOT NULL,
+    status ENUM('PENDING', 'KEPT', 'HIDDEN') NOT NULL DEFAULT 'PENDING',
+    creation_date DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (photo_id, duplicate_of_photo_id),
+    INDEX (status),
+    FOREIGN KEY (photo_id) REFERENCES photos(photo_id) ON DELETE CASCADE,
+    FOREIGN KEY (duplicate_of_photo_id) REFERENCES photos(photo_id) ON DELETE CASCADE
+);
+
+CREATE TABLE missing_photos (
+    photo_id INT UNSIGNED NOT NULL,
+
+    detection_date DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (photo_id),
+    FOREIGN KEY (photo_id) REFERENCES photos(photo_id) ON DELETE CASCADE
+);
+
+CREATE TABLE storage_usage (
+    scope ENUM('GLOBAL', 'EVENT', 'UPLOADER') NOT NULL,
+    scope_id INT UNSIGNED NOT NULL,
+    kind ENUM('ORIGINAL', 'THUMBNAIL', 'PREVIEW') NOT NULL,
+
+    bytes BIGINT NOT NULL DEFAULT 0,
+    files INT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (scope, scope_id, kind)
+);
+
+CREATE TABLE photo_metadata (
+    photo_id INT UNSIGNED NOT NULL,
+
+    capture_date DATETIME,
+    camera_make VARCHAR(255) NOT NULL DEFAULT '',
+    camera_model VARCHAR(255) NOT NULL DEFAULT '',
+    lens_model VARCHAR(255) NOT NULL DEFAULT '',
+    exposure_time VARCHAR(32) NOT NULL DEFAULT '',
+    f_number DOUBLE NOT NULL DEFAULT 0,
+    iso INT UNSIGNED NOT NULL DEFAULT 0,
+    focal_length DOUBLE NOT NULL DEFAULT 0,
+    orientation SMALLINT UNSIGNED NOT NULL DEFAULT 0,
+    width INT UNSIGNED NOT NULL DEFAULT 0,
+    height INT UNSIGNED NOT NULL DEFAULT 0,
+    latitude DOUBLE,
+    longitude DOUBLE,
+    altitude DOUBLE,
+
+    PRIMARY KEY (photo_id),
+    FOREIGN KEY (photo_id) REFERENCES photos(photo_id) ON DELETE CASCADE
+);
+
+CREATE TABLE face_groups (
+    face_group_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+
+    label VARCHAR(255),
+    user_id INT UNSIGNED,
+    creation_date DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (face_group_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE SET NULL
+);
+
+CREATE TABLE image_faces (
+    image_face_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+
+    photo_id INT UNSIGNED NOT NULL,
+    face_group_id INT UNSIGNED NOT NULL,
+    model VARCHAR(64) NOT NULL,
+    descriptor BLOB NOT NULL,
+    min_x DOUBLE NOT NULL,
+    min_y DOUBLE NOT NULL,
+    max_x DOUBLE NOT NULL,
+    max_y DOUBLE NOT NULL,
+    detection_date DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (image_face_id),
+    INDEX (model),
+    FOREIGN KEY (photo_id) REFERENCES photos(photo_id) ON DELETE CASCADE,
+    FOREIGN KEY (face_group_id) REFERENCES face_groups(face_group_id) ON DELETE CASCADE
+);
+
+CREATE TABLE face_scans (
+    photo_id INT UNSIGNED NOT NULL,
+
+    model VARCHAR(64) NOT NULL,
+    faces INT UNSIGNED NOT NULL DEFAULT 0,
+    scan_date DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (photo_id),
+    FOREIGN KEY (photo_id) REFERENCES photos(photo_id) ON DELETE CASCADE
+);
+
+CREATE TABLE unlocked_events (
+    session_id INT UNSIGNED NOT NULL,
+    event_id INT UNSIGNED NOT NULL,
+
+    PRIMARY KEY (session_id, event_id),
+    FOREIGN KEY (session_id) REFERENCES sessions(session_id) ON DELETE CASCADE,
+    FOREIGN KEY (event_id) REFERENCES events(event_id) ON DELETE CASCADE
 );
 
 CREATE TABLE user_folders (
@@ -64,9 +189,9 @@ CREATE TABLE user_folders (
     user_id INT UNSIGNED NOT NULL,
     parent_folder_id INT UNSIGNED,
 
-    PRIMARY KEY (folder_id),
+    PRIMARY KEY (user_folder_id),
     FOREIGN KEY (user_id) REFERENCES users(user_id),
-    FOREIGN KEY (parent_folder_id) REFERENCES user_folders(folder_id)
+    FOREIGN KEY (parent_folder_id) REFERENCES user_folders(user_folder_id)
 );
 
 CREATE TABLE recognized_users (
@@ -75,7 +200,73 @@ CREATE TABLE recognized_users (
     user_id INT UNSIGNED NOT NULL,
     photo_id INT UNSIGNED NOT NULL,
 
-    PRIMARY KEY (id),
-    FOREIGN KEY (user_id) REFERENCES users(user_id),
-    FOREIGN KEY (photo_id) REFERENCES photos(photo_id)
+    PRIMARY KEY (recognized_user_id),
+    UNIQUE (user_id, photo_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (photo_id) REFERENCES photos(photo_id) ON DELETE CASCADE
+);
+
+CREATE TABLE face_consents (
+    user_id INT UNSIGNED NOT NULL,
+
+    consent ENUM('UNKNOWN', 'GRANTED', 'REFUSED') NOT NULL DEFAULT 'UNKNOWN',
+    update_date DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE face_consent_changes (
+    face_consent_change_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+
+    user_id INT UNSIGNED,
+    consent ENUM('UNKNOWN', 'GRANTED', 'REFUSED') NOT NULL,
+    previous_consent ENUM('UNKNOWN', 'GRANTED', 'REFUSED') NOT NULL,
+    changed_by INT UNSIGNED,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    change_date DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (face_consent_change_id),
+    INDEX (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE SET NULL,
+    FOREIGN KEY (changed_by) REFERENCES users(user_id) ON DELETE SET NULL
+);
+
+CREATE TABLE face_group_claims (
+    face_group_id INT UNSIGNED NOT NULL,
+    user_id INT UNSIGNED NOT NULL,
+
+    claim_date DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (face_group_id, user_id),
+    INDEX (user_id),
+    FOREIGN KEY (face_group_id) REFERENCES face_groups(face_group_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE public_face_names (
+    user_id INT UNSIGNED NOT NULL,
+
+    opt_in_date DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE jobs (
+    job_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+
+    kind ENUM('DERIVATIVES', 'METADATA', 'FACES', 'RECOGNIZE') NOT NULL,
+    photo_id INT UNSIGNED,
+    status ENUM('PENDING', 'RUNNING', 'DONE', 'DEAD') NOT NULL DEFAULT 'PENDING',
+    attempts INT UNSIGNED NOT NULL DEFAULT 0,
+    last_error TEXT,
+    run_after DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    leased_until DATETIME,
+    creation_date DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_date DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (job_id),
+    INDEX (status, run_after),
+    FOREIGN KEY (photo_id) REFERENCES photos(photo_id) ON DELETE CASCADE
 );
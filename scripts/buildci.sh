@@ -1,3 +1,6 @@
 golangci-lint run &&
-go build -o bin/launch_photos_server ./cmd/photos_server/launch_server.go
+go build -o bin/launch_photos_server ./cmd/photos_server
 go build -o bin/launch_mock_cas_server ./cmd/cas_server/launch_server.go
+go build -o bin/launch_photos_import ./cmd/photos_import/launch_import.go
+go build -o bin/launch_photos_check ./cmd/photos_check/launch_check.go
+go build -o bin/launch_photos_faces ./cmd/photos_faces/launch_faces.go